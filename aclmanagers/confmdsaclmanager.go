@@ -1,7 +1,10 @@
 package aclmanagers
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -18,6 +21,11 @@ type (
 		ACLExecutionManagerBaseImpl
 		ksengine.ShepherdACLConfigManagerBaseImpl
 		ConfluentRBACOperation
+		connections kafkamanagers.KafkaConnections
+		aclMappings *clusterACLMappings
+		// Role Bindings without resources (e.g. SystemAdmin), which are only listed and never created or deleted.
+		clusterRoles *clusterACLMappings
+		retry        kafkamanagers.RetryPolicy
 	}
 	mappingKey struct {
 		principal         string
@@ -55,10 +63,18 @@ const (
 	mds_CreateDeleteRoleBindings = "/security/1.0/principals/{pName}/roles/{roleName}/bindings"
 )
 
+func NewConfluentRbacACLManager(connections kafkamanagers.KafkaConnections, retry kafkamanagers.RetryPolicy) ConfluentRbacACLExecutionManagerImpl {
+	return ConfluentRbacACLExecutionManagerImpl{
+		ConfluentRBACOperation: ConfluentRBACOperation("Unknown"),
+		connections:            connections,
+		aclMappings:            newClusterACLMappings(),
+		clusterRoles:           newClusterACLMappings(),
+		retry:                  retry,
+	}
+}
+
 var (
 	// ConfACLManager                           ksengine.ShepherdACLConfigManager       = ConfluentRbacACLExecutionManagerImpl{}
-	ConfluentRbacACLManager                  ACLExecutionManager                     = ConfluentRbacACLExecutionManagerImpl{}
-	confRbacAclMappings                      *ksengine.ACLMapping                    = &ksengine.ACLMapping{}
 	confluentRBAC2KafkaPatternTypeConversion map[string]ksengine.KafkaACLPatternType = map[string]ksengine.KafkaACLPatternType{
 		"UNKNOWN":  ksengine.KafkaACLPatternType_UNKNOWN,
 		"LITERAL":  ksengine.KafkaACLPatternType_LITERAL,
@@ -73,70 +89,112 @@ var (
 	it to execute any functionality in this module.
 */
 func (c ConfluentRbacACLExecutionManagerImpl) getConnectionObject(clusterName string) *kafkamanagers.ConfluentMDSConnection {
-	return kafkamanagers.Connections[kafkamanagers.KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: kafkamanagers.ConnectionType_CONFLUENT_MDS}].Connection.(*kafkamanagers.ConfluentMDSConnection)
+	return c.connections.GetConfluentMDSConnection(clusterName)
+}
+
+/*
+	Sends the request built by send to the MDS Server using the retry policy. Every attempt gets a new request
+	bound to the context of the attempt, so the request is abandoned once the attempt runs out of time.
+*/
+func (c ConfluentRbacACLExecutionManagerImpl) executeMDSRequest(ctx context.Context, connObj *kafkamanagers.ConfluentMDSConnection, opName string, errMsg string,
+	send func(req *resty.Request) (*resty.Response, error)) (*resty.Response, error) {
+	resp, err := c.retry.DoWithResult(ctx, opName, func(opCtx context.Context) (interface{}, error) {
+		resp, err := send(connObj.MDS.R().SetContext(opCtx))
+		if err := kafkamanagers.NewMDSError(errMsg, resp, err); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*resty.Response), nil
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) CreateACL(clusterName string, in *ksengine.ACLMapping, dryRun bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) CreateACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Create Cluster ACLs", "=", 80)
-	c.ListClusterACL(clusterName, false)
-	createSet := c.FindNonExistentACLsInCluster(clusterName, confRbacAclMappings, ConfluentRBACOperation("Unknown"))
-	c.createACLs(clusterName, createSet, dryRun)
+	if err := c.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	createSet := c.FindNonExistentACLsInCluster(in, c.aclMappings.get(clusterName))
+	return c.createACLs(ctx, clusterName, createSet, dryRun)
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) createACLs(clusterName string, in *ksengine.ACLMapping, dryRun bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) createACLs(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error {
 	if dryRun {
-		c.ListConfigACL(true, in)
-		return
+		c.ListConfigACL(in)
+		return nil
 	}
 	mappingCache := make(mappingTable)
 	c.createMappingTableForRBExec(clusterName, &mappingCache, in)
 	logger.Debugf("Mapping Table setup: %v", mappingCache)
 	wg_int := new(sync.WaitGroup)
+	errs := new(kafkamanagers.ErrorCollector)
 	wg_int.Add(len(mappingCache))
 	for k, v := range mappingCache {
-		go c.executeRBRequest(clusterName, k, v, resty.MethodPost, mds_CreateDeleteRoleBindings, map[string]string{"pName": k.principal, "roleName": k.role.String()}, wg_int)
+		go c.executeRBRequest(ctx, clusterName, k, v, resty.MethodPost, mds_CreateDeleteRoleBindings, map[string]string{"pName": k.principal, "roleName": k.role.String()}, wg_int, errs)
 	}
 	wg_int.Wait()
+	if err := errs.Err("Role Binding Creation failed"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	logger.Infof("All Rolebindings have been created. Total RoleBinding Creation Requests Executed: %d", len(mappingCache))
+	return nil
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) DeleteProvisionedACL(clusterName string, in *ksengine.ACLMapping, dryRun bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) DeleteProvisionedACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Delete Config ACLs", "=", 80)
-	c.ListClusterACL(clusterName, false)
-	deleteSet := c.FindProvisionedACLsInCluster(clusterName, confRbacAclMappings, ConfluentRBACOperation("Unknown"))
-	c.deleteACLs(clusterName, deleteSet, dryRun)
+	if err := c.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	deleteSet := c.FindProvisionedACLsInCluster(in, c.aclMappings.get(clusterName))
+	return c.deleteACLs(ctx, clusterName, deleteSet, dryRun)
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) DeleteUnknownACL(clusterName string, in *ksengine.ACLMapping, dryRun bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) DeleteUnknownACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Delete Unknown ACLs", "=", 80)
-	c.ListClusterACL(clusterName, false)
-	deleteSet := c.FindNonExistentACLsInConfig(clusterName, confRbacAclMappings, ConfluentRBACOperation("Unknown"))
-	c.deleteACLs(clusterName, deleteSet, dryRun)
+	if err := c.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	deleteSet := c.FindNonExistentACLsInConfig(in, c.aclMappings.get(clusterName))
+	return c.deleteACLs(ctx, clusterName, deleteSet, dryRun)
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) deleteACLs(clusterName string, in *ksengine.ACLMapping, dryRun bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) deleteACLs(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error {
 	if dryRun {
-		c.ListConfigACL(true, in)
-		return
+		c.ListConfigACL(in)
+		return nil
 	}
 	mappingCache := make(mappingTable)
 	c.createMappingTableForRBExec(clusterName, &mappingCache, in)
 	wg_int := new(sync.WaitGroup)
+	errs := new(kafkamanagers.ErrorCollector)
 	wg_int.Add(len(mappingCache))
 	for k, v := range mappingCache {
-		go c.executeRBRequest(clusterName, k, v, resty.MethodDelete, mds_CreateDeleteRoleBindings, map[string]string{"pName": k.principal, "roleName": k.role.String()}, wg_int)
+		go c.executeRBRequest(ctx, clusterName, k, v, resty.MethodDelete, mds_CreateDeleteRoleBindings, map[string]string{"pName": k.principal, "roleName": k.role.String()}, wg_int, errs)
 	}
 	wg_int.Wait()
+	if err := errs.Err("Role Binding Deletion failed"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	logger.Infof("All provided Rolebindings have been deleted. Total RoleBinding Deletion Requests Executed: %d", len(mappingCache))
+	return nil
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) ListClusterACL(clusterName string, printOutput bool) {
+func (c ConfluentRbacACLExecutionManagerImpl) ListClusterACL(ctx context.Context, clusterName string, printOutput bool) error {
 	connObj := c.getConnectionObject(clusterName)
-	resp, err := connObj.MDS.R().Get(mds_ListRoles)
-	if err != nil || resp.StatusCode() >= 400 {
-		logger.Fatalw("Cannot contact the MDS Server. Will not Retry Listing ACL's. Turn on debug for more details.",
-			"Status Code", resp.StatusCode(),
-			"Error", err)
+	resp, err := c.executeMDSRequest(ctx, connObj, "List Roles", "Cannot contact the MDS Server to list the Roles",
+		func(req *resty.Request) (*resty.Response, error) {
+			return req.Get(mds_ListRoles)
+		})
+	if err != nil {
+		return err
 	}
 
 	type listRolesResp struct {
@@ -147,29 +205,39 @@ func (c ConfluentRbacACLExecutionManagerImpl) ListClusterACL(clusterName string,
 
 	temp := []string{}
 	r2 := mapset.NewSet()
-	f2 := func(roleName string, aName string, aVal string) {
-		resp, err = connObj.MDS.R().SetBody(c.createClustersObject(clusterName, "", "")).SetPathParam("roleName", roleName).Post(mds_GetPrincipalsForRoles)
-		if err != nil || resp.StatusCode() >= 400 {
-			logger.Fatalw("Tried Multiple Times to get Principals for RoleName. Cannot Proceed Without MDS Connectivity.",
-				"Principal Name", roleName,
-				"Error", err)
+	f2 := func(roleName string, aName string, aVal string) error {
+		resp, err := c.executeMDSRequest(ctx, connObj, "Get Principals for Role", fmt.Sprintf("Cannot get the Principals for Role %s from the MDS Server", roleName),
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetBody(c.createClustersObject(clusterName, "", "")).SetPathParam("roleName", roleName).Post(mds_GetPrincipalsForRoles)
+			})
+		if err != nil {
+			return err
 		}
 		connObj.MDS.JSONUnmarshal(resp.Body(), &temp)
 		for _, v := range temp {
 			r2.Add(v)
 		}
+		return nil
 	}
 
 	for _, lrr := range r {
-		f2(lrr.Name, "", "")
+		if err := f2(lrr.Name, "", ""); err != nil {
+			return err
+		}
 		if connObj.ConnectClusterID != "" {
-			f2(lrr.Name, cCluster, connObj.ConnectClusterID)
+			if err := f2(lrr.Name, cCluster, connObj.ConnectClusterID); err != nil {
+				return err
+			}
 		}
 		if connObj.KSQLClusterID != "" {
-			f2(lrr.Name, ksqlCluster, connObj.KSQLClusterID)
+			if err := f2(lrr.Name, ksqlCluster, connObj.KSQLClusterID); err != nil {
+				return err
+			}
 		}
 		if connObj.ConnectClusterID != "" {
-			f2(lrr.Name, srCluster, connObj.SRClusterID)
+			if err := f2(lrr.Name, srCluster, connObj.SRClusterID); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -185,30 +253,50 @@ func (c ConfluentRbacACLExecutionManagerImpl) ListClusterACL(clusterName string,
 		}
 	)
 	r3 := []rbResp{}
+	mappings, clusterRoles := &ksengine.ACLMapping{}, &ksengine.ACLMapping{}
 	wg := new(sync.WaitGroup)
 	lock := &sync.Mutex{}
-	f3 := func(pName string) {
-		resp, err := connObj.MDS.R().SetPathParam("pName", pName).Get(mds_GetPrincipalRoleBindings)
-		if err != nil || resp.StatusCode() >= 400 {
-			logger.Fatalw("Cannot contact the MDS Server. Will not Retry Listing ACL's. Turn on debug for more details.",
-				"Status Code", resp.StatusCode(),
-				"Error", err)
+	f3 := func(pName string) error {
+		resp, err := c.executeMDSRequest(ctx, connObj, "Get Role Bindings for Principal", fmt.Sprintf("Cannot get the Role Bindings for Principal %s from the MDS Server", pName),
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParam("pName", pName).Get(mds_GetPrincipalRoleBindings)
+			})
+		if err != nil {
+			return err
 		}
 		connObj.MDS.JSONUnmarshal(resp.Body(), &r3)
 		wg.Add(len(r3))
 		for _, v := range r3 {
-			go c.mapRBACToACLMapping(v.Scope.Clusters, v.Rb, confRbacAclMappings, wg, lock)
+			go c.mapRBACToACLMapping(v.Scope.Clusters, v.Rb, mappings, clusterRoles, wg, lock)
 		}
+		return nil
 	}
-	for item := range r2.Iter() {
-		f3(item.(string))
+	for _, item := range r2.ToSlice() {
+		if err := f3(item.(string)); err != nil {
+			wg.Wait()
+			return err
+		}
 	}
 	wg.Wait()
+	c.aclMappings.set(clusterName, mappings)
+	c.clusterRoles.set(clusterName, clusterRoles)
+	return nil
+}
+
+// Refreshes and returns the Role Bindings provisioned in the cluster as Confluent RBAC mappings.
+func (c ConfluentRbacACLExecutionManagerImpl) GetClusterACL(ctx context.Context, clusterName string) (*ksengine.ACLMapping, error) {
+	if err := c.ListClusterACL(ctx, clusterName, false); err != nil {
+		return nil, err
+	}
+	return c.aclMappings.get(clusterName), nil
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) mapRBACToACLMapping(cluster, rb map[string]interface{}, mapping *ksengine.ACLMapping, wg *sync.WaitGroup, mtx *sync.Mutex) {
+func (c ConfluentRbacACLExecutionManagerImpl) mapRBACToACLMapping(cluster, rb map[string]interface{}, mapping *ksengine.ACLMapping, clusterRoles *ksengine.ACLMapping,
+	wg *sync.WaitGroup, mtx *sync.Mutex) {
 	defer wg.Done()
-	value := make(map[string]string)
+	value := make(ksengine.NVPairs)
+	// The cluster level Role Bindings apply to the most specific cluster of the scope.
+	scopeType, scopeName := ksengine.KafkaResourceType_CLUSTER, kCluster
 	for k, v := range cluster {
 		v := v.(string)
 		switch k {
@@ -216,26 +304,29 @@ func (c ConfluentRbacACLExecutionManagerImpl) mapRBACToACLMapping(cluster, rb ma
 			value[kCluster] = v
 		case cCluster:
 			value[cCluster] = v
+			scopeType, scopeName = ksengine.KafkaResourceType_CONNECT_CLUSTER, cCluster
 		case ksqlCluster:
 			value[ksqlCluster] = v
+			scopeType, scopeName = ksengine.KafkaResourceType_KSQL_CLUSTER, ksqlCluster
 		case srCluster:
 			value[srCluster] = v
+			scopeType, scopeName = ksengine.KafkaResourceType_SCHEMA_REGISTRY_CLUSTER, srCluster
 		}
 	}
 	for user, permMap := range rb {
 		for perm, resMap := range permMap.(map[string]interface{}) {
+			if len(resMap.([]interface{})) == 0 {
+				mtx.Lock()
+				clusterRoles.Append(c.constructACLDetailsObject(scopeType, scopeName, ksengine.KafkaACLPatternType_LITERAL,
+					user, ConfluentRBACOperation(perm), "*"), value)
+				mtx.Unlock()
+			}
 			for _, acl := range resMap.([]interface{}) {
 				acl := acl.(map[string]interface{})
 				resType, _ := ksengine.KafkaResourceType_ANY.GetACLResourceValue(acl["resourceType"].(string))
 				mtx.Lock()
-				mapping.Append(ksengine.ACLDetails{
-					ResourceType: resType,
-					ResourceName: acl["name"].(string),
-					PatternType:  confluentRBAC2KafkaPatternTypeConversion[acl["patternType"].(string)],
-					Principal:    user,
-					Operation:    ConfluentRBACOperation(perm),
-					Hostname:     "*",
-				}, value)
+				mapping.Append(c.constructACLDetailsObject(resType, acl["name"].(string),
+					confluentRBAC2KafkaPatternTypeConversion[acl["patternType"].(string)], user, ConfluentRBACOperation(perm), "*"), value)
 				mtx.Unlock()
 			}
 		}
@@ -248,7 +339,8 @@ func (c ConfluentRbacACLExecutionManagerImpl) mapFromShepherdACL(clusterName str
 		value, multiValue := make(ksengine.NVPairs), make(ksengine.NVPairs)
 		value[kCluster] = connObj.KafkaClusterID
 		multiValue[kCluster] = connObj.KafkaClusterID
-		v := v.(ksengine.NVPairs)
+		// Only the connectors, streams and ksqlDB roles carry additional values, the rest are nil.
+		v, _ := v.(ksengine.NVPairs)
 		switch k.Operation {
 		case ksengine.ShepherdOperationType_PRODUCER:
 			out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_TOPIC, k.ResourceName, c.determinePatternType(k.ResourceName),
@@ -264,8 +356,14 @@ func (c ConfluentRbacACLExecutionManagerImpl) mapFromShepherdACL(clusterName str
 			out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL,
 				k.Principal, ConfluentRBACOperation("DeveloperWrite"), k.Hostname), value)
 		case ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER:
-			out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_TRANSACTIONALID, k.ResourceName, ksengine.KafkaACLPatternType_LITERAL,
-				k.Principal, ConfluentRBACOperation("ResourceOwner"), k.Hostname), value)
+			// DeveloperWrite allows WRITE and DESCRIBE on the Transactional ID, DeveloperRead on the group covers the offset commits.
+			out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_TRANSACTIONALID, k.ResourceName, k.PatternType,
+				k.Principal, ConfluentRBACOperation("DeveloperWrite"), k.Hostname), value)
+			if gName := v[ksengine.KafkaResourceType_GROUP.GetACLResourceString()]; gName != "" {
+				gName, gPattern := c.determineGroupPatternType(gName)
+				out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_GROUP, gName, gPattern,
+					k.Principal, ConfluentRBACOperation("DeveloperRead"), k.Hostname), value)
+			}
 		case ksengine.ShepherdOperationType_CONSUMER:
 			if k.ResourceType == ksengine.KafkaResourceType_TOPIC {
 				out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_TOPIC, k.ResourceName, c.determinePatternType(k.ResourceName),
@@ -277,10 +375,11 @@ func (c ConfluentRbacACLExecutionManagerImpl) mapFromShepherdACL(clusterName str
 					out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_SUBJECT, fmt.Sprintf("%s-value", k.ResourceName), ksengine.KafkaACLPatternType_LITERAL,
 						k.Principal, ConfluentRBACOperation("DeveloperRead"), k.Hostname), multiValue)
 				}
-				if k.ResourceType == ksengine.KafkaResourceType_GROUP {
-					out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_GROUP, k.ResourceName, ksengine.KafkaACLPatternType_PREFIXED,
-						k.Principal, ConfluentRBACOperation("DeveloperRead"), k.Hostname), value)
-				}
+			}
+			// DeveloperRead on the group allows READ and DESCRIBE, which the consumers need to commit and fetch offsets.
+			if k.ResourceType == ksengine.KafkaResourceType_GROUP {
+				out.Append(c.constructACLDetailsObject(ksengine.KafkaResourceType_GROUP, k.ResourceName, k.PatternType,
+					k.Principal, ConfluentRBACOperation("DeveloperRead"), k.Hostname), value)
 			}
 		// case ksengine.ShepherdOperationType_CONSUMER_GROUP:
 		case ksengine.ShepherdOperationType_SOURCE_CONNECTOR, ksengine.ShepherdOperationType_SINK_CONNECTOR:
@@ -335,46 +434,160 @@ func (c ConfluentRbacACLExecutionManagerImpl) mapFromShepherdACL(clusterName str
 	}
 }
 
-// func (c ConfluentRbacACLExecutionManagerImpl) mapToShepherdACL(clusterName string, in *ksengine.ACLMapping, out *ksengine.ACLMapping, failed *ksengine.ACLMapping) {
-// 	// TODO: Convert Confluent ACL's back to the Shepherd ACL format for interconversion support
-// 	for k, v := range *in {
-// 		if k.ResourceType != ksengine.KafkaResourceType_TOPIC && k.ResourceType != ksengine.KafkaResourceType_CLUSTER && k.ResourceType != ksengine.KafkaResourceType_GROUP && k.ResourceType != ksengine.KafkaResourceType_TRANSACTIONALID {
-// 			logger.Warnf("Resource Type %s is not supported as they may not have a logical conversion to the Shepherd ACLs. Adding to the list of Failed ACLs.", k.ResourceType.GetACLResourceString())
-// 			failed.Append(k, v)
-// 			continue
-// 		}
-// 		switch k.Operation {
-// 		case ConfluentRBACOperation("DeveloperRead"):
-// 			if k.ResourceType == ksengine.KafkaResourceType_TOPIC {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_CONSUMER, k.Hostname), nil)
-// 			}
-// 			if k.ResourceType == ksengine.KafkaResourceType_GROUP {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_CONSUMER_GROUP, k.Hostname), nil)
-// 			}
-// 		case ConfluentRBACOperation("DeveloperWrite"):
-// 			if k.ResourceType == ksengine.KafkaResourceType_TOPIC {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_PRODUCER, k.Hostname), nil)
-// 			}
-// 			if k.ResourceType == ksengine.KafkaResourceType_CLUSTER {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_PRODUCER_IDEMPOTENCE, k.Hostname), nil)
-// 			}
-// 			if k.ResourceType == ksengine.KafkaResourceType_TRANSACTIONALID {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER, k.Hostname), nil)
-// 			}
-// 		case ConfluentRBACOperation("ResourceOwner"):
-// 			if k.ResourceType == ksengine.KafkaResourceType_TOPIC {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_CONSUMER, k.Hostname), nil)
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_PRODUCER, k.Hostname), nil)
-// 			}
-// 			if k.ResourceType == ksengine.KafkaResourceType_GROUP {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_CONSUMER_GROUP, k.Hostname), nil)
-// 			}
-// 			if k.ResourceType == ksengine.KafkaResourceType_TRANSACTIONALID {
-// 				out.Append(c.constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER, k.Hostname), nil)
-// 			}
-// 		}
-// 	}
-// }
+/*
+	Translates the provisioned Role Bindings back to the Shepherd client roles, for auditing the cluster. The cluster
+	level Role Bindings (e.g. SystemAdmin, Operator) have no Shepherd equivalent and are listed as unclassified.
+*/
+func (c ConfluentRbacACLExecutionManagerImpl) AuditClusterACL(ctx context.Context, clusterName string) error {
+	provisioned, err := c.GetClusterACL(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	out, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}
+	c.mapToShepherdACL(clusterName, provisioned, &out, &failed)
+	for k, v := range *c.clusterRoles.get(clusterName) {
+		failed.Append(k, v)
+	}
+	c.listAuditedACL(clusterName, &out, &failed)
+	return nil
+}
+
+/*
+	Translates the Role Bindings back to the Shepherd client roles. DeveloperRead, DeveloperWrite and ResourceOwner
+	on the Topics, Groups, Transactional IDs and the Kafka cluster map to the producers and consumers. The Subject
+	bindings of a mapped topic (<topic>-key and <topic>-value) are part of the topic role. The topics read or written
+	by a principal with DeveloperWrite on a ksqlDB cluster map to the ksqlDB roles, along with the internal topics and
+	groups of the ksqlDB cluster. Every other Role Binding has no Shepherd equivalent and is added to the failed mapping.
+*/
+func (c ConfluentRbacACLExecutionManagerImpl) mapToShepherdACL(clusterName string, in *ksengine.ACLMapping, out *ksengine.ACLMapping, failed *ksengine.ACLMapping) {
+	perPrincipal := make(map[string]ksengine.ACLMapping)
+	for k, v := range *in {
+		if _, ok := k.Operation.(ConfluentRBACOperation); !ok {
+			failed.Append(k, v)
+			continue
+		}
+		if _, found := perPrincipal[k.Principal]; !found {
+			perPrincipal[k.Principal] = ksengine.ACLMapping{}
+		}
+		perPrincipal[k.Principal][k] = v
+	}
+	for principal, bindings := range perPrincipal {
+		covered := c.mapPrincipalToShepherdACL(principal, bindings, out)
+		for k, v := range bindings {
+			if !covered[k] {
+				failed.Append(k, v)
+			}
+		}
+	}
+}
+
+func (c ConfluentRbacACLExecutionManagerImpl) mapPrincipalToShepherdACL(principal string, bindings ksengine.ACLMapping, out *ksengine.ACLMapping) map[ksengine.ACLDetails]bool {
+	covered := make(map[ksengine.ACLDetails]bool)
+	// The topics that are part of a role, with their pattern type, so that their Subject bindings can be covered.
+	topics := make(map[string]ksengine.ACLPatternInterface)
+	addRole := func(k ksengine.ACLDetails, rType ksengine.KafkaResourceType, op ksengine.ShepherdOperationType, value interface{}) {
+		out.Append(c.constructACLDetailsObject(rType, k.ResourceName, k.PatternType, principal, op, k.Hostname), value)
+		covered[k] = true
+		if rType == ksengine.KafkaResourceType_TOPIC {
+			topics[k.ResourceName] = k.PatternType
+		}
+	}
+
+	keys := make([]ksengine.ACLDetails, 0, len(bindings))
+	ksqlBindings, ksqlClusters := []ksengine.ACLDetails{}, []string{}
+	for k := range bindings {
+		keys = append(keys, k)
+		switch {
+		case k.ResourceType == ksengine.KafkaResourceType_KSQL_CLUSTER && k.Operation == ConfluentRBACOperation("DeveloperWrite"):
+			ksqlBindings = append(ksqlBindings, k)
+		case k.ResourceType == ksengine.KafkaResourceType_GROUP && k.PatternType == ksengine.KafkaACLPatternType_PREFIXED &&
+			k.Operation == ConfluentRBACOperation("DeveloperRead") && strings.HasPrefix(k.ResourceName, "_confluent-ksql-"):
+			ksqlClusters = append(ksqlClusters, strings.TrimPrefix(k.ResourceName, "_confluent-ksql-"))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	sort.Strings(ksqlClusters)
+
+	if len(ksqlBindings) != 0 {
+		for _, cName := range ksqlClusters {
+			internal := map[ksengine.ACLDetails]bool{
+				c.constructACLDetailsObject(ksengine.KafkaResourceType_GROUP, fmt.Sprintf("_confluent-ksql-%s", cName), ksengine.KafkaACLPatternType_PREFIXED,
+					principal, ConfluentRBACOperation("DeveloperRead"), "*"): true,
+				c.constructACLDetailsObject(ksengine.KafkaResourceType_TOPIC, fmt.Sprintf("%sksql_processing_log", cName), ksengine.KafkaACLPatternType_LITERAL,
+					principal, ConfluentRBACOperation("DeveloperRead"), "*"): true,
+				c.constructACLDetailsObject(ksengine.KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%stransient", cName), ksengine.KafkaACLPatternType_PREFIXED,
+					principal, ConfluentRBACOperation("ResourceOwner"), "*"): true,
+			}
+			value := ksengine.NVPairs{ksengine.KafkaResourceType_KSQL_CLUSTER.GetACLResourceString(): cName}
+			mapped := false
+			for _, k := range keys {
+				if covered[k] || internal[k] || k.ResourceType != ksengine.KafkaResourceType_TOPIC {
+					continue
+				}
+				switch k.Operation {
+				case ConfluentRBACOperation("DeveloperRead"):
+					addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_KSQL_READ, value)
+					mapped = true
+				case ConfluentRBACOperation("DeveloperWrite"):
+					addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_KSQL_WRITE, value)
+					mapped = true
+				}
+			}
+			// The internal topics and groups are only part of a role if the ksqlDB cluster is used for any topic.
+			if !mapped {
+				continue
+			}
+			for k := range internal {
+				if _, found := bindings[k]; found {
+					covered[k] = true
+				}
+			}
+			for _, k := range ksqlBindings {
+				covered[k] = true
+			}
+		}
+	}
+
+	for _, k := range keys {
+		if covered[k] {
+			continue
+		}
+		switch {
+		case k.ResourceType == ksengine.KafkaResourceType_TOPIC && k.Operation == ConfluentRBACOperation("DeveloperRead"):
+			addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_CONSUMER, nil)
+		case k.ResourceType == ksengine.KafkaResourceType_TOPIC && k.Operation == ConfluentRBACOperation("DeveloperWrite"):
+			addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_PRODUCER, nil)
+		case k.ResourceType == ksengine.KafkaResourceType_TOPIC && k.Operation == ConfluentRBACOperation("ResourceOwner"):
+			addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_PRODUCER, nil)
+			addRole(k, ksengine.KafkaResourceType_TOPIC, ksengine.ShepherdOperationType_CONSUMER, nil)
+		case k.ResourceType == ksengine.KafkaResourceType_GROUP &&
+			(k.Operation == ConfluentRBACOperation("DeveloperRead") || k.Operation == ConfluentRBACOperation("ResourceOwner")):
+			addRole(k, ksengine.KafkaResourceType_GROUP, ksengine.ShepherdOperationType_CONSUMER, nil)
+		case k.ResourceType == ksengine.KafkaResourceType_TRANSACTIONALID &&
+			(k.Operation == ConfluentRBACOperation("DeveloperWrite") || k.Operation == ConfluentRBACOperation("ResourceOwner")):
+			addRole(k, ksengine.KafkaResourceType_TRANSACTIONALID, ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER, nil)
+		case k.ResourceType == ksengine.KafkaResourceType_CLUSTER && k.ResourceName == kCluster && k.Operation == ConfluentRBACOperation("DeveloperWrite"):
+			addRole(k, ksengine.KafkaResourceType_CLUSTER, ksengine.ShepherdOperationType_PRODUCER_IDEMPOTENCE, nil)
+		}
+	}
+
+	for _, k := range keys {
+		if covered[k] || k.ResourceType != ksengine.KafkaResourceType_SUBJECT {
+			continue
+		}
+		if k.PatternType == ksengine.KafkaACLPatternType_LITERAL {
+			for _, suffix := range []string{"-key", "-value"} {
+				if pType, found := topics[strings.TrimSuffix(k.ResourceName, suffix)]; found && strings.HasSuffix(k.ResourceName, suffix) &&
+					pType == ksengine.KafkaACLPatternType_LITERAL {
+					covered[k] = true
+				}
+			}
+		} else if pType, found := topics[k.ResourceName]; found && pType == k.PatternType {
+			covered[k] = true
+		}
+	}
+	return covered
+}
 
 func (c ConfluentRBACOperation) String() string {
 	// return strings.ToUpper(strings.TrimSpace(c))
@@ -385,29 +598,87 @@ func (c ConfluentRBACOperation) GetValue(in string) (ksengine.ACLOperationsInter
 	return ConfluentRBACOperation(strings.TrimSpace(in)), nil
 }
 
+/*
+	Mapping to Role Bindings needs the cluster IDs of the MDS connection, so it is done by the ACL Manager
+	(see NewACLControllers) instead. The operation on its own cannot map anything.
+*/
 func (c ConfluentRBACOperation) GenerateACLMappingStructures(clusterName string, in *ksengine.ACLMapping) *ksengine.ACLMapping {
-	out, temp, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}, ksengine.ACLMapping{}
+	logger.Warnw("Role Bindings can only be generated by the Confluent RBAC ACL Manager. Nothing will be mapped.",
+		"Cluster Name", clusterName)
+	return &ksengine.ACLMapping{}
+}
+
+func (c ConfluentRbacACLExecutionManagerImpl) GenerateACLMappingStructures(clusterName string, in *ksengine.ACLMapping) *ksengine.ACLMapping {
+	out, temp, kafkaACLs, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}, ksengine.ACLMapping{}, ksengine.ACLMapping{}
 	for k, v := range *in {
 		switch k.Operation.(type) {
 		case ConfluentRBACOperation:
-			out.Append(k, v)
+			if value, ok := c.retargetScope(clusterName, v); ok {
+				out.Append(k, value)
+				continue
+			}
+			logger.Warnw("The Role Binding scope refers to a cluster that is not configured for the target. The ACL mapping will be added to the Failed list.",
+				"Cluster Name", clusterName,
+				"Principal", k.Principal,
+				"Role Name", k.Operation.String(),
+				"Scope", v)
+			failed.Append(k, v)
 		case ksengine.ShepherdOperationType:
 			temp.Append(k, v)
+		case ksengine.KafkaACLOperation:
+			kafkaACLs.Append(k, v)
 		default:
-			logger.Warnf("Conversion is only supported Between Shepherd Config Type and %T. The ACL mapping will be added to the Failed list", c)
+			logger.Warnf("Conversion is only supported from Shepherd Config Type, Kafka ACLs and %T. The ACL mapping will be added to the Failed list", c.ConfluentRBACOperation)
 			failed.Append(k, v)
 		}
 	}
 	if len(temp) > 0 {
-		ConfluentRbacACLManager.mapFromShepherdACL(clusterName, &temp, &out, &failed)
+		c.mapFromShepherdACL(clusterName, &temp, &out, &failed)
+	}
+	if len(kafkaACLs) > 0 {
+		converted, untranslated, report := c.ConvertKafkaACLs(clusterName, &kafkaACLs)
+		for k, v := range *converted {
+			out.Append(k, v)
+		}
+		for k, v := range *untranslated {
+			failed.Append(k, v)
+		}
+		if report.HasWidening() {
+			ksmisc.DottedLineOutput("Privileges Widened by the Kafka ACL Conversion", "=", 80)
+			report.WriteTable(os.Stdout)
+		}
 	}
 	if len(failed) != 0 {
 		ksmisc.DottedLineOutput("Failed ACLs", "=", 80)
-		ConfluentRbacACLManager.ListConfigACL(true, &failed)
+		c.ListConfigACL(&failed)
 	}
 	return &out
 }
 
+/*
+	Role Bindings read from a cluster carry the cluster IDs of that cluster in their scope. This rewrites
+	the scope with the IDs of the provided cluster, so that the Role Bindings read from one cluster can be
+	created on another. It fails if the scope refers to a cluster type that the provided cluster does not have.
+*/
+func (c ConfluentRbacACLExecutionManagerImpl) retargetScope(clusterName string, in interface{}) (ksengine.NVPairs, bool) {
+	connObj := c.getConnectionObject(clusterName)
+	ids := ksengine.NVPairs{
+		kCluster:    connObj.KafkaClusterID,
+		cCluster:    connObj.ConnectClusterID,
+		ksqlCluster: connObj.KSQLClusterID,
+		srCluster:   connObj.SRClusterID,
+	}
+	out := ksengine.NVPairs{kCluster: connObj.KafkaClusterID}
+	scope, _ := in.(ksengine.NVPairs)
+	for cName := range scope {
+		if ids[cName] == "" {
+			return nil, false
+		}
+		out[cName] = ids[cName]
+	}
+	return out, true
+}
+
 func (c ConfluentRbacACLExecutionManagerImpl) createClustersObject(clusterName string, aName string, aVal string) map[string]interface{} {
 	connObj := c.getConnectionObject(clusterName)
 	if aName != "" {
@@ -425,7 +696,8 @@ func (c ConfluentRbacACLExecutionManagerImpl) createClustersObject(clusterName s
 	}
 }
 
-func (c ConfluentRbacACLExecutionManagerImpl) executeRBRequest(clusterName string, mapKey mappingKey, mapVal []resources, method string, uri string, paramMap map[string]string, wg *sync.WaitGroup) {
+func (c ConfluentRbacACLExecutionManagerImpl) executeRBRequest(ctx context.Context, clusterName string, mapKey mappingKey, mapVal []resources, method string, uri string, paramMap map[string]string, wg *sync.WaitGroup,
+	errs *kafkamanagers.ErrorCollector) {
 	defer wg.Done()
 
 	var cluster Clusters = c.createClustersObject(clusterName, mapKey.otherClusterName, mapKey.otherClusterValue)
@@ -434,16 +706,19 @@ func (c ConfluentRbacACLExecutionManagerImpl) executeRBRequest(clusterName strin
 		Rb:    mapVal,
 	}
 	connObj := c.getConnectionObject(clusterName)
-
-	resp, err := connObj.MDS.R().SetBody(req).SetPathParams(paramMap).Execute(method, uri)
-	if err != nil || resp.StatusCode() >= 400 {
-		logger.Errorw("Cannot contact the MDS Server. Will not Retry Executing ACL Requests. Turn on debug for more details.",
-			"Request Method", resp.Request.Method,
-			"Request URL", resp.Request.URL,
-			"Request Body", resp.Request.Body,
-			"Response Body", string(resp.Body()),
-			"Status Code", resp.StatusCode(),
+	resp, err := c.executeMDSRequest(ctx, connObj, "Role Binding Request", "Cannot execute the Role Binding request on the MDS Server",
+		func(mdsReq *resty.Request) (*resty.Response, error) {
+			return mdsReq.SetBody(req).SetPathParams(paramMap).Execute(method, uri)
+		})
+	if err != nil {
+		logger.Errorw("Role Binding request failed. Will not retry. Turn on debug for more details.",
+			"Request Method", method,
+			"Request URI", uri,
+			"Path Params", paramMap,
+			"Request Body", req,
 			"Error", err)
+		errs.Add(err)
+		return
 	}
 
 	if resp.StatusCode() == 204 {
@@ -0,0 +1,128 @@
+package aclmanagers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+func (s *StackSuite) TestStackSuite_ConfluentRbac_MapToShepherdACL() {
+	c := ConfluentRbacACLExecutionManagerImpl{}
+	rb := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, prin string, role string) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, prin, ConfluentRBACOperation(role), "*")
+	}
+	role := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, prin string, op ksengine.ShepherdOperationType) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, prin, op, "*")
+	}
+	scope := ksengine.NVPairs{kCluster: "abc"}
+	in := &ksengine.ACLMapping{
+		// Producer with idempotence, transactions and the Subjects of the topic.
+		rb(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "User:1", "DeveloperWrite"):          scope,
+		rb(ksengine.KafkaResourceType_SUBJECT, "test.1-key", ksengine.KafkaACLPatternType_LITERAL, "User:1", "ResourceOwner"):     scope,
+		rb(ksengine.KafkaResourceType_SUBJECT, "test.1-value", ksengine.KafkaACLPatternType_LITERAL, "User:1", "ResourceOwner"):   scope,
+		rb(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:1", "DeveloperWrite"): scope,
+		rb(ksengine.KafkaResourceType_TRANSACTIONALID, "txn", ksengine.KafkaACLPatternType_LITERAL, "User:1", "ResourceOwner"):    scope,
+		// Consumer with a group, and a Subject of a topic it does not consume.
+		rb(ksengine.KafkaResourceType_TOPIC, "test.2", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperRead"):         scope,
+		rb(ksengine.KafkaResourceType_GROUP, "grp", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperRead"):            scope,
+		rb(ksengine.KafkaResourceType_SUBJECT, "test.3-value", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperRead"): scope,
+		// ksqlDB user with the internal topics and groups of the ksqlDB cluster.
+		rb(ksengine.KafkaResourceType_KSQL_CLUSTER, "ksql-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:3", "DeveloperWrite"):         scope,
+		rb(ksengine.KafkaResourceType_GROUP, "_confluent-ksql-k1", ksengine.KafkaACLPatternType_PREFIXED, "User:3", "DeveloperRead"):          scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "k1ksql_processing_log", ksengine.KafkaACLPatternType_LITERAL, "User:3", "DeveloperRead"):        scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "_confluent-ksql-k1transient", ksengine.KafkaACLPatternType_PREFIXED, "User:3", "ResourceOwner"): scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "test.4", ksengine.KafkaACLPatternType_PREFIXED, "User:3", "DeveloperRead"):                      scope,
+		// Roles without a Shepherd equivalent.
+		rb(ksengine.KafkaResourceType_TOPIC, "test.5", ksengine.KafkaACLPatternType_LITERAL, "User:4", "DeveloperManage"):   scope,
+		rb(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:4", "Operator"): scope,
+	}
+	out, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}
+	c.mapToShepherdACL("", in, &out, &failed)
+
+	s.Equal(ksengine.ACLMapping{
+		role(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "User:1", ksengine.ShepherdOperationType_PRODUCER):                      nil,
+		role(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:1", ksengine.ShepherdOperationType_PRODUCER_IDEMPOTENCE): nil,
+		role(ksengine.KafkaResourceType_TRANSACTIONALID, "txn", ksengine.KafkaACLPatternType_LITERAL, "User:1", ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER): nil,
+		role(ksengine.KafkaResourceType_TOPIC, "test.2", ksengine.KafkaACLPatternType_LITERAL, "User:2", ksengine.ShepherdOperationType_CONSUMER):                      nil,
+		role(ksengine.KafkaResourceType_GROUP, "grp", ksengine.KafkaACLPatternType_LITERAL, "User:2", ksengine.ShepherdOperationType_CONSUMER):                         nil,
+		role(ksengine.KafkaResourceType_TOPIC, "test.4", ksengine.KafkaACLPatternType_PREFIXED, "User:3", ksengine.ShepherdOperationType_KSQL_READ): ksengine.NVPairs{
+			ksengine.KafkaResourceType_KSQL_CLUSTER.GetACLResourceString(): "k1",
+		},
+	}, out)
+	s.Equal(ksengine.ACLMapping{
+		rb(ksengine.KafkaResourceType_SUBJECT, "test.3-value", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperRead"): scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "test.5", ksengine.KafkaACLPatternType_LITERAL, "User:4", "DeveloperManage"):       scope,
+		rb(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:4", "Operator"):     scope,
+	}, failed)
+}
+
+func (s *StackSuite) TestStackSuite_ConfluentRbac_MapFromShepherdACL_ConsumerGroups() {
+	c := ConfluentRbacACLExecutionManagerImpl{
+		connections: kafkamanagers.KafkaConnections{
+			kafkamanagers.KafkaConnectionsKey{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_CONFLUENT_MDS}: kafkamanagers.KafkaConnectionsValue{
+				Connection: &kafkamanagers.ConfluentMDSConnection{KafkaClusterID: "k1"},
+			},
+		},
+	}
+	role := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, op ksengine.ShepherdOperationType) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, "User:1", op, "*")
+	}
+	rb := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, r string) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, "User:1", ConfluentRBACOperation(r), "*")
+	}
+	in := ksengine.ACLMapping{
+		role(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, ksengine.ShepherdOperationType_CONSUMER): nil,
+		role(ksengine.KafkaResourceType_GROUP, "cg1", ksengine.KafkaACLPatternType_LITERAL, ksengine.ShepherdOperationType_CONSUMER):    nil,
+		role(ksengine.KafkaResourceType_GROUP, "app-", ksengine.KafkaACLPatternType_PREFIXED, ksengine.ShepherdOperationType_CONSUMER):  nil,
+	}
+	out, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}
+	c.mapFromShepherdACL("c1", &in, &out, &failed)
+
+	scope := ksengine.NVPairs{kCluster: "k1"}
+	s.Equal(ksengine.ACLMapping{
+		rb(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "DeveloperRead"): scope,
+		rb(ksengine.KafkaResourceType_GROUP, "cg1", ksengine.KafkaACLPatternType_LITERAL, "DeveloperRead"):    scope,
+		rb(ksengine.KafkaResourceType_GROUP, "app-", ksengine.KafkaACLPatternType_PREFIXED, "DeveloperRead"):  scope,
+	}, out)
+	s.Empty(failed)
+}
+
+func (s *StackSuite) TestStackSuite_ConfluentRbac_MapFromShepherdACL_TransactionalProducer() {
+	c := ConfluentRbacACLExecutionManagerImpl{
+		connections: kafkamanagers.KafkaConnections{
+			kafkamanagers.KafkaConnectionsKey{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_CONFLUENT_MDS}: kafkamanagers.KafkaConnectionsValue{
+				Connection: &kafkamanagers.ConfluentMDSConnection{KafkaClusterID: "k1"},
+			},
+		},
+	}
+	group := ksengine.NVPairs{ksengine.KafkaResourceType_GROUP.GetACLResourceString(): "app-*"}
+	in := ksengine.ACLMapping{
+		c.constructACLDetailsObject(ksengine.KafkaResourceType_TRANSACTIONALID, "orders-", ksengine.KafkaACLPatternType_PREFIXED, "User:1",
+			ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): group,
+		c.constructACLDetailsObject(ksengine.KafkaResourceType_TRANSACTIONALID, "txn.1", ksengine.KafkaACLPatternType_LITERAL, "User:1",
+			ksengine.ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): group,
+	}
+	out, failed := ksengine.ACLMapping{}, ksengine.ACLMapping{}
+	c.mapFromShepherdACL("c1", &in, &out, &failed)
+
+	rb := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, r string) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, "User:1", ConfluentRBACOperation(r), "*")
+	}
+	scope := ksengine.NVPairs{kCluster: "k1"}
+	s.Equal(ksengine.ACLMapping{
+		rb(ksengine.KafkaResourceType_TRANSACTIONALID, "orders-", ksengine.KafkaACLPatternType_PREFIXED, "DeveloperWrite"): scope,
+		rb(ksengine.KafkaResourceType_TRANSACTIONALID, "txn.1", ksengine.KafkaACLPatternType_LITERAL, "DeveloperWrite"):    scope,
+		rb(ksengine.KafkaResourceType_GROUP, "app-", ksengine.KafkaACLPatternType_PREFIXED, "DeveloperRead"):               scope,
+	}, out)
+	s.Empty(failed)
+}
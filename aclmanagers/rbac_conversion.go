@@ -0,0 +1,257 @@
+package aclmanagers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+// Implemented by the ACL Managers that convert Kafka ACLs to their own ACLs, e.g. while migrating a cluster.
+type KafkaACLConverter interface {
+	ConvertKafkaACLs(clusterName string, in *ksengine.ACLMapping) (out *ksengine.ACLMapping, untranslated *ksengine.ACLMapping, report ACLConversionReport)
+}
+
+// The Kafka ACL operations allowed by a predefined Confluent RBAC role on a resource.
+type rbacRolePrivileges struct {
+	role ConfluentRBACOperation
+	ops  []ksengine.KafkaACLOperation
+}
+
+/*
+	The roles that can be bound to the Kafka resources, ordered from the least to the most privileged. The
+	cluster wide roles (e.g. SystemAdmin, ClusterAdmin) are never used for a conversion.
+*/
+var rbacResourceRoles map[ksengine.KafkaResourceType][]rbacRolePrivileges = map[ksengine.KafkaResourceType][]rbacRolePrivileges{
+	ksengine.KafkaResourceType_TOPIC: {
+		{"DeveloperRead", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_READ, ksengine.KafkaACLOperation_DESCRIBE}},
+		{"DeveloperWrite", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_WRITE, ksengine.KafkaACLOperation_DESCRIBE}},
+		{"DeveloperManage", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_CREATE, ksengine.KafkaACLOperation_DELETE, ksengine.KafkaACLOperation_DESCRIBE,
+			ksengine.KafkaACLOperation_DESCRIBECONFIGS, ksengine.KafkaACLOperation_ALTERCONFIGS}},
+		{"ResourceOwner", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_ALL, ksengine.KafkaACLOperation_READ, ksengine.KafkaACLOperation_WRITE,
+			ksengine.KafkaACLOperation_CREATE, ksengine.KafkaACLOperation_DELETE, ksengine.KafkaACLOperation_ALTER, ksengine.KafkaACLOperation_DESCRIBE,
+			ksengine.KafkaACLOperation_DESCRIBECONFIGS, ksengine.KafkaACLOperation_ALTERCONFIGS}},
+	},
+	ksengine.KafkaResourceType_GROUP: {
+		{"DeveloperRead", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_READ, ksengine.KafkaACLOperation_DESCRIBE}},
+		{"DeveloperManage", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_DELETE, ksengine.KafkaACLOperation_DESCRIBE}},
+		{"ResourceOwner", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_ALL, ksengine.KafkaACLOperation_READ, ksengine.KafkaACLOperation_DELETE,
+			ksengine.KafkaACLOperation_DESCRIBE}},
+	},
+	ksengine.KafkaResourceType_TRANSACTIONALID: {
+		{"DeveloperWrite", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_WRITE, ksengine.KafkaACLOperation_DESCRIBE}},
+		{"ResourceOwner", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_ALL, ksengine.KafkaACLOperation_WRITE, ksengine.KafkaACLOperation_DESCRIBE}},
+	},
+	ksengine.KafkaResourceType_CLUSTER: {
+		{"DeveloperWrite", []ksengine.KafkaACLOperation{ksengine.KafkaACLOperation_IDEMPOTENTWRITE}},
+	},
+}
+
+// The operations that Kafka allows implicitly along with another operation on the same resource.
+var kafkaImpliedOperations map[ksengine.KafkaACLOperation][]ksengine.KafkaACLOperation = map[ksengine.KafkaACLOperation][]ksengine.KafkaACLOperation{
+	ksengine.KafkaACLOperation_READ:         {ksengine.KafkaACLOperation_DESCRIBE},
+	ksengine.KafkaACLOperation_WRITE:        {ksengine.KafkaACLOperation_DESCRIBE},
+	ksengine.KafkaACLOperation_DELETE:       {ksengine.KafkaACLOperation_DESCRIBE},
+	ksengine.KafkaACLOperation_ALTER:        {ksengine.KafkaACLOperation_DESCRIBE},
+	ksengine.KafkaACLOperation_ALTERCONFIGS: {ksengine.KafkaACLOperation_DESCRIBECONFIGS},
+}
+
+/*
+	The side by side outcome of converting the Kafka ACLs of a principal on a resource to Role Bindings. Widened lists
+	everything the Role Bindings allow that the Kafka ACLs did not, including the hosts as Role Bindings apply to all
+	of them.
+*/
+type ACLConversionEntry struct {
+	Principal    string
+	ResourceType string
+	ResourceName string
+	PatternType  string
+	KafkaACLs    []string
+	RoleBindings []string
+	Widened      []string
+}
+
+type ACLConversionReport []ACLConversionEntry
+
+func (r ACLConversionReport) HasWidening() bool {
+	for _, v := range r {
+		if len(v.Widened) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ACLConversionReport) WriteTable(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRINCIPAL\tRESOURCE\tKAFKA ACLS\tROLE BINDINGS\tWIDENED")
+	for _, v := range r {
+		widened := strings.Join(v.Widened, ", ")
+		if widened == "" {
+			widened = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s:%s:%s\t%s\t%s\t%s\n", v.Principal, v.ResourceType, v.PatternType, v.ResourceName,
+			strings.Join(v.KafkaACLs, ", "), strings.Join(v.RoleBindings, ", "), widened)
+	}
+	return w.Flush()
+}
+
+/*
+	Converts the Kafka ACLs to the least privileged Role Bindings that allow all of them. The ACLs of a principal
+	on a resource are converted together, and the combination of roles allowing the fewest operations beyond the
+	ACLs is used. The ACLs that no resource role can allow (e.g. cluster operations other than IdempotentWrite,
+	wildcard patterns, or DENY ACLs as Role Bindings only ever allow) are returned as untranslated.
+*/
+func (c ConfluentRbacACLExecutionManagerImpl) ConvertKafkaACLs(clusterName string, in *ksengine.ACLMapping) (*ksengine.ACLMapping, *ksengine.ACLMapping, ACLConversionReport) {
+	return c.convertKafkaACLs(c.getConnectionObject(clusterName).KafkaClusterID, in)
+}
+
+func (c ConfluentRbacACLExecutionManagerImpl) convertKafkaACLs(kafkaClusterID string, in *ksengine.ACLMapping) (*ksengine.ACLMapping, *ksengine.ACLMapping, ACLConversionReport) {
+	type resourceKey struct {
+		resourceType ksengine.ACLResourceInterface
+		resourceName string
+		patternType  ksengine.ACLPatternInterface
+		principal    string
+	}
+	out, untranslated, report := &ksengine.ACLMapping{}, &ksengine.ACLMapping{}, ACLConversionReport{}
+	resources := make(map[resourceKey]ksengine.ACLMapping)
+	for k, v := range *in {
+		if _, ok := k.Operation.(ksengine.KafkaACLOperation); !ok {
+			untranslated.Append(k, v)
+			continue
+		}
+		if k.IsDeny() {
+			logger.Warnw("Role Bindings cannot deny an operation. The DENY ACL will be added to the untranslated list.",
+				"Principal", k.Principal,
+				"Resource Type", k.ResourceType.GetACLResourceString(),
+				"Resource Name", k.ResourceName,
+				"ACL Operation", k.Operation.String())
+			untranslated.Append(k, v)
+			continue
+		}
+		rk := resourceKey{k.ResourceType, k.ResourceName, k.PatternType, k.Principal}
+		if _, found := resources[rk]; !found {
+			resources[rk] = ksengine.ACLMapping{}
+		}
+		resources[rk][k] = v
+	}
+
+	for rk, acls := range resources {
+		ops, hosts := make(map[ksengine.KafkaACLOperation]bool), make(map[string]bool)
+		for k := range acls {
+			ops[k.Operation.(ksengine.KafkaACLOperation)] = true
+			hosts[k.Hostname] = true
+		}
+		rType, _ := rk.resourceType.(ksengine.KafkaResourceType)
+		var roles []rbacRolePrivileges
+		if rk.patternType == ksengine.KafkaACLPatternType_LITERAL || rk.patternType == ksengine.KafkaACLPatternType_PREFIXED {
+			roles = leastPrivilegedRoles(rbacResourceRoles[rType], ops)
+		}
+		if roles == nil {
+			logger.Warnw("No Role Binding can allow the Kafka ACLs. They will be added to the untranslated list.",
+				"Principal", rk.principal,
+				"Resource Type", rk.resourceType.GetACLResourceString(),
+				"Resource Name", rk.resourceName,
+				"Resource Pattern Type", rk.patternType.GetACLPatternString())
+			for k, v := range acls {
+				untranslated.Append(k, v)
+			}
+			continue
+		}
+
+		entry := ACLConversionEntry{
+			Principal:    rk.principal,
+			ResourceType: rk.resourceType.GetACLResourceString(),
+			ResourceName: rk.resourceName,
+			PatternType:  rk.patternType.GetACLPatternString(),
+		}
+		granted := make(map[ksengine.KafkaACLOperation]bool)
+		for _, r := range roles {
+			out.Append(c.constructACLDetailsObject(rk.resourceType, rk.resourceName, rk.patternType, rk.principal, r.role, "*"),
+				ksengine.NVPairs{kCluster: kafkaClusterID})
+			entry.RoleBindings = append(entry.RoleBindings, r.role.String())
+			for _, op := range r.ops {
+				granted[op] = true
+			}
+		}
+		allowed := withImpliedOperations(ops)
+		for op := range ops {
+			entry.KafkaACLs = append(entry.KafkaACLs, op.String())
+		}
+		for op := range granted {
+			if !allowed[op] {
+				entry.Widened = append(entry.Widened, op.String())
+			}
+		}
+		sort.Strings(entry.KafkaACLs)
+		sort.Strings(entry.Widened)
+		if !hosts["*"] {
+			hostList := []string{}
+			for h := range hosts {
+				hostList = append(hostList, h)
+			}
+			sort.Strings(hostList)
+			entry.KafkaACLs = append(entry.KafkaACLs, fmt.Sprintf("hosts %s", strings.Join(hostList, " ")))
+			entry.Widened = append(entry.Widened, "all hosts")
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return fmt.Sprint(report[i].Principal, report[i].ResourceType, report[i].ResourceName, report[i].PatternType) <
+			fmt.Sprint(report[j].Principal, report[j].ResourceType, report[j].ResourceName, report[j].PatternType)
+	})
+	return out, untranslated, report
+}
+
+/*
+	Finds the combination of roles that allows all the operations and the fewest other operations. Ties go to the
+	combination with fewer roles and then to the less privileged roles. Returns nil if no combination allows all
+	the operations.
+*/
+func leastPrivilegedRoles(candidates []rbacRolePrivileges, ops map[ksengine.KafkaACLOperation]bool) []rbacRolePrivileges {
+	allowed := withImpliedOperations(ops)
+	var best []rbacRolePrivileges
+	bestExtra := -1
+	for mask := 1; mask < 1<<len(candidates); mask++ {
+		roles, granted := []rbacRolePrivileges{}, make(map[ksengine.KafkaACLOperation]bool)
+		for idx, r := range candidates {
+			if mask&(1<<idx) != 0 {
+				roles = append(roles, r)
+				for _, op := range r.ops {
+					granted[op] = true
+				}
+			}
+		}
+		covers := true
+		for op := range ops {
+			covers = covers && granted[op]
+		}
+		if !covers {
+			continue
+		}
+		extra := 0
+		for op := range granted {
+			if !allowed[op] {
+				extra++
+			}
+		}
+		if bestExtra == -1 || extra < bestExtra || (extra == bestExtra && len(roles) < len(best)) {
+			best, bestExtra = roles, extra
+		}
+	}
+	return best
+}
+
+func withImpliedOperations(ops map[ksengine.KafkaACLOperation]bool) map[ksengine.KafkaACLOperation]bool {
+	ret := make(map[ksengine.KafkaACLOperation]bool)
+	for op := range ops {
+		ret[op] = true
+		for _, v := range kafkaImpliedOperations[op] {
+			ret[v] = true
+		}
+	}
+	return ret
+}
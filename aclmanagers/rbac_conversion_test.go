@@ -0,0 +1,63 @@
+package aclmanagers
+
+import (
+	"bytes"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+func (s *StackSuite) TestStackSuite_ConfluentRbac_ConvertKafkaACLs() {
+	c := ConfluentRbacACLExecutionManagerImpl{}
+	acl := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, prin string, op ksengine.KafkaACLOperation, host string) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, prin, op, host)
+	}
+	rb := func(rType ksengine.KafkaResourceType, rName string, pType ksengine.KafkaACLPatternType, prin string, role string) ksengine.ACLDetails {
+		return c.constructACLDetailsObject(rType, rName, pType, prin, ConfluentRBACOperation(role), "*")
+	}
+	in := &ksengine.ACLMapping{
+		// Consumer that maps exactly to DeveloperRead.
+		acl(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "User:1", ksengine.KafkaACLOperation_READ, "*"):     nil,
+		acl(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "User:1", ksengine.KafkaACLOperation_DESCRIBE, "*"): nil,
+		acl(ksengine.KafkaResourceType_GROUP, "grp", ksengine.KafkaACLPatternType_PREFIXED, "User:1", ksengine.KafkaACLOperation_READ, "*"):       nil,
+		// Producer with a single host and without DESCRIBE, which is implied by WRITE.
+		acl(ksengine.KafkaResourceType_TOPIC, "test.2", ksengine.KafkaACLPatternType_LITERAL, "User:2", ksengine.KafkaACLOperation_WRITE, "abc.host"):             nil,
+		acl(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:2", ksengine.KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
+		// CREATE needs DeveloperManage, which widens the privileges.
+		acl(ksengine.KafkaResourceType_TOPIC, "test.3", ksengine.KafkaACLPatternType_LITERAL, "User:3", ksengine.KafkaACLOperation_READ, "*"):   nil,
+		acl(ksengine.KafkaResourceType_TOPIC, "test.3", ksengine.KafkaACLPatternType_LITERAL, "User:3", ksengine.KafkaACLOperation_CREATE, "*"): nil,
+		// No Role Binding allows these.
+		acl(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:4", ksengine.KafkaACLOperation_ALTER, "*"): nil,
+		acl(ksengine.KafkaResourceType_TOPIC, "*", ksengine.KafkaACLPatternType_ANY, "User:4", ksengine.KafkaACLOperation_READ, "*"):                    nil,
+	}
+	scope := ksengine.NVPairs{kCluster: "abc"}
+	out, untranslated, report := c.convertKafkaACLs("abc", in)
+
+	s.Equal(&ksengine.ACLMapping{
+		rb(ksengine.KafkaResourceType_TOPIC, "test.1", ksengine.KafkaACLPatternType_LITERAL, "User:1", "DeveloperRead"):           scope,
+		rb(ksengine.KafkaResourceType_GROUP, "grp", ksengine.KafkaACLPatternType_PREFIXED, "User:1", "DeveloperRead"):             scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "test.2", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperWrite"):          scope,
+		rb(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:2", "DeveloperWrite"): scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "test.3", ksengine.KafkaACLPatternType_LITERAL, "User:3", "DeveloperRead"):           scope,
+		rb(ksengine.KafkaResourceType_TOPIC, "test.3", ksengine.KafkaACLPatternType_LITERAL, "User:3", "DeveloperManage"):         scope,
+	}, out)
+	s.Equal(&ksengine.ACLMapping{
+		acl(ksengine.KafkaResourceType_CLUSTER, "kafka-cluster", ksengine.KafkaACLPatternType_LITERAL, "User:4", ksengine.KafkaACLOperation_ALTER, "*"): nil,
+		acl(ksengine.KafkaResourceType_TOPIC, "*", ksengine.KafkaACLPatternType_ANY, "User:4", ksengine.KafkaACLOperation_READ, "*"):                    nil,
+	}, untranslated)
+
+	s.Len(report, 5)
+	s.True(report.HasWidening())
+	widened := make(map[string][]string)
+	for _, v := range report {
+		widened[v.Principal+" "+v.ResourceName] = v.Widened
+	}
+	s.Nil(widened["User:1 test.1"])
+	s.Nil(widened["User:1 grp"])
+	s.Equal([]string{"all hosts"}, widened["User:2 test.2"])
+	s.Equal([]string{"ALTER_CONFIGS", "DELETE", "DESCRIBE_CONFIGS"}, widened["User:3 test.3"])
+
+	var buf bytes.Buffer
+	s.NoError(report.WriteTable(&buf))
+	s.Contains(buf.String(), "WIDENED")
+	s.Contains(buf.String(), "DeveloperRead, DeveloperManage")
+}
@@ -1,6 +1,8 @@
 package aclmanagers
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
 	"github.com/Shopify/sarama"
@@ -11,12 +13,16 @@ import (
 
 type SaramaACLExecutionManagerImpl struct {
 	ACLExecutionManagerBaseImpl
+	connections kafkamanagers.KafkaConnections
+	aclMappings *clusterACLMappings
+	retry       kafkamanagers.RetryPolicy
 }
 
-var (
-	SaramaACLManager  ACLExecutionManager = SaramaACLExecutionManagerImpl{}
-	saramaAclMappings *engine.ACLMapping
+func NewSaramaACLManager(connections kafkamanagers.KafkaConnections, retry kafkamanagers.RetryPolicy) ACLExecutionManager {
+	return SaramaACLExecutionManagerImpl{connections: connections, aclMappings: newClusterACLMappings(), retry: retry}
+}
 
+var (
 	sarama2KafkaResourceTypeConversion map[sarama.AclResourceType]engine.ACLResourceInterface = map[sarama.AclResourceType]engine.ACLResourceInterface{
 		sarama.AclResourceUnknown:         engine.KafkaResourceType_UNKNOWN,
 		sarama.AclResourceAny:             engine.KafkaResourceType_ANY,
@@ -107,18 +113,21 @@ var (
 	it to execute any functionality in this module.
 */
 func (t SaramaACLExecutionManagerImpl) getConnectionObject(clusterName string) *sarama.ClusterAdmin {
-	return kafkamanagers.Connections[kafkamanagers.KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: kafkamanagers.ConnectionType_SARAMA}].Connection.(*kafkamanagers.SaramaConnection).SCA
+	return t.connections.GetSaramaConnection(clusterName)
 }
 
-func (s SaramaACLExecutionManagerImpl) CreateACL(clusterName string, in *engine.ACLMapping, dryRun bool) {
+func (s SaramaACLExecutionManagerImpl) CreateACL(ctx context.Context, clusterName string, in *engine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Create Cluster ACLs", "=", 80)
-	s.ListClusterACL(clusterName, false)
-	createSet := s.FindNonExistentACLsInCluster(clusterName, saramaAclMappings, engine.KafkaACLOperation_ANY)
-	s.createACLs(clusterName, createSet, dryRun)
+	if err := s.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	createSet := s.FindNonExistentACLsInCluster(in, s.aclMappings.get(clusterName))
+	return s.createACLs(ctx, clusterName, createSet, dryRun)
 }
 
-func (s SaramaACLExecutionManagerImpl) createACLs(clusterName string, in *engine.ACLMapping, dryRun bool) {
+func (s SaramaACLExecutionManagerImpl) createACLs(ctx context.Context, clusterName string, in *engine.ACLMapping, dryRun bool) error {
 	wg := new(sync.WaitGroup)
+	errs := new(kafkamanagers.ErrorCollector)
 
 	f := func(key engine.ACLDetails, val interface{}) {
 		defer wg.Done()
@@ -131,7 +140,7 @@ func (s SaramaACLExecutionManagerImpl) createACLs(clusterName string, in *engine
 			Principal:      key.Principal,
 			Host:           key.Hostname,
 			Operation:      kafka2SaramaACLOperationConversion[key.Operation],
-			PermissionType: sarama.AclPermissionAllow,
+			PermissionType: kafka2SaramaPermissionTypeConversion[key.PermissionType],
 		}
 		if dryRun {
 			logger.Infow("CreateACL Request",
@@ -144,12 +153,15 @@ func (s SaramaACLExecutionManagerImpl) createACLs(clusterName string, in *engine
 				"Permission Type", a.PermissionType.String(),
 			)
 		} else {
-			err := (*s.getConnectionObject(clusterName)).CreateACL(r, a)
+			err := s.retry.Do(ctx, "ACL Creation", func(context.Context) error {
+				return (*s.getConnectionObject(clusterName)).CreateACL(r, a)
+			})
 			if err != nil {
 				logger.Warnw("Was not able to create the ACL.",
 					"Resource Details", r.ResourceName,
 					"ACL Type", a.Operation.String(),
 					"Error", err)
+				errs.Add(kafkamanagers.NewSaramaError(fmt.Sprintf("Cannot create the ACL %s", key.PlanName()), err))
 			} else {
 				logger.Infow("Successfully created ACL.",
 					"Resource Details", r.ResourceName,
@@ -164,31 +176,40 @@ func (s SaramaACLExecutionManagerImpl) createACLs(clusterName string, in *engine
 		go f(k, v)
 	}
 	wg.Wait()
+	if err := errs.Err("ACL Creation failed"); err != nil {
+		return err
+	}
+	return ctx.Err()
 }
 
-func (s SaramaACLExecutionManagerImpl) DeleteProvisionedACL(clusterName string, in *engine.ACLMapping, dryRun bool) {
+func (s SaramaACLExecutionManagerImpl) DeleteProvisionedACL(ctx context.Context, clusterName string, in *engine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Delete Config ACLs", "=", 80)
-	s.ListClusterACL(clusterName, false)
-	deleteSet := s.FindProvisionedACLsInCluster(clusterName, saramaAclMappings, engine.KafkaACLOperation_ANY)
-	s.deleteACLs(clusterName, deleteSet, dryRun)
+	if err := s.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	deleteSet := s.FindProvisionedACLsInCluster(in, s.aclMappings.get(clusterName))
+	return s.deleteACLs(ctx, clusterName, deleteSet, dryRun)
 }
 
-func (s SaramaACLExecutionManagerImpl) DeleteUnknownACL(clusterName string, in *engine.ACLMapping, dryRun bool) {
+func (s SaramaACLExecutionManagerImpl) DeleteUnknownACL(ctx context.Context, clusterName string, in *engine.ACLMapping, dryRun bool) error {
 	ksmisc.DottedLineOutput("Delete Unknown ACLs", "=", 80)
-	s.ListClusterACL(clusterName, false)
-	deleteSet := s.FindNonExistentACLsInConfig(clusterName, saramaAclMappings, engine.KafkaACLOperation_ANY)
-	s.deleteACLs(clusterName, deleteSet, dryRun)
+	if err := s.ListClusterACL(ctx, clusterName, false); err != nil {
+		return err
+	}
+	deleteSet := s.FindNonExistentACLsInConfig(in, s.aclMappings.get(clusterName))
+	return s.deleteACLs(ctx, clusterName, deleteSet, dryRun)
 }
 
-func (s SaramaACLExecutionManagerImpl) deleteACLs(clusterName string, in *engine.ACLMapping, dryRun bool) {
+func (s SaramaACLExecutionManagerImpl) deleteACLs(ctx context.Context, clusterName string, in *engine.ACLMapping, dryRun bool) error {
 	wg := new(sync.WaitGroup)
+	errs := new(kafkamanagers.ErrorCollector)
 	f := func(key engine.ACLDetails, val interface{}) {
 		defer wg.Done()
 		filter := sarama.AclFilter{
 			ResourceType:              kafka2SaramaResourceTypeConversion[key.ResourceType],
 			ResourceName:              &key.ResourceName,
 			ResourcePatternTypeFilter: kafka2SaramaPatternTypeConversion[key.PatternType],
-			PermissionType:            sarama.AclPermissionAllow,
+			PermissionType:            kafka2SaramaPermissionTypeConversion[key.PermissionType],
 			Principal:                 &key.Principal,
 			Host:                      &key.Hostname,
 			Operation:                 kafka2SaramaACLOperationConversion[key.Operation],
@@ -205,12 +226,15 @@ func (s SaramaACLExecutionManagerImpl) deleteACLs(clusterName string, in *engine
 				"Permission Type", filter.PermissionType.String(),
 			)
 		} else {
-			match, err := (*s.getConnectionObject(clusterName)).DeleteACL(filter, false)
+			match, err := s.retry.DoWithResult(ctx, "ACL Deletion", func(context.Context) (interface{}, error) {
+				return (*s.getConnectionObject(clusterName)).DeleteACL(filter, false)
+			})
 			if err != nil {
-				logger.Warnw("Was not able to create the ACL.",
+				logger.Warnw("Was not able to delete the ACL.",
 					"Resource Details", filter.ResourceName,
 					"ACL Operation Type", filter.Operation.String(),
 					"Error", err)
+				errs.Add(kafkamanagers.NewSaramaError(fmt.Sprintf("Cannot delete the ACL %s", key.PlanName()), err))
 			} else {
 				logger.Infow("Successfully deleted ACL.",
 					"Resource Details", filter.ResourceName,
@@ -224,18 +248,26 @@ func (s SaramaACLExecutionManagerImpl) deleteACLs(clusterName string, in *engine
 		go f(k, v)
 	}
 	wg.Wait()
+	if err := errs.Err("ACL Deletion failed"); err != nil {
+		return err
+	}
+	return ctx.Err()
 }
 
-func (s SaramaACLExecutionManagerImpl) ListClusterACL(clusterName string, printOutput bool) {
-	acls := s.gatherClusterACLs(clusterName)
+func (s SaramaACLExecutionManagerImpl) ListClusterACL(ctx context.Context, clusterName string, printOutput bool) error {
+	acls, err := s.gatherClusterACLs(ctx, clusterName)
+	if err != nil {
+		return err
+	}
 	wg := new(sync.WaitGroup)
 	lock := &sync.Mutex{}
 	wg.Add(len(*acls))
-	saramaAclMappings = &engine.ACLMapping{}
+	mappings := &engine.ACLMapping{}
 	for _, v := range *acls {
-		go s.mapSaramaToKafkaACL(v, saramaAclMappings, wg, lock)
+		go s.mapSaramaToKafkaACL(v, mappings, wg, lock)
 	}
 	wg.Wait()
+	s.aclMappings.set(clusterName, mappings)
 	if printOutput {
 		for _, in := range *acls {
 			for _, v := range in.Acls {
@@ -250,41 +282,50 @@ func (s SaramaACLExecutionManagerImpl) ListClusterACL(clusterName string, printO
 				)
 			}
 		}
-		for k := range *saramaAclMappings {
-			perm := engine.KafkaACLPermissionType_ALLOW
-			logger.Infow("Mapped Kafka ACL Details (Only Alllow Mappings are filtered)",
+		for k := range *mappings {
+			logger.Infow("Mapped Kafka ACL Details (Only Allow and Deny Mappings are filtered)",
 				"Resource Type", k.ResourceType.GetACLResourceString(),
 				"Resource Name", k.ResourceName,
 				"Resource Pattern Type", k.PatternType.GetACLPatternString(),
 				"Principal Name", k.Principal,
 				"Host", k.Hostname,
 				"ACL Operation", k.Operation.String(),
-				"Permission Type", perm.String(),
+				"Permission Type", k.PermissionType.String(),
 			)
 		}
 	}
+	return nil
+}
+
+// Refreshes and returns the ACLs provisioned in the Kafka Cluster as Kafka ACL mappings.
+func (s SaramaACLExecutionManagerImpl) GetClusterACL(ctx context.Context, clusterName string) (*engine.ACLMapping, error) {
+	if err := s.ListClusterACL(ctx, clusterName, false); err != nil {
+		return nil, err
+	}
+	return s.aclMappings.get(clusterName), nil
 }
 
 func (s SaramaACLExecutionManagerImpl) mapSaramaToKafkaACL(in sarama.ResourceAcls, mapping *engine.ACLMapping, wg *sync.WaitGroup, mtx *sync.Mutex) {
 	defer wg.Done()
 
 	for _, v := range in.Acls {
-		if v.PermissionType == sarama.AclPermissionAllow {
+		if v.PermissionType == sarama.AclPermissionAllow || v.PermissionType == sarama.AclPermissionDeny {
 			mtx.Lock()
 			mapping.Append(engine.ACLDetails{
-				ResourceType: sarama2KafkaResourceTypeConversion[in.Resource.ResourceType],
-				ResourceName: in.Resource.ResourceName,
-				PatternType:  sarama2KafkaPatternTypeConversion[in.Resource.ResourcePatternType],
-				Principal:    v.Principal,
-				Operation:    sarama2KafkaACLOperationConversion[v.Operation],
-				Hostname:     v.Host,
+				ResourceType:   sarama2KafkaResourceTypeConversion[in.Resource.ResourceType],
+				ResourceName:   in.Resource.ResourceName,
+				PatternType:    sarama2KafkaPatternTypeConversion[in.Resource.ResourcePatternType],
+				Principal:      v.Principal,
+				Operation:      sarama2KafkaACLOperationConversion[v.Operation],
+				Hostname:       v.Host,
+				PermissionType: sarama2KafkaPermissionTypeConversion[v.PermissionType],
 			}, nil)
 			mtx.Unlock()
 		}
 	}
 }
 
-func (s SaramaACLExecutionManagerImpl) gatherClusterACLs(clusterName string) *[]sarama.ResourceAcls {
+func (s SaramaACLExecutionManagerImpl) gatherClusterACLs(ctx context.Context, clusterName string) (*[]sarama.ResourceAcls, error) {
 	filter := sarama.AclFilter{
 		ResourcePatternTypeFilter: sarama.AclPatternAny,
 		ResourceType:              sarama.AclResourceAny,
@@ -292,11 +333,14 @@ func (s SaramaACLExecutionManagerImpl) gatherClusterACLs(clusterName string) *[]
 		Operation:                 sarama.AclOperationAny,
 		Version:                   1,
 	}
-	acls, err := (*s.getConnectionObject(clusterName)).ListAcls(filter)
+	acls, err := s.retry.DoWithResult(ctx, "List ACLs", func(context.Context) (interface{}, error) {
+		return (*s.getConnectionObject(clusterName)).ListAcls(filter)
+	})
 	if err != nil {
-		logger.Fatalw("Failed to list Kafka Cluster ACLs. Cannot proceed without the correct ACLs.")
+		return nil, kafkamanagers.NewSaramaError("Failed to list Kafka Cluster ACLs", err)
 	}
-	return &acls
+	aclList := acls.([]sarama.ResourceAcls)
+	return &aclList, nil
 }
 
 func (c SaramaACLExecutionManagerImpl) GenerateACLMappingStructures(clusterName string, in *engine.ACLMapping) *engine.ACLMapping {
@@ -307,6 +351,25 @@ func (s SaramaACLExecutionManagerImpl) mapFromShepherdACL(clusterName string, in
 	panic("implementation not available") // TODO: implement
 }
 
-// func (s SaramaACLExecutionManagerImpl) mapToShepherdACL(clusterName string, in *engine.ACLMapping, out *engine.ACLMapping, failed *engine.ACLMapping) {
-// 	panic("implementation not available") // TODO: implement
-// }
+// Translates the provisioned Kafka ACLs back to the Shepherd client roles, for auditing the cluster.
+func (s SaramaACLExecutionManagerImpl) AuditClusterACL(ctx context.Context, clusterName string) error {
+	provisioned, err := s.GetClusterACL(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	out, failed := engine.ACLMapping{}, engine.ACLMapping{}
+	s.mapToShepherdACL(clusterName, provisioned, &out, &failed)
+	s.listAuditedACL(clusterName, &out, &failed)
+	return nil
+}
+
+// The ACLs that are not part of any Shepherd client role are added to the failed mapping as unclassified.
+func (s SaramaACLExecutionManagerImpl) mapToShepherdACL(clusterName string, in *engine.ACLMapping, out *engine.ACLMapping, failed *engine.ACLMapping) {
+	mapped, unclassified := engine.MapKafkaACLsToShepherd(in)
+	for k, v := range *mapped {
+		out.Append(k, v)
+	}
+	for k, v := range *unclassified {
+		failed.Append(k, v)
+	}
+}
@@ -1,11 +1,14 @@
 package aclmanagers
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
 	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
 )
 
 var (
@@ -13,59 +16,94 @@ var (
 )
 
 /*
-	The two maps below control which manager will be used for what kind of ACL's. It provides the appropriate
-	ACL Manager Object as well as the ACLOperationInterface used for execution.
+	The ACL Managers working with the connections of one registry. The two maps control which manager will be
+	used for what kind of ACL's. They provide the appropriate ACL Manager Object as well as the
+	ACLOperationInterface used for execution.
 */
-var (
-	aclController map[kafkamanagers.ConnectionType]ACLExecutionManager = map[kafkamanagers.ConnectionType]ACLExecutionManager{
-		kafkamanagers.ConnectionType_KAFKA_ACLS:    SaramaACLManager,
-		kafkamanagers.ConnectionType_SARAMA:        SaramaACLManager,
-		kafkamanagers.ConnectionType_CONFLUENT_MDS: ConfluentRbacACLManager,
-	}
+type ACLControllers struct {
+	connections   kafkamanagers.KafkaConnections
+	aclController map[kafkamanagers.ConnectionType]ACLExecutionManager
+	aclInterface  map[kafkamanagers.ConnectionType]ksengine.ACLOperationsInterface
+}
 
-	aclInterface map[kafkamanagers.ConnectionType]ksengine.ACLOperationsInterface = map[kafkamanagers.ConnectionType]ksengine.ACLOperationsInterface{
-		kafkamanagers.ConnectionType_KAFKA_ACLS:    ksengine.KafkaACLOperation_UNKNOWN,
-		kafkamanagers.ConnectionType_SARAMA:        ksengine.KafkaACLOperation_UNKNOWN,
-		kafkamanagers.ConnectionType_CONFLUENT_MDS: ConfluentRBACOperation("Unknown"),
+// Every request made by the ACL Managers to the cluster is executed with the provided retry policy.
+func NewACLControllers(connections kafkamanagers.KafkaConnections, retry kafkamanagers.RetryPolicy) *ACLControllers {
+	saramaManager := NewSaramaACLManager(connections, retry)
+	rbacManager := NewConfluentRbacACLManager(connections, retry)
+	return &ACLControllers{
+		connections: connections,
+		aclController: map[kafkamanagers.ConnectionType]ACLExecutionManager{
+			kafkamanagers.ConnectionType_KAFKA_ACLS:    saramaManager,
+			kafkamanagers.ConnectionType_SARAMA:        saramaManager,
+			kafkamanagers.ConnectionType_CONFLUENT_MDS: rbacManager,
+		},
+		// The Role Bindings are generated by the RBAC manager itself, as it needs the cluster IDs from its connections.
+		aclInterface: map[kafkamanagers.ConnectionType]ksengine.ACLOperationsInterface{
+			kafkamanagers.ConnectionType_KAFKA_ACLS:    ksengine.KafkaACLOperation_UNKNOWN,
+			kafkamanagers.ConnectionType_SARAMA:        ksengine.KafkaACLOperation_UNKNOWN,
+			kafkamanagers.ConnectionType_CONFLUENT_MDS: rbacManager,
+		},
 	}
-)
+}
 
 /*
 	The user can logically derive these values themselves but the convenience method below provides the implemented
-	values as an output. If its a forked repo, this is the method and the maps above are the ones to be changed.
+	values as an output. If its a forked repo, this is the method and the maps in NewACLControllers are the ones to be changed.
 */
-func GetACLControllerDetails(clusterName string, cType string) (ACLExecutionManager, ksengine.ACLOperationsInterface) {
+func (c *ACLControllers) GetACLControllerDetails(clusterName string, cType string) (ACLExecutionManager, ksengine.ACLOperationsInterface) {
 	v, _ := kafkamanagers.ConnectionType_UNKNOWN.GetValue(cType)
-	aclType := kafkamanagers.Connections[kafkamanagers.KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: v}].ConnectionType
-	execMgr := aclController[aclType]
-	execInterface := aclInterface[aclType]
+	aclType := c.connections[kafkamanagers.KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: v}].ConnectionType
+	execMgr := c.aclController[aclType]
+	execInterface := c.aclInterface[aclType]
 	return execMgr, execInterface
 }
 
+/*
+	The ACLs fetched from the clusters are cached per cluster name, as the same ACL Manager is used
+	for every cluster with the same connection type and the clusters may be executed concurrently.
+*/
+type clusterACLMappings struct {
+	lock     sync.RWMutex
+	mappings map[string]*ksengine.ACLMapping
+}
+
+func newClusterACLMappings() *clusterACLMappings {
+	return &clusterACLMappings{mappings: make(map[string]*ksengine.ACLMapping)}
+}
+
+func (c *clusterACLMappings) get(clusterName string) *ksengine.ACLMapping {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if v, found := c.mappings[clusterName]; found {
+		return v
+	}
+	return &ksengine.ACLMapping{}
+}
+
+func (c *clusterACLMappings) set(clusterName string, in *ksengine.ACLMapping) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.mappings[clusterName] = in
+}
+
 // Any ACL Manager will need to implement this interface.
 type ACLExecutionManager interface {
-	CreateACL(clusterName string, in *ksengine.ACLMapping, dryRun bool)
-	DeleteProvisionedACL(clusterName string, in *ksengine.ACLMapping, dryRun bool)
-	DeleteUnknownACL(clusterName string, in *ksengine.ACLMapping, dryRun bool)
-	ListClusterACL(clusterName string, printOutput bool)
-	ListConfigACL(useProvidedInput bool, in *ksengine.ACLMapping)
+	CreateACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error
+	DeleteProvisionedACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error
+	DeleteUnknownACL(ctx context.Context, clusterName string, in *ksengine.ACLMapping, dryRun bool) error
+	ListClusterACL(ctx context.Context, clusterName string, printOutput bool) error
+	GetClusterACL(ctx context.Context, clusterName string) (*ksengine.ACLMapping, error)
+	ListConfigACL(in *ksengine.ACLMapping)
+	AuditClusterACL(ctx context.Context, clusterName string) error
 	GenerateACLMappingStructures(clusterName string, in *ksengine.ACLMapping) *ksengine.ACLMapping
 	mapFromShepherdACL(clusterName string, in *ksengine.ACLMapping, out *ksengine.ACLMapping, failed *ksengine.ACLMapping)
-	// This method will be added when the Migration templates are available.
-	// mapToShepherdACL(clusterName string, in *ksengine.ACLMapping, out *ksengine.ACLMapping, failed *ksengine.ACLMapping)
+	mapToShepherdACL(clusterName string, in *ksengine.ACLMapping, out *ksengine.ACLMapping, failed *ksengine.ACLMapping)
 }
 
 type ACLExecutionManagerBaseImpl struct{}
 
-func (a ACLExecutionManagerBaseImpl) ListConfigACL(useProvidedInput bool, in *ksengine.ACLMapping) {
-	perm := ksengine.KafkaACLPermissionType_ALLOW
-	var temp *ksengine.ACLMapping
-	if useProvidedInput {
-		temp = in
-	} else {
-		temp = ksengine.ShepherdACLList
-	}
-	for k, v := range *temp {
+func (a ACLExecutionManagerBaseImpl) ListConfigACL(in *ksengine.ACLMapping) {
+	for k, v := range *in {
 		logger.Infow("Config ACL Mapping Details",
 			"Resource Type", k.ResourceType.GetACLResourceString(),
 			"Resource Name", k.ResourceName,
@@ -73,7 +111,37 @@ func (a ACLExecutionManagerBaseImpl) ListConfigACL(useProvidedInput bool, in *ks
 			"Principal Name", k.Principal,
 			"Host", k.Hostname,
 			"ACL Operation", k.Operation.String(),
-			"Permission Type", perm.String(),
+			"Permission Type", k.PermissionType.String(),
+			"Value", v,
+		)
+	}
+}
+
+// Lists the provisioned ACLs in Shepherd terms, along with the ones that could not be translated to a client role.
+func (a ACLExecutionManagerBaseImpl) listAuditedACL(clusterName string, roles *ksengine.ACLMapping, unclassified *ksengine.ACLMapping) {
+	ksmisc.DottedLineOutput(fmt.Sprintf("Shepherd Client Roles: %s", clusterName), "=", 80)
+	for k, v := range *roles {
+		logger.Infow("Shepherd Client Role Details",
+			"Resource Type", k.ResourceType.GetACLResourceString(),
+			"Resource Name", k.ResourceName,
+			"Resource Pattern Type", k.PatternType.GetACLPatternString(),
+			"Principal Name", k.Principal,
+			"Host", k.Hostname,
+			"Client Role", k.Operation.String(),
+			"Permission Type", k.PermissionType.String(),
+			"Value", v,
+		)
+	}
+	ksmisc.DottedLineOutput(fmt.Sprintf("Unclassified ACLs: %s", clusterName), "=", 80)
+	for k, v := range *unclassified {
+		logger.Warnw("Unclassified ACL Details",
+			"Resource Type", k.ResourceType.GetACLResourceString(),
+			"Resource Name", k.ResourceName,
+			"Resource Pattern Type", k.PatternType.GetACLPatternString(),
+			"Principal Name", k.Principal,
+			"Host", k.Hostname,
+			"ACL Operation", k.Operation.String(),
+			"Permission Type", k.PermissionType.String(),
 			"Value", v,
 		)
 	}
@@ -82,12 +150,13 @@ func (a ACLExecutionManagerBaseImpl) ListConfigACL(useProvidedInput bool, in *ks
 func (a ACLExecutionManagerBaseImpl) constructACLDetailsObject(resType ksengine.ACLResourceInterface, resName string, patType ksengine.ACLPatternInterface,
 	prin string, op ksengine.ACLOperationsInterface, host string) ksengine.ACLDetails {
 	return ksengine.ACLDetails{
-		ResourceType: resType,
-		ResourceName: resName,
-		PatternType:  patType,
-		Principal:    prin,
-		Operation:    op,
-		Hostname:     host,
+		ResourceType:   resType,
+		ResourceName:   resName,
+		PatternType:    patType,
+		Principal:      prin,
+		Operation:      op,
+		Hostname:       host,
+		PermissionType: ksengine.KafkaACLPermissionType_ALLOW,
 	}
 }
 
@@ -96,43 +165,72 @@ func (a ACLExecutionManagerBaseImpl) determinePatternType(topicName string) ksen
 		return ksengine.KafkaACLPatternType_LITERAL
 		// return KafkaACLPatternType_UNKNOWN
 	}
-	if strings.HasSuffix(topicName, fmt.Sprintf("%s*", ksengine.SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken)) {
+	// Topic names cannot contain "*", so the only names ending with it are the wildcards generated for a scope.
+	if strings.HasSuffix(topicName, "*") {
 		return ksengine.KafkaACLPatternType_PREFIXED
 	}
 	// return KafkaACLPatternType_UNKNOWN
 	return ksengine.KafkaACLPatternType_LITERAL
 }
 
+// Groups and Transactional IDs ending with "*" are prefixed, the resource name is the prefix in front of the "*".
+func (a ACLExecutionManagerBaseImpl) determineGroupPatternType(name string) (string, ksengine.KafkaACLPatternType) {
+	if name != "*" && strings.HasSuffix(name, "*") {
+		return strings.TrimSuffix(name, "*"), ksengine.KafkaACLPatternType_PREFIXED
+	}
+	return name, ksengine.KafkaACLPatternType_LITERAL
+}
+
 /*
 	Returns the Map of ACLMapping by comparing the output of ACL's present in the Kafka Cluster
-	to the map of ACLMapping created by parsing the configurations. The response is the mapping
-	that the Kafka connection will need to create as a baseline.
+	to the map of ACLMapping that is expected to be present (usually generated by parsing the
+	configurations). The response is the mapping that the Kafka connection will need to create
+	as a baseline.
 */
-func (a ACLExecutionManagerBaseImpl) FindNonExistentACLsInCluster(clusterName string, in *ksengine.ACLMapping, providedAclType ksengine.ACLOperationsInterface) *ksengine.ACLMapping {
-	// convertedList := ksengine.Shepherd.RenderACLMappings(clusterName, ksengine.ShepherdACLList, providedAclType)
-	convertedList := providedAclType.GenerateACLMappingStructures(clusterName, ksengine.ShepherdACLList)
-	return a.conditionalACLMapper(convertedList, in, false)
+func (a ACLExecutionManagerBaseImpl) FindNonExistentACLsInCluster(expected *ksengine.ACLMapping, provisioned *ksengine.ACLMapping) *ksengine.ACLMapping {
+	return a.conditionalACLMapper(expected, provisioned, false)
 }
 
 /*
 	Returns ACLMapping construct for the ACLs that are provisioned in the Kafka cluster, but are
-	not available as part of the configuration files.
+	not available in the expected ACLMapping.
 */
-func (a ACLExecutionManagerBaseImpl) FindNonExistentACLsInConfig(clusterName string, in *ksengine.ACLMapping, providedAclType ksengine.ACLOperationsInterface) *ksengine.ACLMapping {
-	// convertedList := ksengine.Shepherd.RenderACLMappings(clusterName, ksengine.ShepherdACLList, providedAclType)
-	convertedList := providedAclType.GenerateACLMappingStructures(clusterName, ksengine.ShepherdACLList)
-	return a.conditionalACLMapper(in, convertedList, false)
+func (a ACLExecutionManagerBaseImpl) FindNonExistentACLsInConfig(expected *ksengine.ACLMapping, provisioned *ksengine.ACLMapping) *ksengine.ACLMapping {
+	return a.conditionalACLMapper(provisioned, expected, false)
 }
 
 /*
-	Compares the list of ACLMappings provided from the Kafka Cluster to the ACLMappings that are
-	part of the Configurations. It returns ACL Stream that is a part of the Shepherd Config and
-	is already provisioned in the Kafka Cluster
+	Compares the list of ACLMappings provided from the Kafka Cluster to the expected ACLMappings.
+	It returns ACL Stream that is a part of the expected ACLMappings and is already provisioned
+	in the Kafka Cluster. The provisioned ACLs that overlap an expected ACL (a literal ACL covered
+	by a prefixed one or the other way around, e.g. from a run before aclPolicy.optimizeACLs was
+	changed) are returned as well, so that the Shepherd ACLs are removed whichever form they are in.
+	The creation and the deletion of the unknown ACLs stay exact, which is safe as the expected ACLs
+	are always created before the unknown ones are deleted.
 */
-func (a ACLExecutionManagerBaseImpl) FindProvisionedACLsInCluster(clusterName string, in *ksengine.ACLMapping, providedAclType ksengine.ACLOperationsInterface) *ksengine.ACLMapping {
-	// convertedList := ksengine.Shepherd.RenderACLMappings(clusterName, ksengine.ShepherdACLList, providedAclType)
-	convertedList := providedAclType.GenerateACLMappingStructures(clusterName, ksengine.ShepherdACLList)
-	return a.conditionalACLMapper(convertedList, in, true)
+func (a ACLExecutionManagerBaseImpl) FindProvisionedACLsInCluster(expected *ksengine.ACLMapping, provisioned *ksengine.ACLMapping) *ksengine.ACLMapping {
+	ret := a.conditionalACLMapper(expected, provisioned, true)
+	for k, v := range *provisioned {
+		if _, found := (*ret)[k]; found {
+			continue
+		}
+		for e := range *expected {
+			if aclsOverlap(e, k) {
+				(*ret)[k] = v
+				break
+			}
+		}
+	}
+	return ret
+}
+
+func aclsOverlap(a ksengine.ACLDetails, b ksengine.ACLDetails) bool {
+	if a.ResourceType != b.ResourceType || a.Principal != b.Principal || a.Operation != b.Operation || a.Hostname != b.Hostname ||
+		a.PermissionType != b.PermissionType {
+		return false
+	}
+	return (a.PatternType == ksengine.KafkaACLPatternType_PREFIXED && strings.HasPrefix(b.ResourceName, a.ResourceName)) ||
+		(b.PatternType == ksengine.KafkaACLPatternType_PREFIXED && strings.HasPrefix(a.ResourceName, b.ResourceName))
 }
 
 func (a ACLExecutionManagerBaseImpl) conditionalACLMapper(inputACLs *ksengine.ACLMapping, findIn *ksengine.ACLMapping, presenceCheck bool) *ksengine.ACLMapping {
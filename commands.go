@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	workflow "github.com/waliaabhishek/kafka-shepherd/workflowmanagers"
+	"gopkg.in/yaml.v2"
+)
+
+type command struct {
+	path  string
+	desc  string
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, sp *workflow.Shepherd) error
+	// Run mode forced by the command, regardless of the runmode flag.
+	runMode engine.RunMode
+	// Number of arguments accepted after the command flags.
+	maxArgs int
+}
+
+// Flag values shared by the commands. Only the ones registered for the invoked command are ever set.
+var (
+	cmdDryRun  bool
+	cmdForce   bool
+	cmdConnect bool
+	cmdSource  string
+	cmdTarget  string
+	cmdFormat  string
+	cmdOutFile string
+	cmdOutDir  string
+	cmdArgs    []string
+)
+
+var commands = []command{
+	{
+		path: "plan",
+		desc: "Lists the topic, ACL, quota, SCRAM credential, subject, KSQL and connector changes that apply would execute, without executing them.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cmdFormat, "format", "", "Output format. Options are table, json. Defaults to table on stdout and json with -out.")
+			fs.StringVar(&cmdOutFile, "out", "", "File to save the plan to, which can then be executed by apply. Defaults to stdout.")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			sp.State.DryRun = true
+			plan, results := sp.PlanAllWorkflows(ctx)
+			if err := results.Err(); err != nil {
+				results.PrintSummary()
+				return err
+			}
+			format := cmdFormat
+			if format == "" && cmdOutFile != "" {
+				format = "json"
+			} else if format == "" {
+				format = "table"
+			}
+			return writeOutput(cmdOutFile, func(out io.Writer) error {
+				return writePlan(out, format, plan)
+			})
+		},
+	},
+	{
+		path:    "apply",
+		desc:    "Creates, modifies and deletes topics, ACLs, quotas, SCRAM credentials, subjects, ksqlDB queries and connectors to match the configurations. If a saved plan file is provided, only the planned changes are executed.",
+		flags:   dryRunFlags,
+		maxArgs: 1,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if len(cmdArgs) == 1 {
+				return applyPlanFile(ctx, sp, cmdArgs[0])
+			}
+			if err := report(sp.ExecuteAllWorkflows(ctx)); err != nil || !sp.State.IsTest {
+				return err
+			}
+			if err := report(sp.DeleteShepherdTopics(ctx, true)); err != nil {
+				return err
+			}
+			return report(sp.DeleteShepherdACLs(ctx, true))
+		},
+	},
+	{
+		path:  "topics create",
+		desc:  "Creates the topics that are configured but not present in the clusters.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteTopicManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "topics modify",
+		desc:  "Aligns the partitions and configurations of the provisioned topics with the configurations.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteTopicManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "topics delete",
+		desc:  "Deletes the topics present in the clusters but not in the configurations.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics = true
+			}
+			return report(sp.ExecuteTopicManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path:  "acls create",
+		desc:  "Creates the ACLs that are configured but not present in the clusters.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteACLManagementWorkflow(ctx, true, false))
+		},
+	},
+	{
+		path:  "acls delete",
+		desc:  "Deletes the ACLs present in the clusters but not in the configurations.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs = true
+			}
+			return report(sp.ExecuteACLManagementWorkflow(ctx, false, true))
+		},
+	},
+	{
+		path: "acls list",
+		desc: "Lists the ACLs from the clusters or from the configurations.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cmdSource, "source", "cluster", "Where the ACLs are listed from. Options are cluster, config")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			switch strings.ToLower(cmdSource) {
+			case "cluster":
+				return report(sp.ListACLs(ctx, true))
+			case "config":
+				return report(sp.ListACLs(ctx, false))
+			}
+			return fmt.Errorf("unknown source %q, options are cluster, config", cmdSource)
+		},
+	},
+	{
+		path:  "acls audit",
+		desc:  "Lists the ACLs from the clusters as Shepherd client roles, along with the ACLs that are not part of any role.",
+		flags: func(fs *flag.FlagSet) {},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.AuditACLs(ctx))
+		},
+	},
+	{
+		path:  "quotas create",
+		desc:  "Sets the quotas of the users and client ids that have no quotas in the clusters yet.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteQuotaManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "quotas modify",
+		desc:  "Aligns the quotas of the users and client ids in the clusters with the configurations.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteQuotaManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "quotas delete",
+		desc:  "Removes the quotas of the users and client ids that are not in the configurations.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownQuotas = true
+			}
+			return report(sp.ExecuteQuotaManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path:  "scram create",
+		desc:  "Creates the SCRAM credentials of the users that have none in the clusters yet.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteScramManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "scram modify",
+		desc:  "Aligns the SCRAM mechanisms and iterations of the users with the configurations, and sets the rotated passwords.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteScramManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "scram delete",
+		desc:  "Deletes the SCRAM credentials of the users that are not in the configurations, except the protected ones.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownScramUsers = true
+			}
+			return report(sp.ExecuteScramManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path:  "subjects create",
+		desc:  "Registers the schemas of the topics whose subjects are not present in the Schema Registry.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteSubjectManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "subjects modify",
+		desc:  "Registers the changed schemas and aligns the compatibility levels of the provisioned subjects with the configurations.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteSubjectManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "subjects delete",
+		desc:  "Soft deletes the subjects of the topics that are not in the configurations.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownSubjects = true
+			}
+			return report(sp.ExecuteSubjectManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path:  "ksql create",
+		desc:  "Creates the streams, the tables and the queries of the KSQL files that are not present in the ksqlDB clusters.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteKSQLManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "ksql modify",
+		desc:  "Replaces the queries running in the ksqlDB clusters that drifted from the statements of the KSQL files.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteKSQLManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "ksql delete",
+		desc:  "Terminates the persistent queries running in the ksqlDB clusters that are not in the KSQL files.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownKSQLQueries = true
+			}
+			return report(sp.ExecuteKSQLManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path:  "connectors create",
+		desc:  "Creates the connectors that are configured but not present in the Connect clusters.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteConnectorManagementWorkflow(ctx, true, false, false))
+		},
+	},
+	{
+		path:  "connectors modify",
+		desc:  "Aligns the configs and the running or paused state of the provisioned connectors with the configurations.",
+		flags: dryRunFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return report(sp.ExecuteConnectorManagementWorkflow(ctx, false, true, false))
+		},
+	},
+	{
+		path:  "connectors delete",
+		desc:  "Deletes the connectors present in the Connect clusters but not in the configurations.",
+		flags: deleteFlags,
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdForce {
+				sp.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownConnectors = true
+			}
+			return report(sp.ExecuteConnectorManagementWorkflow(ctx, false, false, true))
+		},
+	},
+	{
+		path: "config validate",
+		desc: "Parses and validates the configurations. Optionally connects to the enabled clusters.",
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&cmdConnect, "connect", false, "Also connects to every enabled cluster to validate the connection details.")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdConnect {
+				return report(sp.ValidateConnections(ctx))
+			}
+			return nil
+		},
+	},
+	{
+		path:    "config generate",
+		desc:    "Generates the blueprints and definitions files from the topics and ACLs of the enabled cluster.",
+		runMode: engine.RunMode_CREATE_CONFIGS,
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cmdOutDir, "outDir", ".", "Directory to write blueprints.yaml and definitions.yaml to.")
+			fs.BoolVar(&cmdForce, "force", false, "Overwrites the files if they already exist.")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			r, err := sp.ExecuteCreateConfigsWorkflow(ctx)
+			if err != nil {
+				return err
+			}
+			r.Print()
+			if err := writeYAMLFile(filepath.Join(cmdOutDir, "blueprints.yaml"), r.Blueprints, cmdForce); err != nil {
+				return err
+			}
+			return writeYAMLFile(filepath.Join(cmdOutDir, "definitions.yaml"), r.Definitions, cmdForce)
+		},
+	},
+	{
+		path:    "migrate",
+		desc:    "Copies the topics and ACLs from a source cluster to a target cluster.",
+		runMode: engine.RunMode_MIGRATION,
+		flags: func(fs *flag.FlagSet) {
+			dryRunFlags(fs)
+			fs.StringVar(&cmdSource, "source", "", "Name of the cluster to migrate from.")
+			fs.StringVar(&cmdTarget, "target", "", "Name of the cluster to migrate to.")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			if cmdSource == "" || cmdTarget == "" {
+				return fmt.Errorf("both source and target clusters are required")
+			}
+			r, err := sp.ExecuteMigrationWorkflow(ctx, cmdSource, cmdTarget)
+			if r != nil {
+				r.Print()
+			}
+			return err
+		},
+	},
+	{
+		path: "export",
+		desc: "Exports the resolved topics and ACLs from the configurations.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cmdFormat, "format", "yaml", "Output format. Options are json, yaml")
+			fs.StringVar(&cmdOutFile, "out", "", "File to write the export to. Defaults to stdout.")
+		},
+		run: func(ctx context.Context, sp *workflow.Shepherd) error {
+			return writeOutput(cmdOutFile, func(out io.Writer) error {
+				return writeExport(out, cmdFormat, sp.State.ExportConfigs())
+			})
+		},
+	},
+}
+
+/*
+	Finds the command matching the leading arguments. Commands with a sub command (e.g. topics create)
+	need both words to be present, so the longest path is checked first.
+*/
+func findCommand(args []string) (command, bool) {
+	for _, depth := range []int{2, 1} {
+		if len(args) < depth {
+			continue
+		}
+		path := strings.Join(args[:depth], " ")
+		for _, c := range commands {
+			if c.path == path {
+				return c, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+func (c command) depth() int {
+	return len(strings.Fields(c.path))
+}
+
+/*
+	Creates the Shepherd instance after the command line has been parsed. The config parsing failures are
+	returned instead of exiting, so the command is not run and the failure is reported by main like any
+	other command error. Panics not recovered by the workflows are turned into an error here as well.
+*/
+func (c command) execute(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	cmdOpts := opts
+	if c.runMode != engine.RunMode_UNKNOWN {
+		cmdOpts.RunMode = c.runMode
+	}
+	cmdOpts.DryRun = cmdOpts.DryRun || cmdDryRun
+	sp, err := workflow.New(cmdOpts)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+	return c.run(ctx, sp)
+}
+
+func report(results workflow.ClusterResults) error {
+	results.PrintSummary()
+	return results.Err()
+}
+
+func dryRunFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&cmdDryRun, "dryRun", false, "Does not execute anything but lists what will be executed.")
+}
+
+func deleteFlags(fs *flag.FlagSet) {
+	dryRunFlags(fs)
+	fs.BoolVar(&cmdForce, "force", false, "Deletes even if the delete switch is turned off in the Shepherd core configuration.")
+}
+
+func applyPlanFile(ctx context.Context, sp *workflow.Shepherd, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	plan, err := engine.ReadPlan(f)
+	if err != nil {
+		return err
+	}
+	results, err := sp.ApplyPlan(ctx, plan)
+	if err != nil {
+		return err
+	}
+	return report(results)
+}
+
+// Calls write with the file at path, or with stdout if the path is empty.
+func writeOutput(path string, write func(out io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f)
+}
+
+func writePlan(out io.Writer, format string, in *engine.Plan) error {
+	switch strings.ToLower(format) {
+	case "table":
+		return in.WriteTable(out)
+	case "json":
+		return in.WriteJSON(out)
+	}
+	return fmt.Errorf("unknown format %q, options are table, json", format)
+}
+
+func writeExport(out io.Writer, format string, in *engine.ExportedConfig) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(in)
+	case "yaml":
+		return yaml.NewEncoder(out).Encode(in)
+	}
+	return fmt.Errorf("unknown format %q, options are json, yaml", format)
+}
+
+func writeYAMLFile(path string, in interface{}, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return yaml.NewEncoder(f).Encode(in)
+}
@@ -0,0 +1,226 @@
+package connectormanagers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+type ConnectRESTExecutionManagerImpl struct {
+	connections kafkamanagers.KafkaConnections
+	expected    *ksengine.ConnectorConfigMapping
+	retry       kafkamanagers.RetryPolicy
+}
+
+const (
+	connect_ListConnectors  = "/connectors"
+	connect_ConnectorConfig = "/connectors/{name}/config"
+	connect_PauseConnector  = "/connectors/{name}/pause"
+	connect_ResumeConnector = "/connectors/{name}/resume"
+	connect_DeleteConnector = "/connectors/{name}"
+)
+
+/*
+	Creates the Connector Manager working with the Kafka Connect connections in the provided registry. The
+	connectors are the ones expected by the configurations (usually State.Maps.Connectors). Every request to
+	the Connect clusters is executed with the provided retry policy.
+*/
+func NewConnectRESTManager(connections kafkamanagers.KafkaConnections, expected *ksengine.ConnectorConfigMapping, retry kafkamanagers.RetryPolicy) ConnectorExecutionManager {
+	return ConnectRESTExecutionManagerImpl{connections: connections, expected: expected, retry: retry}
+}
+
+// The REST clients of the Connect clusters configured for the cluster, keyed by the Connect cluster name.
+func (c ConnectRESTExecutionManagerImpl) getConnectClients(clusterName string) map[string]*resty.Client {
+	connObj := c.connections.GetKafkaConnectConnection(clusterName)
+	if connObj == nil {
+		return map[string]*resty.Client{}
+	}
+	return connObj.Clusters
+}
+
+// The connectors expected in the Connect clusters configured for the cluster.
+func (c ConnectRESTExecutionManagerImpl) expectedConnectors(clusterName string) ksengine.ConnectorConfigMapping {
+	names := []string{}
+	for name := range c.getConnectClients(clusterName) {
+		names = append(names, name)
+	}
+	return c.expected.Filter(names)
+}
+
+/*
+	Sends the request built by send to the Connect cluster using the retry policy. Every attempt gets a new
+	request bound to the context of the attempt.
+*/
+func (c ConnectRESTExecutionManagerImpl) executeConnectRequest(ctx context.Context, client *resty.Client, opName string, errMsg string,
+	send func(req *resty.Request) (*resty.Response, error)) (*resty.Response, error) {
+	resp, err := c.retry.DoWithResult(ctx, opName, func(opCtx context.Context) (interface{}, error) {
+		resp, err := send(client.R().SetContext(opCtx))
+		if err := kafkamanagers.NewRESTError(errMsg, resp, err); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*resty.Response), nil
+}
+
+func (c ConnectRESTExecutionManagerImpl) GetClusterConnectors(ctx context.Context, clusterName string) (ksengine.ConnectorConfigMapping, error) {
+	type connectorDetails struct {
+		Info struct {
+			Config ksengine.NVPairs `json:"config"`
+		} `json:"info"`
+		Status struct {
+			Connector struct {
+				State string `json:"state"`
+			} `json:"connector"`
+		} `json:"status"`
+	}
+	ret := ksengine.ConnectorConfigMapping{}
+	for name, client := range c.getConnectClients(clusterName) {
+		resp, err := c.executeConnectRequest(ctx, client, "List Connectors", fmt.Sprintf("Cannot list the connectors of the Connect cluster %s", name),
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetQueryString("expand=info&expand=status").Get(connect_ListConnectors)
+			})
+		if err != nil {
+			return nil, err
+		}
+		r := make(map[string]connectorDetails)
+		if err := client.JSONUnmarshal(resp.Body(), &r); err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Kafka Connect Response Data", err)
+		}
+		for cName, v := range r {
+			ret[ksengine.ConnectorConfigMappingKey{ConnectCluster: name, Name: cName}] = ksengine.ConnectorConfigMappingValue{
+				Config: v.Info.Config,
+				Paused: v.Status.Connector.State == "PAUSED",
+			}
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Lists the connector creations, updates and deletions needed to align the Connect clusters of the cluster
+	with the configurations as PlanChanges. Nothing is executed.
+*/
+func (c ConnectRESTExecutionManagerImpl) PlanConnectors(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	provisioned, err := c.GetClusterConnectors(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return ksengine.PlanConnectorChanges(clusterName, c.expectedConnectors(clusterName), provisioned, executeCreateFlow, executeModifyFlow, executeDeleteFlow), nil
+}
+
+func (c ConnectRESTExecutionManagerImpl) ExecuteConnectors(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool, dryRun bool) error {
+	changes, err := c.PlanConnectors(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	if err != nil {
+		return err
+	}
+	return c.ApplyConnectorPlan(ctx, clusterName, changes, dryRun)
+}
+
+/*
+	Executes the connector changes of a saved plan for the cluster. The changes only name the connectors, the
+	configs are taken from the configurations, so the sensitive values never have to be part of a plan.
+*/
+func (c ConnectRESTExecutionManagerImpl) ApplyConnectorPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	planned := []ksengine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType == ksengine.PlanResourceType_CONNECTOR {
+			planned = append(planned, v)
+		}
+	}
+	sort.Slice(planned, func(i, j int) bool {
+		return planned[i].Name < planned[j].Name
+	})
+	ksmisc.DottedLineOutput(fmt.Sprintf("Connector Changes: %s", clusterName), "=", 80)
+	if len(planned) == 0 {
+		logger.Infow("No connector changes needed.",
+			"Cluster Name", clusterName)
+		return nil
+	}
+
+	// The planned connectors are looked up by name, the deleted ones are only known to the Connect clusters.
+	known := c.expectedConnectors(clusterName)
+	provisioned, err := c.GetClusterConnectors(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]ksengine.ConnectorConfigMappingKey)
+	for _, m := range []ksengine.ConnectorConfigMapping{provisioned, known} {
+		for k := range m {
+			byName[k.PlanName()] = k
+		}
+	}
+
+	clients := c.getConnectClients(clusterName)
+	for _, v := range planned {
+		k, found := byName[v.Name]
+		if _, expected := known[k]; !found || (v.Action != ksengine.PlanAction_DELETE && !expected) {
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned connector not found. Connector: %s", v.Name), nil)
+		}
+		logger.Infow("Connector Change",
+			"Cluster Name", clusterName,
+			"Action", v.Action.String(),
+			"Connect Cluster", k.ConnectCluster,
+			"Connector Name", k.Name,
+			"Dry Run", dryRun)
+		if dryRun {
+			continue
+		}
+		if err := c.executeConnectorChange(ctx, clients[k.ConnectCluster], k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c ConnectRESTExecutionManagerImpl) executeConnectorChange(ctx context.Context, client *resty.Client, k ksengine.ConnectorConfigMappingKey, change ksengine.PlanChange) error {
+	pathParams := map[string]string{"name": k.Name}
+	if change.Action == ksengine.PlanAction_DELETE {
+		_, err := c.executeConnectRequest(ctx, client, "Delete Connector", fmt.Sprintf("Cannot delete the connector %s", change.Name),
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).Delete(connect_DeleteConnector)
+			})
+		return err
+	}
+
+	expected := (*c.expected)[k]
+	configChanged := change.Action == ksengine.PlanAction_CREATE
+	for key := range change.After {
+		if key != ksengine.ConnectorPlanStateKey {
+			configChanged = true
+		}
+	}
+	if configChanged {
+		_, err := c.executeConnectRequest(ctx, client, "Put Connector Config", fmt.Sprintf("Cannot set the config of the connector %s", change.Name),
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).SetBody(expected.Config).Put(connect_ConnectorConfig)
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	// A new connector starts running, so it only needs to be paused.
+	if _, found := change.After[ksengine.ConnectorPlanStateKey]; !found || (change.Action == ksengine.PlanAction_CREATE && !expected.Paused) {
+		return nil
+	}
+	opName, path := "Resume Connector", connect_ResumeConnector
+	if expected.Paused {
+		opName, path = "Pause Connector", connect_PauseConnector
+	}
+	_, err := c.executeConnectRequest(ctx, client, opName, fmt.Sprintf("Cannot change the state of the connector %s", change.Name),
+		func(req *resty.Request) (*resty.Response, error) {
+			return req.SetPathParams(pathParams).Put(path)
+		})
+	return err
+}
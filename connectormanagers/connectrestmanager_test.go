@@ -0,0 +1,160 @@
+package connectormanagers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers/resttest"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+// The connectors of a fake Connect cluster, served by a resttest.Server.
+type fakeConnectCluster struct {
+	connectors map[string]ksengine.ConnectorConfigMappingValue
+}
+
+func (f *fakeConnectCluster) handle(w http.ResponseWriter, r *http.Request) string {
+	if r.Method == http.MethodGet && r.URL.Path == "/connectors" {
+		type state struct {
+			State string `json:"state"`
+		}
+		out := map[string]interface{}{}
+		for name, v := range f.connectors {
+			s := "RUNNING"
+			if v.Paused {
+				s = "PAUSED"
+			}
+			out[name] = map[string]interface{}{
+				"info":   map[string]interface{}{"name": name, "config": v.Config},
+				"status": map[string]interface{}{"connector": state{State: s}},
+			}
+		}
+		json.NewEncoder(w).Encode(out)
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/connectors/"), "/")
+	v := f.connectors[parts[0]]
+	switch {
+	case r.Method == http.MethodDelete:
+		delete(f.connectors, parts[0])
+	case parts[1] == "config":
+		json.NewDecoder(r.Body).Decode(&v.Config)
+		f.connectors[parts[0]] = v
+	case parts[1] == "pause":
+		v.Paused = true
+		f.connectors[parts[0]] = v
+	case parts[1] == "resume":
+		v.Paused = false
+		f.connectors[parts[0]] = v
+	}
+	w.WriteHeader(http.StatusAccepted)
+	return r.Method + " " + r.URL.Path
+}
+
+// Starts a Connect cluster serving the connectors of fake, as the connect-1 Connect cluster of c1.
+func (s *StackSuite) newManager(fake *fakeConnectCluster, expected *ksengine.ConnectorConfigMapping) (ConnectorExecutionManager, *resttest.Server) {
+	srv := resttest.NewServer(fake.handle)
+	connections := kafkamanagers.KafkaConnections{
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_KAFKA_CONNECT}: {
+			Connection: &kafkamanagers.KafkaConnectConnection{Clusters: map[string]*resty.Client{"connect-1": srv.Client()}},
+		},
+	}
+	return NewConnectRESTManager(connections, expected, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{MaxAttempts: 1}, time.Second)), srv
+}
+
+func (s *StackSuite) TestStackSuite_ConnectREST_ExecuteConnectors() {
+	fake := &fakeConnectCluster{connectors: map[string]ksengine.ConnectorConfigMappingValue{
+		"drifted": {Config: ksengine.NVPairs{"name": "drifted", "connector.class": "A", "tasks.max": "1"}},
+		"paused":  {Config: ksengine.NVPairs{"name": "paused", "connector.class": "A"}},
+		"unknown": {Config: ksengine.NVPairs{"name": "unknown", "connector.class": "A"}},
+	}}
+	key := func(connectCluster string, name string) ksengine.ConnectorConfigMappingKey {
+		return ksengine.ConnectorConfigMappingKey{ConnectCluster: connectCluster, Name: name}
+	}
+	expected := ksengine.ConnectorConfigMapping{
+		key("connect-1", "new"):     {Config: ksengine.NVPairs{"name": "new", "connector.class": "A"}, Paused: true},
+		key("connect-1", "drifted"): {Config: ksengine.NVPairs{"name": "drifted", "connector.class": "A", "tasks.max": "2"}},
+		key("connect-1", "paused"):  {Config: ksengine.NVPairs{"name": "paused", "connector.class": "A"}, Paused: true},
+		// Connect clusters that are not configured for the cluster are left alone.
+		key("connect-2", "other"): {Config: ksengine.NVPairs{"name": "other", "connector.class": "A"}},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	s.NoError(m.ExecuteConnectors(ctx, "c1", true, true, true, true))
+	s.Empty(srv.Changes(), "Connectors were changed by a dry run")
+
+	s.NoError(m.ExecuteConnectors(ctx, "c1", true, true, false, false))
+	changes := srv.Changes()
+	sort.Strings(changes)
+	s.Equal([]string{
+		"PUT /connectors/drifted/config",
+		"PUT /connectors/new/config",
+		"PUT /connectors/new/pause",
+		"PUT /connectors/paused/pause",
+	}, changes)
+	s.Contains(fake.connectors, "unknown", "Unknown connectors should only be deleted with the delete flow")
+
+	s.NoError(m.ExecuteConnectors(ctx, "c1", true, true, true, false))
+	s.Equal([]string{"DELETE /connectors/unknown"}, srv.Changes())
+
+	provisioned, err := m.GetClusterConnectors(ctx, "c1")
+	s.NoError(err)
+	delete(expected, key("connect-2", "other"))
+	s.Equal(expected, provisioned)
+
+	planned, err := m.PlanConnectors(ctx, "c1", true, true, true)
+	s.NoError(err)
+	s.Empty(planned, "Nothing should be left to change once the connectors are executed")
+}
+
+func (s *StackSuite) TestStackSuite_ConnectREST_Failures() {
+	fake := &fakeConnectCluster{connectors: map[string]ksengine.ConnectorConfigMappingValue{}}
+	expected := ksengine.ConnectorConfigMapping{
+		{ConnectCluster: "connect-1", Name: "new"}: {Config: ksengine.NVPairs{"name": "new", "connector.class": "A"}},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	srv.Fail("PUT /connectors/new/config", http.StatusInternalServerError)
+	err := m.ExecuteConnectors(ctx, "c1", true, true, true, false)
+	s.True(errors.Is(err, ksengine.ErrClusterUnreachable), "Error: %v", err)
+	s.Empty(fake.connectors)
+
+	srv.Fail("GET /connectors", http.StatusUnauthorized)
+	_, err = m.PlanConnectors(ctx, "c1", true, true, true)
+	s.True(errors.Is(err, ksengine.ErrAuthFailed), "Error: %v", err)
+}
+
+func (s *StackSuite) TestStackSuite_ConnectREST_ApplyUnknownPlannedConnector() {
+	m, srv := s.newManager(&fakeConnectCluster{connectors: map[string]ksengine.ConnectorConfigMappingValue{}}, &ksengine.ConnectorConfigMapping{})
+	defer srv.Close()
+	err := m.ApplyConnectorPlan(context.Background(), "c1", []ksengine.PlanChange{
+		{Cluster: "c1", Action: ksengine.PlanAction_CREATE, ResourceType: ksengine.PlanResourceType_CONNECTOR, Name: "connect-1/missing"},
+	}, false)
+	s.Error(err, "Connectors that are not in the configurations cannot be created")
+
+	// c2 has no Connect cluster configured, so no connector is planned for it.
+	changes, err := m.PlanConnectors(context.Background(), "c2", true, true, true)
+	s.NoError(err)
+	s.Empty(changes)
+}
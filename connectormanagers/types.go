@@ -0,0 +1,22 @@
+package connectormanagers
+
+import (
+	"context"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+var (
+	logger = ksengine.Shepherd.GetLogger()
+)
+
+/*
+	Any Connector Manager will need to implement this interface. Only the connectors of the Connect clusters
+	configured for a cluster are managed for that cluster.
+*/
+type ConnectorExecutionManager interface {
+	GetClusterConnectors(ctx context.Context, clusterName string) (ksengine.ConnectorConfigMapping, error)
+	ExecuteConnectors(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error
+	PlanConnectors(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplyConnectorPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
+}
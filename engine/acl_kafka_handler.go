@@ -97,8 +97,14 @@ func (c KafkaACLOperation) GenerateACLMappingStructures(clusterName string, in *
 				temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, determinePatternType(k.ResourceName), k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
 				temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, determinePatternType(k.ResourceName), k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
 			case ShepherdOperationType_TRANSACTIONAL_PRODUCER:
-				temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, k.ResourceName, KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
-				temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, k.ResourceName, KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
+				temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
+				temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
+				// Exactly once processing commits the consumer offsets as a part of the transaction, which needs READ on the group.
+				// The IDEMPOTENT_WRITE on the cluster is added by the idempotence that the transactions enable.
+				if v, ok := v.(NVPairs); ok && v[KafkaResourceType_GROUP.GetACLResourceString()] != "" {
+					gName, gPattern := determineGroupPatternType(v[KafkaResourceType_GROUP.GetACLResourceString()])
+					temp[constructACLDetailsObject(KafkaResourceType_GROUP, gName, gPattern, k.Principal, KafkaACLOperation_READ, k.Hostname)] = nil
+				}
 			case ShepherdOperationType_PRODUCER_IDEMPOTENCE:
 				// Repeat of above for the corner case where the customer wants idempotence but not the transactional behavior
 				temp[constructACLDetailsObject(KafkaResourceType_CLUSTER, k.ResourceName, KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_IDEMPOTENTWRITE, k.Hostname)] = nil
@@ -108,7 +114,9 @@ func (c KafkaACLOperation) GenerateACLMappingStructures(clusterName string, in *
 					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, determinePatternType(k.ResourceName), k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
 				}
 				if k.ResourceType == KafkaResourceType_GROUP {
-					temp[constructACLDetailsObject(KafkaResourceType_GROUP, k.ResourceName, KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_READ, k.Hostname)] = nil
+					// Offsets are committed with READ on the group and DESCRIBE is needed to look them up.
+					temp[constructACLDetailsObject(KafkaResourceType_GROUP, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_READ, k.Hostname)] = nil
+					temp[constructACLDetailsObject(KafkaResourceType_GROUP, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
 				}
 			// case ShepherdOperationType_CONSUMER_GROUP:
 			case ShepherdOperationType_STREAM_READ:
@@ -146,12 +154,9 @@ func (c KafkaACLOperation) GenerateACLMappingStructures(clusterName string, in *
 				temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_READ, k.Hostname)] = nil
 				temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
 				if cName := v.(NVPairs)[KafkaResourceType_KSQL_CLUSTER.GetACLResourceString()]; cName != "" {
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_GROUP, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("%sksql_processing_log", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, fmt.Sprintf("ksql-%s", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
+					for _, acl := range ksqlClusterACLs(cName, k.Principal, k.Hostname) {
+						temp[acl] = nil
+					}
 				}
 				temp[constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBECONFIGS, k.Hostname)] = nil
 			case ShepherdOperationType_KSQL_WRITE:
@@ -159,12 +164,9 @@ func (c KafkaACLOperation) GenerateACLMappingStructures(clusterName string, in *
 				temp[constructACLDetailsObject(KafkaResourceType_TOPIC, k.ResourceName, k.PatternType, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
 				if cName := v.(NVPairs)[KafkaResourceType_KSQL_CLUSTER.GetACLResourceString()]; cName != "" {
 					// temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("ksql-%s", cName), KafkaACLPatternType_PREFIXED, k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_GROUP, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("%sksql_processing_log", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_ALL, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_WRITE, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
-					temp[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, fmt.Sprintf("ksql-%s", cName), KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBE, k.Hostname)] = nil
+					for _, acl := range ksqlClusterACLs(cName, k.Principal, k.Hostname) {
+						temp[acl] = nil
+					}
 				}
 				temp[constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, k.Principal, KafkaACLOperation_DESCRIBECONFIGS, k.Hostname)] = nil
 			default:
@@ -181,15 +183,39 @@ func (c KafkaACLOperation) GenerateACLMappingStructures(clusterName string, in *
 	return &temp
 }
 
+// The ACLs needed by the members of a ksqlDB cluster for its internal topics, groups and transactions.
+func ksqlClusterACLs(cName string, principal string, hostname string) []ACLDetails {
+	return []ACLDetails{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, principal, KafkaACLOperation_ALL, hostname),
+		constructACLDetailsObject(KafkaResourceType_GROUP, fmt.Sprintf("_confluent-ksql-%s", cName), KafkaACLPatternType_PREFIXED, principal, KafkaACLOperation_ALL, hostname),
+		constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("%sksql_processing_log", cName), KafkaACLPatternType_LITERAL, principal, KafkaACLOperation_ALL, hostname),
+		constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, principal, KafkaACLOperation_WRITE, hostname),
+		constructACLDetailsObject(KafkaResourceType_TOPIC, fmt.Sprintf("_confluent-ksql-%s_command_topic", cName), KafkaACLPatternType_LITERAL, principal, KafkaACLOperation_DESCRIBE, hostname),
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, fmt.Sprintf("ksql-%s", cName), KafkaACLPatternType_LITERAL, principal, KafkaACLOperation_DESCRIBE, hostname),
+	}
+}
+
 func determinePatternType(topicName string) KafkaACLPatternType {
 	if topicName == "*" {
 		return KafkaACLPatternType_LITERAL
 		// return KafkaACLPatternType_UNKNOWN
 	}
-	if strings.HasSuffix(topicName, fmt.Sprintf("%s*", SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken)) {
+	// Topic names cannot contain "*", so the only names ending with it are the wildcards generated for a scope (e.g. "test.*").
+	if strings.HasSuffix(topicName, "*") {
 		return KafkaACLPatternType_PREFIXED
 		// return KafkaACLPatternType_UNKNOWN
 	}
 	// return KafkaACLPatternType_UNKNOWN
 	return KafkaACLPatternType_LITERAL
 }
+
+/*
+	Consumer groups and transactional ids ending with "*" (e.g. "app-*") are prefixed, and the ACL is set up
+	for the prefix in front of the "*". A "*" on its own stays the literal wildcard matching every name.
+*/
+func determineGroupPatternType(groupName string) (string, KafkaACLPatternType) {
+	if groupName != "*" && strings.HasSuffix(groupName, "*") {
+		return strings.TrimSuffix(groupName, "*"), KafkaACLPatternType_PREFIXED
+	}
+	return groupName, KafkaACLPatternType_LITERAL
+}
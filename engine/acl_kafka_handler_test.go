@@ -31,12 +31,14 @@ var (
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1104", KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1104", KafkaACLPatternType_LITERAL, "User:1104", KafkaACLOperation_DESCRIBE, "*"):         nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1104", KafkaACLPatternType_LITERAL, "User:1104", KafkaACLOperation_WRITE, "*"):            nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1104", KafkaACLPatternType_LITERAL, "User:1104", KafkaACLOperation_READ, "*"):                       nil,
 				// User:1105
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_WRITE, "*"):                    nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_DESCRIBE, "*"):                 nil,
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1105", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_DESCRIBE, "*"):         nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1105", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_WRITE, "*"):            nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1105", KafkaACLPatternType_LITERAL, "User:1105", KafkaACLOperation_READ, "*"):                       nil,
 				//User:1106
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_WRITE, "abc.host"):                    nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_DESCRIBE, "abc.host"):                 nil,
@@ -48,6 +50,17 @@ var (
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_WRITE, "abc.host"):            nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_DESCRIBE, "def.host"):         nil,
 				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_WRITE, "def.host"):            nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1106", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_READ, "abc.host"):                       nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1106", KafkaACLPatternType_LITERAL, "User:1106", KafkaACLOperation_READ, "def.host"):                       nil,
+				// User:1107
+				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_WRITE, "*"):                    nil,
+				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_DESCRIBE, "*"):                 nil,
+				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "orders-", KafkaACLPatternType_PREFIXED, "User:1107", KafkaACLOperation_DESCRIBE, "*"):     nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "orders-", KafkaACLPatternType_PREFIXED, "User:1107", KafkaACLOperation_WRITE, "*"):        nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn.1", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_DESCRIBE, "*"):        nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn.1", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_WRITE, "*"):           nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1107", KafkaACLPatternType_LITERAL, "User:1107", KafkaACLOperation_READ, "*"):                       nil,
 				// // Consumers
 				// // User:1111
 				// constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1111", ShepherdOperationType_CONSUMER, "*"): nil,
@@ -66,13 +79,16 @@ var (
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1112", KafkaACLOperation_READ, "*"):     nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1112", KafkaACLOperation_DESCRIBE, "*"): nil,
 				constructACLDetailsObject(KafkaResourceType_GROUP, "1112", KafkaACLPatternType_LITERAL, "User:1112", KafkaACLOperation_READ, "*"):       nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1112", KafkaACLPatternType_LITERAL, "User:1112", KafkaACLOperation_DESCRIBE, "*"):   nil,
 				// User:1113
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_READ, "ghi.host"):     nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_DESCRIBE, "ghi.host"): nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_READ, "jkl.host"):     nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_DESCRIBE, "jkl.host"): nil,
 				constructACLDetailsObject(KafkaResourceType_GROUP, "1113", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_READ, "ghi.host"):       nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1113", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_DESCRIBE, "ghi.host"):   nil,
 				constructACLDetailsObject(KafkaResourceType_GROUP, "1113", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_READ, "jkl.host"):       nil,
+				constructACLDetailsObject(KafkaResourceType_GROUP, "1113", KafkaACLPatternType_LITERAL, "User:1113", KafkaACLOperation_DESCRIBE, "jkl.host"):   nil,
 			},
 			"Consumer ACL Mismatch"},
 		{"./testdata/utm_mapping/acl/shepherd/definitions_3.yaml",
@@ -204,10 +220,11 @@ func (s *StackSuite) TestStackSuite_ExternalFunctions_KafkaACLMapping() {
 	// aclMappingCases is defined in external_functions_test.go
 	for _, c := range kafkaAclMappingCases {
 		os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", c.inDefFileName)
-		SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-		ConfMaps.utm = UserTopicMapping{}
-		GenerateMappings()
-		result := KafkaACLOperation_ANY.GenerateACLMappingStructures("", ConfMaps.utm.getShepherdACLList())
+		_, err := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+		s.NoError(err)
+		s.st.Maps.utm = UserTopicMapping{}
+		s.NoError(s.st.GenerateMappings())
+		result := KafkaACLOperation_ANY.GenerateACLMappingStructures("", s.st.Maps.utm.getShepherdACLList())
 		s.True(reflect.DeepEqual(c.out, result), fmt.Sprintf("Expected Value: %v \n\n  Actual Value: %v \n\nFilename: %v\n\nError: Failed while invoking GenerateACLMappingStructures with error %v", c.out, result, c.inDefFileName, c.err))
 	}
 }
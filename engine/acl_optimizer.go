@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+/*
+	Collapses the literal topic ACLs into prefixed ones if aclPolicy.optimizeACLs is enabled in the
+	blueprints. When a principal has the same Kafka ACL operation on every topic in the configurations
+	sharing a scope prefix (the leading parts of the topic names separated by the SeperatorToken), those
+	ACLs are replaced by a single PREFIXED ACL for the prefix. The widest prefix is used, and only prefixes
+	covering at least two topics are considered. Any other ACL is returned as it is.
+
+	The prefixed ACL also covers the topics that are created with the prefix later, including the ones not
+	managed by Shepherd.
+*/
+func (st *State) OptimizeACLs(in *ACLMapping) *ACLMapping {
+	policy := st.Core.Blueprints.Blueprint.Policy.ACLPolicy
+	if policy == nil || !policy.OptimizeACLs {
+		return in
+	}
+	sep := st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken
+	// The topic list is not cached here, as the clusters are handled concurrently.
+	configTopics := []string{}
+	for tName := range st.Maps.TCM {
+		if ksmisc.IsTopicName(tName, sep) {
+			configTopics = append(configTopics, tName)
+		}
+	}
+
+	type groupKey struct {
+		principal  string
+		operation  KafkaACLOperation
+		hostname   string
+		permission KafkaACLPermissionType
+	}
+	groups := make(map[groupKey]map[string]bool)
+	out := ACLMapping{}
+	for k, v := range *in {
+		op, isKafkaACL := k.Operation.(KafkaACLOperation)
+		if !isKafkaACL || k.ResourceType != KafkaResourceType_TOPIC || k.PatternType != KafkaACLPatternType_LITERAL {
+			out[k] = v
+			continue
+		}
+		gk := groupKey{principal: k.Principal, operation: op, hostname: k.Hostname, permission: k.PermissionType}
+		if groups[gk] == nil {
+			groups[gk] = make(map[string]bool)
+		}
+		groups[gk][k.ResourceName] = true
+	}
+
+	for gk, topics := range groups {
+		prefixes := []string{}
+		for tName := range topics {
+			parts := strings.Split(tName, sep)
+			for i := 1; i < len(parts); i++ {
+				prefixes = append(prefixes, strings.Join(parts[:i], sep)+sep)
+			}
+		}
+		sort.Slice(prefixes, func(i, j int) bool {
+			if len(prefixes[i]) != len(prefixes[j]) {
+				return len(prefixes[i]) < len(prefixes[j])
+			}
+			return prefixes[i] < prefixes[j]
+		})
+
+		chosen := []string{}
+		for _, p := range prefixes {
+			if hasAnyPrefix(p, chosen) {
+				continue
+			}
+			covered := 0
+			for _, tName := range configTopics {
+				if strings.HasPrefix(tName, p) {
+					if !topics[tName] {
+						covered = -1
+						break
+					}
+					covered++
+				}
+			}
+			if covered >= 2 {
+				chosen = append(chosen, p)
+				logger.Debugw("Literal topic ACLs collapsed into a prefixed ACL.",
+					"Principal", gk.principal,
+					"Operation", gk.operation.String(),
+					"Prefix", p,
+					"Topic Count", covered)
+			}
+		}
+
+		for tName := range topics {
+			if !hasAnyPrefix(tName, chosen) {
+				k := constructACLDetailsObject(KafkaResourceType_TOPIC, tName, KafkaACLPatternType_LITERAL, gk.principal, gk.operation, gk.hostname)
+				k.PermissionType = gk.permission
+				out[k] = nil
+			}
+		}
+		for _, p := range chosen {
+			k := constructACLDetailsObject(KafkaResourceType_TOPIC, p, KafkaACLPatternType_PREFIXED, gk.principal, gk.operation, gk.hostname)
+			k.PermissionType = gk.permission
+			out[k] = nil
+		}
+	}
+	return &out
+}
+
+func hasAnyPrefix(in string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(in, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package engine
+
+func (s *StackSuite) TestStackSuite_ACLOptimizer_OptimizeACLs() {
+	st := &State{}
+	st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken = "."
+	st.Maps.TCM = TopicConfigMapping{"app.orders.v1": nil, "app.orders.v2": nil, "app.payments.v1": nil, "other.1": nil}
+
+	in := &ACLMapping{
+		// Covers every topic under app. for WRITE and only app.orders. for READ.
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_WRITE, "*"):   nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v2", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_WRITE, "*"):   nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.payments.v1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_WRITE, "*"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*"):    nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v2", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*"):    nil,
+		// A single topic under the prefix is not collapsed.
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "other.1", KafkaACLPatternType_LITERAL, "User:2", KafkaACLOperation_READ, "*"): nil,
+		// Different hosts are not merged together.
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v1", KafkaACLPatternType_LITERAL, "User:3", KafkaACLOperation_READ, "abc.host"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v2", KafkaACLPatternType_LITERAL, "User:3", KafkaACLOperation_READ, "def.host"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "1", KafkaACLPatternType_PREFIXED, "User:1", KafkaACLOperation_READ, "*"):                   nil,
+	}
+
+	// Nothing changes unless the policy asks for it.
+	s.Equal(in, st.OptimizeACLs(in))
+	st.Core.Blueprints.Blueprint.Policy.ACLPolicy = &ACLPolicyConfigs{OptimizeACLs: false}
+	s.Equal(in, st.OptimizeACLs(in))
+
+	st.Core.Blueprints.Blueprint.Policy.ACLPolicy.OptimizeACLs = true
+	s.Equal(&ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.", KafkaACLPatternType_PREFIXED, "User:1", KafkaACLOperation_WRITE, "*"):               nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.", KafkaACLPatternType_PREFIXED, "User:1", KafkaACLOperation_READ, "*"):         nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "other.1", KafkaACLPatternType_LITERAL, "User:2", KafkaACLOperation_READ, "*"):              nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v1", KafkaACLPatternType_LITERAL, "User:3", KafkaACLOperation_READ, "abc.host"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "app.orders.v2", KafkaACLPatternType_LITERAL, "User:3", KafkaACLOperation_READ, "def.host"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "1", KafkaACLPatternType_PREFIXED, "User:1", KafkaACLOperation_READ, "*"):                   nil,
+	}, st.OptimizeACLs(in))
+}
@@ -30,6 +30,7 @@ const (
 	ShepherdOperationType_STREAM_WRITE
 	ShepherdOperationType_KSQL_READ
 	ShepherdOperationType_KSQL_WRITE
+	ShepherdOperationType_DENY
 )
 
 func (in ShepherdOperationType) String() string {
@@ -47,6 +48,7 @@ func (in ShepherdOperationType) String() string {
 		ShepherdOperationType_STREAM_WRITE:           "STREAM_WRITE",
 		ShepherdOperationType_KSQL_READ:              "KSQL_READ",
 		ShepherdOperationType_KSQL_WRITE:             "KSQL_WRITE",
+		ShepherdOperationType_DENY:                   "DENY",
 		// ShepherdOperationType_KSQL:                   "KSQL",
 	}
 	ret, present := m[in]
@@ -71,6 +73,7 @@ func (c ShepherdOperationType) GetValue(in string) (ACLOperationsInterface, erro
 		"STREAM_WRITE":                  ShepherdOperationType_STREAM_WRITE,
 		"KSQL_READ":                     ShepherdOperationType_KSQL_READ,
 		"KSQL_WRITE":                    ShepherdOperationType_KSQL_WRITE,
+		"DENY":                          ShepherdOperationType_DENY,
 	}
 	s, ok := m[strings.ToUpper(strings.TrimSpace(in))]
 	if !ok {
@@ -83,34 +86,35 @@ func (c ShepherdOperationType) GetValue(in string) (ACLOperationsInterface, erro
 }
 
 // This function will generate the mappings in the ShepherdOperationType internal structure type for all the mappings provided
-// as the input `in` value. Whatever it is able to properly convert, those mappings will be added to the success
-// map and the rest will be added to the failed map.
+// as the input `in` value. The Kafka ACLs are translated back to the client roles (see MapKafkaACLsToShepherd) and the ones
+// that are not part of any role are reported as unclassified and left out of the output.
 func (c ShepherdOperationType) GenerateACLMappingStructures(clusterName string, in *ACLMapping) *ACLMapping {
 	logger.Info("Conversion back to Shepherd ACL is performed on a best effort basis. Please ensure that you back up the actual ACLs as well.")
-	temp := make(ACLMapping)
+	temp, kafkaACLs := make(ACLMapping), make(ACLMapping)
 	for k, v := range *in {
 		switch k.Operation.(type) {
 		case ShepherdOperationType:
 			temp[k] = v
 		case KafkaACLOperation:
-			switch k.Operation {
-			case KafkaACLOperation_READ:
-				temp[constructACLDetailsObject(k.ResourceType, k.ResourceName, k.PatternType, k.Principal, ShepherdOperationType_CONSUMER, k.Hostname)] = v
-			case KafkaACLOperation_WRITE:
-			case KafkaACLOperation_IDEMPOTENTWRITE:
-			case KafkaACLOperation_DESCRIBE:
-			default:
-				logger.Warnw(" Could not generate Shepherd ACL Mappings for the provided ACL Map. Details:",
-					"Principal", k.Principal,
-					"Resource Type", k.ResourceType.GetACLResourceString(),
-					"Resource Value", k.ResourceName,
-					"Hostname", k.Hostname,
-					"ACL Operation Wanted", k.Operation.String())
-			}
+			kafkaACLs[k] = v
 		default:
 			logger.Warnf("Conversion from %T type to %T type is not supported yet. The ACL mapping will be added to the Failed list.", k.Operation, c)
 			temp[k] = nil
 		}
 	}
+	mapped, unclassified := MapKafkaACLsToShepherd(&kafkaACLs)
+	for k, v := range *mapped {
+		temp[k] = v
+	}
+	for k := range *unclassified {
+		logger.Warnw("Unclassified Kafka ACL. It is not part of any Shepherd client role.",
+			"Cluster Name", clusterName,
+			"Principal", k.Principal,
+			"Resource Type", k.ResourceType.GetACLResourceString(),
+			"Resource Value", k.ResourceName,
+			"Resource Pattern Type", k.PatternType.GetACLPatternString(),
+			"Hostname", k.Hostname,
+			"ACL Operation", k.Operation.String())
+	}
 	return &temp
 }
@@ -9,10 +9,46 @@ import (
 func (s *StackSuite) TestStackSuite_ExternalFunctions_ShepherdACLMapping() {
 	for _, c := range aclMappingCases {
 		os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", c.inDefFileName)
-		SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-		ConfMaps.utm = UserTopicMapping{}
-		GenerateMappings()
-		result := ShepherdOperationType_EMPTY.GenerateACLMappingStructures("", ConfMaps.utm.getShepherdACLList())
+		_, err := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+		s.NoError(err)
+		s.st.Maps.utm = UserTopicMapping{}
+		s.NoError(s.st.GenerateMappings())
+		result := ShepherdOperationType_EMPTY.GenerateACLMappingStructures("", s.st.Maps.utm.getShepherdACLList())
 		s.True(reflect.DeepEqual(c.out, result), fmt.Sprintf("Expected Value: %v \n\n  Actual Value: %v \n\nFilename: %v\n\nError: Failed while invoking GenerateACLMappingStructures with error %v", c.out, result, c.inDefFileName, c.err))
 	}
 }
+
+func (s *StackSuite) TestStackSuite_ExternalFunctions_KafkaACLsToShepherd() {
+	// The client roles recognized from the Kafka ACLs generate the same Kafka ACLs again.
+	for _, c := range kafkaAclMappingCases {
+		mapped, unclassified := MapKafkaACLsToShepherd(c.out)
+		s.Empty(*unclassified, "Filename: %s", c.inDefFileName)
+		s.Equal(c.out, KafkaACLOperation_UNKNOWN.GenerateACLMappingStructures("", mapped), "Filename: %s", c.inDefFileName)
+	}
+
+	in := &ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_WRITE, "*"):           nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_DESCRIBE, "*"):        nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_ALTERCONFIGS, "*"):    nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*"):            nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "connect-files", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3", KafkaACLPatternType_LITERAL, "User:2", KafkaACLOperation_READ, "abc.host"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3", KafkaACLPatternType_LITERAL, "User:2", KafkaACLOperation_DESCRIBE, "abc.host"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "app", KafkaACLPatternType_PREFIXED, "User:2", KafkaACLOperation_READ, "abc.host"):       nil,
+	}
+	mapped, unclassified := MapKafkaACLsToShepherd(in)
+	s.Equal(&ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1", ShepherdOperationType_SOURCE_CONNECTOR, "*"): NVPairs{
+			KafkaResourceType_CONNECTOR.GetACLResourceString(): "files",
+			KafkaResourceType_CLUSTER.GetACLResourceString():   "kafka-cluster",
+		},
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3", KafkaACLPatternType_LITERAL, "User:2", ShepherdOperationType_STREAM_READ, "abc.host"): NVPairs{
+			KafkaResourceType_GROUP.GetACLResourceString(): "app",
+		},
+	}, mapped)
+	// Reading without describing the topic is not a consumer, and the connector group is only used by a source connector.
+	s.Equal(&ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_ALTERCONFIGS, "*"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*"):         nil,
+	}, unclassified)
+}
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Shepherd client role along with every Kafka ACL it is generated into.
+type shepherdRoleCandidate struct {
+	role     ACLDetails
+	value    interface{}
+	requires []ACLDetails
+}
+
+/*
+	Translates the Kafka ACLs back to the Shepherd client roles. The ACLs are grouped per principal and
+	host, and a role is only recognized if every ACL it generates (see KafkaACLOperation) is present, so
+	that the roles translate back to the same ACLs. The more specific roles are recognized first: ksqlDB,
+	connectors (groups named connect-<connector>), streams (prefixed groups), transactional and idempotent
+	producers, and then the plain producers and consumers. The ACLs that are not part of any role are
+	returned as unclassified. DENY ACLs are deny rules of their own and are returned as mapped.
+*/
+func MapKafkaACLsToShepherd(in *ACLMapping) (mapped *ACLMapping, unclassified *ACLMapping) {
+	mapped, unclassified = &ACLMapping{}, &ACLMapping{}
+	type clientKey struct {
+		principal string
+		hostname  string
+	}
+	clients := make(map[clientKey]ACLMapping)
+	for k, v := range *in {
+		if _, ok := k.Operation.(KafkaACLOperation); !ok {
+			(*unclassified)[k] = v
+			continue
+		}
+		if k.IsDeny() {
+			(*mapped)[k] = v
+			continue
+		}
+		ck := clientKey{principal: k.Principal, hostname: k.Hostname}
+		if _, found := clients[ck]; !found {
+			clients[ck] = ACLMapping{}
+		}
+		clients[ck][k] = v
+	}
+
+	for ck, acls := range clients {
+		consumed := make(map[ACLDetails]bool)
+		for _, c := range shepherdRoleCandidates(ck.principal, ck.hostname, acls) {
+			if _, found := (*mapped)[c.role]; found {
+				continue
+			}
+			// A role has to explain at least one ACL that is not explained by the roles already recognized.
+			complete, fresh := true, false
+			for _, r := range c.requires {
+				if _, found := acls[r]; !found {
+					complete = false
+					break
+				}
+				fresh = fresh || !consumed[r]
+			}
+			if !complete || !fresh {
+				continue
+			}
+			for _, r := range c.requires {
+				consumed[r] = true
+			}
+			(*mapped)[c.role] = c.value
+		}
+		for k, v := range acls {
+			if !consumed[k] {
+				(*unclassified)[k] = v
+			}
+		}
+	}
+	return mapped, unclassified
+}
+
+func shepherdRoleCandidates(principal string, hostname string, acls ACLMapping) []shepherdRoleCandidate {
+	acl := func(rType KafkaResourceType, rName string, pType ACLPatternInterface, op ACLOperationsInterface) ACLDetails {
+		return constructACLDetailsObject(rType, rName, pType, principal, op, hostname)
+	}
+
+	topics, ksqlClusters, connectors, streamGroups, consumerGroups, txnIDs := []ACLDetails{}, []string{}, []string{}, []string{}, []ACLDetails{}, []ACLDetails{}
+	idempotent, txnGroups := false, []ACLDetails{}
+	groupDescribe := func(k ACLDetails) ACLDetails {
+		return acl(KafkaResourceType_GROUP, k.ResourceName, k.PatternType, KafkaACLOperation_DESCRIBE)
+	}
+	for k := range acls {
+		_, hasDescribe := acls[groupDescribe(k)]
+		// The group of a transactional producer only has READ, for the offsets committed in the transaction.
+		if k.ResourceType == KafkaResourceType_GROUP && k.Operation == KafkaACLOperation_READ && !hasDescribe {
+			txnGroups = append(txnGroups, k)
+		}
+		switch {
+		case k.ResourceType == KafkaResourceType_TOPIC && (k.Operation == KafkaACLOperation_READ || k.Operation == KafkaACLOperation_WRITE):
+			topics = append(topics, k)
+		case k.ResourceType == KafkaResourceType_TOPIC && k.PatternType == KafkaACLPatternType_PREFIXED && k.Operation == KafkaACLOperation_ALL &&
+			strings.HasPrefix(k.ResourceName, "_confluent-ksql-"):
+			ksqlClusters = append(ksqlClusters, strings.TrimPrefix(k.ResourceName, "_confluent-ksql-"))
+		// Prefixed consumer groups have DESCRIBE along with READ, the groups of the streams only READ.
+		case k.ResourceType == KafkaResourceType_GROUP && k.PatternType == KafkaACLPatternType_PREFIXED && k.Operation == KafkaACLOperation_READ && hasDescribe:
+			consumerGroups = append(consumerGroups, k)
+		case k.ResourceType == KafkaResourceType_GROUP && k.PatternType == KafkaACLPatternType_PREFIXED && k.Operation == KafkaACLOperation_READ:
+			streamGroups = append(streamGroups, k.ResourceName)
+		case k.ResourceType == KafkaResourceType_GROUP && k.PatternType == KafkaACLPatternType_LITERAL && k.Operation == KafkaACLOperation_READ:
+			if strings.HasPrefix(k.ResourceName, "connect-") {
+				connectors = append(connectors, strings.TrimPrefix(k.ResourceName, "connect-"))
+			}
+			consumerGroups = append(consumerGroups, k)
+		case k.ResourceType == KafkaResourceType_TRANSACTIONALID && k.Operation == KafkaACLOperation_WRITE:
+			txnIDs = append(txnIDs, k)
+		case k.ResourceType == KafkaResourceType_CLUSTER && k.ResourceName == "kafka-cluster" && k.Operation == KafkaACLOperation_IDEMPOTENTWRITE:
+			idempotent = true
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].ResourceName != topics[j].ResourceName {
+			return topics[i].ResourceName < topics[j].ResourceName
+		}
+		return topics[i].Operation.String() < topics[j].Operation.String()
+	})
+	for _, v := range [][]ACLDetails{consumerGroups, txnIDs, txnGroups} {
+		sort.Slice(v, func(i, j int) bool {
+			if v[i].ResourceName != v[j].ResourceName {
+				return v[i].ResourceName < v[j].ResourceName
+			}
+			return v[i].PatternType.GetACLPatternString() < v[j].PatternType.GetACLPatternString()
+		})
+	}
+	for _, v := range [][]string{ksqlClusters, connectors, streamGroups} {
+		sort.Strings(v)
+	}
+
+	// The internal topics of the ksqlDB clusters are part of the ksqlDB roles and not roles of their own.
+	ksqlInternal := make(map[ACLDetails]bool)
+	for _, cName := range ksqlClusters {
+		for _, v := range ksqlClusterACLs(cName, principal, hostname) {
+			ksqlInternal[v] = true
+		}
+	}
+
+	// The producer and consumer side of a topic role, along with the role type for either side.
+	type topicRole struct {
+		onWrite    ShepherdOperationType
+		onRead     ShepherdOperationType
+		value      NVPairs
+		additional []ACLDetails
+	}
+	topicRoles := []topicRole{}
+	for _, cName := range ksqlClusters {
+		topicRoles = append(topicRoles, topicRole{ShepherdOperationType_KSQL_WRITE, ShepherdOperationType_KSQL_READ,
+			NVPairs{KafkaResourceType_KSQL_CLUSTER.GetACLResourceString(): cName},
+			append(ksqlClusterACLs(cName, principal, hostname),
+				acl(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, KafkaACLOperation_DESCRIBECONFIGS))})
+	}
+	for _, cName := range connectors {
+		topicRoles = append(topicRoles, topicRole{ShepherdOperationType_SOURCE_CONNECTOR, ShepherdOperationType_SINK_CONNECTOR,
+			NVPairs{KafkaResourceType_CONNECTOR.GetACLResourceString(): cName, KafkaResourceType_CLUSTER.GetACLResourceString(): "kafka-cluster"},
+			[]ACLDetails{acl(KafkaResourceType_GROUP, "connect-"+cName, KafkaACLPatternType_LITERAL, KafkaACLOperation_READ)}})
+	}
+	for _, gName := range streamGroups {
+		topicRoles = append(topicRoles, topicRole{ShepherdOperationType_STREAM_WRITE, ShepherdOperationType_STREAM_READ,
+			NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): gName},
+			[]ACLDetails{acl(KafkaResourceType_GROUP, gName, KafkaACLPatternType_PREFIXED, KafkaACLOperation_READ)}})
+	}
+
+	ret := []shepherdRoleCandidate{}
+	addTopicCandidates := func(r topicRole) {
+		for _, t := range topics {
+			if ksqlInternal[t] {
+				continue
+			}
+			op := r.onRead
+			if t.Operation == KafkaACLOperation_WRITE {
+				op = r.onWrite
+			}
+			var value interface{}
+			if r.value != nil {
+				value = r.value
+			}
+			ret = append(ret, shepherdRoleCandidate{
+				role:  acl(KafkaResourceType_TOPIC, t.ResourceName, t.PatternType, op),
+				value: value,
+				requires: append([]ACLDetails{t, acl(KafkaResourceType_TOPIC, t.ResourceName, t.PatternType, KafkaACLOperation_DESCRIBE)},
+					r.additional...),
+			})
+		}
+	}
+	for _, r := range topicRoles {
+		addTopicCandidates(r)
+	}
+	for _, t := range txnIDs {
+		role := acl(KafkaResourceType_TRANSACTIONALID, t.ResourceName, t.PatternType, ShepherdOperationType_TRANSACTIONAL_PRODUCER)
+		requires := []ACLDetails{acl(KafkaResourceType_TRANSACTIONALID, t.ResourceName, t.PatternType, KafkaACLOperation_DESCRIBE), t}
+		for _, g := range txnGroups {
+			gName := g.ResourceName
+			if g.PatternType == KafkaACLPatternType_PREFIXED {
+				gName += "*"
+			}
+			ret = append(ret, shepherdRoleCandidate{
+				role:     role,
+				value:    NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): gName},
+				requires: append([]ACLDetails{g}, requires...),
+			})
+		}
+		ret = append(ret, shepherdRoleCandidate{role: role, requires: requires})
+	}
+	if idempotent {
+		ret = append(ret, shepherdRoleCandidate{
+			role:     acl(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, ShepherdOperationType_PRODUCER_IDEMPOTENCE),
+			requires: []ACLDetails{acl(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, KafkaACLOperation_IDEMPOTENTWRITE)},
+		})
+	}
+	addTopicCandidates(topicRole{onWrite: ShepherdOperationType_PRODUCER, onRead: ShepherdOperationType_CONSUMER})
+	// DESCRIBE is only part of the role if present, as the literal groups were provisioned with READ alone before.
+	for _, g := range consumerGroups {
+		requires := []ACLDetails{g}
+		if _, found := acls[groupDescribe(g)]; found {
+			requires = append(requires, groupDescribe(g))
+		}
+		ret = append(ret, shepherdRoleCandidate{
+			role:     acl(KafkaResourceType_GROUP, g.ResourceName, g.PatternType, ShepherdOperationType_CONSUMER),
+			requires: requires,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,414 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+/*
+	GeneratedConfigs is the outcome of reverse engineering the Shepherd configurations from the topics and
+	ACLs that are already provisioned in a cluster. The Blueprints and Definitions can be written as is to
+	the blueprints and definitions YAML files. ACLs that cannot be represented as a client definition are
+	not part of the Definitions and are listed in UnmappedACLs along with the reason.
+*/
+type GeneratedConfigs struct {
+	Blueprints   ShepherdBlueprint
+	Definitions  ShepherdDefinition
+	UnmappedACLs ACLMapping
+}
+
+const generatedBlueprintPrefix string = "blueprint_"
+
+// The ACL permissions of a single principal from a single host, as understood by the client definitions.
+type clientProfile struct {
+	Produces   []string `yaml:"produces,omitempty"`
+	Consumes   []string `yaml:"consumes,omitempty"`
+	Groups     []string `yaml:"groups,omitempty"`
+	TxnIDs     []string `yaml:"txnIds,omitempty"`
+	TxnGroup   string   `yaml:"txnGroup,omitempty"`
+	Idempotent bool     `yaml:"idempotent,omitempty"`
+}
+
+/*
+	Generates the Blueprints and the Definitions that describe the topics and the Kafka ACLs provided. The
+	configurations that are common to all the topics become the topic policy defaults and the topics sharing
+	the same remaining configurations are grouped into a topic blueprint. The ACLs are mapped back to the
+	producers and consumers of the topics. Internal topics (prefixed with "_") are not part of the output.
+*/
+func GenerateConfigsFromCluster(topics *TopicConfigMapping, acls *ACLMapping) *GeneratedConfigs {
+	out := &GeneratedConfigs{UnmappedACLs: ACLMapping{}}
+
+	topicNames := []string{}
+	for tName := range *topics {
+		if !strings.HasPrefix(tName, "_") {
+			topicNames = append(topicNames, tName)
+		}
+	}
+	sort.Strings(topicNames)
+
+	defaults, blueprints, topicBlueprint, topicOverrides := inferTopicBlueprints(topics, topicNames)
+	out.Blueprints.Blueprint.Topic.TopicConfigs = blueprints
+	out.Blueprints.Blueprint.Policy.TopicPolicy = &TopicPolicyConfigs{}
+	if len(defaults) != 0 {
+		out.Blueprints.Blueprint.Policy.TopicPolicy.Defaults = []NVPairs{defaults}
+	}
+
+	clients := out.inferClients(topicNames, acls)
+
+	// Topics (and ACL only resources) with the same blueprint, overrides and clients share a definition.
+	resources := append([]string{}, topicNames...)
+	for rName := range clients {
+		if _, found := (*topics)[rName]; !found {
+			resources = append(resources, rName)
+		}
+	}
+	sort.Strings(resources)
+	definitions := make(map[string]*TopicDefinition)
+	order := []string{}
+	for _, rName := range resources {
+		td := TopicDefinition{TopicBlueprintEnumRef: topicBlueprint[rName], Clients: clients[rName]}
+		if len(topicOverrides[rName]) != 0 {
+			td.ConfigOverrides = []NVPairs{topicOverrides[rName]}
+		}
+		key := definitionKey(td)
+		if _, found := definitions[key]; !found {
+			definitions[key] = &td
+			order = append(order, key)
+		}
+		definitions[key].Name = append(definitions[key].Name, rName)
+	}
+	for _, key := range order {
+		out.Definitions.DefinitionRoot.AdhocConfigs.Topics = append(out.Definitions.DefinitionRoot.AdhocConfigs.Topics, *definitions[key])
+	}
+	return out
+}
+
+func inferTopicBlueprints(topics *TopicConfigMapping, topicNames []string) (defaults NVPairs, blueprints []TopicBlueprintConfigs,
+	topicBlueprint map[string]string, topicOverrides map[string]NVPairs) {
+	defaults = NVPairs{}
+	topicBlueprint = make(map[string]string)
+	topicOverrides = make(map[string]NVPairs)
+	if len(topicNames) == 0 {
+		return defaults, blueprints, topicBlueprint, topicOverrides
+	}
+
+	for k, v := range (*topics)[topicNames[0]] {
+		defaults[k] = v
+	}
+	for _, tName := range topicNames[1:] {
+		for k, v := range defaults {
+			if value, found := (*topics)[tName][k]; !found || value != v {
+				delete(defaults, k)
+			}
+		}
+	}
+
+	groups := make(map[string][]string)
+	order := []string{}
+	for _, tName := range topicNames {
+		residual := NVPairs{}
+		for k, v := range (*topics)[tName] {
+			if _, found := defaults[k]; !found {
+				residual[k] = v
+			}
+		}
+		topicOverrides[tName] = residual
+		key := nvPairsKey(residual)
+		if _, found := groups[key]; !found {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], tName)
+	}
+
+	for _, key := range order {
+		members := groups[key]
+		if len(members) < 2 || len(topicOverrides[members[0]]) == 0 {
+			continue
+		}
+		bName := fmt.Sprintf("%s%d", generatedBlueprintPrefix, len(blueprints)+1)
+		blueprints = append(blueprints, TopicBlueprintConfigs{Name: bName, Overrides: []NVPairs{topicOverrides[members[0]]}})
+		for _, tName := range members {
+			topicBlueprint[tName] = bName
+			delete(topicOverrides, tName)
+		}
+	}
+	return defaults, blueprints, topicBlueprint, topicOverrides
+}
+
+/*
+	Maps the ACLs back to the producers and consumers of every topic. The ACLs of a principal are grouped
+	by host and a host is only mapped if every ACL for it can be generated back from the client definitions.
+	As the definitions merge the hostnames of a principal, a principal is only mapped for the hosts that
+	share the same permissions, otherwise the generated ACLs would be wider than the provisioned ones.
+*/
+func (out *GeneratedConfigs) inferClients(topicNames []string, acls *ACLMapping) map[string]ClientDefinition {
+	topicSet := make(map[string]bool)
+	for _, tName := range topicNames {
+		topicSet[tName] = true
+	}
+
+	perHost := make(map[string]map[string]ACLMapping)
+	for k, v := range *acls {
+		if _, ok := k.Operation.(KafkaACLOperation); !ok {
+			out.UnmappedACLs.Append(k, NVPairs{"Reason": "Not a Kafka ACL"})
+			continue
+		}
+		if k.IsDeny() {
+			out.UnmappedACLs.Append(k, NVPairs{"Reason": "Deny rules are not generated, they need to be reviewed and added to the definitions"})
+			continue
+		}
+		if _, found := perHost[k.Principal]; !found {
+			perHost[k.Principal] = make(map[string]ACLMapping)
+		}
+		if _, found := perHost[k.Principal][k.Hostname]; !found {
+			perHost[k.Principal][k.Hostname] = ACLMapping{}
+		}
+		perHost[k.Principal][k.Hostname][k] = v
+	}
+
+	clients := make(map[string]ClientDefinition)
+	for principal, hosts := range perHost {
+		profiles := make(map[string]clientProfile)
+		hostsByProfile := make(map[string][]string)
+		for host, mapping := range hosts {
+			p := newClientProfile(mapping, topicSet)
+			generated := p.generateACLs(principal, host)
+			for k := range mapping {
+				if _, found := generated[k]; !found {
+					out.UnmappedACLs.Append(k, NVPairs{"Reason": "Cannot be represented by a producer or consumer definition"})
+				}
+			}
+			if len(p.Produces) == 0 && len(p.Consumes) == 0 {
+				continue
+			}
+			key := p.key()
+			profiles[key] = p
+			hostsByProfile[key] = append(hostsByProfile[key], host)
+		}
+		if len(profiles) == 0 {
+			continue
+		}
+
+		selected := selectProfile(hostsByProfile)
+		for key, hostnames := range hostsByProfile {
+			if key == selected {
+				continue
+			}
+			for _, host := range hostnames {
+				for k := range profiles[key].generateACLs(principal, host) {
+					out.UnmappedACLs.Append(k, NVPairs{"Reason": "Principal has different permissions for different hosts"})
+				}
+			}
+		}
+
+		hostnames := hostsByProfile[selected]
+		sort.Strings(hostnames)
+		if len(hostnames) == 1 && hostnames[0] == "*" {
+			hostnames = nil
+		}
+		profiles[selected].addToClients(clients, principal, hostnames)
+	}
+	for rName, c := range clients {
+		sort.Slice(c.Producers, func(i, j int) bool {
+			return c.Producers[i].Principal+c.Producers[i].Group < c.Producers[j].Principal+c.Producers[j].Group
+		})
+		sort.Slice(c.Consumers, func(i, j int) bool {
+			return c.Consumers[i].Principal+c.Consumers[i].Group < c.Consumers[j].Principal+c.Consumers[j].Group
+		})
+		clients[rName] = c
+	}
+	return clients
+}
+
+func newClientProfile(mapping ACLMapping, topicSet map[string]bool) clientProfile {
+	topicOps := make(map[string]map[ACLOperationsInterface]bool)
+	txnOps := make(map[string]map[ACLOperationsInterface]bool)
+	groups, readGroups, idempotent := []string{}, []string{}, false
+	for k := range mapping {
+		// Any group with READ can be the group of a transactional producer.
+		if k.ResourceType == KafkaResourceType_GROUP && k.Operation == KafkaACLOperation_READ {
+			readGroups = append(readGroups, groupDefinitionName(k))
+		}
+		switch {
+		case k.ResourceType == KafkaResourceType_TOPIC && isMappableTopicResource(k, topicSet):
+			if _, found := topicOps[k.ResourceName]; !found {
+				topicOps[k.ResourceName] = make(map[ACLOperationsInterface]bool)
+			}
+			topicOps[k.ResourceName][k.Operation] = true
+		case k.ResourceType == KafkaResourceType_GROUP && k.PatternType == KafkaACLPatternType_LITERAL && k.Operation == KafkaACLOperation_READ:
+			groups = append(groups, k.ResourceName)
+		// Prefixed consumer groups are written with the "*" suffix. The prefixed groups of the streams do not have DESCRIBE.
+		case k.ResourceType == KafkaResourceType_GROUP && k.PatternType == KafkaACLPatternType_PREFIXED && k.Operation == KafkaACLOperation_READ:
+			describe := k
+			describe.Operation = KafkaACLOperation_DESCRIBE
+			if _, found := mapping[describe]; found {
+				groups = append(groups, groupDefinitionName(k))
+			}
+		case k.ResourceType == KafkaResourceType_TRANSACTIONALID:
+			txnID := groupDefinitionName(k)
+			if _, found := txnOps[txnID]; !found {
+				txnOps[txnID] = make(map[ACLOperationsInterface]bool)
+			}
+			txnOps[txnID][k.Operation] = true
+		case k.ResourceType == KafkaResourceType_CLUSTER && k.ResourceName == "kafka-cluster" &&
+			k.PatternType == KafkaACLPatternType_LITERAL && k.Operation == KafkaACLOperation_IDEMPOTENTWRITE:
+			idempotent = true
+		}
+	}
+
+	p := clientProfile{}
+	for rName, ops := range topicOps {
+		if ops[KafkaACLOperation_WRITE] && ops[KafkaACLOperation_DESCRIBE] {
+			p.Produces = append(p.Produces, rName)
+		}
+		if ops[KafkaACLOperation_READ] && ops[KafkaACLOperation_DESCRIBE] {
+			p.Consumes = append(p.Consumes, rName)
+		}
+	}
+	if len(p.Consumes) != 0 {
+		p.Groups = groups
+	}
+	// Idempotence and Transactions can only be enabled for a producer.
+	if len(p.Produces) != 0 {
+		p.Idempotent = idempotent
+		if idempotent {
+			for txnID, ops := range txnOps {
+				if ops[KafkaACLOperation_DESCRIBE] && ops[KafkaACLOperation_WRITE] {
+					p.TxnIDs = append(p.TxnIDs, txnID)
+				}
+			}
+		}
+		if len(p.TxnIDs) != 0 && len(readGroups) != 0 {
+			sort.Strings(readGroups)
+			p.TxnGroup = readGroups[0]
+		}
+	}
+	sort.Strings(p.Produces)
+	sort.Strings(p.Consumes)
+	sort.Strings(p.Groups)
+	sort.Strings(p.TxnIDs)
+	return p
+}
+
+// The name of a group or a transactional id in the definitions, where the prefixed ones end with "*".
+func groupDefinitionName(k ACLDetails) string {
+	if k.PatternType == KafkaACLPatternType_PREFIXED {
+		return k.ResourceName + "*"
+	}
+	return k.ResourceName
+}
+
+/*
+	Literal topic ACLs are only mapped for the topics present in the cluster, as the definitions would
+	otherwise create the topic. Prefixed ACLs are mapped only if the definitions generate the same pattern.
+*/
+func isMappableTopicResource(k ACLDetails, topicSet map[string]bool) bool {
+	if k.PatternType != determinePatternType(k.ResourceName) {
+		return false
+	}
+	if k.PatternType == KafkaACLPatternType_LITERAL {
+		return topicSet[k.ResourceName] || k.ResourceName == "*"
+	}
+	return true
+}
+
+// Generates the Kafka ACLs that the client definitions of this profile would produce.
+func (p clientProfile) generateACLs(principal string, host string) ACLMapping {
+	utm := UserTopicMapping{}
+	clients := make(map[string]ClientDefinition)
+	hostnames := []string{host}
+	p.addToClients(clients, principal, hostnames)
+	for rName, c := range clients {
+		for _, v := range c.Consumers {
+			utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_CONSUMER, v.Group, rName, hostnames, make(NVPairs))
+		}
+		for _, v := range c.Producers {
+			utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER, v.Group, rName, hostnames, make(NVPairs))
+			for _, txnID := range v.TransactionalIDs {
+				utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_TRANSACTIONAL_PRODUCER, txnID, rName, hostnames,
+					NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): v.Group})
+			}
+			if v.EnableIdempotence {
+				utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER_IDEMPOTENCE, v.Group, rName, hostnames, make(NVPairs))
+			}
+		}
+	}
+	return *KafkaACLOperation_UNKNOWN.GenerateACLMappingStructures("", utm.getShepherdACLList())
+}
+
+func (p clientProfile) addToClients(clients map[string]ClientDefinition, principal string, hostnames []string) {
+	producers := []ProducerDefinition{}
+	switch {
+	case len(p.TxnIDs) != 0:
+		// Transactions need a producer group as well, the Principal is used if no group has READ.
+		group := p.TxnGroup
+		if group == "" {
+			group = strings.TrimPrefix(principal, "User:")
+		}
+		producers = append(producers, ProducerDefinition{Principal: principal, Group: group, Hostnames: hostnames,
+			EnableIdempotence: true, TransactionalID: true, TransactionalIDs: p.TxnIDs})
+	case p.Idempotent:
+		// Idempotence needs a producer group, which is not part of the idempotence ACL. Principal is used instead.
+		producers = append(producers, ProducerDefinition{Principal: principal, Group: strings.TrimPrefix(principal, "User:"),
+			Hostnames: hostnames, EnableIdempotence: true})
+	default:
+		producers = append(producers, ProducerDefinition{Principal: principal, Hostnames: hostnames})
+	}
+	consumers := []ConsumerDefinition{}
+	if len(p.Groups) == 0 {
+		consumers = append(consumers, ConsumerDefinition{Principal: principal, Hostnames: hostnames})
+	}
+	for _, group := range p.Groups {
+		consumers = append(consumers, ConsumerDefinition{Principal: principal, Group: group, Hostnames: hostnames})
+	}
+
+	for _, rName := range p.Produces {
+		c := clients[rName]
+		c.Producers = append(c.Producers, producers...)
+		clients[rName] = c
+	}
+	for _, rName := range p.Consumes {
+		c := clients[rName]
+		c.Consumers = append(c.Consumers, consumers...)
+		clients[rName] = c
+	}
+}
+
+func (p clientProfile) key() string {
+	out, _ := yaml.Marshal(p)
+	return string(out)
+}
+
+// Prefers the profile that covers the most hosts. Ties are resolved by the first host name in sort order.
+func selectProfile(hostsByProfile map[string][]string) string {
+	selected, selectedHost := "", ""
+	for key, hosts := range hostsByProfile {
+		sort.Strings(hosts)
+		if selected == "" || len(hosts) > len(hostsByProfile[selected]) ||
+			(len(hosts) == len(hostsByProfile[selected]) && hosts[0] < selectedHost) {
+			selected, selectedHost = key, hosts[0]
+		}
+	}
+	return selected
+}
+
+func definitionKey(td TopicDefinition) string {
+	out, _ := yaml.Marshal(td)
+	return string(out)
+}
+
+func nvPairsKey(in NVPairs) string {
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("%s=%s\n", k, in[k]))
+	}
+	return sb.String()
+}
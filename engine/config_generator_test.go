@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+func (s *StackSuite) TestStackSuite_ConfigGenerator_RoundTrip() {
+	topics := TopicConfigMapping{
+		"test.1":    NVPairs{"num.partitions": "3", "retention.ms": "1000", "cleanup.policy": "delete"},
+		"test.2":    NVPairs{"num.partitions": "3", "retention.ms": "1000", "cleanup.policy": "delete"},
+		"test.3":    NVPairs{"num.partitions": "6", "cleanup.policy": "delete"},
+		"other.1":   NVPairs{"num.partitions": "1", "cleanup.policy": "delete", "min.insync.replicas": "2"},
+		"_internal": NVPairs{"num.partitions": "50", "cleanup.policy": "compact"},
+	}
+	mapped := ACLMapping{
+		// Plain Producer
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_WRITE, "*"):    nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_DESCRIBE, "*"): nil,
+		// Consumer with a group from multiple hosts
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h1"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h1"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h1"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h1"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "cg1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h1"):        nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "cg1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h1"):    nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h2"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h2"): nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h2"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h2"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "cg1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h2"):        nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "cg1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h2"):    nil,
+		// Idempotent Producer
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3", KafkaACLPatternType_LITERAL, "User:p2", KafkaACLOperation_WRITE, "*"):                    nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3", KafkaACLPatternType_LITERAL, "User:p2", KafkaACLOperation_DESCRIBE, "*"):                 nil,
+		constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:p2", KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
+		// Transactional Producer
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "other.1", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_WRITE, "*"):                   nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "other.1", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_DESCRIBE, "*"):                nil,
+		constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_IDEMPOTENTWRITE, "*"): nil,
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn1", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_WRITE, "*"):            nil,
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn1", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_DESCRIBE, "*"):         nil,
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "orders-", KafkaACLPatternType_PREFIXED, "User:p3", KafkaACLOperation_WRITE, "*"):        nil,
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "orders-", KafkaACLPatternType_PREFIXED, "User:p3", KafkaACLOperation_DESCRIBE, "*"):     nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "tg1", KafkaACLPatternType_LITERAL, "User:p3", KafkaACLOperation_READ, "*"):                        nil,
+		// Prefixed Consumer
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.*", KafkaACLPatternType_PREFIXED, "User:c2", KafkaACLOperation_READ, "*"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.*", KafkaACLPatternType_PREFIXED, "User:c2", KafkaACLOperation_DESCRIBE, "*"): nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "app-", KafkaACLPatternType_PREFIXED, "User:c2", KafkaACLOperation_READ, "*"):       nil,
+		constructACLDetailsObject(KafkaResourceType_GROUP, "app-", KafkaACLPatternType_PREFIXED, "User:c2", KafkaACLOperation_DESCRIBE, "*"):   nil,
+	}
+	unmapped := ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_ALTER, "*"):         nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "missing", KafkaACLPatternType_LITERAL, "User:c3", KafkaACLOperation_READ, "*"):         nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "missing", KafkaACLPatternType_LITERAL, "User:c3", KafkaACLOperation_DESCRIBE, "*"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "h3"):         nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.2", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "h3"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn2", KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_WRITE, "*"): nil,
+	}
+	acls := ACLMapping{}
+	for _, m := range []ACLMapping{mapped, unmapped} {
+		for k, v := range m {
+			acls[k] = v
+		}
+	}
+
+	out := GenerateConfigsFromCluster(&topics, &acls)
+	s.Len(out.Blueprints.Blueprint.Topic.TopicConfigs, 1, "test.1 & test.2 should share a generated blueprint")
+	s.EqualValues([]NVPairs{{"cleanup.policy": "delete"}}, out.Blueprints.Blueprint.Policy.TopicPolicy.Defaults, "Only the common configs should be defaults")
+	unmappedKeys := []ACLDetails{}
+	for k := range out.UnmappedACLs {
+		unmappedKeys = append(unmappedKeys, k)
+	}
+	expectedUnmapped := []ACLDetails{}
+	for k := range unmapped {
+		expectedUnmapped = append(expectedUnmapped, k)
+	}
+	s.ElementsMatch(expectedUnmapped, unmappedKeys, "Unmapped ACLs do not match")
+
+	// Write the generated files and run them through the regular parsing flow.
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	b, err := yaml.Marshal(out.Blueprints)
+	s.NoError(err)
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "blueprints.yaml"), b, 0644))
+	d, err := yaml.Marshal(out.Definitions)
+	s.NoError(err)
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "definitions.yaml"), d, 0644))
+
+	s.st.Core.Blueprints = ShepherdBlueprint{}
+	s.NoError(s.st.Core.Blueprints.ParseShepherBlueprints(filepath.Join(dir, "blueprints.yaml")))
+	_, err = s.st.Core.Definitions.ParseShepherDefinitions(filepath.Join(dir, "definitions.yaml"), true)
+	s.NoError(err)
+	s.st.Maps.TCM = TopicConfigMapping{}
+	s.st.Maps.utm = UserTopicMapping{}
+	s.st.Core.blueprintMap = nil
+	s.NoError(s.st.GenerateMappings())
+
+	for _, tName := range s.st.ListTopicsInConfig(true) {
+		s.EqualValues(topics[tName], s.st.Maps.TCM[tName], "Topic configs do not match for topic: "+tName)
+	}
+	s.ElementsMatch([]string{"test.1", "test.2", "test.3", "other.1"}, s.st.ListTopicsInConfig(false), "Topic list does not match")
+
+	generated := []ACLDetails{}
+	for k := range *KafkaACLOperation_UNKNOWN.GenerateACLMappingStructures("", s.st.Maps.utm.getShepherdACLList()) {
+		generated = append(generated, k)
+	}
+	expectedMapped := []ACLDetails{}
+	for k := range mapped {
+		expectedMapped = append(expectedMapped, k)
+	}
+	s.ElementsMatch(expectedMapped, generated, "ACLs generated from the definitions do not match the cluster")
+}
@@ -109,14 +109,14 @@ const (
 	KafkaACLPermissionType_ALLOW
 )
 
-func (in *KafkaACLPermissionType) String() string {
+func (in KafkaACLPermissionType) String() string {
 	m := map[KafkaACLPermissionType]string{
 		KafkaACLPermissionType_UNKNOWN: "KafkaACLPermissionType_UNKNOWN",
 		KafkaACLPermissionType_ANY:     "Any",
 		KafkaACLPermissionType_DENY:    "Deny",
 		KafkaACLPermissionType_ALLOW:   "Allow",
 	}
-	s, ok := m[*in]
+	s, ok := m[in]
 	if !ok {
 		s = m[KafkaACLPermissionType_UNKNOWN]
 	}
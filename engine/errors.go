@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+	The kinds of failures returned by the library. Every error returned by the engine and the managers
+	wraps one of these, so the callers can check the kind of failure with errors.Is.
+*/
+var (
+	// The configuration files (or the ENV Variables they refer to) are missing or have invalid values.
+	ErrConfigInvalid = errors.New("invalid configuration")
+	// The cluster (or one of its servers like MDS) cannot be connected to or did not respond as expected.
+	ErrClusterUnreachable = errors.New("cluster unreachable")
+	// The cluster rejected the credentials or the principal is not authorized for the request.
+	ErrAuthFailed = errors.New("authentication failed")
+)
+
+/*
+	ShepherdError carries the kind of failure (one of the Err* values), a message describing what was being
+	done and the underlying error, if any. errors.Is matches both the kind and the underlying error.
+*/
+type ShepherdError struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+func NewShepherdError(kind error, msg string, err error) error {
+	return &ShepherdError{Kind: kind, Msg: msg, Err: err}
+}
+
+func (e *ShepherdError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Kind, e.Msg, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Msg)
+}
+
+func (e *ShepherdError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ShepherdError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func configError(format string, a ...interface{}) error {
+	return NewShepherdError(ErrConfigInvalid, fmt.Sprintf(format, a...), nil)
+}
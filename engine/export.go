@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"sort"
+)
+
+/*
+	ExportedConfig is the flattened view of the configuration after the blueprints and
+	definitions have been resolved. It is what Shepherd expects the clusters to look like
+	and is used by the export command to share the resolved state with external tooling.
+*/
+type ExportedConfig struct {
+	Topics []ExportedTopic `json:"topics" yaml:"topics"`
+	ACLs   []ExportedACL   `json:"acls" yaml:"acls"`
+}
+
+type ExportedTopic struct {
+	Name    string  `json:"name" yaml:"name"`
+	Configs NVPairs `json:"configs" yaml:"configs"`
+}
+
+type ExportedACL struct {
+	ResourceType   string `json:"resourceType" yaml:"resourceType"`
+	ResourceName   string `json:"resourceName" yaml:"resourceName"`
+	PatternType    string `json:"patternType" yaml:"patternType"`
+	Principal      string `json:"principal" yaml:"principal"`
+	Operation      string `json:"operation" yaml:"operation"`
+	Hostname       string `json:"hostname" yaml:"hostname"`
+	PermissionType string `json:"permissionType" yaml:"permissionType"`
+}
+
+// Generates the ExportedConfig from the currently parsed configurations. Output is sorted for stable diffs.
+func (st *State) ExportConfigs() *ExportedConfig {
+	out := &ExportedConfig{
+		Topics: []ExportedTopic{},
+		ACLs:   []ExportedACL{},
+	}
+	for _, tName := range st.ListTopicsInConfig(true) {
+		out.Topics = append(out.Topics, ExportedTopic{Name: tName, Configs: st.Maps.TCM[tName]})
+	}
+	sort.Slice(out.Topics, func(i, j int) bool {
+		return out.Topics[i].Name < out.Topics[j].Name
+	})
+	if st.ACLList != nil {
+		out.ACLs = st.ACLList.Export()
+	}
+	return out
+}
+
+// Converts the ACLMapping into a sorted slice of ExportedACL.
+func (m *ACLMapping) Export() []ExportedACL {
+	out := make([]ExportedACL, 0, len(*m))
+	for k := range *m {
+		out = append(out, ExportedACL{
+			ResourceType:   k.ResourceType.GetACLResourceString(),
+			ResourceName:   k.ResourceName,
+			PatternType:    k.PatternType.GetACLPatternString(),
+			Principal:      k.Principal,
+			Operation:      k.Operation.String(),
+			Hostname:       k.Hostname,
+			PermissionType: k.PermissionType.String(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].sortKey() < out[j].sortKey()
+	})
+	return out
+}
+
+func (e ExportedACL) sortKey() string {
+	return e.Principal + "|" + e.ResourceType + "|" + e.ResourceName + "|" + e.PatternType + "|" + e.Operation + "|" + e.Hostname + "|" + e.PermissionType
+}
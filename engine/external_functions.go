@@ -23,8 +23,11 @@ func (utm *UserTopicMapping) getShepherdACLList() *ACLMapping {
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = nil
 			case ShepherdOperationType_TRANSACTIONAL_PRODUCER:
-				ret[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, i[1], determinePatternType(i[4]),
-					i[0], varType, i[3])] = nil
+				// The Group of the producer is part of the NVPairs, as the Group ID is the Transactional ID here.
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				txnID, txnPattern := determineGroupPatternType(i[1])
+				ret[constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, txnID, txnPattern,
+					i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_PRODUCER_IDEMPOTENCE:
 				ret[constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", determinePatternType(i[4]),
 					i[0], varType, i[3])] = nil
@@ -32,46 +35,52 @@ func (utm *UserTopicMapping) getShepherdACLList() *ACLMapping {
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = nil
 				if i[1] != "" {
-					ret[constructACLDetailsObject(KafkaResourceType_GROUP, i[1], KafkaACLPatternType_LITERAL,
+					gName, gPattern := determineGroupPatternType(i[1])
+					ret[constructACLDetailsObject(KafkaResourceType_GROUP, gName, gPattern,
 						i[0], varType, i[3])] = nil
 				}
 			// case ShepherdOperationType_CONSUMER_GROUP:
 			// 	ret[constructACLDetailsObject(KafkaResourceType_GROUP, i[1], determinePatternType(i[4]),
 			// 		i[0], varType, i[3])] = nil
 			case ShepherdOperationType_SOURCE_CONNECTOR:
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_SINK_CONNECTOR:
 				// The Connect Cluster Name (Group Name) is part of the NVPairs and should be fetched from there for additional info.
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 				// ret[constructACLDetailsObject(KafkaResourceType_GROUP, i[1], determinePatternType(i[4]),
 				// 	i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_STREAM_READ:
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_STREAM_WRITE:
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_KSQL_READ:
 				// TODO: Implement KSQL Permission sets
 				// Added only the TOPIC Resource type and the KSQL Service ID should be available in the NVPairs
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 				// ret[constructACLDetailsObject(KafkaResourceType_KSQL_CLUSTER, i[1], KafkaACLPatternType_PREFIXED,
 				// 	i[0], varType, i[3])] = value.AddlData
 			case ShepherdOperationType_KSQL_WRITE:
 				// TODO: Implement KSQL Permission sets
-				value := ConfMaps.utm[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
+				value := (*utm)[UserTopicMappingKey{Principal: i[0], ClientType: varType.(ShepherdOperationType), GroupID: i[1]}]
 				ret[constructACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
 					i[0], varType, i[3])] = value.AddlData
 				// ret[constructACLDetailsObject(KafkaResourceType_KSQL_CLUSTER, i[1], KafkaACLPatternType_PREFIXED,
 				// 	i[0], varType, i[3])] = value.AddlData
+			case ShepherdOperationType_DENY:
+				// Deny rules are explicit Kafka ACLs, so they are passed through as is by the ACL Managers.
+				op, _ := KafkaACLOperation_UNKNOWN.GetValue(i[1])
+				ret[constructDenyACLDetailsObject(KafkaResourceType_TOPIC, i[4], determinePatternType(i[4]),
+					i[0], op, i[3])] = nil
 			default:
 				// TODO: Error handling if the Client Type provided is unknown
 			}
@@ -143,8 +152,8 @@ func conditionalACLMapper(inputACLs *ACLMapping, findIn *ACLMapping, presenceChe
 // 	}
 // }
 
-func ListTopicsInConfig(forceRefresh bool) []string {
-	return ksmisc.GetStringSliceFromMapSet(Shepherd.GetTopicList(forceRefresh))
+func (st *State) ListTopicsInConfig(forceRefresh bool) []string {
+	return ksmisc.GetStringSliceFromMapSet(st.GetTopicList(forceRefresh))
 }
 
 /*
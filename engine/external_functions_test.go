@@ -33,11 +33,12 @@ func (s *StackSuite) TestStackSuite_ExternalFunctions_ListTopicsInConfig() {
 
 	for _, c := range cases {
 		os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", c.inDefFileName)
-		SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-		ConfMaps.TCM = TopicConfigMapping{}
-		topicsInConfig = mapset.NewSet()
-		GenerateMappings()
-		out := ListTopicsInConfig(true)
+		_, err := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+		s.NoError(err)
+		s.st.Maps.TCM = TopicConfigMapping{}
+		s.st.topicsInConfig = mapset.NewSet()
+		s.NoError(s.st.GenerateMappings())
+		out := s.st.ListTopicsInConfig(true)
 		s.ElementsMatch(c.out, out, c.err)
 	}
 }
@@ -61,18 +62,23 @@ var (
 				// User:1104
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1104", ShepherdOperationType_PRODUCER, "*"):                       nil,
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1104", ShepherdOperationType_PRODUCER_IDEMPOTENCE, "*"):  nil,
-				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1104", KafkaACLPatternType_LITERAL, "User:1104", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1104", KafkaACLPatternType_LITERAL, "User:1104", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1104"},
 				// User:1105
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1105", ShepherdOperationType_PRODUCER, "*"):                       nil,
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1105", ShepherdOperationType_PRODUCER_IDEMPOTENCE, "*"):  nil,
-				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1105", KafkaACLPatternType_LITERAL, "User:1105", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1105", KafkaACLPatternType_LITERAL, "User:1105", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1105"},
 				//User:1106
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_PRODUCER, "abc.host"):                       nil,
 				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_PRODUCER, "def.host"):                       nil,
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_PRODUCER_IDEMPOTENCE, "abc.host"):  nil,
 				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_PRODUCER_IDEMPOTENCE, "def.host"):  nil,
-				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "abc.host"): nil,
-				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "def.host"): nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "abc.host"): NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1106"},
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "1106", KafkaACLPatternType_LITERAL, "User:1106", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "def.host"): NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1106"},
+				// User:1107
+				constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1107", ShepherdOperationType_PRODUCER, "*"):                           nil,
+				constructACLDetailsObject(KafkaResourceType_CLUSTER, "kafka-cluster", KafkaACLPatternType_LITERAL, "User:1107", ShepherdOperationType_PRODUCER_IDEMPOTENCE, "*"):      nil,
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "orders-", KafkaACLPatternType_PREFIXED, "User:1107", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"): NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1107"},
+				constructACLDetailsObject(KafkaResourceType_TRANSACTIONALID, "txn.1", KafkaACLPatternType_LITERAL, "User:1107", ShepherdOperationType_TRANSACTIONAL_PRODUCER, "*"):    NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "1107"},
 				// // Consumers
 				// // User:1111
 				// constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:1111", ShepherdOperationType_CONSUMER, "*"): nil,
@@ -152,10 +158,11 @@ var (
 func (s *StackSuite) TestStackSuite_ExternalFunctions_ListACLsInConfig() {
 	for _, c := range aclMappingCases {
 		os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", c.inDefFileName)
-		SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-		ConfMaps.utm = UserTopicMapping{}
-		GenerateMappings()
-		result := ConfMaps.utm.getShepherdACLList()
+		_, err := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+		s.NoError(err)
+		s.st.Maps.utm = UserTopicMapping{}
+		s.NoError(s.st.GenerateMappings())
+		result := s.st.Maps.utm.getShepherdACLList()
 		// s.EqualValues(c.out, out, c.err)
 		s.True(reflect.DeepEqual(c.out, result), fmt.Sprintf("Expected Value: %v \n\n  Actual Value: %v \n\nFilename: %v\n\nError: %v", c.out, result, c.inDefFileName, c.err))
 
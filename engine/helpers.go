@@ -12,36 +12,62 @@ import (
 // This function can be used to check if a specific string value begins with the ENV Var prefix or not.
 // If it begins with the ENVVAR_PREFIX constant, then it will consider everything after the ENVVAR_PREFIX
 // as the ENV Variable name and try to fetch it or return the default Value
-func envVarCheckNReplace(s string, def string) string {
+func envVarCheckNReplace(s string, def string) (string, error) {
 	if strings.HasPrefix(s, ENVVAR_PREFIX) {
 		return getEnvVarsWithDefaults(strings.Replace(s, ENVVAR_PREFIX, "", 1), def)
 	}
 	if s == "" && def != "" {
-		return def
+		return def, nil
 	}
-	return s
+	return s, nil
 }
 
 // If the ENV Variable is empty but the default value exists, then the default value would be returned.
-// If the ENV Variable is not present and the default value is also empty, it will fail with a fatal exception.
+// If the ENV Variable is not present and the default value is also empty, an ErrConfigInvalid error is returned.
 // If Env Variable is found and has a value, the string value will be returned.
-func getEnvVarsWithDefaults(envVarName string, defaultValue string) string {
+func getEnvVarsWithDefaults(envVarName string, defaultValue string) (string, error) {
 	envVarValue, present := os.LookupEnv(envVarName)
 	if !present && defaultValue == "" {
-		logger.Fatal(envVarName, " is not available in ENV Variables. Cannot proceed without it.")
+		return "", configError("%s is not available in ENV Variables. Cannot proceed without it", envVarName)
 	}
 
 	if envVarValue == "" && defaultValue == "" {
-		logger.Fatal(envVarName, "ENV Variable is empty. Please ensure it is instantiated properly.")
+		return "", configError("%s ENV Variable is empty. Please ensure it is instantiated properly", envVarName)
 	} else if envVarValue == "" && defaultValue != "" {
 		logger.Debugw("ENV Variable is empty. Using Default value\n",
 			"ENV Variable Name", envVarName,
 			"ENV Variable Value", envVarValue,
 			"Default Value", defaultValue)
-		return defaultValue
+		return defaultValue, nil
 	}
 
-	return envVarValue
+	return envVarValue, nil
+}
+
+/*
+	Resolves the ENV Variable references while walking the configuration structures. The first failure is
+	retained and the walk carries on, so the error only needs to be checked once the walk is complete.
+*/
+type envResolver struct {
+	err error
+}
+
+func (r *envResolver) replace(s string, def string) string {
+	v, err := envVarCheckNReplace(s, def)
+	r.fail(err)
+	return v
+}
+
+func (r *envResolver) env(envVarName string, def string) string {
+	v, err := getEnvVarsWithDefaults(envVarName, def)
+	r.fail(err)
+	return v
+}
+
+func (r *envResolver) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
 }
 
 /* The standard YAML parsers creates a lot of NVPairs and sets up arrays for all or them.
@@ -1,5 +1,10 @@
 package engine
 
+import (
+	"errors"
+	"os"
+)
+
 // var _ = func() bool {
 // 	testing.Init()
 // 	os.Setenv("SHEPHERD_CONFIG_FILE_LOCATION", "./../configs/shepherd.yaml")
@@ -26,3 +31,25 @@ func (s *StackSuite) TestStackSuite_Helpers() {
 	s.Error(err)
 
 }
+
+func (s *StackSuite) TestStackSuite_Helpers_ConfigErrors() {
+	os.Unsetenv("SHEPHERD_TEST_MISSING_VAR")
+	_, err := envVarCheckNReplace("env::SHEPHERD_TEST_MISSING_VAR", "")
+	s.True(errors.Is(err, ErrConfigInvalid))
+	s.False(errors.Is(err, ErrClusterUnreachable))
+
+	value, err := envVarCheckNReplace("env::SHEPHERD_TEST_MISSING_VAR", "default")
+	s.NoError(err)
+	s.Equal("default", value)
+
+	r := &envResolver{}
+	r.replace("env::SHEPHERD_TEST_MISSING_VAR", "")
+	r.fail(NewShepherdError(ErrAuthFailed, "second failure", nil))
+	s.True(errors.Is(r.err, ErrConfigInvalid), "The first failure should be kept.")
+
+	cause := errors.New("connection refused")
+	err = NewShepherdError(ErrClusterUnreachable, "cannot list topics", cause)
+	s.True(errors.Is(err, ErrClusterUnreachable))
+	s.True(errors.Is(err, cause))
+	s.Equal("cluster unreachable: cannot list topics: connection refused", err.Error())
+}
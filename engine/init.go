@@ -1,10 +1,12 @@
 package engine
 
 import (
-	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
 	yaml "gopkg.in/yaml.v2"
@@ -13,113 +15,140 @@ import (
 	"go.uber.org/zap"
 )
 
-// Externally available variables.
 var (
-	ConfMaps ConfigurationMaps = ConfigurationMaps{
-		TCM: TopicConfigMapping{},
-		utm: UserTopicMapping{},
-		CCM: ClusterConfigMapping{},
-	}
-	SpdCore ShepherdCore = ShepherdCore{
-		Configs:     ShepherdConfig{},
-		Blueprints:  ShepherdBlueprint{},
-		Definitions: ShepherdDefinition{},
-	}
-	ShepherdACLList *ACLMapping
-	DryRun          bool
-	IsTest          bool
+	enableDebug bool               = false
+	logger      *zap.SugaredLogger = ksmisc.GetLogger(false, false)
 )
 
-// Internal variables for function
-var (
-	enableDebug          bool = false
-	enableStructuredLogs bool = false
-	configFile           string
-	blueprintsFile       string
-	definitionsFile      string
-	runMode              RunMode
-	blueprintMap         map[string]NVPairs
-	logger               *zap.SugaredLogger
-	shepherdACLList      *ACLMapping
-)
+/*
+	The inputs needed to load a set of configurations. The file paths can still be overwritten by the
+	SHEPHERD_CONFIG_FILE_LOCATION, SHEPHERD_BLUEPRINTS_FILE_LOCATION and SHEPHERD_DEFINITIONS_FILE_LOCATION
+	ENV Variables for additional flexibility. RunMode_UNKNOWN is treated as RunMode_SINGLE_CLUSTER.
+*/
+type Options struct {
+	ConfigFile      string
+	BlueprintsFile  string
+	DefinitionsFile string
+	RunMode         RunMode
+	// Does not execute anything but lists what will be executed.
+	DryRun bool
+	// Wipes out all the objects that the configurations provide after the execution.
+	IsTest bool
+	// Deadline for every single request to a cluster. Each retry of a request gets its own deadline. 0 means no deadline.
+	OperationTimeout time.Duration
+}
 
-// func InitializeShepherdCore(enableDebug bool, enableConsoleLogs bool, shepherdConfigFilePath string,
-// 	shepherdBlueprintsFilePath string, shepherdDefinitionsFilePath string, runString string) {
-func Init() {
-	// Initialize Logger
-	if !flag.Parsed() {
-		ResolveFlags()
-	}
-	logger = ksmisc.GetLogger(enableDebug, enableStructuredLogs)
-	// runMode = assertRunMode(runString)
+/*
+	State holds the configurations parsed from one set of files along with the mappings generated from
+	them. Every State is independent of the others, so multiple configuration sets can be loaded in the
+	same process.
+*/
+type State struct {
+	Options
+	Core           ShepherdCore
+	Maps           ConfigurationMaps
+	ACLList        *ACLMapping
+	configPaths    []string
+	topicsInConfig mapset.Set
+}
 
-	// Parse Shepherd Internal Configurations from the YAML file.
-	SpdCore.Configs.ParseShepherdConfig(getEnvVarsWithDefaults("SHEPHERD_CONFIG_FILE_LOCATION", configFile), true)
-	logger.Debug("Shepherd Config File parse Result: ", SpdCore.Configs)
+// Parses the config, blueprints and definitions files and generates the mappings for them.
+func Load(opts Options) (*State, error) {
+	if opts.RunMode == RunMode_UNKNOWN {
+		opts.RunMode = RunMode_SINGLE_CLUSTER
+	}
+	st := &State{
+		Options: opts,
+		Maps: ConfigurationMaps{
+			TCM:              TopicConfigMapping{},
+			utm:              UserTopicMapping{},
+			CCM:              ClusterConfigMapping{},
+			Connectors:       ConnectorConfigMapping{},
+			Subjects:         SubjectConfigMapping{},
+			KSQL:             KSQLConfigMapping{},
+			Quotas:           QuotaConfigMapping{},
+			ScramCredentials: ScramCredentialMapping{},
+		},
+		topicsInConfig: mapset.NewSet(),
+	}
 
-	// Parse Shepherd Blueprints from the YAML file.
-	SpdCore.Blueprints.ParseShepherBlueprints(getEnvVarsWithDefaults("SHEPHERD_BLUEPRINTS_FILE_LOCATION", blueprintsFile))
-	logger.Debug("Shepherd Blueprints parse Result: ", SpdCore.Blueprints)
+	r := &envResolver{}
+	st.configPaths = []string{
+		r.env("SHEPHERD_CONFIG_FILE_LOCATION", opts.ConfigFile),
+		r.env("SHEPHERD_BLUEPRINTS_FILE_LOCATION", opts.BlueprintsFile),
+		r.env("SHEPHERD_DEFINITIONS_FILE_LOCATION", opts.DefinitionsFile),
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
 
 	// Parse Shepherd Internal Configurations from the YAML file.
-	SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", definitionsFile), true)
-	logger.Debug("Shepherd Definitions parse Result: ", SpdCore.Definitions)
+	if err := st.Core.Configs.ParseShepherdConfig(st.configPaths[0], true); err != nil {
+		return nil, err
+	}
+	if err := st.Core.Configs.validateShepherdConfig(st.RunMode); err != nil {
+		return nil, err
+	}
+	logger.Debug("Shepherd Config File parse Result: ", st.Core.Configs)
 
-	// Understand the Blueprints & Definitions file and setup the External facing representation of the core files.
-	GenerateMappings()
-	logger.Debug("Config File parse Result: ", ConfMaps)
+	// Blueprints & Definitions are generated from the cluster in CREATE_CONFIGS mode, so they are not expected to exist yet.
+	if st.RunMode != RunMode_CREATE_CONFIGS {
+		// Parse Shepherd Blueprints from the YAML file.
+		if err := st.Core.Blueprints.ParseShepherBlueprints(st.configPaths[1]); err != nil {
+			return nil, err
+		}
+		logger.Debug("Shepherd Blueprints parse Result: ", st.Core.Blueprints)
 
-	shepherdACLList = ConfMaps.utm.getShepherdACLList()
-	ShepherdACLList = shepherdACLList
-}
+		// Parse Shepherd Internal Configurations from the YAML file.
+		if _, err := st.Core.Definitions.ParseShepherDefinitions(st.configPaths[2], true); err != nil {
+			return nil, err
+		}
+		logger.Debug("Shepherd Definitions parse Result: ", st.Core.Definitions)
+	}
 
-func ResolveFlags() {
-	flag.BoolVar(&DryRun, "dryRun", false, "Does not execute anything but lists what will be executed.")
-	flag.BoolVar(&IsTest, "testRun", false, "Executes the whole flow and then wipes out all the objects that the configurations provide (not just for this run but configured in the earlier runs as well). This helps in testing the same configurations multiple times without wiping your clusters and starting over.")
-	flag.BoolVar(&enableDebug, "debug", false, "Turns on Debug mode log")
-	flag.BoolVar(&enableStructuredLogs, "enableStructuredLogs", false, "Turns off unstructured mode logging features and use Structured logging instead.")
-	flag.StringVar(&configFile, "configPath", "./configs/shepherd.yaml", "Absolute file Path for Core Configuration file. Please note that this might still be overwritten by the SHEPHERD_CONFIG_FILE_LOCATION for additional flexibility.")
-	flag.StringVar(&blueprintsFile, "blueprintsPath", "./configs/blueprints.yaml", "Absolute file Path for Shepherd Blueprints file. Please note that this might still be overwritten by the SHEPHERD_BLUEPRINTS_FILE_LOCATION for additional flexibility.")
-	flag.StringVar(&definitionsFile, "definitionsPath", "./configs/definitions_dev.yaml", "Absolute file Path for Shepherd Definitions file. Please note that this might still be overwritten by the SHEPHERD_DEFINITIONS_FILE_LOCATION for additional flexibility.")
-	runMode = assertRunMode(flag.String("runmode", "SINGLE_CLUSTER", "Changes the mode in which the tool is operating. Options are SINGLE_CLUSTER, MULTI_CLUSTER, MIGRATION, CREATE_CONFIGS_FROM_EXISTING_CLUSTER"))
-	flag.Parse()
-}
+	// Understand the Blueprints & Definitions file and setup the External facing representation of the core files.
+	if err := st.GenerateMappings(); err != nil {
+		return nil, err
+	}
+	logger.Debug("Config File parse Result: ", st.Maps)
 
-func GetConfigMaps() (sc *ConfigurationMaps) {
-	return &ConfMaps
+	st.ACLList = st.Maps.utm.getShepherdACLList()
+	return st, nil
 }
 
-func GetConfigTopicsAsMapSet() mapset.Set {
-	return topicsInConfig
+/*
+	Sets up the logger shared by all the packages. The logger is updated in place so that the packages
+	holding a reference to it pick up the new settings.
+*/
+func ConfigureLogger(debug bool, structuredLogs bool) {
+	enableDebug = debug
+	*logger = *ksmisc.GetLogger(debug, structuredLogs)
 }
 
 func IsDebugEnabled() bool {
 	return enableDebug
 }
 
-func assertRunMode(mode *string) RunMode {
-	switch strings.ToUpper(strings.TrimSpace(*mode)) {
+func ParseRunMode(mode string) RunMode {
+	switch strings.ToUpper(strings.TrimSpace(mode)) {
 	case RunMode_SINGLE_CLUSTER.String():
 		return RunMode_SINGLE_CLUSTER
 	case RunMode_MULTI_CLUSTER.String():
-		logger.Fatal(RunMode_MULTI_CLUSTER.String(), " mode has not been implemented yet, but should be available soon.")
+		return RunMode_MULTI_CLUSTER
 	case RunMode_MIGRATION.String():
-		logger.Fatal(RunMode_MIGRATION.String(), " mode has not been implemented yet, but should be available soon.")
+		return RunMode_MIGRATION
 	case RunMode_CREATE_CONFIGS.String():
-		logger.Fatal(RunMode_CREATE_CONFIGS.String(), " mode has not been implemented yet, but should be available soon.")
+		return RunMode_CREATE_CONFIGS
 	default:
-		logger.Warnf("Selected runMode '%s' is incorrect. Reverting to %s mode to continue with the process.", *mode, RunMode_SINGLE_CLUSTER.String())
+		logger.Warnf("Selected runMode '%s' is incorrect. Reverting to %s mode to continue with the process.", mode, RunMode_SINGLE_CLUSTER.String())
 	}
 	return RunMode_SINGLE_CLUSTER
 }
 
-func (shp *ShepherdBlueprint) ParseShepherBlueprints(configFilePath string) {
-	temp, err := ioutil.ReadFile(configFilePath)
+func (shp *ShepherdBlueprint) ParseShepherBlueprints(configFilePath string) error {
+	temp, err := readConfigFile(configFilePath, "SHEPHERD_BLUEPRINTS_FILE_LOCATION")
 	if err != nil {
-		pwd, _ := os.Getwd()
-		logger.Fatalw("Cannot read the filepath provided in SHEPHERD_BLUEPRINTS_FILE_LOCATION variable. Please Correct.",
-			"Current Working Directory", pwd,
-			"Error Received", err)
+		return err
 	}
 
 	if shp == nil {
@@ -127,24 +156,22 @@ func (shp *ShepherdBlueprint) ParseShepherBlueprints(configFilePath string) {
 	}
 
 	if err := yaml.Unmarshal(temp, shp); err != nil {
-		logger.Fatal("Error Unmarshaling Shepherd Blueprints File", err)
+		return NewShepherdError(ErrConfigInvalid, "Error Unmarshaling Shepherd Blueprints File", err)
 	}
 	shp.validateShepherdBlueprints()
-	shp.readValuesFromENV()
-	// return shp
+	r := &envResolver{}
+	shp.readValuesFromENV(r)
+	return r.err
 }
 
 func (scf *ShepherdBlueprint) validateShepherdBlueprints() {
 	logger.Debug("No Validations for Shepherd Blueprints at the moment.")
 }
 
-func (shp *ShepherdDefinition) ParseShepherDefinitions(configFilePath string, overwriteExisting bool) *ShepherdDefinition {
-	temp, err := ioutil.ReadFile(configFilePath)
+func (shp *ShepherdDefinition) ParseShepherDefinitions(configFilePath string, overwriteExisting bool) (*ShepherdDefinition, error) {
+	temp, err := readConfigFile(configFilePath, "SHEPHERD_DEFINITIONS_FILE_LOCATION")
 	if err != nil {
-		pwd, _ := os.Getwd()
-		logger.Fatalw("Cannot read the filepath provided in SHEPHERD_DEFINITIONS_FILE_LOCATION variable. Please Correct.",
-			"Current Working Directory", pwd,
-			"Error Received", err)
+		return nil, err
 	}
 
 	if overwriteExisting || shp == nil {
@@ -152,24 +179,27 @@ func (shp *ShepherdDefinition) ParseShepherDefinitions(configFilePath string, ov
 	}
 
 	if err := yaml.Unmarshal(temp, shp); err != nil {
-		logger.Fatal("Error Unmarshalling Shepherd Definitions File", err)
+		return nil, NewShepherdError(ErrConfigInvalid, "Error Unmarshalling Shepherd Definitions File", err)
 	}
 	shp.validateShepherdDefinitions()
-	shp.readValuesFromENV()
-	return shp
+	r := &envResolver{}
+	shp.readValuesFromENV(r)
+	if r.err != nil {
+		return nil, r.err
+	}
+	shp.DefinitionRoot.resolveSchemaFiles(filepath.Dir(configFilePath))
+	shp.DefinitionRoot.resolveKSQLFiles(filepath.Dir(configFilePath))
+	return shp, nil
 }
 
 func (scf *ShepherdDefinition) validateShepherdDefinitions() {
 	return
 }
 
-func (shp *ShepherdConfig) ParseShepherdConfig(configFilePath string, overwriteExisting bool) {
-	temp, err := ioutil.ReadFile(configFilePath)
+func (shp *ShepherdConfig) ParseShepherdConfig(configFilePath string, overwriteExisting bool) error {
+	temp, err := readConfigFile(configFilePath, "SHEPHERD_CONFIG_FILE_LOCATION")
 	if err != nil {
-		pwd, _ := os.Getwd()
-		logger.Fatalw("Cannot read the filepath provided in SHEPHERD_CONFIG_FILELOCATION variable.",
-			"Current Working Directory", pwd,
-			"Error Received", err)
+		return err
 	}
 
 	if shp == nil || overwriteExisting {
@@ -177,14 +207,36 @@ func (shp *ShepherdConfig) ParseShepherdConfig(configFilePath string, overwriteE
 	}
 
 	if err := yaml.Unmarshal(temp, shp); err != nil {
-		logger.Fatal("Error Unmarshaling Shepherd Configs File", err)
+		return NewShepherdError(ErrConfigInvalid, "Error Unmarshaling Shepherd Configs File", err)
 	}
-	shp.validateShepherdConfig()
-	shp.readValuesFromENV()
-	// return shp
+	r := &envResolver{}
+	shp.readValuesFromENV(r)
+	return r.err
 }
 
-func (scf *ShepherdConfig) validateShepherdConfig() {
+func readConfigFile(configFilePath string, envVarName string) ([]byte, error) {
+	temp, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		pwd, _ := os.Getwd()
+		return nil, NewShepherdError(ErrConfigInvalid,
+			fmt.Sprintf("Cannot read the filepath provided in %s variable. Current Working Directory: %s", envVarName, pwd), err)
+	}
+	return temp, nil
+}
+
+func (scf *ShepherdConfig) validateShepherdConfig(runMode RunMode) error {
+	if err := scf.ConfigRoot.ShepherdCoreConfig.Retry.validate(); err != nil {
+		return err
+	}
+	if err := scf.ConfigRoot.ShepherdCoreConfig.Reassignment.validate(); err != nil {
+		return err
+	}
+	if err := scf.ConfigRoot.ShepherdCoreConfig.TopicDeletion.validate(); err != nil {
+		return err
+	}
+	if err := scf.ConfigRoot.ShepherdCoreConfig.ScramCredentials.validate(); err != nil {
+		return err
+	}
 	count := 0
 	for _, cluster := range scf.ConfigRoot.Clusters {
 		if cluster.IsEnabled {
@@ -193,29 +245,21 @@ func (scf *ShepherdConfig) validateShepherdConfig() {
 	}
 
 	switch runMode {
-	case RunMode_SINGLE_CLUSTER:
+	case RunMode_SINGLE_CLUSTER, RunMode_CREATE_CONFIGS:
 		if count != 1 {
-			logger.Fatalw("Unique cluster not enabled in the config file for selected run mode. Either select the correct runMode or enable only ONE cluster via 'is.enabled' flag.",
-				"Selected RunMode", runMode.String(),
-				"Enabled Clusters", count)
+			return configError("Unique cluster not enabled in the config file for selected run mode. Either select the correct runMode or enable only ONE cluster via 'is.enabled' flag. Selected RunMode: %s, Enabled Clusters: %d",
+				runMode.String(), count)
 		}
 	case RunMode_MULTI_CLUSTER:
 		if count < 1 {
-			logger.Fatalw("Cannot have less than one cluster(s) enabled in the config file for selected run mode. Either select the correct runMode or enable more clusters via 'is.enabled' flag.",
-				"Selected RunMode", runMode.String(),
-				"Enabled Clusters", count)
+			return configError("Cannot have less than one cluster(s) enabled in the config file for selected run mode. Either select the correct runMode or enable more clusters via 'is.enabled' flag. Selected RunMode: %s, Enabled Clusters: %d",
+				runMode.String(), count)
 		}
 	case RunMode_MIGRATION:
 		if count < 2 {
-			logger.Fatalw("Cannot have less than two cluster(s) enabled in the config file for selected run mode. Either select the correct runMode or enable more clusters via 'is.enabled' flag.",
-				"Selected RunMode", runMode.String(),
-				"Enabled Clusters", count)
-		}
-	case RunMode_CREATE_CONFIGS:
-		if count != 1 {
-			logger.Fatalw("Unique cluster not enabled in the config file for selected run mode. Either select the correct runMode or enable only ONE cluster via 'is.enabled' flag.",
-				"Selected RunMode", runMode.String(),
-				"Enabled Clusters", count)
+			return configError("Cannot have less than two cluster(s) enabled in the config file for selected run mode. Either select the correct runMode or enable more clusters via 'is.enabled' flag. Selected RunMode: %s, Enabled Clusters: %d",
+				runMode.String(), count)
 		}
 	}
+	return nil
 }
@@ -17,6 +17,7 @@ var _ = func() bool {
 
 type StackSuite struct {
 	suite.Suite
+	st *State
 }
 
 func TestStackSuite(t *testing.T) {
@@ -24,7 +25,25 @@ func TestStackSuite(t *testing.T) {
 }
 
 func (s *StackSuite) SetupTest() {
-	Init()
+	st, err := Load(Options{})
+	s.Require().NoError(err)
+	s.st = st
 	os.Setenv("SHEPHERD_BLUEPRINTS_FILE_LOCATION", "./testdata/blueprints_0.yaml")
-	SpdCore.Blueprints.ParseShepherBlueprints(getEnvVarsWithDefaults("SHEPHERD_BLUEPRINTS_FILE_LOCATION", ""))
+	s.NoError(s.st.Core.Blueprints.ParseShepherBlueprints(os.Getenv("SHEPHERD_BLUEPRINTS_FILE_LOCATION")))
+}
+
+func (s *StackSuite) TestStackSuite_Init_IndependentStates() {
+	defer os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"))
+	os.Setenv("SHEPHERD_BLUEPRINTS_FILE_LOCATION", "./../configs/blueprints.yaml")
+	first, err := Load(Options{})
+	s.Require().NoError(err)
+	firstTopics := first.GetTopicList(false).Clone()
+
+	os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", "./testdata/definitions_0.yaml")
+	os.Setenv("HOSTNAME_REPLACEMENT_TEST", "abhishek.replaced.hostname")
+	second, err := Load(Options{})
+	s.Require().NoError(err)
+
+	s.False(firstTopics.Equal(second.GetTopicList(false)), "Both the configuration sets resolved to the same topics.")
+	s.True(firstTopics.Equal(first.GetTopicList(false)), "Loading a configuration set changed the topics of another one.")
 }
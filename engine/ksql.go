@@ -0,0 +1,464 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	KSQLStatementKind_STREAM string = "STREAM"
+	KSQLStatementKind_TABLE  string = "TABLE"
+	KSQLStatementKind_INSERT string = "INSERT"
+)
+
+const ksqlIdentifier string = "`[^`]+`|[A-Za-z_][A-Za-z0-9_]*"
+
+var (
+	ksqlCreatePattern  = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(SOURCE\s+)?(STREAM|TABLE)\s+(?:IF\s+NOT\s+EXISTS\s+)?(` + ksqlIdentifier + `)`)
+	ksqlInsertPattern  = regexp.MustCompile(`(?is)^INSERT\s+INTO\s+(` + ksqlIdentifier + `)`)
+	ksqlQueryPattern   = regexp.MustCompile(`(?is)\bAS\s+SELECT\b`)
+	ksqlSelectPattern  = regexp.MustCompile(`(?is)\bSELECT\b`)
+	ksqlSourcePattern  = regexp.MustCompile(`(?is)\b(?:FROM|JOIN)\s+(` + ksqlIdentifier + `)`)
+	ksqlReplacePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(STREAM|TABLE)\s+(?:IF\s+NOT\s+EXISTS\s+)?`)
+)
+
+/*
+	A statement of the .sql files of the ksql definitions. Only the statements creating a stream or a table
+	and the INSERT INTO statements can be declared, the other ones are managed outside of Shepherd.
+*/
+type KSQLStatement struct {
+	File string
+	// STREAM, TABLE or INSERT.
+	Kind string
+	// The stream or the table created by the statement, or the one the INSERT INTO statement writes to.
+	Name      string
+	Statement string
+	// Set for the statements starting a persistent query (CREATE ... AS SELECT and INSERT INTO).
+	Query bool
+	// The streams and the tables the statement reads from.
+	Sources []string
+}
+
+/*
+	KSQLConfigMapping holds the statements declared for every ksqlDB cluster, keyed by the ksqlDB cluster
+	name (its ksql.service.id). The statements of a cluster are kept in the order they are applied in.
+*/
+type KSQLConfigMapping map[string][]KSQLStatement
+
+// The name of the stream or the table, the quoted names are case sensitive.
+func ksqlName(in string) string {
+	if strings.HasPrefix(in, "`") {
+		return strings.Trim(in, "`")
+	}
+	return strings.ToUpper(in)
+}
+
+/*
+	Splits the contents of a .sql file into statements. The comments are dropped, and the semicolons in the
+	string literals and the quoted names do not end a statement.
+*/
+func splitKSQLStatements(in string) []string {
+	ret := []string{}
+	var sb strings.Builder
+	var quote rune
+	runes := []rune(in)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			ch = '\n'
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/'); i++ {
+			}
+			i++
+			ch = ' '
+		case ch == ';':
+			if s := strings.TrimSpace(sb.String()); s != "" {
+				ret = append(ret, s+";")
+			}
+			sb.Reset()
+			continue
+		}
+		sb.WriteRune(ch)
+	}
+	if s := strings.TrimSpace(sb.String()); s != "" {
+		ret = append(ret, s+";")
+	}
+	return ret
+}
+
+func parseKSQLStatement(file string, in string) (KSQLStatement, error) {
+	ret := KSQLStatement{File: file, Statement: in, Sources: []string{}}
+	if m := ksqlCreatePattern.FindStringSubmatch(in); m != nil {
+		ret.Kind, ret.Name = strings.ToUpper(m[2]), ksqlName(m[3])
+		ret.Query = ksqlQueryPattern.MatchString(in)
+		if ret.Query && m[1] != "" {
+			return ret, configError("Source streams and tables cannot be created with a query. KSQL File: %s, Name: %s", file, ret.Name)
+		}
+	} else if m := ksqlInsertPattern.FindStringSubmatch(in); m != nil {
+		ret.Kind, ret.Name, ret.Query = KSQLStatementKind_INSERT, ksqlName(m[1]), true
+	} else {
+		return ret, configError("Only the CREATE STREAM, CREATE TABLE and INSERT INTO statements can be declared. KSQL File: %s, Statement: %s",
+			file, collapseKSQL(in))
+	}
+	for _, m := range ksqlSourcePattern.FindAllStringSubmatch(in, -1) {
+		ret.Sources = append(ret.Sources, ksqlName(m[1]))
+	}
+	return ret, nil
+}
+
+// The statements of the .sql file in the order they are written in.
+func parseKSQLFile(file string) ([]KSQLStatement, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot read the KSQL file. KSQL File: %s", file), err)
+	}
+	ret := []KSQLStatement{}
+	for _, v := range splitKSQLStatements(string(b)) {
+		s, err := parseKSQLStatement(file, v)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, s)
+	}
+	return ret, nil
+}
+
+// The statement on a single line, used in the plans and the logs.
+func collapseKSQL(in string) string {
+	return strings.Join(strings.Fields(in), " ")
+}
+
+/*
+	Normalizes a statement for comparisons. The whitespaces are collapsed and dropped around the punctuation,
+	the trailing semicolon is dropped, and everything outside of the string literals and the quoted names is
+	upper cased.
+*/
+func normalizeKSQL(in string) string {
+	var sb strings.Builder
+	var quote, last rune
+	space := false
+	isPunct := func(ch rune) bool {
+		return strings.ContainsRune("(),=;", ch)
+	}
+	for _, ch := range strings.TrimSuffix(strings.TrimSpace(in), ";") {
+		if quote == 0 && unicode.IsSpace(ch) {
+			space = true
+			continue
+		}
+		if space && last != 0 && !isPunct(last) && !isPunct(ch) {
+			sb.WriteRune(' ')
+		}
+		space = false
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '`':
+			quote = ch
+		default:
+			ch = unicode.ToUpper(ch)
+		}
+		sb.WriteRune(ch)
+		last = ch
+	}
+	return sb.String()
+}
+
+/*
+	The query of the statement, from the SELECT on. ksqlDB adds the properties of the created topics to the
+	WITH clause of the statements it runs, so only the queries are compared to the declared statements.
+*/
+func ksqlQueryText(in string) string {
+	if loc := ksqlSelectPattern.FindStringIndex(in); loc != nil {
+		return normalizeKSQL(in[loc[0]:])
+	}
+	return normalizeKSQL(in)
+}
+
+/*
+	The key identifying the statement among the statements of its ksqlDB cluster. A stream or a table can
+	be written to by many INSERT INTO statements, so those are identified by their queries as well.
+*/
+func (s KSQLStatement) Key() string {
+	if s.Kind != KSQLStatementKind_INSERT {
+		return s.Name
+	}
+	sum := sha256.Sum256([]byte(ksqlQueryText(s.Statement)))
+	return fmt.Sprintf("INSERT INTO %s #%s", s.Name, hex.EncodeToString(sum[:4]))
+}
+
+// The name identifying the statement in a Plan.
+func (s KSQLStatement) PlanName(ksqlCluster string) string {
+	return fmt.Sprintf("%s/%s", ksqlCluster, s.Key())
+}
+
+// The statement replacing the stream or the table of the statement, along with its query.
+func (s KSQLStatement) ReplaceStatement() string {
+	return ksqlReplacePattern.ReplaceAllString(s.Statement, "CREATE OR REPLACE $1 ")
+}
+
+/*
+	Adds the statements of the .sql files of the ksql definitions. The files are read once, files holds the
+	statements read so far. A statement can be declared more than once, as long as it is the same every time.
+*/
+func (c KSQLConfigMapping) addKSQLDefinitions(in []KSQLDefinition, files map[string][]KSQLStatement) error {
+	for _, v := range in {
+		for _, file := range v.Files {
+			statements, found := files[file]
+			if !found {
+				var err error
+				if statements, err = parseKSQLFile(file); err != nil {
+					return err
+				}
+				files[file] = statements
+			}
+			for _, s := range statements {
+				if err := c.addStatement(v.ClusterNameRef, s); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c KSQLConfigMapping) addStatement(ksqlCluster string, s KSQLStatement) error {
+	for _, existing := range c[ksqlCluster] {
+		if existing.Key() != s.Key() {
+			continue
+		}
+		if normalizeKSQL(existing.Statement) != normalizeKSQL(s.Statement) {
+			return configError("KSQL statement is declared more than once with different queries. KSQL Cluster: %s, Name: %s, KSQL Files: %s, %s",
+				ksqlCluster, s.Name, existing.File, s.File)
+		}
+		return nil
+	}
+	c[ksqlCluster] = append(c[ksqlCluster], s)
+	return nil
+}
+
+/*
+	Orders the statements of every ksqlDB cluster so that the streams and the tables are created before the
+	statements reading from them or inserting into them. The statements that do not depend on each other stay
+	in the order they are declared in. Statements depending on each other in a cycle are refused.
+*/
+func (c KSQLConfigMapping) orderStatements() error {
+	for ksqlCluster, statements := range c {
+		declared := make(map[string]bool)
+		for _, s := range statements {
+			if s.Kind != KSQLStatementKind_INSERT {
+				declared[s.Name] = true
+			}
+		}
+		ready := func(s KSQLStatement, created map[string]bool) bool {
+			deps := s.Sources
+			if s.Kind == KSQLStatementKind_INSERT {
+				deps = append([]string{s.Name}, deps...)
+			}
+			for _, d := range deps {
+				if declared[d] && !created[d] && !(d == s.Name && s.Kind != KSQLStatementKind_INSERT) {
+					return false
+				}
+			}
+			return true
+		}
+		created := make(map[string]bool)
+		done := make([]bool, len(statements))
+		ordered := make([]KSQLStatement, 0, len(statements))
+		for len(ordered) < len(statements) {
+			next := -1
+			for i, s := range statements {
+				if !done[i] && ready(s, created) {
+					next = i
+					break
+				}
+			}
+			if next == -1 {
+				pending := []string{}
+				for i, s := range statements {
+					if !done[i] {
+						pending = append(pending, s.Key())
+					}
+				}
+				return configError("KSQL statements depend on each other in a cycle. KSQL Cluster: %s, Statements: %s", ksqlCluster, strings.Join(pending, ", "))
+			}
+			done[next] = true
+			ordered = append(ordered, statements[next])
+			if statements[next].Kind != KSQLStatementKind_INSERT {
+				created[statements[next].Name] = true
+			}
+		}
+		c[ksqlCluster] = ordered
+	}
+	return nil
+}
+
+// Statements are only applied to the ksqlDB clusters configured for a cluster, the others are left out.
+func (c KSQLConfigMapping) warnUnknownKSQLClusters(clusters []ShepherdCluster) {
+	known := make(map[string]bool)
+	for _, cluster := range clusters {
+		for _, v := range cluster.KSQLClusters {
+			known[v.Name] = true
+		}
+	}
+	for ksqlCluster := range c {
+		if !known[ksqlCluster] {
+			logger.Warnw("ksqlDB cluster of the KSQL files is not configured for any cluster. The statements will not be applied.",
+				"KSQL Cluster", ksqlCluster)
+		}
+	}
+}
+
+// Returns the statements of the provided ksqlDB clusters.
+func (c KSQLConfigMapping) Filter(ksqlClusters []string) KSQLConfigMapping {
+	ret := KSQLConfigMapping{}
+	for _, name := range ksqlClusters {
+		if v, found := c[name]; found {
+			ret[name] = v
+		}
+	}
+	return ret
+}
+
+// A persistent query running in a ksqlDB cluster, as listed by SHOW QUERIES.
+type KSQLQuery struct {
+	ID        string
+	Sinks     []string
+	Statement string
+}
+
+// The streams, the tables and the persistent queries of a ksqlDB cluster.
+type KSQLState struct {
+	Objects map[string]bool
+	Queries []KSQLQuery
+}
+
+// KSQLStateMapping holds the state of the ksqlDB clusters of a cluster, keyed by the ksqlDB cluster name.
+type KSQLStateMapping map[string]KSQLState
+
+// Returns the running query of the statement, if any.
+func (c KSQLState) findQuery(s KSQLStatement) (KSQLQuery, bool) {
+	insert := s.Kind == KSQLStatementKind_INSERT
+	for _, q := range c.Queries {
+		if strings.HasPrefix(normalizeKSQL(q.Statement), "INSERT") != insert {
+			continue
+		}
+		for _, sink := range q.Sinks {
+			if sink == s.Name && (!insert || ksqlQueryText(q.Statement) == ksqlQueryText(s.Statement)) {
+				return q, true
+			}
+		}
+	}
+	return KSQLQuery{}, false
+}
+
+/*
+	Compares the streams, the tables and the queries running in the ksqlDB clusters to the declared statements
+	and returns the changes needed to align them. A missing stream or table is created along with its query.
+	A query that drifted from its statement is replaced, the streams and the tables without a query are only
+	checked for their existence. The INSERT INTO statements are identified by their queries, so a changed
+	INSERT INTO statement is created next to the previous one, which is terminated with the other running
+	queries that are not declared only if executeDeleteFlow is set.
+*/
+func PlanKSQLChanges(clusterName string, expected KSQLConfigMapping, provisioned KSQLStateMapping,
+	executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) []PlanChange {
+	ret := []PlanChange{}
+	for ksqlCluster, state := range provisioned {
+		matched := make(map[string]bool)
+		for _, s := range expected[ksqlCluster] {
+			q, running := state.findQuery(s)
+			if running {
+				matched[q.ID] = true
+			}
+			after := NVPairs{"statement": collapseKSQL(s.Statement), "file": s.File}
+			switch {
+			case !running && !state.Objects[s.Name] || !running && s.Kind == KSQLStatementKind_INSERT:
+				if executeCreateFlow {
+					ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_CREATE, ResourceType: PlanResourceType_KSQL,
+						Name: s.PlanName(ksqlCluster), After: after})
+				}
+			case !s.Query || !executeModifyFlow:
+			case !running || ksqlQueryText(q.Statement) != ksqlQueryText(s.Statement):
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_UPDATE, ResourceType: PlanResourceType_KSQL,
+					Name: s.PlanName(ksqlCluster), Before: NVPairs{"statement": collapseKSQL(q.Statement)}, After: after})
+			}
+		}
+		if !executeDeleteFlow {
+			continue
+		}
+		for _, q := range state.Queries {
+			if !matched[q.ID] {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_DELETE, ResourceType: PlanResourceType_KSQL,
+					Name: fmt.Sprintf("%s/%s", ksqlCluster, q.ID), Before: NVPairs{"statement": collapseKSQL(q.Statement)}})
+			}
+		}
+	}
+	return ret
+}
+
+// Fingerprint of the streams, the tables and the queries observed in the ksqlDB clusters of a cluster.
+func KSQLStateFingerprint(in KSQLStateMapping) string {
+	type ksqlCluster struct {
+		Name    string      `json:"name"`
+		Objects []string    `json:"objects"`
+		Queries []KSQLQuery `json:"queries"`
+	}
+	state := []ksqlCluster{}
+	for name, v := range in {
+		c := ksqlCluster{Name: name, Objects: []string{}, Queries: append([]KSQLQuery{}, v.Queries...)}
+		for o := range v.Objects {
+			c.Objects = append(c.Objects, o)
+		}
+		sort.Strings(c.Objects)
+		sort.Slice(c.Queries, func(i, j int) bool {
+			return c.Queries[i].ID < c.Queries[j].ID
+		})
+		state = append(state, c)
+	}
+	sort.Slice(state, func(i, j int) bool {
+		return state[i].Name < state[j].Name
+	})
+	b, _ := json.Marshal(state)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Relative KSQL files are relative to the directory of the definitions file.
+func (c *DefinitionRoot) resolveKSQLFiles(baseDir string) {
+	resolve := func(in []KSQLDefinition) {
+		for i := range in {
+			for j, f := range in[i].Files {
+				if !filepath.IsAbs(f) {
+					in[i].Files[j] = filepath.Join(baseDir, f)
+				}
+			}
+		}
+	}
+	for i := range c.AdhocConfigs.Topics {
+		resolve(c.AdhocConfigs.Topics[i].Clients.KSQL)
+	}
+	for i := range c.ScopeFlow {
+		for s := &c.ScopeFlow[i]; s != nil; s = s.Child {
+			resolve(s.Clients.KSQL)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+func (s *StackSuite) TestStackSuite_KSQL_Definitions() {
+	_, err := s.st.Core.Definitions.ParseShepherDefinitions("./testdata/ksql/definitions_1.yaml", true)
+	s.Require().NoError(err)
+	s.st.Maps.KSQL = KSQLConfigMapping{}
+	s.Require().NoError(s.st.GenerateMappings())
+
+	statements := s.st.Maps.KSQL["ksql-1"]
+	keys := []string{}
+	for _, v := range statements {
+		keys = append(keys, v.Key())
+	}
+	s.Equal([]string{"PAGEVIEWS", "PAGEVIEWS_HOME", "PAGE_COUNTS", statements[3].Key()}, keys,
+		"Statements should be ordered after the streams and the tables they depend on")
+	s.Regexp(`^INSERT INTO PAGEVIEWS_HOME #[0-9a-f]{8}$`, statements[3].Key())
+	s.Contains(statements[3].Statement, "'index;html'", "Semicolons in literals should not split the statements")
+	s.Equal(filepath.Join("testdata", "ksql", "streams.sql"), statements[0].File, "KSQL files should be relative to the definitions file")
+	s.False(statements[0].Query)
+	s.True(statements[2].Query)
+	s.Equal("TABLE", statements[2].Kind)
+	s.Equal("CREATE OR REPLACE TABLE page_counts AS\n  SELECT page, COUNT(*) AS views FROM pageviews_home GROUP BY page EMIT CHANGES;",
+		statements[2].ReplaceStatement())
+
+	r := &envResolver{}
+	(&KSQLDefinition{Principal: "User:1", Type: "read", ClusterNameRef: "ksql-1", Files: []string{"a.ksql"}}).readValuesFromENV(r)
+	s.True(errors.Is(r.err, ErrConfigInvalid), "Only .sql files should be accepted")
+	_, err = parseKSQLStatement("a.sql", "DROP STREAM pageviews;")
+	s.True(errors.Is(err, ErrConfigInvalid), "Only the create and insert statements should be accepted")
+
+	conflict := KSQLConfigMapping{}
+	s.NoError(conflict.addStatement("ksql-1", statements[1]))
+	changed := statements[1]
+	changed.Statement = "CREATE STREAM pageviews_home AS SELECT * FROM pageviews EMIT CHANGES;"
+	s.True(errors.Is(conflict.addStatement("ksql-1", changed), ErrConfigInvalid), "Conflicting statements should fail")
+
+	cycle := KSQLConfigMapping{"ksql-1": {}}
+	for _, v := range []string{"CREATE STREAM a AS SELECT * FROM b;", "CREATE STREAM b AS SELECT * FROM a;"} {
+		st, err := parseKSQLStatement("a.sql", v)
+		s.NoError(err)
+		cycle["ksql-1"] = append(cycle["ksql-1"], st)
+	}
+	s.True(errors.Is(cycle.orderStatements(), ErrConfigInvalid), "Statements depending on each other should fail")
+}
+
+func (s *StackSuite) TestStackSuite_KSQL_PlanChanges() {
+	parse := func(in string) KSQLStatement {
+		st, err := parseKSQLStatement("q.sql", in)
+		s.Require().NoError(err)
+		return st
+	}
+	expected := KSQLConfigMapping{"ksql-1": {
+		parse("CREATE STREAM src (id VARCHAR) WITH (KAFKA_TOPIC='t', VALUE_FORMAT='JSON');"),
+		parse("CREATE STREAM new AS SELECT * FROM src EMIT CHANGES;"),
+		parse("CREATE STREAM drifted AS SELECT id FROM src WHERE id = 'b' EMIT CHANGES;"),
+		parse("CREATE STREAM same AS SELECT *\n  FROM src EMIT CHANGES;"),
+		parse("INSERT INTO same SELECT * FROM src WHERE id = 'x' EMIT CHANGES;"),
+	}}
+	provisioned := KSQLStateMapping{"ksql-1": {
+		Objects: map[string]bool{"SRC": true, "DRIFTED": true, "SAME": true},
+		Queries: []KSQLQuery{
+			{ID: "CSAS_DRIFTED_1", Sinks: []string{"DRIFTED"}, Statement: "CREATE STREAM DRIFTED WITH (KAFKA_TOPIC='DRIFTED') AS SELECT ID FROM SRC WHERE ID = 'a' EMIT CHANGES;"},
+			{ID: "CSAS_SAME_2", Sinks: []string{"SAME"}, Statement: "CREATE STREAM SAME WITH (KAFKA_TOPIC='SAME', PARTITIONS=1) AS SELECT * FROM SRC EMIT CHANGES;"},
+			{ID: "INSERTQUERY_3", Sinks: []string{"SAME"}, Statement: "INSERT INTO SAME SELECT * FROM SRC WHERE ID = 'y' EMIT CHANGES;"},
+		},
+	}}
+
+	changes := map[string]PlanChange{}
+	for _, v := range PlanKSQLChanges("c1", expected, provisioned, true, true, true) {
+		s.Equal(PlanResourceType_KSQL, v.ResourceType)
+		changes[v.Name] = v
+	}
+	insertName := expected["ksql-1"][4].PlanName("ksql-1")
+	s.Len(changes, 4)
+	s.Equal(PlanAction_CREATE, changes["ksql-1/NEW"].Action)
+	s.Equal(NVPairs{"statement": "CREATE STREAM new AS SELECT * FROM src EMIT CHANGES;", "file": "q.sql"}, changes["ksql-1/NEW"].After)
+	s.Equal(PlanAction_UPDATE, changes["ksql-1/DRIFTED"].Action, "Queries that drifted from their statements should be replaced")
+	s.Equal(PlanAction_CREATE, changes[insertName].Action, "Changed INSERT INTO statements should be created next to the running ones")
+	s.Equal(PlanAction_DELETE, changes["ksql-1/INSERTQUERY_3"].Action)
+
+	s.Len(PlanKSQLChanges("c1", expected, provisioned, true, true, false), 3)
+	s.Len(PlanKSQLChanges("c1", expected, provisioned, false, true, false), 1)
+	s.Len(PlanKSQLChanges("c1", expected, KSQLStateMapping{}, true, true, true), 0, "Only the ksqlDB clusters of the cluster should be planned")
+}
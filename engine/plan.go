@@ -0,0 +1,365 @@
+package engine
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+type PlanAction int
+
+const (
+	PlanAction_UNKNOWN PlanAction = iota
+	PlanAction_CREATE
+	PlanAction_UPDATE
+	PlanAction_DELETE
+)
+
+func (in PlanAction) String() string {
+	m := map[PlanAction]string{
+		PlanAction_UNKNOWN: "PlanAction_UNKNOWN",
+		PlanAction_CREATE:  "create",
+		PlanAction_UPDATE:  "update",
+		PlanAction_DELETE:  "delete",
+	}
+	ret, present := m[in]
+	if !present {
+		ret = m[PlanAction_UNKNOWN]
+	}
+	return ret
+}
+
+func (in PlanAction) symbol() string {
+	m := map[PlanAction]string{
+		PlanAction_CREATE: "+",
+		PlanAction_UPDATE: "~",
+		PlanAction_DELETE: "-",
+	}
+	return m[in]
+}
+
+func (in PlanAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(in.String())
+}
+
+func (in *PlanAction) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for _, v := range []PlanAction{PlanAction_CREATE, PlanAction_UPDATE, PlanAction_DELETE} {
+		if v.String() == s {
+			*in = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown plan action: %s", s)
+}
+
+const (
+	PlanResourceType_TOPIC     string = "topic"
+	PlanResourceType_ACL       string = "acl"
+	PlanResourceType_CONNECTOR string = "connector"
+	PlanResourceType_SUBJECT   string = "subject"
+	PlanResourceType_KSQL      string = "ksql"
+	PlanResourceType_QUOTA     string = "quota"
+	PlanResourceType_SCRAM     string = "scram"
+)
+
+/*
+	A single change that the execution will make to a cluster. Before holds the values currently in the
+	cluster and After holds the values from the configurations, so a create only has After values and a
+	delete only has Before values. For an update only the values being changed are listed.
+*/
+type PlanChange struct {
+	Cluster      string     `json:"cluster"`
+	Action       PlanAction `json:"action"`
+	ResourceType string     `json:"resourceType"`
+	Name         string     `json:"name"`
+	Before       NVPairs    `json:"before,omitempty"`
+	After        NVPairs    `json:"after,omitempty"`
+}
+
+type PlanSummary struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// Version of the saved plan file format. Plan files with a different version are not applied.
+const planFormatVersion int = 2
+
+const fingerprintSaltLength int = 32
+
+/*
+	The Plan lists every change the execution would make across all the clusters, without making them.
+	Changes can be appended concurrently as the clusters may be planned in parallel. The fingerprints
+	identify the configuration files and the cluster state the plan was computed from, so that a saved
+	plan is only applied as long as neither of them has changed. The secrets of the configurations are
+	fingerprinted with the random salt of the plan (see State.ConfigFingerprint).
+*/
+type Plan struct {
+	lock                sync.Mutex
+	Version             int               `json:"version"`
+	FingerprintSalt     []byte            `json:"fingerprintSalt,omitempty"`
+	ConfigFingerprint   string            `json:"configFingerprint,omitempty"`
+	ClusterFingerprints map[string]string `json:"clusterFingerprints,omitempty"`
+	Changes             []PlanChange      `json:"changes"`
+}
+
+func NewPlan() *Plan {
+	salt := make([]byte, fingerprintSaltLength)
+	// Without a salt, the configurations with secrets cannot be fingerprinted (and the plan cannot be applied).
+	if _, err := rand.Read(salt); err != nil {
+		salt = nil
+	}
+	return &Plan{Version: planFormatVersion, FingerprintSalt: salt, ClusterFingerprints: make(map[string]string), Changes: []PlanChange{}}
+}
+
+// Reads a plan saved with WriteJSON.
+func ReadPlan(in io.Reader) (*Plan, error) {
+	p := NewPlan()
+	if err := json.NewDecoder(in).Decode(p); err != nil {
+		return nil, fmt.Errorf("cannot parse the plan: %w", err)
+	}
+	if p.Version != planFormatVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", p.Version, planFormatVersion)
+	}
+	return p, nil
+}
+
+func (p *Plan) Append(in ...PlanChange) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.Changes = append(p.Changes, in...)
+}
+
+func (p *Plan) SetClusterFingerprint(clusterName string, fingerprint string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.ClusterFingerprints[clusterName] = fingerprint
+}
+
+// Returns the planned changes for the cluster.
+func (p *Plan) ClusterChanges(clusterName string) []PlanChange {
+	ret := []PlanChange{}
+	for _, v := range p.Changes {
+		if v.Cluster == clusterName {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func (p *Plan) Summary() PlanSummary {
+	s := PlanSummary{}
+	for _, v := range p.Changes {
+		switch v.Action {
+		case PlanAction_CREATE:
+			s.Add += 1
+		case PlanAction_UPDATE:
+			s.Change += 1
+		case PlanAction_DELETE:
+			s.Destroy += 1
+		}
+	}
+	return s
+}
+
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) != 0
+}
+
+// Orders the changes by cluster, resource type, name and action so that the output is stable.
+func (p *Plan) Sort() {
+	sort.SliceStable(p.Changes, func(i, j int) bool {
+		a, b := p.Changes[i], p.Changes[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType > b.ResourceType
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Action < b.Action
+	})
+}
+
+func (p *Plan) WriteJSON(out io.Writer) error {
+	p.Sort()
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Version             int               `json:"version"`
+		FingerprintSalt     []byte            `json:"fingerprintSalt,omitempty"`
+		ConfigFingerprint   string            `json:"configFingerprint,omitempty"`
+		ClusterFingerprints map[string]string `json:"clusterFingerprints,omitempty"`
+		Changes             []PlanChange      `json:"changes"`
+		Summary             PlanSummary       `json:"summary"`
+	}{p.Version, p.FingerprintSalt, p.ConfigFingerprint, p.ClusterFingerprints, p.Changes, p.Summary()})
+}
+
+func (p *Plan) WriteTable(out io.Writer) error {
+	p.Sort()
+	w := tabwriter.NewWriter(out, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tCLUSTER\tTYPE\tNAME\tDETAILS")
+	for _, v := range p.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Action.symbol(), v.Cluster, v.ResourceType, v.Name, v.details())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	s := p.Summary()
+	_, err := fmt.Fprintf(out, "\nPlan: %d to add, %d to change, %d to destroy.\n", s.Add, s.Change, s.Destroy)
+	return err
+}
+
+func (c PlanChange) details() string {
+	// The name of an ACL already describes all of it.
+	if c.ResourceType == PlanResourceType_ACL {
+		return ""
+	}
+	keys := []string{}
+	for k := range c.Before {
+		keys = append(keys, k)
+	}
+	for k := range c.After {
+		if _, found := c.Before[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		switch c.Action {
+		case PlanAction_CREATE:
+			out = append(out, fmt.Sprintf("%s=%s", k, c.After[k]))
+		case PlanAction_DELETE:
+			out = append(out, fmt.Sprintf("%s=%s", k, c.Before[k]))
+		default:
+			out = append(out, fmt.Sprintf("%s: %s -> %s", k, planValue(c.Before, k), planValue(c.After, k)))
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+func planValue(in NVPairs, key string) string {
+	if v, found := in[key]; found {
+		return v
+	}
+	return "(default)"
+}
+
+/*
+	Creates the PlanChange for an ACL. The ACL is described as a whole by its name, so the ACL details are
+	only listed as the After (create) or Before (delete) values.
+*/
+func NewACLPlanChange(clusterName string, action PlanAction, k ACLDetails) PlanChange {
+	details := NVPairs{
+		"resourceType": k.ResourceType.GetACLResourceString(),
+		"resourceName": k.ResourceName,
+		"patternType":  k.PatternType.GetACLPatternString(),
+		"principal":    k.Principal,
+		"operation":    k.Operation.String(),
+		"host":         k.Hostname,
+	}
+	if k.IsDeny() {
+		details["permissionType"] = k.PermissionType.String()
+	}
+	c := PlanChange{
+		Cluster:      clusterName,
+		Action:       action,
+		ResourceType: PlanResourceType_ACL,
+		Name:         k.PlanName(),
+	}
+	if action == PlanAction_DELETE {
+		c.Before = details
+	} else {
+		c.After = details
+	}
+	return c
+}
+
+// The name identifying the ACL in a Plan. DENY ACLs are marked, as they share the rest of the name with the ALLOW ones.
+func (k ACLDetails) PlanName() string {
+	if k.IsDeny() {
+		return fmt.Sprintf("%s DENY %s %s:%s:%s @%s", k.Principal, k.Operation.String(), k.ResourceType.GetACLResourceString(),
+			k.PatternType.GetACLPatternString(), k.ResourceName, k.Hostname)
+	}
+	return fmt.Sprintf("%s %s %s:%s:%s @%s", k.Principal, k.Operation.String(), k.ResourceType.GetACLResourceString(),
+		k.PatternType.GetACLPatternString(), k.ResourceName, k.Hostname)
+}
+
+/*
+	Fingerprint of the Shepherd config, blueprints and definitions files currently in use, along with the schema
+	and the KSQL files and the SCRAM passwords. The passwords are only fingerprinted with the provided salt (see
+	ScramPasswordFingerprint), as the fingerprint is saved along with the plans.
+*/
+func (st *State) ConfigFingerprint(salt []byte) (string, error) {
+	h := sha256.New()
+	for _, path := range st.configPaths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		// Only the contents are fingerprinted, so the plan can be applied from a different working directory.
+		fmt.Fprintf(h, "%d\n", len(b))
+		h.Write(b)
+	}
+	subjects := []string{}
+	for subject := range st.Maps.Subjects {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		fmt.Fprintf(h, "%s\n%d\n%s", subject, len(st.Maps.Subjects[subject].Schema), st.Maps.Subjects[subject].Schema)
+	}
+	ksqlClusters := []string{}
+	for ksqlCluster := range st.Maps.KSQL {
+		ksqlClusters = append(ksqlClusters, ksqlCluster)
+	}
+	sort.Strings(ksqlClusters)
+	for _, ksqlCluster := range ksqlClusters {
+		for _, s := range st.Maps.KSQL[ksqlCluster] {
+			fmt.Fprintf(h, "%s\n%d\n%s", ksqlCluster, len(s.Statement), s.Statement)
+		}
+	}
+	// The SCRAM passwords can come from ENV Variables or a secrets file, so they are part of the fingerprint as well.
+	users := []string{}
+	for user := range st.Maps.ScramCredentials {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	if len(users) != 0 && len(salt) == 0 {
+		return "", fmt.Errorf("cannot fingerprint the SCRAM passwords without a salt")
+	}
+	for _, user := range users {
+		fmt.Fprintf(h, "%s\n%s\n", user, ScramPasswordFingerprint(salt, st.Maps.ScramCredentials[user].Password))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Fingerprint of the topics (with their configurations) and the ACLs observed in a cluster.
+func ClusterStateFingerprint(topics *TopicConfigMapping, acls *ACLMapping) string {
+	state := ExportedConfig{Topics: []ExportedTopic{}, ACLs: acls.Export()}
+	for tName, configs := range *topics {
+		state.Topics = append(state.Topics, ExportedTopic{Name: tName, Configs: configs})
+	}
+	sort.Slice(state.Topics, func(i, j int) bool {
+		return state.Topics[i].Name < state.Topics[j].Name
+	})
+	// Maps are marshalled with sorted keys, so the output is stable.
+	b, _ := json.Marshal(state)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+func (s *StackSuite) TestStackSuite_Plan_Output() {
+	p := NewPlan()
+	p.Append(
+		PlanChange{Cluster: "c1", Action: PlanAction_UPDATE, ResourceType: PlanResourceType_TOPIC, Name: "test.2",
+			Before: NVPairs{"retention.ms": "1000", "segment.ms": "10"}, After: NVPairs{"retention.ms": "2000"}},
+		PlanChange{Cluster: "c1", Action: PlanAction_CREATE, ResourceType: PlanResourceType_TOPIC, Name: "test.1",
+			After: NVPairs{"num.partitions": "3"}},
+		NewACLPlanChange("c1", PlanAction_DELETE, constructACLDetailsObject(KafkaResourceType_TOPIC, "test.3",
+			KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_READ, "*")),
+		NewACLPlanChange("c0", PlanAction_CREATE, constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1",
+			KafkaACLPatternType_LITERAL, "User:1", KafkaACLOperation_WRITE, "*")),
+	)
+	s.Equal(PlanSummary{Add: 2, Change: 1, Destroy: 1}, p.Summary(), "Plan counts do not match")
+
+	table := new(bytes.Buffer)
+	s.NoError(p.WriteTable(table))
+	s.Contains(table.String(), "retention.ms: 1000 -> 2000, segment.ms: 10 -> (default)", "Update details missing")
+	s.Contains(table.String(), "num.partitions=3", "Create details missing")
+	s.Contains(table.String(), "Plan: 2 to add, 1 to change, 1 to destroy.", "Summary line missing")
+
+	out := new(bytes.Buffer)
+	s.NoError(p.WriteJSON(out))
+	parsed := struct {
+		Changes []struct {
+			Cluster string  `json:"cluster"`
+			Action  string  `json:"action"`
+			Name    string  `json:"name"`
+			Before  NVPairs `json:"before"`
+		} `json:"changes"`
+		Summary PlanSummary `json:"summary"`
+	}{}
+	s.NoError(json.Unmarshal(out.Bytes(), &parsed))
+	s.Equal(PlanSummary{Add: 2, Change: 1, Destroy: 1}, parsed.Summary, "JSON summary does not match")
+	s.Len(parsed.Changes, 4)
+	// Sorted by cluster, then topics before ACLs.
+	s.Equal("c0", parsed.Changes[0].Cluster)
+	s.Equal([]string{"create", "update"}, []string{parsed.Changes[1].Action, parsed.Changes[2].Action})
+	s.Equal("delete", parsed.Changes[3].Action)
+	s.Equal("READ", parsed.Changes[3].Before["operation"])
+}
+
+func (s *StackSuite) TestStackSuite_Plan_SavedPlanRoundTrip() {
+	p := NewPlan()
+	p.ConfigFingerprint = "abc"
+	p.SetClusterFingerprint("c1", "def")
+	p.Append(
+		PlanChange{Cluster: "c1", Action: PlanAction_UPDATE, ResourceType: PlanResourceType_TOPIC, Name: "test.1",
+			Before: NVPairs{"retention.ms": "1000"}, After: NVPairs{"retention.ms": "2000"}},
+		NewACLPlanChange("c1", PlanAction_DELETE, constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1",
+			KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_WRITE, "*")),
+	)
+	buf := new(bytes.Buffer)
+	s.NoError(p.WriteJSON(buf))
+
+	read, err := ReadPlan(buf)
+	s.NoError(err)
+	s.Equal(planFormatVersion, read.Version)
+	s.Equal("abc", read.ConfigFingerprint)
+	s.Len(read.FingerprintSalt, fingerprintSaltLength)
+	s.Equal(p.FingerprintSalt, read.FingerprintSalt)
+	s.EqualValues(map[string]string{"c1": "def"}, read.ClusterFingerprints)
+	s.EqualValues(p.Changes, read.Changes, "Changes should survive the round trip")
+	s.Len(read.ClusterChanges("c1"), 2)
+	s.Len(read.ClusterChanges("c2"), 0)
+
+	_, err = ReadPlan(strings.NewReader(`{"version": 99, "changes": []}`))
+	s.Error(err, "Plans with a different version should be rejected")
+	_, err = ReadPlan(strings.NewReader(`{"version": 2, "changes": [{"action": "rename"}]}`))
+	s.Error(err, "Unknown actions should be rejected")
+}
+
+func (s *StackSuite) TestStackSuite_Plan_ClusterStateFingerprint() {
+	acl := constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:p1", KafkaACLOperation_WRITE, "*")
+	topics := TopicConfigMapping{"a": NVPairs{"retention.ms": "1000"}, "b": NVPairs{"num.partitions": "3"}}
+	acls := ACLMapping{acl: nil}
+	fp := ClusterStateFingerprint(&topics, &acls)
+	for i := 0; i < 5; i++ {
+		s.Equal(fp, ClusterStateFingerprint(&topics, &acls), "Fingerprint should be stable")
+	}
+
+	changed := TopicConfigMapping{"a": NVPairs{"retention.ms": "2000"}, "b": NVPairs{"num.partitions": "3"}}
+	s.NotEqual(fp, ClusterStateFingerprint(&changed, &acls), "Fingerprint should change with the topic configs")
+	s.NotEqual(fp, ClusterStateFingerprint(&topics, &ACLMapping{}), "Fingerprint should change with the ACLs")
+}
+
+func (s *StackSuite) TestStackSuite_Plan_ConfigFingerprint() {
+	st := &State{}
+	st.Maps.ScramCredentials = ScramCredentialMapping{"alice": {Password: "alice-secret"}}
+	salt, otherSalt := []byte("salt"), []byte("other")
+
+	fp, err := st.ConfigFingerprint(salt)
+	s.NoError(err)
+	again, err := st.ConfigFingerprint(salt)
+	s.NoError(err)
+	s.Equal(fp, again, "Fingerprint should be stable for the same salt")
+	other, err := st.ConfigFingerprint(otherSalt)
+	s.NoError(err)
+	s.NotEqual(fp, other, "Passwords should only be fingerprinted with the salt of the plan")
+
+	st.Maps.ScramCredentials["alice"] = ScramCredential{Password: "rotated"}
+	rotated, err := st.ConfigFingerprint(salt)
+	s.NoError(err)
+	s.NotEqual(fp, rotated, "Fingerprint should change with the passwords")
+
+	_, err = st.ConfigFingerprint(nil)
+	s.Error(err, "Passwords should not be fingerprinted without a salt")
+	st.Maps.ScramCredentials = ScramCredentialMapping{}
+	_, err = st.ConfigFingerprint(nil)
+	s.NoError(err)
+}
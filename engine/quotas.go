@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var quotaKeys = []string{"producer_byte_rate", "consumer_byte_rate", "request_percentage"}
+
+// The quotas need to be one of the client quotas of Kafka, with a value that is not negative.
+func validateQuotas(in NVPairs) error {
+	for k, v := range in {
+		if !isOneOf(k, quotaKeys) {
+			return configError("Quotas need to be one of %s. Quota provided: %q", strings.Join(quotaKeys, ", "), k)
+		}
+		if f, err := strconv.ParseFloat(v, 64); err != nil || f < 0 {
+			return configError("Quota values need to be numbers that are not negative. Quota: %s, Value provided: %q", k, v)
+		}
+	}
+	return nil
+}
+
+// The quota value in the format used for the comparisons with the values of the clusters.
+func formatQuotaValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// The quotas of the quota blueprints, keyed by the blueprint name.
+func quotaTiers(b BlueprintRoot) map[string]NVPairs {
+	ret := make(map[string]NVPairs)
+	for _, v := range b.Quota.QuotaConfigs {
+		temp := NVPairs{}
+		for _, q := range v.Quotas {
+			for k, qv := range q {
+				f, _ := strconv.ParseFloat(qv, 64)
+				temp[k] = formatQuotaValue(f)
+			}
+		}
+		ret[strings.ToLower(strings.TrimSpace(v.Name))] = temp
+	}
+	return ret
+}
+
+// The quota entity, a user along with one of its client ids if the ClientID is set.
+type QuotaConfigMappingKey struct {
+	User     string
+	ClientID string
+}
+
+// The name identifying the quota entity in a Plan.
+func (k QuotaConfigMappingKey) PlanName() string {
+	if k.ClientID == "" {
+		return fmt.Sprintf("user=%s", k.User)
+	}
+	return fmt.Sprintf("user=%s,client-id=%s", k.User, k.ClientID)
+}
+
+/*
+	QuotaConfigMapping holds the client quotas, either the ones declared for the producers and the consumers
+	or the ones set in the cluster, keyed by the quota entity.
+*/
+type QuotaConfigMapping map[QuotaConfigMappingKey]NVPairs
+
+/*
+	Adds the quotas of the producers and the consumers that declare them. The quotas of the same entity are
+	merged, as long as every quota has the same value every time it is declared.
+*/
+func (c QuotaConfigMapping) addClientQuotas(in ClientDefinition, tiers map[string]NVPairs) error {
+	for _, v := range in.Producers {
+		if err := c.addQuotaDefinition(v.Principal, v.Quota, tiers); err != nil {
+			return err
+		}
+	}
+	for _, v := range in.Consumers {
+		if err := c.addQuotaDefinition(v.Principal, v.Quota, tiers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c QuotaConfigMapping) addQuotaDefinition(principal string, in *QuotaDefinition, tiers map[string]NVPairs) error {
+	if in == nil {
+		return nil
+	}
+	user := strings.TrimPrefix(principal, "User:")
+	if user == principal || user == "" || user == "*" {
+		return configError("Quotas can only be set for a single user principal. Principal: %s", principal)
+	}
+	quotas := NVPairs{}
+	if in.QuotaBlueprintRef != "" {
+		tier, found := tiers[strings.ToLower(strings.TrimSpace(in.QuotaBlueprintRef))]
+		if !found {
+			return configError("Quota blueprint not found. Principal: %s, Blueprint provided: %q", principal, in.QuotaBlueprintRef)
+		}
+		for k, v := range tier {
+			quotas[k] = v
+		}
+	}
+	for _, q := range in.Quotas {
+		for k, v := range q {
+			f, _ := strconv.ParseFloat(v, 64)
+			quotas[k] = formatQuotaValue(f)
+		}
+	}
+
+	key := QuotaConfigMappingKey{User: user, ClientID: in.ClientID}
+	existing, found := c[key]
+	if !found {
+		c[key] = quotas
+		return nil
+	}
+	for k, v := range quotas {
+		if ev, found := existing[k]; found && ev != v {
+			return configError("Quota is declared more than once with different values. Quota Entity: %s, Quota: %s, Values: %s, %s",
+				key.PlanName(), k, ev, v)
+		}
+		existing[k] = v
+	}
+	return nil
+}
+
+/*
+	Compares the quotas set in the cluster to the expected ones and returns the changes needed to align them.
+	A quota that is set in the cluster but not declared for the entity is removed with the update. The quotas
+	of the entities that are not declared at all are removed only if executeDeleteFlow is set. Only the quotas
+	that can be declared (quotaKeys) are compared, the other client quotas of Kafka (like
+	controller_mutation_rate) are left as they are.
+*/
+func PlanQuotaChanges(clusterName string, expected QuotaConfigMapping, provisioned QuotaConfigMapping,
+	executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) []PlanChange {
+	ret := []PlanChange{}
+	for k, v := range expected {
+		current, found := provisioned[k]
+		current = managedQuotas(current)
+		switch {
+		case !found && executeCreateFlow:
+			ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_CREATE, ResourceType: PlanResourceType_QUOTA,
+				Name: k.PlanName(), After: v})
+		case found && executeModifyFlow:
+			before, after := NVPairs{}, NVPairs{}
+			for key, value := range v {
+				if current[key] != value {
+					before[key], after[key] = current[key], value
+				}
+			}
+			for key, value := range current {
+				if _, found := v[key]; !found {
+					before[key], after[key] = value, ""
+				}
+			}
+			if len(after) != 0 {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_UPDATE, ResourceType: PlanResourceType_QUOTA,
+					Name: k.PlanName(), Before: before, After: after})
+			}
+		}
+	}
+	if executeDeleteFlow {
+		for k, v := range provisioned {
+			if _, found := expected[k]; !found && len(managedQuotas(v)) != 0 {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_DELETE, ResourceType: PlanResourceType_QUOTA,
+					Name: k.PlanName(), Before: managedQuotas(v)})
+			}
+		}
+	}
+	return ret
+}
+
+// Returns the quotas that can be declared (quotaKeys) among the provided ones.
+func managedQuotas(in NVPairs) NVPairs {
+	ret := NVPairs{}
+	for k, v := range in {
+		if isOneOf(k, quotaKeys) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// Fingerprint of the client quotas observed in a cluster.
+func QuotaStateFingerprint(in QuotaConfigMapping) string {
+	type quota struct {
+		Entity string  `json:"entity"`
+		Quotas NVPairs `json:"quotas"`
+	}
+	state := []quota{}
+	for k, v := range in {
+		state = append(state, quota{Entity: k.PlanName(), Quotas: v})
+	}
+	sort.Slice(state, func(i, j int) bool {
+		return state[i].Entity < state[j].Entity
+	})
+	b, _ := json.Marshal(state)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
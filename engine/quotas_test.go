@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"errors"
+)
+
+func (s *StackSuite) TestStackSuite_Quotas_Definitions() {
+	for _, c := range []QuotaDefinition{
+		{},
+		{Quotas: []NVPairs{{"fetch_byte_rate": "10"}}},
+		{Quotas: []NVPairs{{"producer_byte_rate": "-1"}}},
+		{Quotas: []NVPairs{{"producer_byte_rate": "fast"}}},
+	} {
+		r := &envResolver{}
+		c.readValuesFromENV(r)
+		s.True(errors.Is(r.err, ErrConfigInvalid), "Quota definition %+v should be invalid", c)
+	}
+
+	tiers := quotaTiers(BlueprintRoot{Quota: QuotaBlueprints{QuotaConfigs: []QuotaBlueprintConfigs{
+		{Name: "Silver", Quotas: []NVPairs{{"producer_byte_rate": "1024.0", "consumer_byte_rate": "2048"}}},
+	}}})
+	m := QuotaConfigMapping{}
+	s.NoError(m.addClientQuotas(ClientDefinition{
+		Producers: []ProducerDefinition{{Principal: "User:1", Quota: &QuotaDefinition{QuotaBlueprintRef: "silver", Quotas: []NVPairs{{"producer_byte_rate": "4096"}}}}},
+		Consumers: []ConsumerDefinition{
+			{Principal: "User:1", Quota: &QuotaDefinition{Quotas: []NVPairs{{"request_percentage": "50"}}}},
+			{Principal: "User:1", Quota: &QuotaDefinition{ClientID: "app", QuotaBlueprintRef: "silver"}},
+			{Principal: "User:2"},
+		},
+	}, tiers))
+	s.Equal(QuotaConfigMapping{
+		{User: "1"}:                  {"producer_byte_rate": "4096", "consumer_byte_rate": "2048", "request_percentage": "50"},
+		{User: "1", ClientID: "app"}: {"producer_byte_rate": "1024", "consumer_byte_rate": "2048"},
+	}, m, "Quotas of the same entity should be merged over the blueprint tier")
+
+	for _, c := range []ConsumerDefinition{
+		{Principal: "User:1", Quota: &QuotaDefinition{Quotas: []NVPairs{{"request_percentage": "25"}}}},
+		{Principal: "User:1", Quota: &QuotaDefinition{QuotaBlueprintRef: "platinum"}},
+		{Principal: "User:*", Quota: &QuotaDefinition{Quotas: []NVPairs{{"request_percentage": "25"}}}},
+		{Principal: "Group:1", Quota: &QuotaDefinition{Quotas: []NVPairs{{"request_percentage": "25"}}}},
+	} {
+		s.True(errors.Is(m.addClientQuotas(ClientDefinition{Consumers: []ConsumerDefinition{c}}, tiers), ErrConfigInvalid),
+			"Quota of %s %+v should be refused", c.Principal, *c.Quota)
+	}
+}
+
+func (s *StackSuite) TestStackSuite_Quotas_PlanChanges() {
+	expected := QuotaConfigMapping{
+		{User: "new"}:                      {"producer_byte_rate": "1024"},
+		{User: "changed", ClientID: "app"}: {"producer_byte_rate": "2048"},
+		{User: "same"}:                     {"request_percentage": "50"},
+	}
+	provisioned := QuotaConfigMapping{
+		{User: "changed", ClientID: "app"}: {"producer_byte_rate": "1024", "consumer_byte_rate": "1024", "controller_mutation_rate": "10"},
+		{User: "same"}:                     {"request_percentage": "50", "controller_mutation_rate": "10"},
+		{User: "unknown"}:                  {"consumer_byte_rate": "1024", "controller_mutation_rate": "10"},
+		{User: "unmanaged"}:                {"controller_mutation_rate": "10"},
+	}
+
+	changes := map[string]PlanChange{}
+	for _, v := range PlanQuotaChanges("c1", expected, provisioned, true, true, true) {
+		s.Equal(PlanResourceType_QUOTA, v.ResourceType)
+		changes[v.Name] = v
+	}
+	s.Len(changes, 3)
+	s.Equal(PlanAction_CREATE, changes["user=new"].Action)
+	s.Equal(PlanAction_UPDATE, changes["user=changed,client-id=app"].Action)
+	s.Equal(NVPairs{"producer_byte_rate": "1024", "consumer_byte_rate": "1024"}, changes["user=changed,client-id=app"].Before)
+	s.Equal(NVPairs{"producer_byte_rate": "2048", "consumer_byte_rate": ""}, changes["user=changed,client-id=app"].After,
+		"Quotas that are not declared should be removed, the ones that cannot be declared should be left alone")
+	s.Equal(PlanAction_DELETE, changes["user=unknown"].Action)
+	s.Equal(NVPairs{"consumer_byte_rate": "1024"}, changes["user=unknown"].Before)
+
+	s.Len(PlanQuotaChanges("c1", expected, provisioned, true, true, false), 2)
+	s.Len(PlanQuotaChanges("c1", expected, provisioned, false, false, false), 0)
+	s.NotEqual(QuotaStateFingerprint(expected), QuotaStateFingerprint(provisioned))
+}
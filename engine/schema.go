@@ -1,14 +1,19 @@
 package engine
 
+import (
+	"strings"
+	"time"
+)
+
 type CustomParser interface {
-	readValuesFromENV()
+	readValuesFromENV(r *envResolver)
 }
 
 type NVPairs map[string]string
 
-func (nv *NVPairs) readValuesFromENV() {
+func (nv *NVPairs) readValuesFromENV(r *envResolver) {
 	for k, v := range *nv {
-		(*nv)[k] = envVarCheckNReplace(v, "")
+		(*nv)[k] = r.replace(v, "")
 	}
 }
 
@@ -20,9 +25,14 @@ func (nv *NVPairs) readValuesFromENV() {
 	one layer to access everything.
 */
 type ConfigurationMaps struct {
-	TCM TopicConfigMapping
-	utm UserTopicMapping
-	CCM ClusterConfigMapping
+	TCM              TopicConfigMapping
+	utm              UserTopicMapping
+	CCM              ClusterConfigMapping
+	Connectors       ConnectorConfigMapping
+	Subjects         SubjectConfigMapping
+	KSQL             KSQLConfigMapping
+	Quotas           QuotaConfigMapping
+	ScramCredentials ScramCredentialMapping
 }
 
 /*
@@ -34,20 +44,22 @@ type ShepherdCore struct {
 	Configs     ShepherdConfig
 	Blueprints  ShepherdBlueprint
 	Definitions ShepherdDefinition
+	// Properties of the topic blueprints with the defaults merged in, looked up by the blueprint name.
+	blueprintMap map[string]NVPairs
 }
 
-func (c *ShepherdCore) readValuesFromENV() {
-	c.Configs.readValuesFromENV()
-	c.Blueprints.readValuesFromENV()
-	c.Definitions.readValuesFromENV()
+func (c *ShepherdCore) readValuesFromENV(r *envResolver) {
+	c.Configs.readValuesFromENV(r)
+	c.Blueprints.readValuesFromENV(r)
+	c.Definitions.readValuesFromENV(r)
 }
 
 type ShepherdConfig struct {
 	ConfigRoot ConfigRoot `yaml:"configs"`
 }
 
-func (c *ShepherdConfig) readValuesFromENV() {
-	c.ConfigRoot.readValuesFromENV()
+func (c *ShepherdConfig) readValuesFromENV(r *envResolver) {
+	c.ConfigRoot.readValuesFromENV(r)
 }
 
 type ConfigRoot struct {
@@ -55,21 +67,80 @@ type ConfigRoot struct {
 	Clusters           []ShepherdCluster  `yaml:"clusters,flow"`
 }
 
-func (c *ConfigRoot) readValuesFromENV() {
-	c.ShepherdCoreConfig.readValuesFromENV()
+func (c *ConfigRoot) readValuesFromENV(r *envResolver) {
+	c.ShepherdCoreConfig.readValuesFromENV(r)
 	for idx := 0; idx < len(c.Clusters); idx++ {
-		c.Clusters[idx].readValuesFromENV()
+		c.Clusters[idx].readValuesFromENV(r)
 	}
 }
 
 type ShepherdCoreConfig struct {
-	SeperatorToken      string `yaml:"separatorToken"`
-	DeleteUnknownTopics bool   `yaml:"deleteUnknownTopics"`
-	DeleteUnknownACLs   bool   `yaml:"deleteUnknownACLs"`
+	SeperatorToken           string                 `yaml:"separatorToken"`
+	DeleteUnknownTopics      bool                   `yaml:"deleteUnknownTopics"`
+	DeleteUnknownACLs        bool                   `yaml:"deleteUnknownACLs"`
+	DeleteUnknownConnectors  bool                   `yaml:"deleteUnknownConnectors"`
+	DeleteUnknownSubjects    bool                   `yaml:"deleteUnknownSubjects"`
+	DeleteUnknownKSQLQueries bool                   `yaml:"deleteUnknownKSQLQueries"`
+	DeleteUnknownQuotas      bool                   `yaml:"deleteUnknownQuotas"`
+	DeleteUnknownScramUsers  bool                   `yaml:"deleteUnknownScramUsers"`
+	Retry                    RetryConfig            `yaml:"retry,omitempty"`
+	Reassignment             ReassignmentConfig     `yaml:"reassignment,omitempty"`
+	TopicDeletion            TopicDeletionConfig    `yaml:"topicDeletion,omitempty"`
+	ScramCredentials         ScramCredentialsConfig `yaml:"scramCredentials,omitempty"`
+}
+
+/*
+	Controls how the failed requests to the clusters are retried. The durations are in the Go format
+	(e.g. 500ms, 10s, 2m). Any value that is not provided (or is 0) uses the default of the managers, except
+	the jitter that is only defaulted when not provided, so 0 turns it off.
+*/
+type RetryConfig struct {
+	MaxAttempts     int           `yaml:"maxAttempts"`
+	InitialInterval time.Duration `yaml:"initialInterval"`
+	MaxInterval     time.Duration `yaml:"maxInterval"`
+	Multiplier      float64       `yaml:"multiplier"`
+	Jitter          *float64      `yaml:"jitter"`
+	MaxElapsedTime  time.Duration `yaml:"maxElapsedTime"`
+}
+
+func (c RetryConfig) validate() error {
+	switch {
+	case c.MaxAttempts < 0:
+		return configError("core.retry.maxAttempts cannot be negative. Provided: %d", c.MaxAttempts)
+	case c.InitialInterval < 0 || c.MaxInterval < 0 || c.MaxElapsedTime < 0:
+		return configError("core.retry intervals cannot be negative. Provided: initialInterval %s, maxInterval %s, maxElapsedTime %s",
+			c.InitialInterval, c.MaxInterval, c.MaxElapsedTime)
+	case c.Multiplier != 0 && c.Multiplier < 1:
+		return configError("core.retry.multiplier cannot be less than 1. Provided: %v", c.Multiplier)
+	case c.Jitter != nil && (*c.Jitter < 0 || *c.Jitter > 1):
+		return configError("core.retry.jitter should be between 0 and 1. Provided: %v", *c.Jitter)
+	}
+	return nil
+}
+
+/*
+	Controls the replica reassignments started to change the replication factor of the topics. The
+	replication traffic is throttled to ThrottleBytesPerSec on the brokers involved while the reassignment
+	is in progress, if provided. The progress is checked every PollInterval (10s if not provided).
+*/
+type ReassignmentConfig struct {
+	ThrottleBytesPerSec int64         `yaml:"throttleBytesPerSec"`
+	PollInterval        time.Duration `yaml:"pollInterval"`
+}
+
+func (c ReassignmentConfig) validate() error {
+	switch {
+	case c.ThrottleBytesPerSec < 0:
+		return configError("core.reassignment.throttleBytesPerSec cannot be negative. Provided: %d", c.ThrottleBytesPerSec)
+	case c.PollInterval < 0:
+		return configError("core.reassignment.pollInterval cannot be negative. Provided: %s", c.PollInterval)
+	}
+	return nil
 }
 
-func (c *ShepherdCoreConfig) readValuesFromENV() {
-	c.SeperatorToken = envVarCheckNReplace(c.SeperatorToken, ".")
+func (c *ShepherdCoreConfig) readValuesFromENV(r *envResolver) {
+	c.SeperatorToken = r.replace(c.SeperatorToken, ".")
+	c.ScramCredentials.readValuesFromENV(r)
 }
 
 type ShepherdCluster struct {
@@ -82,25 +153,67 @@ type ShepherdCluster struct {
 	TLSDetails       ShepherdCerts `yaml:"tlsDetails,omitempty"`
 	Configs          []NVPairs     `yaml:"configOverrides,flow"`
 	ClusterDetails   []NVPairs     `yaml:"clusterDetails,flow"`
-}
-
-func (c *ShepherdCluster) readValuesFromENV() {
-	c.Name = envVarCheckNReplace(c.Name, "")
+	// The Kafka version of the brokers, as "2.6.0". The requests are made with it, so the features needing a
+	// later version are refused. Prefixed ACLs need at least 2.0.0, the default.
+	KafkaVersion string `yaml:"kafkaVersion" default:"2.0.0"`
+	// The Kafka Connect clusters working with the cluster. The connector definitions refer to them by name.
+	ConnectClusters []RESTEndpoint `yaml:"connectClusters,omitempty"`
+	// The Schema Registry of the cluster. The topic schemas are only registered if its url is provided.
+	SchemaRegistry RESTEndpoint `yaml:"schemaRegistry,omitempty"`
+	// The ksqlDB clusters working with the cluster, named after their ksql.service.id. The ksql definitions refer to them by name.
+	KSQLClusters []RESTEndpoint `yaml:"ksqlClusters,omitempty"`
+}
+
+func (c *ShepherdCluster) readValuesFromENV(r *envResolver) {
+	c.Name = r.replace(c.Name, "")
 	for i, v := range c.BootstrapServers {
-		c.BootstrapServers[i] = envVarCheckNReplace(v, "")
+		c.BootstrapServers[i] = r.replace(v, "")
 	}
-	c.ACLManager = envVarCheckNReplace(c.ACLManager, "kafka_acl")
-	c.TopicManager = envVarCheckNReplace(c.TopicManager, "sarama")
-	c.ClientID = envVarCheckNReplace(c.ClientID, "")
-	c.TLSDetails.readValuesFromENV()
+	c.ACLManager = r.replace(c.ACLManager, "kafka_acl")
+	c.TopicManager = r.replace(c.TopicManager, "sarama")
+	c.ClientID = r.replace(c.ClientID, "")
+	c.KafkaVersion = r.replace(c.KafkaVersion, "2.0.0")
+	c.TLSDetails.readValuesFromENV(r)
 	c.Configs = streamlineNVPairs(c.Configs)
 	for idx := 0; idx < len(c.Configs); idx++ {
-		c.Configs[idx].readValuesFromENV()
+		c.Configs[idx].readValuesFromENV(r)
 	}
 	c.ClusterDetails = streamlineNVPairs(c.ClusterDetails)
 	for idx := 0; idx < len(c.ClusterDetails); idx++ {
-		c.ClusterDetails[idx].readValuesFromENV()
+		c.ClusterDetails[idx].readValuesFromENV(r)
+	}
+	for idx := 0; idx < len(c.ConnectClusters); idx++ {
+		c.ConnectClusters[idx].readValuesFromENV(r)
+	}
+	if c.SchemaRegistry.URL != "" {
+		if c.SchemaRegistry.Name == "" {
+			c.SchemaRegistry.Name = "schema-registry"
+		}
+		c.SchemaRegistry.readValuesFromENV(r)
+	}
+	for idx := 0; idx < len(c.KSQLClusters); idx++ {
+		c.KSQLClusters[idx].readValuesFromENV(r)
+	}
+}
+
+// The REST endpoint of a server working with the cluster. The credentials are only used if provided.
+type RESTEndpoint struct {
+	Name       string        `yaml:"name"`
+	URL        string        `yaml:"url"`
+	Username   string        `yaml:"username,omitempty"`
+	Password   string        `yaml:"password,omitempty"`
+	TLSDetails ShepherdCerts `yaml:"tlsDetails,omitempty"`
+}
+
+func (c *RESTEndpoint) readValuesFromENV(r *envResolver) {
+	c.Name = r.replace(c.Name, "")
+	c.URL = r.replace(c.URL, "")
+	if c.Name == "" || c.URL == "" {
+		r.fail(configError("REST endpoints need a name and a url. Name: %q, URL: %q", c.Name, c.URL))
 	}
+	c.Username = r.replace(c.Username, "")
+	c.Password = r.replace(c.Password, "")
+	c.TLSDetails.readValuesFromENV(r)
 }
 
 type ShepherdCerts struct {
@@ -111,34 +224,36 @@ type ShepherdCerts struct {
 	PrivateKeyPassword string   `yaml:"privateKeyPass"`
 }
 
-func (c *ShepherdCerts) readValuesFromENV() {
+func (c *ShepherdCerts) readValuesFromENV(r *envResolver) {
 	for i, v := range c.TrustedCerts {
-		c.TrustedCerts[i] = envVarCheckNReplace(v, "")
+		c.TrustedCerts[i] = r.replace(v, "")
 	}
-	c.ClientCert = envVarCheckNReplace(c.ClientCert, "")
-	c.PrivateKey = envVarCheckNReplace(c.PrivateKey, "")
-	c.PrivateKeyPassword = envVarCheckNReplace(c.PrivateKeyPassword, "")
+	c.ClientCert = r.replace(c.ClientCert, "")
+	c.PrivateKey = r.replace(c.PrivateKey, "")
+	c.PrivateKeyPassword = r.replace(c.PrivateKeyPassword, "")
 }
 
 type ShepherdBlueprint struct {
 	Blueprint BlueprintRoot `yaml:"blueprints"`
 }
 
-func (c *ShepherdBlueprint) readValuesFromENV() {
-	c.Blueprint.readValuesFromENV()
+func (c *ShepherdBlueprint) readValuesFromENV(r *envResolver) {
+	c.Blueprint.readValuesFromENV(r)
 }
 
 type BlueprintRoot struct {
 	Topic       TopicBlueprints  `yaml:"topic,omitempty"`
+	Quota       QuotaBlueprints  `yaml:"quota,omitempty"`
 	Policy      PolicyBlueprints `yaml:"policy,omitempty"`
 	CustomEnums []CustomEnums    `yaml:"customEnums,flow,omitempty"`
 }
 
-func (c *BlueprintRoot) readValuesFromENV() {
-	c.Topic.readValuesFromENV()
-	c.Policy.readValuesFromENV()
+func (c *BlueprintRoot) readValuesFromENV(r *envResolver) {
+	c.Topic.readValuesFromENV(r)
+	c.Quota.readValuesFromENV(r)
+	c.Policy.readValuesFromENV(r)
 	for i := 0; i < len(c.CustomEnums); i++ {
-		c.CustomEnums[i].readValuesFromENV()
+		c.CustomEnums[i].readValuesFromENV(r)
 	}
 }
 
@@ -146,9 +261,9 @@ type TopicBlueprints struct {
 	TopicConfigs []TopicBlueprintConfigs `yaml:"topicConfigs,flow,omitempty"`
 }
 
-func (c *TopicBlueprints) readValuesFromENV() {
+func (c *TopicBlueprints) readValuesFromENV(r *envResolver) {
 	for i := 0; i < len(c.TopicConfigs); i++ {
-		c.TopicConfigs[i].readValuesFromENV()
+		c.TopicConfigs[i].readValuesFromENV(r)
 	}
 }
 
@@ -157,11 +272,39 @@ type TopicBlueprintConfigs struct {
 	Overrides []NVPairs `yaml:"configOverrides,omitempty,flow"`
 }
 
-func (c *TopicBlueprintConfigs) readValuesFromENV() {
-	c.Name = envVarCheckNReplace(c.Name, "")
+func (c *TopicBlueprintConfigs) readValuesFromENV(r *envResolver) {
+	c.Name = r.replace(c.Name, "")
 	c.Overrides = streamlineNVPairs(c.Overrides)
 	for i := 0; i < len(c.Overrides); i++ {
-		c.Overrides[i].readValuesFromENV()
+		c.Overrides[i].readValuesFromENV(r)
+	}
+}
+
+// Quota tiers that the client quotas can refer to by name, e.g. bronze, silver and gold.
+type QuotaBlueprints struct {
+	QuotaConfigs []QuotaBlueprintConfigs `yaml:"quotaConfigs,flow,omitempty"`
+}
+
+func (c *QuotaBlueprints) readValuesFromENV(r *envResolver) {
+	for i := 0; i < len(c.QuotaConfigs); i++ {
+		c.QuotaConfigs[i].readValuesFromENV(r)
+	}
+}
+
+type QuotaBlueprintConfigs struct {
+	Name   string    `yaml:"name"`
+	Quotas []NVPairs `yaml:"quotas,omitempty,flow"`
+}
+
+func (c *QuotaBlueprintConfigs) readValuesFromENV(r *envResolver) {
+	c.Name = r.replace(c.Name, "")
+	if c.Name == "" {
+		r.fail(configError("Quota blueprints need a name."))
+	}
+	c.Quotas = streamlineNVPairs(c.Quotas)
+	for i := 0; i < len(c.Quotas); i++ {
+		c.Quotas[i].readValuesFromENV(r)
+		r.fail(validateQuotas(c.Quotas[i]))
 	}
 }
 
@@ -170,12 +313,12 @@ type PolicyBlueprints struct {
 	ACLPolicy   *ACLPolicyConfigs   `yaml:"aclPolicy,omitempty"`
 }
 
-func (c *PolicyBlueprints) readValuesFromENV() {
+func (c *PolicyBlueprints) readValuesFromENV(r *envResolver) {
 	if c.TopicPolicy != nil {
-		c.TopicPolicy.readValuesFromENV()
+		c.TopicPolicy.readValuesFromENV(r)
 	}
 	if c.ACLPolicy != nil {
-		c.ACLPolicy.readValuesFromENV()
+		c.ACLPolicy.readValuesFromENV(r)
 	}
 }
 
@@ -184,12 +327,12 @@ type TopicPolicyConfigs struct {
 	Overrides TopicPolicyOverrides `yaml:"overrides,omitempty"`
 }
 
-func (c *TopicPolicyConfigs) readValuesFromENV() {
+func (c *TopicPolicyConfigs) readValuesFromENV(r *envResolver) {
 	c.Defaults = streamlineNVPairs(c.Defaults)
 	for i := 0; i < len(c.Defaults); i++ {
-		c.Defaults[i].readValuesFromENV()
+		c.Defaults[i].readValuesFromENV(r)
 	}
-	c.Overrides.readValuesFromENV()
+	c.Overrides.readValuesFromENV(r)
 }
 
 type TopicPolicyOverrides struct {
@@ -197,12 +340,12 @@ type TopicPolicyOverrides struct {
 	Blacklist []string `yaml:"blacklist,flow,omitempty"`
 }
 
-func (c *TopicPolicyOverrides) readValuesFromENV() {
+func (c *TopicPolicyOverrides) readValuesFromENV(r *envResolver) {
 	for i, v := range c.Whitelist {
-		c.Whitelist[i] = envVarCheckNReplace(v, "")
+		c.Whitelist[i] = r.replace(v, "")
 	}
 	for i, v := range c.Blacklist {
-		c.Blacklist[i] = envVarCheckNReplace(v, "")
+		c.Blacklist[i] = r.replace(v, "")
 	}
 }
 
@@ -212,8 +355,8 @@ type ACLPolicyConfigs struct {
 	OptimizeACLs bool   `yaml:"optimizeACLs,omitempty"`
 }
 
-func (c *ACLPolicyConfigs) readValuesFromENV() {
-	c.ACLType = envVarCheckNReplace(c.ACLType, "")
+func (c *ACLPolicyConfigs) readValuesFromENV(r *envResolver) {
+	c.ACLType = r.replace(c.ACLType, "")
 }
 
 type CustomEnums struct {
@@ -222,10 +365,10 @@ type CustomEnums struct {
 	IncludeInTopicName bool     `yaml:"mandatoryInTopicName,omitempty"`
 }
 
-func (c *CustomEnums) readValuesFromENV() {
-	c.Name = envVarCheckNReplace(c.Name, "")
+func (c *CustomEnums) readValuesFromENV(r *envResolver) {
+	c.Name = r.replace(c.Name, "")
 	for i, v := range c.Values {
-		c.Values[i] = envVarCheckNReplace(v, "")
+		c.Values[i] = r.replace(v, "")
 	}
 }
 
@@ -233,8 +376,8 @@ type ShepherdDefinition struct {
 	DefinitionRoot DefinitionRoot `yaml:"definitions"`
 }
 
-func (c *ShepherdDefinition) readValuesFromENV() {
-	c.DefinitionRoot.readValuesFromENV()
+func (c *ShepherdDefinition) readValuesFromENV(r *envResolver) {
+	c.DefinitionRoot.readValuesFromENV(r)
 }
 
 type DefinitionRoot struct {
@@ -242,10 +385,10 @@ type DefinitionRoot struct {
 	ScopeFlow    []ScopeDefinition `yaml:"scopeFlow,flow,omitempty"`
 }
 
-func (c *DefinitionRoot) readValuesFromENV() {
-	c.AdhocConfigs.readValuesFromENV()
+func (c *DefinitionRoot) readValuesFromENV(r *envResolver) {
+	c.AdhocConfigs.readValuesFromENV(r)
 	for i := 0; i < len(c.ScopeFlow); i++ {
-		c.ScopeFlow[i].readValuesFromENV()
+		c.ScopeFlow[i].readValuesFromENV(r)
 	}
 }
 
@@ -253,9 +396,9 @@ type AdhocConfig struct {
 	Topics []TopicDefinition `yaml:"topics,flow,omitempty"`
 }
 
-func (c *AdhocConfig) readValuesFromENV() {
+func (c *AdhocConfig) readValuesFromENV(r *envResolver) {
 	for i := 0; i < len(c.Topics); i++ {
-		c.Topics[i].readValuesFromENV()
+		c.Topics[i].readValuesFromENV(r)
 	}
 }
 
@@ -265,21 +408,23 @@ type TopicDefinition struct {
 	IgnoreScope           []string         `yaml:"ignoreScope,flow,omitempty"`
 	TopicBlueprintEnumRef string           `yaml:"blueprintEnum,omitempty"`
 	ConfigOverrides       []NVPairs        `yaml:"configOverrides,flow,omitempty"`
+	Schemas               TopicSchemas     `yaml:"schemas,omitempty"`
 }
 
-func (c *TopicDefinition) readValuesFromENV() {
+func (c *TopicDefinition) readValuesFromENV(r *envResolver) {
 	for i, v := range c.Name {
-		c.Name[i] = envVarCheckNReplace(v, "")
+		c.Name[i] = r.replace(v, "")
 	}
-	c.Clients.readValuesFromENV()
+	c.Clients.readValuesFromENV(r)
 	for i, v := range c.IgnoreScope {
-		c.IgnoreScope[i] = envVarCheckNReplace(v, "")
+		c.IgnoreScope[i] = r.replace(v, "")
 	}
-	c.TopicBlueprintEnumRef = envVarCheckNReplace(c.TopicBlueprintEnumRef, "")
+	c.TopicBlueprintEnumRef = r.replace(c.TopicBlueprintEnumRef, "")
 	c.ConfigOverrides = streamlineNVPairs(c.ConfigOverrides)
 	for i := 0; i < len(c.ConfigOverrides); i++ {
-		c.ConfigOverrides[i].readValuesFromENV()
+		c.ConfigOverrides[i].readValuesFromENV(r)
 	}
+	c.Schemas.readValuesFromENV(r)
 }
 
 type ClientDefinition struct {
@@ -288,45 +433,78 @@ type ClientDefinition struct {
 	Connectors []ConnectorDefinition `yaml:"connectors,flow,omitempty"`
 	Streams    []StreamDefinition    `yaml:"streams,flow,omitempty"`
 	KSQL       []KSQLDefinition      `yaml:"ksql,flow,omitempty"`
+	Deny       []DenyDefinition      `yaml:"deny,flow,omitempty"`
 }
 
-func (c *ClientDefinition) readValuesFromENV() {
+func (c *ClientDefinition) readValuesFromENV(r *envResolver) {
 	for i := 0; i < len(c.Consumers); i++ {
-		c.Consumers[i].readValuesFromENV()
+		c.Consumers[i].readValuesFromENV(r)
 	}
 	for i := 0; i < len(c.Producers); i++ {
-		c.Producers[i].readValuesFromENV()
+		c.Producers[i].readValuesFromENV(r)
 	}
 	for i := 0; i < len(c.Connectors); i++ {
-		c.Connectors[i].readValuesFromENV()
+		c.Connectors[i].readValuesFromENV(r)
 	}
 	for i := 0; i < len(c.Streams); i++ {
-		c.Streams[i].readValuesFromENV()
+		c.Streams[i].readValuesFromENV(r)
 	}
 	for i := 0; i < len(c.KSQL); i++ {
-		c.KSQL[i].readValuesFromENV()
+		c.KSQL[i].readValuesFromENV(r)
+	}
+	for i := 0; i < len(c.Deny); i++ {
+		c.Deny[i].readValuesFromENV(r)
 	}
 }
 
 type ConsumerDefinition struct {
-	Principal string   `yaml:"id,omitempty"`
-	Group     string   `yaml:"group,omitempty"`
-	Hostnames []string `yaml:"hostnames,omitempty,flow"`
+	Principal string           `yaml:"id,omitempty"`
+	Group     string           `yaml:"group,omitempty"`
+	Hostnames []string         `yaml:"hostnames,omitempty,flow"`
+	Quota     *QuotaDefinition `yaml:"quota,omitempty"`
 }
 
-func (c *ConsumerDefinition) readValuesFromENV() {
-	c.Principal = envVarCheckNReplace(c.Principal, "")
+func (c *ConsumerDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
 	if len(c.Principal) == 0 {
-		logger.Fatal("ID needs to be defined, otherwise the ACL's cannot be set up.")
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
 	}
-	c.Group = envVarCheckNReplace(c.Group, "")
+	c.Group = r.replace(c.Group, "")
 	if len(c.Hostnames) == 0 {
 		c.Hostnames = append(c.Hostnames, "*")
 	} else {
 		for i, v := range c.Hostnames {
-			c.Hostnames[i] = envVarCheckNReplace(v, "")
+			c.Hostnames[i] = r.replace(v, "")
 		}
 	}
+	if c.Quota != nil {
+		c.Quota.readValuesFromENV(r)
+	}
+}
+
+/*
+	The quotas of a client, set for the principal or for the client id of the principal if one is provided.
+	The quotas of the blueprint tier are the baseline, the quotas provided here override them.
+*/
+type QuotaDefinition struct {
+	ClientID          string    `yaml:"clientId,omitempty"`
+	QuotaBlueprintRef string    `yaml:"blueprint,omitempty"`
+	Quotas            []NVPairs `yaml:"quotas,omitempty,flow"`
+}
+
+func (c *QuotaDefinition) readValuesFromENV(r *envResolver) {
+	c.ClientID = r.replace(c.ClientID, "")
+	c.QuotaBlueprintRef = r.replace(c.QuotaBlueprintRef, "")
+	c.Quotas = streamlineNVPairs(c.Quotas)
+	count := 0
+	for i := 0; i < len(c.Quotas); i++ {
+		c.Quotas[i].readValuesFromENV(r)
+		r.fail(validateQuotas(c.Quotas[i]))
+		count += len(c.Quotas[i])
+	}
+	if c.QuotaBlueprintRef == "" && count == 0 {
+		r.fail(configError("Quotas need a blueprint or the quotas. Client ID: %q", c.ClientID))
+	}
 }
 
 type ProducerDefinition struct {
@@ -335,35 +513,48 @@ type ProducerDefinition struct {
 	Hostnames         []string `yaml:"hostnames,omitempty,flow"`
 	EnableIdempotence bool     `yaml:"enableIdempotence"`
 	TransactionalID   bool     `yaml:"enableTransactions"`
+	// Literal transactional.id values or prefixes ending with "*", e.g. "orders-*". Declaring them enables transactions.
+	TransactionalIDs []string         `yaml:"transactionalIds,omitempty,flow"`
+	Quota            *QuotaDefinition `yaml:"quota,omitempty"`
 }
 
-func (c *ProducerDefinition) readValuesFromENV() {
-	c.Principal = envVarCheckNReplace(c.Principal, "")
+func (c *ProducerDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
 	if len(c.Principal) == 0 {
-		logger.Fatal("ID needs to be defined, otherwise the ACL's cannot be set up.")
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
 	}
-	c.Group = envVarCheckNReplace(c.Group, "")
+	c.Group = r.replace(c.Group, "")
 	if len(c.Hostnames) == 0 {
 		c.Hostnames = append(c.Hostnames, "*")
 	} else {
 		for i, v := range c.Hostnames {
-			c.Hostnames[i] = envVarCheckNReplace(v, "")
+			c.Hostnames[i] = r.replace(v, "")
 		}
 	}
 	if c.EnableIdempotence && c.Group == "" {
-		logger.Fatalw("If Idempotence is enabled, Producer needs to have a group defined.",
-			"Producer Principal", c.Principal,
-			"Producer Group", c.Group,
-			"Idempotence Enabled", c.EnableIdempotence)
+		r.fail(configError("If Idempotence is enabled, Producer needs to have a group defined. Producer Principal: %s", c.Principal))
+	}
+	for i, v := range c.TransactionalIDs {
+		c.TransactionalIDs[i] = r.replace(v, "")
+		if c.TransactionalIDs[i] == "" {
+			r.fail(configError("Transactional IDs cannot be empty. Producer Principal: %s", c.Principal))
+		}
+	}
+	if len(c.TransactionalIDs) != 0 {
+		c.TransactionalID = true
 	}
 	if c.TransactionalID {
 		c.EnableIdempotence = true
 	}
 	if c.TransactionalID && c.Group == "" {
-		logger.Fatalw("If Transactions are enabled, Producer needs to have a group defined.",
-			"Producer Principal", c.Principal,
-			"Producer Group", c.Group,
-			"Transactions Enabled", c.TransactionalID)
+		r.fail(configError("If Transactions are enabled, Producer needs to have a group defined. Producer Principal: %s", c.Principal))
+	}
+	if c.Quota != nil {
+		c.Quota.readValuesFromENV(r)
+	}
+	// The group was used as the transactional.id before the ids could be declared, so it stays the default.
+	if c.TransactionalID && len(c.TransactionalIDs) == 0 {
+		c.TransactionalIDs = []string{c.Group}
 	}
 }
 
@@ -373,28 +564,45 @@ type ConnectorDefinition struct {
 	Type           string   `yaml:"type,omitempty"`
 	ClusterNameRef string   `yaml:"clusterName,omitempty"`
 	Hostnames      []string `yaml:"hostnames,omitempty,flow"`
+	// The connector is only deployed and reconciled by Shepherd if its config is provided.
+	Config NVPairs `yaml:"config,omitempty"`
+	// Either running (default) or paused.
+	State string `yaml:"state,omitempty"`
 }
 
-func (c *ConnectorDefinition) readValuesFromENV() {
-	c.Principal = envVarCheckNReplace(c.Principal, "")
+func (c *ConnectorDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
 	if len(c.Principal) == 0 {
-		logger.Fatal("ID needs to be defined, otherwise the ACL's cannot be set up.")
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
 	}
-	c.ConnectorName = envVarCheckNReplace(c.ConnectorName, "")
-	c.Type = envVarCheckNReplace(c.Type, "")
+	c.ConnectorName = r.replace(c.ConnectorName, "")
+	c.Type = r.replace(c.Type, "")
 	if c.Type != "source" && c.Type != "sink" {
-		logger.Fatalw("Connectors need to be source or sink type. null or any other values are not expected.",
-			"Connector Principal", c.Principal,
-			"Connector Type provided", c.Type)
+		r.fail(configError("Connectors need to be source or sink type. Connector Principal: %s, Connector Type provided: %q", c.Principal, c.Type))
 	}
 	if len(c.Hostnames) == 0 {
 		c.Hostnames = append(c.Hostnames, "*")
 	} else {
 		for i, v := range c.Hostnames {
-			c.Hostnames[i] = envVarCheckNReplace(v, "")
+			c.Hostnames[i] = r.replace(v, "")
 		}
 	}
-	c.ClusterNameRef = envVarCheckNReplace(c.ClusterNameRef, "")
+	c.ClusterNameRef = r.replace(c.ClusterNameRef, "")
+	c.State = strings.ToLower(r.replace(c.State, "running"))
+	if c.Config == nil {
+		return
+	}
+	c.Config.readValuesFromENV(r)
+	switch {
+	case c.ConnectorName == "" || c.ClusterNameRef == "":
+		r.fail(configError("Connectors with a config need the connectorName and the clusterName. Connector Principal: %s", c.Principal))
+	case c.Config["connector.class"] == "":
+		r.fail(configError("Connector config needs the connector.class. Connector Name: %s", c.ConnectorName))
+	case c.Config["name"] != "" && c.Config["name"] != c.ConnectorName:
+		r.fail(configError("Connector config name does not match the connectorName. Connector Name: %s, Config Name: %s", c.ConnectorName, c.Config["name"]))
+	case c.State != "running" && c.State != "paused":
+		r.fail(configError("Connector state needs to be running or paused. Connector Name: %s, State provided: %q", c.ConnectorName, c.State))
+	}
 }
 
 type StreamDefinition struct {
@@ -404,28 +612,24 @@ type StreamDefinition struct {
 	Hostnames []string `yaml:"hostnames,omitempty,flow"`
 }
 
-func (c *StreamDefinition) readValuesFromENV() {
-	c.Principal = envVarCheckNReplace(c.Principal, "")
+func (c *StreamDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
 	if len(c.Principal) == 0 {
-		logger.Fatal("ID needs to be defined, otherwise the ACL's cannot be set up.")
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
 	}
-	c.Type = envVarCheckNReplace(c.Type, "")
+	c.Type = r.replace(c.Type, "")
 	if c.Type != "read" && c.Type != "write" {
-		logger.Fatalw("Streams need to be read or write type. null or any other values are not expected.",
-			"Stream Principal", c.Principal,
-			"Stream Type provided", c.Type)
+		r.fail(configError("Streams need to be read or write type. Stream Principal: %s, Stream Type provided: %q", c.Principal, c.Type))
 	}
-	c.Group = envVarCheckNReplace(c.Group, "")
+	c.Group = r.replace(c.Group, "")
 	if c.Group == "" {
-		logger.Fatalw("Streams need a group Name. It is the application.id that the Streams application is expected to use. null is not expected.",
-			"Stream Principal", c.Principal,
-			"Stream Group Name", c.Group)
+		r.fail(configError("Streams need a group Name. It is the application.id that the Streams application is expected to use. Stream Principal: %s", c.Principal))
 	}
 	if len(c.Hostnames) == 0 {
 		c.Hostnames = append(c.Hostnames, "*")
 	} else {
 		for i, v := range c.Hostnames {
-			c.Hostnames[i] = envVarCheckNReplace(v, "")
+			c.Hostnames[i] = r.replace(v, "")
 		}
 	}
 }
@@ -435,32 +639,84 @@ type KSQLDefinition struct {
 	Type           string   `yaml:"type,omitempty"`
 	ClusterNameRef string   `yaml:"clusterName,omitempty"`
 	Hostnames      []string `yaml:"hostnames,omitempty,flow"`
+	// The .sql files with the streams, the tables and the queries applied to the ksqlDB cluster.
+	Files []string `yaml:"files,omitempty,flow"`
 }
 
-func (c *KSQLDefinition) readValuesFromENV() {
-	c.Principal = envVarCheckNReplace(c.Principal, "")
+func (c *KSQLDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
 	if len(c.Principal) == 0 {
-		logger.Fatal("ID needs to be defined, otherwise the ACL's cannot be set up.")
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
 	}
-	c.Type = envVarCheckNReplace(c.Type, "")
+	c.Type = r.replace(c.Type, "")
 	if c.Type != "read" && c.Type != "write" {
-		logger.Fatalw("KSQL need to be read or write type. null or any other values are not expected.",
-			"KSQL Principal", c.Principal,
-			"KSQL Type provided", c.Type)
+		r.fail(configError("KSQL need to be read or write type. KSQL Principal: %s, KSQL Type provided: %q", c.Principal, c.Type))
 	}
 	if len(c.Hostnames) == 0 {
 		c.Hostnames = append(c.Hostnames, "*")
 	} else {
 		for i, v := range c.Hostnames {
-			c.Hostnames[i] = envVarCheckNReplace(v, "")
+			c.Hostnames[i] = r.replace(v, "")
 		}
 	}
-	c.ClusterNameRef = envVarCheckNReplace(c.ClusterNameRef, "")
+	c.ClusterNameRef = r.replace(c.ClusterNameRef, "")
 	if c.ClusterNameRef == "" {
-		logger.Fatalw("KSQL cluster id is required. It is the ksql.service.id that the KSQL user is expected to use. null is not expected.",
-			"KSQL Principal", c.Principal,
-			"KSQL Group Name", c.ClusterNameRef)
+		r.fail(configError("KSQL cluster id is required. It is the ksql.service.id that the KSQL user is expected to use. KSQL Principal: %s", c.Principal))
+	}
+	for i, v := range c.Files {
+		c.Files[i] = r.replace(v, "")
+		if !strings.HasSuffix(strings.ToLower(c.Files[i]), ".sql") {
+			r.fail(configError("KSQL files need to be .sql files. KSQL Cluster: %s, File provided: %q", c.ClusterNameRef, c.Files[i]))
+		}
+	}
+}
+
+/*
+	An explicit DENY rule for the topics it is defined for. The Operations are Kafka ACL operations on a topic
+	and default to ALL. Use "User:*" as the id to deny the operations to every principal connecting from the
+	hostnames. Kafka evaluates DENY before ALLOW, so the rule wins over any client definition of the principal.
+*/
+type DenyDefinition struct {
+	Principal  string   `yaml:"id,omitempty"`
+	Operations []string `yaml:"operations,flow,omitempty"`
+	Hostnames  []string `yaml:"hostnames,omitempty,flow"`
+}
+
+var denyTopicOperations []KafkaACLOperation = []KafkaACLOperation{
+	KafkaACLOperation_ALL, KafkaACLOperation_READ, KafkaACLOperation_WRITE, KafkaACLOperation_CREATE, KafkaACLOperation_DELETE,
+	KafkaACLOperation_ALTER, KafkaACLOperation_DESCRIBE, KafkaACLOperation_DESCRIBECONFIGS, KafkaACLOperation_ALTERCONFIGS,
+}
+
+func (c *DenyDefinition) readValuesFromENV(r *envResolver) {
+	c.Principal = r.replace(c.Principal, "")
+	if len(c.Principal) == 0 {
+		r.fail(configError("ID needs to be defined, otherwise the ACL's cannot be set up"))
+	}
+	if len(c.Operations) == 0 {
+		c.Operations = append(c.Operations, KafkaACLOperation_ALL.String())
+	}
+	for i, v := range c.Operations {
+		c.Operations[i] = strings.ToUpper(strings.TrimSpace(r.replace(v, "")))
+		if !isDenyTopicOperation(c.Operations[i]) {
+			r.fail(configError("Deny rules need a Kafka ACL operation allowed on a topic. Deny Principal: %s, Operation provided: %q", c.Principal, v))
+		}
+	}
+	if len(c.Hostnames) == 0 {
+		c.Hostnames = append(c.Hostnames, "*")
+	} else {
+		for i, v := range c.Hostnames {
+			c.Hostnames[i] = r.replace(v, "")
+		}
+	}
+}
+
+func isDenyTopicOperation(in string) bool {
+	for _, op := range denyTopicOperations {
+		if op.String() == in {
+			return true
+		}
 	}
+	return false
 }
 
 type ScopeDefinition struct {
@@ -473,16 +729,16 @@ type ScopeDefinition struct {
 	Child              *ScopeDefinition `yaml:"child,omitempty"`
 }
 
-func (c *ScopeDefinition) readValuesFromENV() {
-	c.ShortName = envVarCheckNReplace(c.ShortName, "")
+func (c *ScopeDefinition) readValuesFromENV(r *envResolver) {
+	c.ShortName = r.replace(c.ShortName, "")
 	for i, v := range c.Values {
-		(c.Values)[i] = envVarCheckNReplace(v, "")
+		(c.Values)[i] = r.replace(v, "")
 	}
-	c.CustomEnumRef = envVarCheckNReplace(c.CustomEnumRef, "")
-	c.Topics.readValuesFromENV()
-	c.Clients.readValuesFromENV()
+	c.CustomEnumRef = r.replace(c.CustomEnumRef, "")
+	c.Topics.readValuesFromENV(r)
+	c.Clients.readValuesFromENV(r)
 	if c.Child != nil {
-		c.Child.readValuesFromENV()
+		c.Child.readValuesFromENV(r)
 	}
 }
 
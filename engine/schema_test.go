@@ -1,7 +1,9 @@
 package engine
 
 import (
+	"errors"
 	"os"
+	"time"
 )
 
 func (s *StackSuite) TestStackSuite_YAMLFileParsing() {
@@ -10,133 +12,157 @@ func (s *StackSuite) TestStackSuite_YAMLFileParsing() {
 	os.Setenv("SHEPHERD_CLIENTCERT_PEM", "clientCert1")
 	os.Setenv("SHEPHERD_CLIENTKEY_KEY", "password2")
 	os.Setenv("SHEPHERD_CLIENTKEY_KEYPASS", "password1")
-	SpdCore.Configs.ParseShepherdConfig(getEnvVarsWithDefaults("SHEPHERD_CONFIG_FILE_LOCATION", ""), true)
+	s.NoError(s.st.Core.Configs.ParseShepherdConfig(os.Getenv("SHEPHERD_CONFIG_FILE_LOCATION"), true))
 	os.Setenv("SHEPHERD_BLUEPRINTS_FILE_LOCATION", "./testdata/blueprints_0.yaml")
-	SpdCore.Blueprints.ParseShepherBlueprints(getEnvVarsWithDefaults("SHEPHERD_BLUEPRINTS_FILE_LOCATION", ""))
+	s.NoError(s.st.Core.Blueprints.ParseShepherBlueprints(os.Getenv("SHEPHERD_BLUEPRINTS_FILE_LOCATION")))
 
 	// Shepherd Cluster file Validation
-	s.Equal(".", SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken, "Separator Token Mismatch")
-	s.Equal(false, SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics, "Unknown Topic switch mismatch")
-	s.Equal(true, SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs, "Unknown ACL switch mismatch")
-
-	s.Equal(5, len(SpdCore.Configs.ConfigRoot.Clusters), "Defined Cluster count mismatch")
-	s.Equal("dev_plaintext", SpdCore.Configs.ConfigRoot.Clusters[0].Name)
-	s.Equal("test_ssl_1WaySSL", SpdCore.Configs.ConfigRoot.Clusters[1].Name)
-	s.Equal("test2_sasl_plaintext", SpdCore.Configs.ConfigRoot.Clusters[2].Name)
-	s.Equal("test3_sasl_plaintext_scram", SpdCore.Configs.ConfigRoot.Clusters[3].Name)
-	s.Equal("test4_confluent_rbac", SpdCore.Configs.ConfigRoot.Clusters[4].Name)
-
-	s.Equal("PLAINTEXT", SpdCore.Configs.ConfigRoot.Clusters[0].Configs[0]["security.protocol"])
-	s.Equal("SSL", SpdCore.Configs.ConfigRoot.Clusters[1].Configs[0]["security.protocol"])
-	s.Equal("SASL_PLAINTEXT", SpdCore.Configs.ConfigRoot.Clusters[2].Configs[0]["security.protocol"])
-	s.Equal("SASL_PLAINTEXT", SpdCore.Configs.ConfigRoot.Clusters[3].Configs[0]["security.protocol"])
-	s.Equal("SASL_PLAINTEXT", SpdCore.Configs.ConfigRoot.Clusters[4].Configs[0]["security.protocol"])
-
-	s.Equal("", SpdCore.Configs.ConfigRoot.Clusters[0].Configs[0]["sasl.mechanism"])
-	s.Equal("", SpdCore.Configs.ConfigRoot.Clusters[1].Configs[0]["sasl.mechanism"])
-	s.Equal("PLAIN", SpdCore.Configs.ConfigRoot.Clusters[2].Configs[0]["sasl.mechanism"])
-	s.Equal("SCRAM-SHA-256", SpdCore.Configs.ConfigRoot.Clusters[3].Configs[0]["sasl.mechanism"])
-	s.Equal("PLAIN", SpdCore.Configs.ConfigRoot.Clusters[4].Configs[0]["sasl.mechanism"])
-
-	s.Equal("kafka_acl", SpdCore.Configs.ConfigRoot.Clusters[0].ACLManager)
-	s.Equal("kafka_acl", SpdCore.Configs.ConfigRoot.Clusters[1].ACLManager)
-	s.Equal("kafka_acl", SpdCore.Configs.ConfigRoot.Clusters[2].ACLManager)
-	s.Equal("kafka_acl", SpdCore.Configs.ConfigRoot.Clusters[3].ACLManager)
-	s.Equal("confluent_mds", SpdCore.Configs.ConfigRoot.Clusters[4].ACLManager)
-
-	s.Equal("sarama", SpdCore.Configs.ConfigRoot.Clusters[0].TopicManager)
-	s.Equal("sarama", SpdCore.Configs.ConfigRoot.Clusters[1].TopicManager)
-	s.Equal("sarama", SpdCore.Configs.ConfigRoot.Clusters[2].TopicManager)
-	s.Equal("sarama", SpdCore.Configs.ConfigRoot.Clusters[3].TopicManager)
-	s.Equal("sarama", SpdCore.Configs.ConfigRoot.Clusters[4].TopicManager)
+	s.Equal(".", s.st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken, "Separator Token Mismatch")
+	s.Equal(false, s.st.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics, "Unknown Topic switch mismatch")
+	s.Equal(true, s.st.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs, "Unknown ACL switch mismatch")
+	s.Equal(RetryConfig{MaxAttempts: 3, InitialInterval: 500 * time.Millisecond, MaxElapsedTime: time.Minute},
+		s.st.Core.Configs.ConfigRoot.ShepherdCoreConfig.Retry, "Retry configuration mismatch")
+
+	s.Equal(5, len(s.st.Core.Configs.ConfigRoot.Clusters), "Defined Cluster count mismatch")
+	s.Equal("dev_plaintext", s.st.Core.Configs.ConfigRoot.Clusters[0].Name)
+	s.Equal("test_ssl_1WaySSL", s.st.Core.Configs.ConfigRoot.Clusters[1].Name)
+	s.Equal("test2_sasl_plaintext", s.st.Core.Configs.ConfigRoot.Clusters[2].Name)
+	s.Equal("test3_sasl_plaintext_scram", s.st.Core.Configs.ConfigRoot.Clusters[3].Name)
+	s.Equal("test4_confluent_rbac", s.st.Core.Configs.ConfigRoot.Clusters[4].Name)
+
+	s.Equal("PLAINTEXT", s.st.Core.Configs.ConfigRoot.Clusters[0].Configs[0]["security.protocol"])
+	s.Equal("SSL", s.st.Core.Configs.ConfigRoot.Clusters[1].Configs[0]["security.protocol"])
+	s.Equal("SASL_PLAINTEXT", s.st.Core.Configs.ConfigRoot.Clusters[2].Configs[0]["security.protocol"])
+	s.Equal("SASL_PLAINTEXT", s.st.Core.Configs.ConfigRoot.Clusters[3].Configs[0]["security.protocol"])
+	s.Equal("SASL_PLAINTEXT", s.st.Core.Configs.ConfigRoot.Clusters[4].Configs[0]["security.protocol"])
+
+	s.Equal("", s.st.Core.Configs.ConfigRoot.Clusters[0].Configs[0]["sasl.mechanism"])
+	s.Equal("", s.st.Core.Configs.ConfigRoot.Clusters[1].Configs[0]["sasl.mechanism"])
+	s.Equal("PLAIN", s.st.Core.Configs.ConfigRoot.Clusters[2].Configs[0]["sasl.mechanism"])
+	s.Equal("SCRAM-SHA-256", s.st.Core.Configs.ConfigRoot.Clusters[3].Configs[0]["sasl.mechanism"])
+	s.Equal("PLAIN", s.st.Core.Configs.ConfigRoot.Clusters[4].Configs[0]["sasl.mechanism"])
+
+	s.Equal("kafka_acl", s.st.Core.Configs.ConfigRoot.Clusters[0].ACLManager)
+	s.Equal("kafka_acl", s.st.Core.Configs.ConfigRoot.Clusters[1].ACLManager)
+	s.Equal("kafka_acl", s.st.Core.Configs.ConfigRoot.Clusters[2].ACLManager)
+	s.Equal("kafka_acl", s.st.Core.Configs.ConfigRoot.Clusters[3].ACLManager)
+	s.Equal("confluent_mds", s.st.Core.Configs.ConfigRoot.Clusters[4].ACLManager)
+
+	s.Equal("sarama", s.st.Core.Configs.ConfigRoot.Clusters[0].TopicManager)
+	s.Equal("sarama", s.st.Core.Configs.ConfigRoot.Clusters[1].TopicManager)
+	s.Equal("sarama", s.st.Core.Configs.ConfigRoot.Clusters[2].TopicManager)
+	s.Equal("sarama", s.st.Core.Configs.ConfigRoot.Clusters[3].TopicManager)
+	s.Equal("sarama", s.st.Core.Configs.ConfigRoot.Clusters[4].TopicManager)
 
 	// Blueprints File Validation
-	s.Equal(4, len(SpdCore.Blueprints.Blueprint.Topic.TopicConfigs))
-	s.Equal("bronze", SpdCore.Blueprints.Blueprint.Topic.TopicConfigs[0].Name)
-	s.Equal("silver", SpdCore.Blueprints.Blueprint.Topic.TopicConfigs[1].Name)
-	s.Equal("gold", SpdCore.Blueprints.Blueprint.Topic.TopicConfigs[2].Name)
-	s.Equal("platinum", SpdCore.Blueprints.Blueprint.Topic.TopicConfigs[3].Name)
-
-	s.Equal(3, len(SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]))
-	s.Equal("2", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["replication.factor"])
-	s.Equal("1", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["min.insync.replicas"])
-	s.Equal("5", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["num.partitions"])
-	s.Equal(1, len(SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Whitelist))
-	s.Equal("partition.count", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Whitelist[0])
-	s.Equal(2, len(SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist))
-	s.Equal("replication.factor", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist[0])
-	s.Equal("min.insync.replicas", SpdCore.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist[1])
-
-	s.Equal(false, SpdCore.Blueprints.Blueprint.Policy.ACLPolicy.SetupACLs)
-	s.Equal("kafka_acl", SpdCore.Blueprints.Blueprint.Policy.ACLPolicy.ACLType)
-	s.Equal(true, SpdCore.Blueprints.Blueprint.Policy.ACLPolicy.OptimizeACLs)
-
-	s.Equal(3, len(SpdCore.Blueprints.Blueprint.CustomEnums))
-	s.Equal("zones", SpdCore.Blueprints.Blueprint.CustomEnums[0].Name)
-	s.Equal(3, len(SpdCore.Blueprints.Blueprint.CustomEnums[0].Values))
-	s.Equal(false, SpdCore.Blueprints.Blueprint.CustomEnums[0].IncludeInTopicName)
-	s.Equal("categories", SpdCore.Blueprints.Blueprint.CustomEnums[1].Name)
-	s.Equal(2, len(SpdCore.Blueprints.Blueprint.CustomEnums[1].Values))
-	s.Equal(true, SpdCore.Blueprints.Blueprint.CustomEnums[1].IncludeInTopicName)
-	s.Equal("logicalEnv", SpdCore.Blueprints.Blueprint.CustomEnums[2].Name)
-	s.Equal(4, len(SpdCore.Blueprints.Blueprint.CustomEnums[2].Values))
-	s.Equal(false, SpdCore.Blueprints.Blueprint.CustomEnums[2].IncludeInTopicName)
+	s.Equal(4, len(s.st.Core.Blueprints.Blueprint.Topic.TopicConfigs))
+	s.Equal("bronze", s.st.Core.Blueprints.Blueprint.Topic.TopicConfigs[0].Name)
+	s.Equal("silver", s.st.Core.Blueprints.Blueprint.Topic.TopicConfigs[1].Name)
+	s.Equal("gold", s.st.Core.Blueprints.Blueprint.Topic.TopicConfigs[2].Name)
+	s.Equal("platinum", s.st.Core.Blueprints.Blueprint.Topic.TopicConfigs[3].Name)
+
+	s.Equal(3, len(s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]))
+	s.Equal("2", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["replication.factor"])
+	s.Equal("1", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["min.insync.replicas"])
+	s.Equal("5", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Defaults[0]["num.partitions"])
+	s.Equal(1, len(s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Whitelist))
+	s.Equal("partition.count", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Whitelist[0])
+	s.Equal(2, len(s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist))
+	s.Equal("replication.factor", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist[0])
+	s.Equal("min.insync.replicas", s.st.Core.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist[1])
+
+	s.Equal(false, s.st.Core.Blueprints.Blueprint.Policy.ACLPolicy.SetupACLs)
+	s.Equal("kafka_acl", s.st.Core.Blueprints.Blueprint.Policy.ACLPolicy.ACLType)
+	s.Equal(true, s.st.Core.Blueprints.Blueprint.Policy.ACLPolicy.OptimizeACLs)
+
+	s.Equal(3, len(s.st.Core.Blueprints.Blueprint.CustomEnums))
+	s.Equal("zones", s.st.Core.Blueprints.Blueprint.CustomEnums[0].Name)
+	s.Equal(3, len(s.st.Core.Blueprints.Blueprint.CustomEnums[0].Values))
+	s.Equal(false, s.st.Core.Blueprints.Blueprint.CustomEnums[0].IncludeInTopicName)
+	s.Equal("categories", s.st.Core.Blueprints.Blueprint.CustomEnums[1].Name)
+	s.Equal(2, len(s.st.Core.Blueprints.Blueprint.CustomEnums[1].Values))
+	s.Equal(true, s.st.Core.Blueprints.Blueprint.CustomEnums[1].IncludeInTopicName)
+	s.Equal("logicalEnv", s.st.Core.Blueprints.Blueprint.CustomEnums[2].Name)
+	s.Equal(4, len(s.st.Core.Blueprints.Blueprint.CustomEnums[2].Values))
+	s.Equal(false, s.st.Core.Blueprints.Blueprint.CustomEnums[2].IncludeInTopicName)
 
 	// Definitions File Validation
 	os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", "./testdata/definitions_0.yaml")
 	os.Setenv("HOSTNAME_REPLACEMENT_TEST", "abhishek.replaced.hostname")
-	SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-	s.Equal(2, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Name))
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers))
-	s.Equal("test.consumer", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers[0].Hostnames[0])
-	s.Equal("abhishek.replaced.hostname", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers[0].Hostnames[1])
-	s.Equal(4, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers))
-	s.Equal("test.producer", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers[2].Hostnames[0])
-	s.Equal("abhishek.replaced.hostname", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers[2].Hostnames[1])
-	s.Equal(2, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors))
-	s.Equal("test.connectors", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors[0].Hostnames[0])
-	s.Equal("abhishek.replaced.hostname", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors[0].Hostnames[1])
-	s.Equal(2, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL))
-	s.Equal("test.ksql", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL[0].Hostnames[0])
-	s.Equal("abhishek.replaced.hostname", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL[0].Hostnames[1])
-	s.Equal(2, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams), "Streams consumer definition parsing error")
-	s.Equal("test.streams", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams[0].Hostnames[0])
-	s.Equal("abhishek.replaced.hostname", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams[0].Hostnames[1])
-	s.Equal("platinum", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].TopicBlueprintEnumRef)
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides))
-	s.Equal(2, len(SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]))
-	s.Equal("3", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["min.insync.replicas"])
-	s.Equal("20", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["test.property"])
-	s.Equal("", SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["test.property.failed"])
-
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.ScopeFlow))
-	s.Equal("team", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].ShortName)
-	s.Equal(3, len(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Values))
-	s.Equal(false, SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].IncludeInTopicName)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].CustomEnumRef)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Clients)
-
-	s.NotEmpty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.ShortName)
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Topics.Name))
-	s.Equal(true, SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.IncludeInTopicName)
-	s.NotEmpty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers)
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers))
-	s.Equal("User:1211", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Principal)
-	s.Equal("hello1211", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Group)
-	s.NotEmpty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Hostnames)
-	s.NotEmpty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Producers)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Connectors)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.KSQL)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Streams)
-
-	s.NotEmpty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child)
-	s.Equal("logicalEnv", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.CustomEnumRef)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.ShortName)
-	s.Equal(false, SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.IncludeInTopicName)
-	s.Empty(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Child)
-	s.Equal(1, len(SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.Name))
-	s.Equal("logicalenvtopictest", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.Name[0])
-	s.Equal("dev", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.IgnoreScope[0])
-	s.Equal("pprd", SpdCore.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.IgnoreScope[1])
+	_, err := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+	s.NoError(err)
+	s.Equal(2, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Name))
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers))
+	s.Equal("test.consumer", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers[0].Hostnames[0])
+	s.Equal("abhishek.replaced.hostname", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Consumers[0].Hostnames[1])
+	s.Equal(4, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers))
+	s.Equal("test.producer", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers[2].Hostnames[0])
+	s.Equal("abhishek.replaced.hostname", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Producers[2].Hostnames[1])
+	s.Equal(2, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors))
+	s.Equal("test.connectors", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors[0].Hostnames[0])
+	s.Equal("abhishek.replaced.hostname", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Connectors[0].Hostnames[1])
+	s.Equal(2, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL))
+	s.Equal("test.ksql", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL[0].Hostnames[0])
+	s.Equal("abhishek.replaced.hostname", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.KSQL[0].Hostnames[1])
+	s.Equal(2, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams), "Streams consumer definition parsing error")
+	s.Equal("test.streams", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams[0].Hostnames[0])
+	s.Equal("abhishek.replaced.hostname", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].Clients.Streams[0].Hostnames[1])
+	s.Equal("platinum", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].TopicBlueprintEnumRef)
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides))
+	s.Equal(2, len(s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]))
+	s.Equal("3", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["min.insync.replicas"])
+	s.Equal("20", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["test.property"])
+	s.Equal("", s.st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics[0].ConfigOverrides[0]["test.property.failed"])
+
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.ScopeFlow))
+	s.Equal("team", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].ShortName)
+	s.Equal(3, len(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Values))
+	s.Equal(false, s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].IncludeInTopicName)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].CustomEnumRef)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Clients)
+
+	s.NotEmpty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.ShortName)
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Topics.Name))
+	s.Equal(true, s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.IncludeInTopicName)
+	s.NotEmpty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers)
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers))
+	s.Equal("User:1211", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Principal)
+	s.Equal("hello1211", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Group)
+	s.NotEmpty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Consumers[0].Hostnames)
+	s.NotEmpty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Producers)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Connectors)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.KSQL)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Clients.Streams)
+
+	s.NotEmpty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child)
+	s.Equal("logicalEnv", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.CustomEnumRef)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.ShortName)
+	s.Equal(false, s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.IncludeInTopicName)
+	s.Empty(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Child)
+	s.Equal(1, len(s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.Name))
+	s.Equal("logicalenvtopictest", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.Name[0])
+	s.Equal("dev", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.IgnoreScope[0])
+	s.Equal("pprd", s.st.Core.Definitions.DefinitionRoot.ScopeFlow[0].Child.Child.Topics.IgnoreScope[1])
+}
+
+func (s *StackSuite) TestStackSuite_RetryConfigValidation() {
+	s.NoError(RetryConfig{}.validate())
+	jitter, noJitter, tooMuchJitter := 1.0, 0.0, 1.5
+	s.NoError(RetryConfig{MaxAttempts: 3, Multiplier: 1.5, Jitter: &jitter}.validate())
+	s.NoError(RetryConfig{Jitter: &noJitter}.validate())
+
+	for _, c := range []RetryConfig{
+		{MaxAttempts: -1},
+		{InitialInterval: -time.Second},
+		{MaxElapsedTime: -time.Second},
+		{Multiplier: 0.5},
+		{Jitter: &tooMuchJitter},
+	} {
+		s.True(errors.Is(c.validate(), ErrConfigInvalid), "Retry configuration %+v should be invalid", c)
+	}
+
+	s.NoError(ReassignmentConfig{ThrottleBytesPerSec: 1024, PollInterval: time.Second}.validate())
+	s.True(errors.Is(ReassignmentConfig{ThrottleBytesPerSec: -1}.validate(), ErrConfigInvalid))
+	s.True(errors.Is(ReassignmentConfig{PollInterval: -time.Second}.validate(), ErrConfigInvalid))
 }
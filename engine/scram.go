@@ -0,0 +1,384 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+	"github.com/xdg/scram"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	defaultScramStateFile  string = "shepherd_scram_state.json"
+	defaultScramIterations int    = 4096
+	// The plan value listed for the users whose password is set again with the same mechanisms.
+	ScramPasswordKey string = "password"
+)
+
+var scramMechanisms = []string{"SCRAM-SHA-256", "SCRAM-SHA-512"}
+
+/*
+	Manages the SCRAM credentials of the user principals of the definitions. The users managed are the ones
+	listed in Users, or the ones with a password if none are listed, so the principals authenticating another
+	way (like mTLS or OAuth) are left out. Every user gets a credential for each of the Mechanisms
+	(SCRAM-SHA-256, SCRAM-SHA-512) with Iterations (4096 if not provided), and the credentials are only managed
+	if at least one mechanism is provided. The passwords are the ones in Passwords, which can be ENV Variable
+	references, or else the ones in SecretsFile (a YAML map of the user names to their passwords). The brokers
+	do not reveal anything about the passwords, so a fingerprint of every password set is kept in StateFile,
+	and the credentials are set again when the password changes. The Protected users (along with the user
+	Shepherd connects with) are never deleted.
+*/
+type ScramCredentialsConfig struct {
+	Mechanisms  []string  `yaml:"mechanisms,flow"`
+	Iterations  int       `yaml:"iterations"`
+	Users       []string  `yaml:"users,flow"`
+	Passwords   []NVPairs `yaml:"passwords,flow"`
+	SecretsFile string    `yaml:"secretsFile"`
+	Protected   []string  `yaml:"protected,flow"`
+	StateFile   string    `yaml:"stateFile"`
+}
+
+func (c *ScramCredentialsConfig) readValuesFromENV(r *envResolver) {
+	for i, v := range c.Mechanisms {
+		c.Mechanisms[i] = strings.ToUpper(strings.TrimSpace(r.replace(v, "")))
+	}
+	for i, v := range c.Users {
+		c.Users[i] = r.replace(v, "")
+	}
+	c.Passwords = streamlineNVPairs(c.Passwords)
+	for i := 0; i < len(c.Passwords); i++ {
+		c.Passwords[i].readValuesFromENV(r)
+	}
+	c.SecretsFile = r.replace(c.SecretsFile, "")
+	c.StateFile = r.replace(c.StateFile, "")
+}
+
+func (c ScramCredentialsConfig) validate() error {
+	for _, v := range c.Mechanisms {
+		if !isOneOf(v, scramMechanisms) {
+			return configError("core.scramCredentials.mechanisms need to be one of %s. Mechanism provided: %q", strings.Join(scramMechanisms, ", "), v)
+		}
+	}
+	// The limits enforced by the brokers.
+	if c.Iterations != 0 && (c.Iterations < 4096 || c.Iterations > 16384) {
+		return configError("core.scramCredentials.iterations should be between 4096 and 16384. Provided: %d", c.Iterations)
+	}
+	return nil
+}
+
+func (c ScramCredentialsConfig) Enabled() bool {
+	return len(c.Mechanisms) != 0
+}
+
+func (c ScramCredentialsConfig) iterations() int {
+	if c.Iterations == 0 {
+		return defaultScramIterations
+	}
+	return c.Iterations
+}
+
+// The passwords of the users, the ones in Passwords taking precedence over the ones in SecretsFile.
+func (c ScramCredentialsConfig) passwords() (NVPairs, error) {
+	ret := NVPairs{}
+	if c.SecretsFile != "" {
+		b, err := ioutil.ReadFile(c.SecretsFile)
+		if err != nil {
+			return nil, NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot read the SCRAM secrets file %s", c.SecretsFile), err)
+		}
+		if err := yaml.Unmarshal(b, &ret); err != nil {
+			return nil, NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot parse the SCRAM secrets file %s", c.SecretsFile), err)
+		}
+		r := &envResolver{}
+		ret.readValuesFromENV(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+	for _, v := range c.Passwords {
+		for user, password := range v {
+			ret[user] = password
+		}
+	}
+	return ret, nil
+}
+
+/*
+	The users that are never deleted from the cluster: the Protected ones, along with the user Shepherd
+	connects to the cluster with.
+*/
+func (c ConfigRoot) ProtectedScramUsers(clusterName string) []string {
+	ret := append([]string{}, c.ShepherdCoreConfig.ScramCredentials.Protected...)
+	for _, cluster := range c.Clusters {
+		if cluster.Name != clusterName || len(cluster.Configs) == 0 {
+			continue
+		}
+		if jaas := cluster.Configs[0]["sasl.jaas.config"]; strings.Contains(jaas, "username") {
+			ret = append(ret, ksmisc.FindSASLValues(jaas, "username"))
+		}
+	}
+	return ret
+}
+
+// The SCRAM credential expected for a user, with the iterations (as the value) of every mechanism.
+type ScramCredential struct {
+	Password   string
+	Mechanisms NVPairs
+}
+
+// ScramCredentialMapping holds the SCRAM credentials expected for the user principals, keyed by the user name.
+type ScramCredentialMapping map[string]ScramCredential
+
+/*
+	ScramUserMapping holds values for the mechanisms of the SCRAM users, keyed by the user name. The values
+	are either the iterations set in the cluster or the password fingerprints of the state file.
+*/
+type ScramUserMapping map[string]NVPairs
+
+// Collects the users of the principals of the client definitions, except the wildcard one.
+func scramUsers(in ClientDefinition, users map[string]bool) {
+	principals := []string{}
+	for _, v := range in.Producers {
+		principals = append(principals, v.Principal)
+	}
+	for _, v := range in.Consumers {
+		principals = append(principals, v.Principal)
+	}
+	for _, v := range in.Connectors {
+		principals = append(principals, v.Principal)
+	}
+	for _, v := range in.Streams {
+		principals = append(principals, v.Principal)
+	}
+	for _, v := range in.KSQL {
+		principals = append(principals, v.Principal)
+	}
+	for _, v := range principals {
+		if user := strings.TrimPrefix(v, "User:"); user != v && user != "" && user != "*" {
+			users[user] = true
+		}
+	}
+}
+
+/*
+	Adds the credentials of the users selected for SCRAM management (see ScramCredentialsConfig). Every user
+	listed in Users needs a password in the configurations, the other users are skipped if they have none.
+*/
+func (c ScramCredentialMapping) addScramCredentials(users map[string]bool, config ScramCredentialsConfig) error {
+	passwords, err := config.passwords()
+	if err != nil {
+		return err
+	}
+	missing := []string{}
+	for user := range users {
+		if len(config.Users) != 0 && !isOneOf(user, config.Users) {
+			continue
+		}
+		password, found := passwords[user]
+		if !found || password == "" {
+			if len(config.Users) != 0 {
+				missing = append(missing, user)
+			} else {
+				logger.Debugw("User has no SCRAM password and its SCRAM credentials will not be managed.",
+					"User", user)
+			}
+			continue
+		}
+		mechanisms := NVPairs{}
+		for _, m := range config.Mechanisms {
+			mechanisms[m] = strconv.Itoa(config.iterations())
+		}
+		c[user] = ScramCredential{Password: password, Mechanisms: mechanisms}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return configError("SCRAM credentials are managed for some users without a password. Users: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+/*
+	The fingerprint of a password, salted with the salt provided, which is kept along with it. The fingerprint
+	is the SCRAM-SHA-256 StoredKey of the password, so it is as costly to brute force as the credentials kept
+	by the brokers.
+*/
+func ScramPasswordFingerprint(salt []byte, password string) string {
+	// The brokers do not normalize the passwords either.
+	client, _ := scram.SHA256.NewClientUnprepped("", password, "")
+	credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: string(salt), Iters: defaultScramIterations})
+	return base64.StdEncoding.EncodeToString(salt) + ":" + hex.EncodeToString(credentials.StoredKey)
+}
+
+func scramPasswordMatches(fingerprint string, password string) bool {
+	parts := strings.SplitN(fingerprint, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(ScramPasswordFingerprint(salt, password)), []byte(fingerprint))
+}
+
+/*
+	Compares the SCRAM credentials set in the cluster to the expected ones and returns the changes needed to
+	align them. The credentials are set again if their iterations differ, or if the password differs from (or
+	is not in) the tracked fingerprints, in which case the password is listed as rotated. The mechanisms that
+	are set in the cluster but not expected are removed with the update. The users that are not expected at
+	all are deleted only if executeDeleteFlow is set, and never if they are protected.
+*/
+func PlanScramChanges(clusterName string, expected ScramCredentialMapping, provisioned ScramUserMapping, tracked ScramUserMapping,
+	protected []string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) []PlanChange {
+	ret := []PlanChange{}
+	for user, v := range expected {
+		current, found := provisioned[user]
+		switch {
+		case !found && executeCreateFlow:
+			ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_CREATE, ResourceType: PlanResourceType_SCRAM,
+				Name: user, After: v.Mechanisms})
+		case found && executeModifyFlow:
+			before, after := NVPairs{}, NVPairs{}
+			for m, iterations := range v.Mechanisms {
+				rotated := !scramPasswordMatches(tracked[user][m], v.Password)
+				if current[m] != iterations || rotated {
+					before[m], after[m] = current[m], iterations
+				}
+				if rotated {
+					after[ScramPasswordKey] = "rotated"
+				}
+			}
+			for m, iterations := range current {
+				if _, found := v.Mechanisms[m]; !found {
+					before[m], after[m] = iterations, ""
+				}
+			}
+			if len(after) != 0 {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_UPDATE, ResourceType: PlanResourceType_SCRAM,
+					Name: user, Before: before, After: after})
+			}
+		}
+	}
+	if executeDeleteFlow {
+		for user, v := range provisioned {
+			if _, found := expected[user]; found {
+				continue
+			}
+			if isOneOf(user, protected) {
+				logger.Infow("SCRAM user is protected and will not be deleted.",
+					"Cluster Name", clusterName,
+					"User", user)
+				continue
+			}
+			ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_DELETE, ResourceType: PlanResourceType_SCRAM,
+				Name: user, Before: v})
+		}
+	}
+	return ret
+}
+
+// Fingerprint of the SCRAM credentials observed in a cluster, which only consist of the mechanisms and their iterations.
+func ScramStateFingerprint(in ScramUserMapping) string {
+	type credential struct {
+		User       string  `json:"user"`
+		Mechanisms NVPairs `json:"mechanisms"`
+	}
+	state := []credential{}
+	for k, v := range in {
+		state = append(state, credential{User: k, Mechanisms: v})
+	}
+	sort.Slice(state, func(i, j int) bool {
+		return state[i].User < state[j].User
+	})
+	b, _ := json.Marshal(state)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+var scramStateLock sync.Mutex
+
+func (c ScramCredentialsConfig) stateFile() string {
+	if c.StateFile == "" {
+		return defaultScramStateFile
+	}
+	return c.StateFile
+}
+
+func (c ScramCredentialsConfig) readScramState() (map[string]ScramUserMapping, error) {
+	path := c.stateFile()
+	state := map[string]ScramUserMapping{}
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &state); err != nil {
+			return nil, NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot parse the SCRAM state file %s", path), err)
+		}
+	case !os.IsNotExist(err):
+		return nil, NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot read the SCRAM state file %s", path), err)
+	}
+	return state, nil
+}
+
+// Returns the password fingerprints tracked for the SCRAM users of the cluster.
+func (c ScramCredentialsConfig) TrackedScramCredentials(clusterName string) (ScramUserMapping, error) {
+	scramStateLock.Lock()
+	defer scramStateLock.Unlock()
+	state, err := c.readScramState()
+	if err != nil {
+		return nil, err
+	}
+	if state[clusterName] == nil {
+		return ScramUserMapping{}, nil
+	}
+	return state[clusterName], nil
+}
+
+/*
+	Saves the password fingerprints of the mechanisms set for a user of the cluster to StateFile. The mechanisms
+	with an empty fingerprint have been removed and are not tracked anymore.
+*/
+func (c ScramCredentialsConfig) TrackScramCredentials(clusterName string, user string, fingerprints NVPairs) error {
+	scramStateLock.Lock()
+	defer scramStateLock.Unlock()
+	state, err := c.readScramState()
+	if err != nil {
+		return err
+	}
+	if state[clusterName] == nil {
+		state[clusterName] = ScramUserMapping{}
+	}
+	if state[clusterName][user] == nil {
+		state[clusterName][user] = NVPairs{}
+	}
+	for m, v := range fingerprints {
+		if v == "" {
+			delete(state[clusterName][user], m)
+		} else {
+			state[clusterName][user][m] = v
+		}
+	}
+	if len(state[clusterName][user]) == 0 {
+		delete(state[clusterName], user)
+	}
+
+	path := c.stateFile()
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		// The fingerprints are only readable by the owner, like the passwords they are computed from.
+		err = ioutil.WriteFile(path, b, 0600)
+	}
+	if err != nil {
+		return NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot save the SCRAM state file %s", path), err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func (s *StackSuite) TestStackSuite_Scram_Config() {
+	s.NoError(ScramCredentialsConfig{}.validate())
+	s.NoError(ScramCredentialsConfig{Mechanisms: []string{"SCRAM-SHA-256", "SCRAM-SHA-512"}, Iterations: 8192}.validate())
+	for _, c := range []ScramCredentialsConfig{
+		{Mechanisms: []string{"PLAIN"}},
+		{Mechanisms: []string{"SCRAM-SHA-512"}, Iterations: 1024},
+		{Mechanisms: []string{"SCRAM-SHA-512"}, Iterations: 20000},
+	} {
+		s.True(errors.Is(c.validate(), ErrConfigInvalid), "SCRAM configuration %+v should be invalid", c)
+	}
+
+	c := ConfigRoot{
+		ShepherdCoreConfig: ShepherdCoreConfig{ScramCredentials: ScramCredentialsConfig{Protected: []string{"admin"}}},
+		Clusters: []ShepherdCluster{
+			{Name: "a", Configs: []NVPairs{{"sasl.jaas.config": `org.apache.kafka.common.security.scram.ScramLoginModule required username="kafka" password="kafka-pass"`}}},
+			{Name: "b", Configs: []NVPairs{{"security.protocol": "PLAINTEXT"}}},
+		},
+	}
+	s.ElementsMatch([]string{"admin", "kafka"}, c.ProtectedScramUsers("a"))
+	s.ElementsMatch([]string{"admin"}, c.ProtectedScramUsers("b"))
+}
+
+func (s *StackSuite) TestStackSuite_Scram_Credentials() {
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	secrets := filepath.Join(dir, "secrets.yaml")
+	s.NoError(ioutil.WriteFile(secrets, []byte("alice: from-file\nbob: bob-secret\n"), 0600))
+
+	users := map[string]bool{}
+	scramUsers(ClientDefinition{
+		Producers: []ProducerDefinition{{Principal: "User:alice"}, {Principal: "User:*"}},
+		Consumers: []ConsumerDefinition{{Principal: "User:bob"}, {Principal: "Group:readers"}},
+	}, users)
+	s.Equal(map[string]bool{"alice": true, "bob": true}, users)
+
+	config := ScramCredentialsConfig{Mechanisms: []string{"SCRAM-SHA-512"}, Passwords: []NVPairs{{"alice": "alice-secret"}}, SecretsFile: secrets}
+	m := ScramCredentialMapping{}
+	s.NoError(m.addScramCredentials(users, config))
+	s.Equal(ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: NVPairs{"SCRAM-SHA-512": "4096"}},
+		"bob":   {Password: "bob-secret", Mechanisms: NVPairs{"SCRAM-SHA-512": "4096"}},
+	}, m)
+
+	// carol authenticates another way, so she has no password and her credentials are not managed.
+	users["carol"] = true
+	m = ScramCredentialMapping{}
+	s.NoError(m.addScramCredentials(users, config))
+	s.Len(m, 2)
+	s.NotContains(m, "carol")
+
+	// The users listed need a password, and they are the only ones managed.
+	config.Users = []string{"alice", "carol"}
+	err = ScramCredentialMapping{}.addScramCredentials(users, config)
+	s.True(errors.Is(err, ErrConfigInvalid))
+	s.Contains(err.Error(), "carol")
+	config.Users = []string{"alice"}
+	m = ScramCredentialMapping{}
+	s.NoError(m.addScramCredentials(users, config))
+	s.Len(m, 1)
+	s.Contains(m, "alice")
+
+	config.SecretsFile = filepath.Join(dir, "missing.yaml")
+	s.True(errors.Is(ScramCredentialMapping{}.addScramCredentials(users, config), ErrConfigInvalid))
+}
+
+func (s *StackSuite) TestStackSuite_Scram_PlanChanges() {
+	salt := []byte("salt")
+	expected := ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: NVPairs{"SCRAM-SHA-512": "4096"}},
+		"bob":   {Password: "bob-new", Mechanisms: NVPairs{"SCRAM-SHA-512": "4096"}},
+		"carol": {Password: "carol-secret", Mechanisms: NVPairs{"SCRAM-SHA-512": "8192"}},
+		"dave":  {Password: "dave-secret", Mechanisms: NVPairs{"SCRAM-SHA-512": "4096"}},
+	}
+	provisioned := ScramUserMapping{
+		"alice": {"SCRAM-SHA-512": "4096"},
+		"bob":   {"SCRAM-SHA-512": "4096"},
+		"carol": {"SCRAM-SHA-512": "4096", "SCRAM-SHA-256": "4096"},
+		"kafka": {"SCRAM-SHA-256": "4096"},
+		"old":   {"SCRAM-SHA-256": "4096"},
+	}
+	tracked := ScramUserMapping{
+		"alice": {"SCRAM-SHA-512": ScramPasswordFingerprint(salt, "alice-secret")},
+		"bob":   {"SCRAM-SHA-512": ScramPasswordFingerprint(salt, "bob-old")},
+		"carol": {"SCRAM-SHA-512": ScramPasswordFingerprint(salt, "carol-secret")},
+	}
+
+	changes := PlanScramChanges("c1", expected, provisioned, tracked, []string{"kafka"}, true, true, false)
+	s.ElementsMatch([]PlanChange{
+		{Cluster: "c1", Action: PlanAction_CREATE, ResourceType: PlanResourceType_SCRAM, Name: "dave",
+			After: NVPairs{"SCRAM-SHA-512": "4096"}},
+		{Cluster: "c1", Action: PlanAction_UPDATE, ResourceType: PlanResourceType_SCRAM, Name: "bob",
+			Before: NVPairs{"SCRAM-SHA-512": "4096"}, After: NVPairs{"SCRAM-SHA-512": "4096", ScramPasswordKey: "rotated"}},
+		{Cluster: "c1", Action: PlanAction_UPDATE, ResourceType: PlanResourceType_SCRAM, Name: "carol",
+			Before: NVPairs{"SCRAM-SHA-512": "4096", "SCRAM-SHA-256": "4096"}, After: NVPairs{"SCRAM-SHA-512": "8192", "SCRAM-SHA-256": ""}},
+	}, changes)
+
+	// The unknown users are only deleted with the delete flow, and never if they are protected.
+	changes = PlanScramChanges("c1", expected, provisioned, tracked, []string{"kafka"}, false, false, true)
+	s.Equal([]PlanChange{
+		{Cluster: "c1", Action: PlanAction_DELETE, ResourceType: PlanResourceType_SCRAM, Name: "old", Before: NVPairs{"SCRAM-SHA-256": "4096"}},
+	}, changes)
+
+	s.Equal(ScramStateFingerprint(ScramUserMapping{"a": {"SCRAM-SHA-512": "4096"}, "b": {}}),
+		ScramStateFingerprint(ScramUserMapping{"b": {}, "a": {"SCRAM-SHA-512": "4096"}}))
+	s.NotEqual(ScramStateFingerprint(ScramUserMapping{"a": {"SCRAM-SHA-512": "4096"}}),
+		ScramStateFingerprint(ScramUserMapping{"a": {"SCRAM-SHA-512": "8192"}}))
+}
+
+func (s *StackSuite) TestStackSuite_Scram_State() {
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	c := ScramCredentialsConfig{StateFile: filepath.Join(dir, "state.json")}
+
+	tracked, err := c.TrackedScramCredentials("c1")
+	s.NoError(err)
+	s.Empty(tracked)
+
+	fingerprint := ScramPasswordFingerprint([]byte("salt"), "secret")
+	s.True(scramPasswordMatches(fingerprint, "secret"))
+	s.False(scramPasswordMatches(fingerprint, "other"))
+	s.False(scramPasswordMatches("", "secret"))
+
+	s.NoError(c.TrackScramCredentials("c1", "alice", NVPairs{"SCRAM-SHA-256": fingerprint, "SCRAM-SHA-512": fingerprint}))
+	s.NoError(c.TrackScramCredentials("c1", "alice", NVPairs{"SCRAM-SHA-256": ""}))
+	s.NoError(c.TrackScramCredentials("c1", "bob", NVPairs{"SCRAM-SHA-512": fingerprint}))
+	s.NoError(c.TrackScramCredentials("c1", "bob", NVPairs{"SCRAM-SHA-512": ""}))
+	tracked, err = c.TrackedScramCredentials("c1")
+	s.NoError(err)
+	s.Equal(ScramUserMapping{"alice": {"SCRAM-SHA-512": fingerprint}}, tracked)
+	tracked, err = c.TrackedScramCredentials("c2")
+	s.NoError(err)
+	s.Empty(tracked)
+
+	info, err := os.Stat(c.StateFile)
+	s.NoError(err)
+	s.Equal(os.FileMode(0600), info.Mode().Perm())
+}
@@ -10,26 +10,53 @@ import (
 ///////// User Topic Maping and Topic Configuration Mapping Generator /////////
 ///////////////////////////////////////////////////////////////////////////////
 
-func GenerateMappings() {
+func (st *State) GenerateMappings() error {
+	schemaFiles := make(map[string]string)
+	ksqlFiles := make(map[string][]KSQLStatement)
+	tiers := quotaTiers(st.Core.Blueprints.Blueprint)
+	users := make(map[string]bool)
 	// Adhoc Topic Structure Parsing and table setup
-	for _, v := range SpdCore.Definitions.DefinitionRoot.AdhocConfigs.Topics {
+	for _, v := range st.Core.Definitions.DefinitionRoot.AdhocConfigs.Topics {
 		for _, tName := range v.Name {
-			v.Clients.addClientToUTM(tName)
+			v.Clients.addClientToUTM(&st.Maps.utm, tName)
+			if err := st.Maps.Subjects.addTopicSchemas(tName, v.Schemas, schemaFiles); err != nil {
+				return err
+			}
+		}
+		if err := st.Maps.Connectors.addConnectorDefinitions(v.Clients.Connectors); err != nil {
+			return err
+		}
+		if err := st.Maps.KSQL.addKSQLDefinitions(v.Clients.KSQL, ksqlFiles); err != nil {
+			return err
+		}
+		if err := st.Maps.Quotas.addClientQuotas(v.Clients, tiers); err != nil {
+			return err
 		}
+		scramUsers(v.Clients, users)
 		// v.Clients.addHostnamesToUTM(&ConfMaps.utm)
-		ConfMaps.TCM.addDataToTopicConfigMapping(&SpdCore, &v, v.Name)
+		st.Maps.TCM.addDataToTopicConfigMapping(&st.Core, &v, v.Name)
 	}
 
-	for _, v := range SpdCore.Definitions.DefinitionRoot.ScopeFlow {
+	for _, v := range st.Core.Definitions.DefinitionRoot.ScopeFlow {
 		iter := 0
 		values := [][]string{}
 		val1, cont, snd := []string{}, true, &v
-		sep := SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken
+		sep := st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken
 		for cont {
 			currTopics := append(snd.Topics.Name, "*")
 			currClients := snd.Clients
 			currFilters := snd.Topics.IgnoreScope
-			val1, cont, snd = snd.getTokensForThisLevel(iter, &SpdCore.Blueprints.Blueprint)
+			if err := st.Maps.Connectors.addConnectorDefinitions(currClients.Connectors); err != nil {
+				return err
+			}
+			if err := st.Maps.KSQL.addKSQLDefinitions(currClients.KSQL, ksqlFiles); err != nil {
+				return err
+			}
+			if err := st.Maps.Quotas.addClientQuotas(currClients, tiers); err != nil {
+				return err
+			}
+			scramUsers(currClients, users)
+			val1, cont, snd = snd.getTokensForThisLevel(iter, &st.Core.Blueprints.Blueprint)
 			if !ksmisc.IsZero1DSlice(val1) {
 				values = append(values, val1)
 			}
@@ -45,15 +72,28 @@ func GenerateMappings() {
 				// Ignore topic combinations with the filterscope at that level from being added to the utm list
 				if !ksmisc.ExistsInString(temp, currFilters, ksmisc.RemoveValuesFromSlice(currTopics, "*"), sep) {
 					// fmt.Println("Inside the filter for *. Topic Name:", temp)
-					currClients.addClientToUTM(temp)
+					currClients.addClientToUTM(&st.Maps.utm, temp)
 					if !strings.HasSuffix(temp, ".*") {
-						ConfMaps.TCM.addDataToTopicConfigMapping(&SpdCore, &v.Topics, []string{temp})
+						st.Maps.TCM.addDataToTopicConfigMapping(&st.Core, &v.Topics, []string{temp})
+						if err := st.Maps.Subjects.addTopicSchemas(temp, v.Topics.Schemas, schemaFiles); err != nil {
+							return err
+						}
 					}
 				}
 			}
 		}
 	}
-	SpdCore.addDataToClusterConfigMapping(&ConfMaps.CCM)
+	st.Maps.Connectors.warnUnknownConnectClusters(st.Core.Configs.ConfigRoot.Clusters)
+	st.Maps.KSQL.warnUnknownKSQLClusters(st.Core.Configs.ConfigRoot.Clusters)
+	if err := st.Maps.KSQL.orderStatements(); err != nil {
+		return err
+	}
+	if scram := st.Core.Configs.ConfigRoot.ShepherdCoreConfig.ScramCredentials; scram.Enabled() {
+		if err := st.Maps.ScramCredentials.addScramCredentials(users, scram); err != nil {
+			return err
+		}
+	}
+	return st.Core.addDataToClusterConfigMapping(&st.Maps.CCM)
 }
 
 func (sd ScopeDefinition) getTokensForThisLevel(level int, b *BlueprintRoot) ([]string, bool, *ScopeDefinition) {
@@ -83,22 +123,24 @@ func (sd ScopeDefinition) getTokensForThisLevel(level int, b *BlueprintRoot) ([]
 	return ret, sd.Child != nil, sd.Child
 }
 
-func (c ClientDefinition) addClientToUTM(topic string) {
+func (c ClientDefinition) addClientToUTM(utm *UserTopicMapping, topic string) {
 	for _, v := range c.Consumers {
 		addlData := make(NVPairs)
-		ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_CONSUMER, v.Group, topic, v.Hostnames, addlData)
+		utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_CONSUMER, v.Group, topic, v.Hostnames, addlData)
 		// if v.Group != "" {
 		// 	ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_CONSUMER_GROUP, v.Group, topic, v.Hostnames, addlData)
 		// }
 	}
 	for _, v := range c.Producers {
 		addlData := make(NVPairs)
-		ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER, v.Group, topic, v.Hostnames, addlData)
-		if v.TransactionalID {
-			ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_TRANSACTIONAL_PRODUCER, v.Group, topic, v.Hostnames, addlData)
+		utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER, v.Group, topic, v.Hostnames, addlData)
+		// The transactional id takes the place of the group, which is kept in the additional data for the group ACLs.
+		for _, txnID := range v.TransactionalIDs {
+			utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_TRANSACTIONAL_PRODUCER, txnID, topic, v.Hostnames,
+				NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): v.Group})
 		}
 		if v.EnableIdempotence {
-			ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER_IDEMPOTENCE, v.Group, topic, v.Hostnames, addlData)
+			utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER_IDEMPOTENCE, v.Group, topic, v.Hostnames, addlData)
 		}
 	}
 	for _, v := range c.Connectors {
@@ -106,7 +148,7 @@ func (c ClientDefinition) addClientToUTM(topic string) {
 		addlData[KafkaResourceType_CONNECTOR.GetACLResourceString()] = v.ConnectorName
 		addlData[KafkaResourceType_CONNECT_CLUSTER.GetACLResourceString()] = v.ClusterNameRef
 		addlData[KafkaResourceType_CLUSTER.GetACLResourceString()] = "kafka-cluster"
-		ConfMaps.utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.ClusterNameRef, topic, v.Hostnames, addlData)
+		utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.ClusterNameRef, topic, v.Hostnames, addlData)
 		// v.addClientToUTM(utm, topic)
 	}
 	for _, v := range c.Streams {
@@ -119,14 +161,20 @@ func (c ClientDefinition) addClientToUTM(topic string) {
 		// }
 		// ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_TRANSACTIONAL_PRODUCER, v.Group, topic, v.Hostnames)
 		// ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_PRODUCER_IDEMPOTENCE, v.Group, topic, v.Hostnames)
-		ConfMaps.utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.Group, topic, v.Hostnames, addlData)
+		utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.Group, topic, v.Hostnames, addlData)
 	}
 	for _, v := range c.KSQL {
 		addlData := make(NVPairs)
 		addlData[KafkaResourceType_KSQL_CLUSTER.GetACLResourceString()] = v.ClusterNameRef
-		ConfMaps.utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.ClusterNameRef, topic, v.Hostnames, addlData)
+		utm.addToUserTopicMapping(v.Principal, v.getTypeValue(), v.ClusterNameRef, topic, v.Hostnames, addlData)
 		// ConfMaps.utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_KSQL, v.ClusterNameRef, topic, v.Hostnames, addlData)
 	}
+	// The denied operation takes the place of the group, so every operation denied to a principal is a mapping of its own.
+	for _, v := range c.Deny {
+		for _, op := range v.Operations {
+			utm.addToUserTopicMapping(v.Principal, ShepherdOperationType_DENY, op, topic, v.Hostnames, make(NVPairs))
+		}
+	}
 }
 
 func (c ConnectorDefinition) getTypeValue() ShepherdOperationType {
@@ -244,8 +292,8 @@ func (in *NVPairs) overrideMergeMaps(temp []NVPairs, whitelist []string, blackli
 }
 
 func (sc *ShepherdCore) getBlueprintProps(blueprintName string) NVPairs {
-	if blueprintMap == nil {
-		blueprintMap = make(map[string]NVPairs)
+	if sc.blueprintMap == nil {
+		sc.blueprintMap = make(map[string]NVPairs)
 		for _, v := range sc.Blueprints.Blueprint.Topic.TopicConfigs {
 			temp := NVPairs{}
 			// Get the default values configured in Topic Blueprints Defaults as those act as our baseline
@@ -254,17 +302,20 @@ func (sc *ShepherdCore) getBlueprintProps(blueprintName string) NVPairs {
 			temp.overrideMergeMaps(v.Overrides,
 				sc.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Whitelist,
 				sc.Blueprints.Blueprint.Policy.TopicPolicy.Overrides.Blacklist)
-			blueprintMap[strings.ToLower(strings.TrimSpace(v.Name))] = temp
+			sc.blueprintMap[strings.ToLower(strings.TrimSpace(v.Name))] = temp
 		}
 		// fmt.Println("Blueprint Topic Plan Map:", blueprintMap)
 	}
-	return blueprintMap[strings.ToLower(strings.TrimSpace(blueprintName))]
+	return sc.blueprintMap[strings.ToLower(strings.TrimSpace(blueprintName))]
 }
 
-func (sc *ShepherdCore) addDataToClusterConfigMapping(ccm *ClusterConfigMapping) {
+func (sc *ShepherdCore) addDataToClusterConfigMapping(ccm *ClusterConfigMapping) error {
 	for _, cluster := range sc.Configs.ConfigRoot.Clusters {
 		if cluster.IsEnabled {
-			sp, sc, am := cluster.understandClusterTopology()
+			sp, sc, am, err := cluster.understandClusterTopology()
+			if err != nil {
+				return err
+			}
 			value := ClusterConfigMappingValue{
 				IsActive:                false,
 				ClientID:                cluster.ClientID,
@@ -280,6 +331,7 @@ func (sc *ShepherdCore) addDataToClusterConfigMapping(ccm *ClusterConfigMapping)
 			(*ccm)[ClusterConfigMappingKey{IsEnabled: cluster.IsEnabled, Name: cluster.Name}] = value
 		}
 	}
+	return nil
 }
 
 /*
@@ -288,7 +340,7 @@ func (sc *ShepherdCore) addDataToClusterConfigMapping(ccm *ClusterConfigMapping)
 	leveraging the properties provided. The two properties it uses is `security.protocol` and the
 	`sasl.mechanism` to parse and understand the security mechanism. Still is a work in progress though.
 */
-func (sc *ShepherdCluster) understandClusterTopology() (ClusterSecurityProtocol, ClusterSASLMechanism, bool) {
+func (sc *ShepherdCluster) understandClusterTopology() (ClusterSecurityProtocol, ClusterSASLMechanism, bool, error) {
 	var sp ClusterSecurityProtocol
 	var am bool = true
 	// Figure Out the Security Protocol
@@ -312,10 +364,8 @@ func (sc *ShepherdCluster) understandClusterTopology() (ClusterSecurityProtocol,
 			am = false
 		}
 	default:
-		sp = ClusterSecurityProtocol_UNKNOWN
-		logger.Fatalw("Unknown security mode supplied for Cluster Config",
-			"Cluster Name", sc.Name,
-			"Cluster Security Protocol Provided", p)
+		return ClusterSecurityProtocol_UNKNOWN, ClusterSASLMechanism_UNKNOWN, false,
+			configError("Unknown security mode supplied for Cluster Config. Cluster Name: %s, Cluster Security Protocol Provided: %q", sc.Name, p)
 	}
 
 	var sm ClusterSASLMechanism = ClusterSASLMechanism_UNKNOWN
@@ -341,5 +391,5 @@ func (sc *ShepherdCluster) understandClusterTopology() (ClusterSecurityProtocol,
 		//
 	}
 
-	return sp, sm, am
+	return sp, sm, am, nil
 }
@@ -43,15 +43,15 @@ func (s *StackSuite) TestStackSuite_ExternalFunctions_ProducerDefinitionsToUTMMa
 		{"./testdata/utm_mapping/producers/definitions_9.yaml", UserTopicMapping{
 			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_PRODUCER, GroupID: "pg1"}:               UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
 			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_PRODUCER_IDEMPOTENCE, GroupID: "pg1"}:   UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
-			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg1"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)}},
+			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg1"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "pg1"}}},
 			"Everything Present"},
 		{"./testdata/utm_mapping/producers/definitions_10.yaml", UserTopicMapping{
 			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_PRODUCER, GroupID: "pg1"}:               UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
 			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_PRODUCER_IDEMPOTENCE, GroupID: "pg1"}:   UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
-			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg1"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
+			UserTopicMappingKey{Principal: "pi1", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg1"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "pg1"}},
 			UserTopicMappingKey{Principal: "pi2", ClientType: ShepherdOperationType_PRODUCER, GroupID: "pg2"}:               UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
 			UserTopicMappingKey{Principal: "pi2", ClientType: ShepherdOperationType_PRODUCER_IDEMPOTENCE, GroupID: "pg2"}:   UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
-			UserTopicMappingKey{Principal: "pi2", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg2"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)}},
+			UserTopicMappingKey{Principal: "pi2", ClientType: ShepherdOperationType_TRANSACTIONAL_PRODUCER, GroupID: "pg2"}: UserTopicMappingValue{TopicList: []string{"test.1", "test.2"}, Hostnames: []string{"*"}, AddlData: NVPairs{KafkaResourceType_GROUP.GetACLResourceString(): "pg2"}}},
 			"Everything Present"},
 	}
 
@@ -226,11 +226,12 @@ func (s *StackSuite) TestStackSuite_ExternalFunctions_KSQLDefinitionsToUTMMappin
 
 func (s *StackSuite) testUTMMapping(in string, expected UserTopicMapping, err string) {
 	os.Setenv("SHEPHERD_DEFINITIONS_FILE_LOCATION", in)
-	SpdCore.Definitions = *SpdCore.Definitions.ParseShepherDefinitions(getEnvVarsWithDefaults("SHEPHERD_DEFINITIONS_FILE_LOCATION", ""), true)
-	ConfMaps.utm = UserTopicMapping{}
-	GenerateMappings()
-	// s.EqualValues(expected, ConfMaps.utm, fmt.Sprintf("Input File Name: %v\n\nError: %v", in, err))
-	s.True(reflect.DeepEqual(expected, ConfMaps.utm), fmt.Sprintf("File Name Reference: %v\n\nExpected Value: %v\n\nActual Value:   %v\n\nError: %v", in, expected, ConfMaps.utm, err))
+	_, parseErr := s.st.Core.Definitions.ParseShepherDefinitions(os.Getenv("SHEPHERD_DEFINITIONS_FILE_LOCATION"), true)
+	s.NoError(parseErr)
+	s.st.Maps.utm = UserTopicMapping{}
+	s.NoError(s.st.GenerateMappings())
+	// s.EqualValues(expected, s.st.Maps.utm, fmt.Sprintf("Input File Name: %v\n\nError: %v", in, err))
+	s.True(reflect.DeepEqual(expected, s.st.Maps.utm), fmt.Sprintf("File Name Reference: %v\n\nExpected Value: %v\n\nActual Value:   %v\n\nError: %v", in, expected, s.st.Maps.utm, err))
 }
 
 func (s *StackSuite) TestStackSuite_Init_CCMMappingTests() {
@@ -286,10 +287,39 @@ func (s *StackSuite) TestStackSuite_Init_CCMMappingTests() {
 
 	for _, c := range cases {
 		os.Setenv("SHEPHERD_CONFIG_FILE_LOCATION", c.inFileName)
-		SpdCore.Configs.ParseShepherdConfig(getEnvVarsWithDefaults("SHEPHERD_CONFIG_FILE_LOCATION", ""), true)
-		ConfMaps.CCM = ClusterConfigMapping{}
-		GenerateMappings()
-		// s.EqualValues(expected, ConfMaps.utm, fmt.Sprintf("Input File Name: %v\n\nError: %v", in, err))
-		s.True(reflect.DeepEqual(c.out, ConfMaps.CCM), fmt.Sprintf("File Name Reference: %v\n\nExpected Value: %v\n\nActual Value:   %v\n\nError: %v", c.inFileName, c.out, ConfMaps.CCM, c.err))
+		s.NoError(s.st.Core.Configs.ParseShepherdConfig(os.Getenv("SHEPHERD_CONFIG_FILE_LOCATION"), true))
+		s.st.Maps.CCM = ClusterConfigMapping{}
+		s.NoError(s.st.GenerateMappings())
+		// s.EqualValues(expected, s.st.Maps.utm, fmt.Sprintf("Input File Name: %v\n\nError: %v", in, err))
+		s.True(reflect.DeepEqual(c.out, s.st.Maps.CCM), fmt.Sprintf("File Name Reference: %v\n\nExpected Value: %v\n\nActual Value:   %v\n\nError: %v", c.inFileName, c.out, s.st.Maps.CCM, c.err))
 	}
 }
+
+func (s *StackSuite) TestStackSuite_ExternalFunctions_DenyDefinitionsToACLMapping() {
+	s.testUTMMapping("./testdata/utm_mapping/deny/definitions_1.yaml", UserTopicMapping{
+		UserTopicMappingKey{Principal: "User:c1", ClientType: ShepherdOperationType_CONSUMER, GroupID: ""}: UserTopicMappingValue{TopicList: []string{"test.1"}, Hostnames: []string{"*"}, AddlData: make(NVPairs)},
+		UserTopicMappingKey{Principal: "User:c1", ClientType: ShepherdOperationType_DENY, GroupID: "READ"}: UserTopicMappingValue{TopicList: []string{"test.1"}, Hostnames: []string{"abc.host"}, AddlData: make(NVPairs)},
+		UserTopicMappingKey{Principal: "User:*", ClientType: ShepherdOperationType_DENY, GroupID: "ALL"}:   UserTopicMappingValue{TopicList: []string{"test.1"}, Hostnames: []string{"def.host"}, AddlData: make(NVPairs)},
+	}, "Deny rules for a principal and for a host")
+
+	acls := s.st.Maps.utm.getShepherdACLList()
+	s.Equal(&ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", ShepherdOperationType_CONSUMER, "*"):    nil,
+		constructDenyACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "abc.host"): nil,
+		constructDenyACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:*", KafkaACLOperation_ALL, "def.host"):   nil,
+	}, acls)
+
+	// The DENY ACLs are kept apart from the ALLOW ACLs for the same resource and operation.
+	kafkaACLs := KafkaACLOperation_UNKNOWN.GenerateACLMappingStructures("", &ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", ShepherdOperationType_CONSUMER, "abc.host"): nil,
+		constructDenyACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "abc.host"):     nil,
+	})
+	s.Equal(&ACLMapping{
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "abc.host"):     nil,
+		constructACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_DESCRIBE, "abc.host"): nil,
+		constructDenyACLDetailsObject(KafkaResourceType_TOPIC, "test.1", KafkaACLPatternType_LITERAL, "User:c1", KafkaACLOperation_READ, "abc.host"): nil,
+	}, kafkaACLs)
+
+	// Back to Shepherd, the DENY ACLs stay as they are.
+	s.Equal(acls, ShepherdOperationType_EMPTY.GenerateACLMappingStructures("", acls))
+}
@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+const (
+	subjectKeySuffix   string = "-key"
+	subjectValueSuffix string = "-value"
+)
+
+var (
+	schemaTypes         = []string{"AVRO", "JSON", "PROTOBUF"}
+	compatibilityLevels = []string{"BACKWARD", "BACKWARD_TRANSITIVE", "FORWARD", "FORWARD_TRANSITIVE", "FULL", "FULL_TRANSITIVE", "NONE"}
+)
+
+/*
+	The schemas of the keys and the values of the topics. They are registered in the Schema Registry of the
+	cluster under the <topic>-key and <topic>-value subjects.
+*/
+type TopicSchemas struct {
+	Key   *SchemaDefinition `yaml:"key,omitempty"`
+	Value *SchemaDefinition `yaml:"value,omitempty"`
+}
+
+func (c *TopicSchemas) readValuesFromENV(r *envResolver) {
+	if c.Key != nil {
+		c.Key.readValuesFromENV(r)
+	}
+	if c.Value != nil {
+		c.Value.readValuesFromENV(r)
+	}
+}
+
+// Relative schema files are relative to the directory of the definitions file.
+func (c *TopicSchemas) resolveFiles(baseDir string) {
+	for _, v := range []*SchemaDefinition{c.Key, c.Value} {
+		if v != nil && !filepath.IsAbs(v.File) {
+			v.File = filepath.Join(baseDir, v.File)
+		}
+	}
+}
+
+type SchemaDefinition struct {
+	File string `yaml:"file"`
+	// AVRO (default), JSON or PROTOBUF.
+	Type string `yaml:"type,omitempty"`
+	// The compatibility level of the subject. The level of the Schema Registry is used if not provided.
+	Compatibility string `yaml:"compatibility,omitempty"`
+}
+
+func (c *SchemaDefinition) readValuesFromENV(r *envResolver) {
+	c.File = r.replace(c.File, "")
+	if c.File == "" {
+		r.fail(configError("Schemas need the file with the schema definition."))
+	}
+	c.Type = strings.ToUpper(r.replace(c.Type, "AVRO"))
+	if !isOneOf(c.Type, schemaTypes) {
+		r.fail(configError("Schema type needs to be one of %s. Schema File: %s, Type provided: %q", strings.Join(schemaTypes, ", "), c.File, c.Type))
+	}
+	c.Compatibility = strings.ToUpper(r.replace(c.Compatibility, ""))
+	if c.Compatibility != "" && !isOneOf(c.Compatibility, compatibilityLevels) {
+		r.fail(configError("Schema compatibility needs to be one of %s. Schema File: %s, Compatibility provided: %q",
+			strings.Join(compatibilityLevels, ", "), c.File, c.Compatibility))
+	}
+}
+
+func isOneOf(in string, options []string) bool {
+	for _, v := range options {
+		if in == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *DefinitionRoot) resolveSchemaFiles(baseDir string) {
+	for i := range c.AdhocConfigs.Topics {
+		c.AdhocConfigs.Topics[i].Schemas.resolveFiles(baseDir)
+	}
+	for i := range c.ScopeFlow {
+		for s := &c.ScopeFlow[i]; s != nil; s = s.Child {
+			s.Topics.Schemas.resolveFiles(baseDir)
+		}
+	}
+}
+
+// The schema expected to be registered under a subject.
+type SubjectConfigMappingValue struct {
+	Topic         string
+	SchemaType    string
+	SchemaFile    string
+	Schema        string
+	Compatibility string
+}
+
+// SubjectConfigMapping holds the subjects of the topics declared with schemas, keyed by the subject name.
+type SubjectConfigMapping map[string]SubjectConfigMappingValue
+
+/*
+	Adds the subjects of the topic. The schema files are read once, files holds the contents read so far.
+	A topic can be generated more than once, as long as its schemas are the same every time.
+*/
+func (c SubjectConfigMapping) addTopicSchemas(topicName string, in TopicSchemas, files map[string]string) error {
+	for suffix, def := range map[string]*SchemaDefinition{subjectKeySuffix: in.Key, subjectValueSuffix: in.Value} {
+		if def == nil {
+			continue
+		}
+		schema, found := files[def.File]
+		if !found {
+			b, err := ioutil.ReadFile(def.File)
+			if err != nil {
+				return NewShepherdError(ErrConfigInvalid, fmt.Sprintf("Cannot read the schema file. Topic Name: %s, Schema File: %s", topicName, def.File), err)
+			}
+			schema = string(b)
+			files[def.File] = schema
+		}
+		value := SubjectConfigMappingValue{Topic: topicName, SchemaType: def.Type, SchemaFile: def.File, Schema: schema, Compatibility: def.Compatibility}
+		subject := topicName + suffix
+		if existing, found := c[subject]; found && existing != value {
+			return configError("Subject is declared more than once with different schemas. Subject: %s", subject)
+		}
+		c[subject] = value
+	}
+	return nil
+}
+
+// Returns the topic of a subject named with the topic name strategy (<topic>-key or <topic>-value).
+func SubjectTopic(subject string) (string, bool) {
+	for _, suffix := range []string{subjectKeySuffix, subjectValueSuffix} {
+		if strings.HasSuffix(subject, suffix) && len(subject) > len(suffix) {
+			return strings.TrimSuffix(subject, suffix), true
+		}
+	}
+	return "", false
+}
+
+// The state of a subject in the Schema Registry.
+type SubjectState struct {
+	LatestVersion int
+	// The version the expected schema is registered as under the subject, 0 if it is not registered.
+	SchemaVersion int
+	// The compatibility level set for the subject, empty if the level of the Schema Registry is used.
+	Compatibility string
+}
+
+type SubjectStateMapping map[string]SubjectState
+
+/*
+	Compares the subjects registered in the Schema Registry to the expected ones and returns the changes needed
+	to align them. A new version is registered if the expected schema is not registered under the subject yet,
+	and the compatibility level is only changed if it is part of the configurations. The subjects of the topics
+	that are neither in the configurations (topics) nor in the cluster (clusterTopics) anymore are soft deleted
+	only if executeDeleteFlow is set, the subjects that are not named after a topic are never deleted.
+*/
+func PlanSubjectChanges(clusterName string, expected SubjectConfigMapping, provisioned SubjectStateMapping, topics mapset.Set, clusterTopics mapset.Set,
+	executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) []PlanChange {
+	ret := []PlanChange{}
+	for subject, v := range expected {
+		current, found := provisioned[subject]
+		switch {
+		case !found && executeCreateFlow:
+			after := NVPairs{"schema": v.SchemaFile, "schemaType": v.SchemaType}
+			if v.Compatibility != "" {
+				after["compatibility"] = v.Compatibility
+			}
+			ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_CREATE, ResourceType: PlanResourceType_SUBJECT,
+				Name: subject, After: after})
+		case found && executeModifyFlow:
+			before, after := NVPairs{}, NVPairs{}
+			if current.SchemaVersion == 0 {
+				before["schema"], after["schema"] = fmt.Sprintf("version %d", current.LatestVersion), v.SchemaFile
+			} else if current.SchemaVersion != current.LatestVersion {
+				logger.Warnw("Schema is registered as an older version of the subject. It cannot be registered again.",
+					"Cluster Name", clusterName,
+					"Subject", subject,
+					"Schema Version", current.SchemaVersion,
+					"Latest Version", current.LatestVersion)
+			}
+			if v.Compatibility != "" && v.Compatibility != current.Compatibility {
+				before["compatibility"], after["compatibility"] = current.Compatibility, v.Compatibility
+			}
+			if len(after) != 0 {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_UPDATE, ResourceType: PlanResourceType_SUBJECT,
+					Name: subject, Before: before, After: after})
+			}
+		}
+	}
+	if executeDeleteFlow {
+		for subject, v := range provisioned {
+			if _, found := expected[subject]; found {
+				continue
+			}
+			if topic, ok := SubjectTopic(subject); ok && !topics.Contains(topic) && !clusterTopics.Contains(topic) {
+				ret = append(ret, PlanChange{Cluster: clusterName, Action: PlanAction_DELETE, ResourceType: PlanResourceType_SUBJECT,
+					Name: subject, Before: NVPairs{"schema": fmt.Sprintf("version %d", v.LatestVersion)}})
+			}
+		}
+	}
+	return ret
+}
+
+// Fingerprint of the subjects observed in the Schema Registry of a cluster.
+func SubjectStateFingerprint(in SubjectStateMapping) string {
+	state := []string{}
+	for subject, v := range in {
+		state = append(state, strings.Join([]string{subject, strconv.Itoa(v.LatestVersion), strconv.Itoa(v.SchemaVersion), v.Compatibility}, "/"))
+	}
+	sort.Strings(state)
+	b, _ := json.Marshal(state)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+func (s *StackSuite) TestStackSuite_Subjects_TopicSchemas() {
+	_, err := s.st.Core.Definitions.ParseShepherDefinitions("./testdata/schemas/definitions_1.yaml", true)
+	s.Require().NoError(err)
+	s.st.Maps.Subjects = SubjectConfigMapping{}
+	s.Require().NoError(s.st.GenerateMappings())
+
+	s.Len(s.st.Maps.Subjects, 4, "Only the topics with schemas should have subjects")
+	value := s.st.Maps.Subjects["test.2-value"]
+	s.Equal("test.2", value.Topic)
+	s.Equal("AVRO", value.SchemaType, "Schema type should default to AVRO")
+	s.Equal("BACKWARD", value.Compatibility)
+	s.Equal(filepath.Join("testdata", "schemas", "value.avsc"), value.SchemaFile, "Schema files should be relative to the definitions file")
+	s.Contains(value.Schema, `"name": "Test"`)
+	key := s.st.Maps.Subjects["test.1-key"]
+	s.Equal("JSON", key.SchemaType)
+	s.Equal("", key.Compatibility)
+
+	for _, c := range []SchemaDefinition{{}, {File: "a.avsc", Type: "XML"}, {File: "a.avsc", Compatibility: "SOMETIMES"}} {
+		r := &envResolver{}
+		c.readValuesFromENV(r)
+		s.True(errors.Is(r.err, ErrConfigInvalid), "Schema definition %+v should be invalid", c)
+	}
+	missing := TopicSchemas{Value: &SchemaDefinition{File: "./testdata/schemas/missing.avsc", Type: "AVRO"}}
+	s.True(errors.Is(SubjectConfigMapping{}.addTopicSchemas("test.1", missing, map[string]string{}), ErrConfigInvalid))
+}
+
+func (s *StackSuite) TestStackSuite_Subjects_PlanChanges() {
+	expected := SubjectConfigMapping{
+		"new-value":       {Topic: "new", SchemaType: "AVRO", SchemaFile: "new.avsc", Compatibility: "FULL"},
+		"changed-value":   {Topic: "changed", SchemaType: "AVRO", SchemaFile: "changed.avsc"},
+		"level-value":     {Topic: "level", SchemaType: "AVRO", SchemaFile: "level.avsc", Compatibility: "FULL"},
+		"unchanged-value": {Topic: "unchanged", SchemaType: "AVRO", SchemaFile: "unchanged.avsc"},
+	}
+	provisioned := SubjectStateMapping{
+		"changed-value":   {LatestVersion: 2},
+		"level-value":     {LatestVersion: 1, SchemaVersion: 1, Compatibility: "BACKWARD"},
+		"unchanged-value": {LatestVersion: 3, SchemaVersion: 3},
+		"kept-key":        {LatestVersion: 1},
+		"removed-key":     {LatestVersion: 4},
+		"unmanaged-value": {LatestVersion: 2},
+		"other-subject":   {LatestVersion: 1},
+	}
+	topics := mapset.NewSet("new", "changed", "level", "unchanged", "kept")
+	clusterTopics := mapset.NewSet("changed", "level", "unchanged", "kept", "unmanaged")
+
+	changes := map[string]PlanChange{}
+	for _, v := range PlanSubjectChanges("c1", expected, provisioned, topics, clusterTopics, true, true, true) {
+		s.Equal(PlanResourceType_SUBJECT, v.ResourceType)
+		changes[v.Name] = v
+	}
+	s.Len(changes, 4)
+	s.Equal(PlanAction_CREATE, changes["new-value"].Action)
+	s.Equal(NVPairs{"schema": "new.avsc", "schemaType": "AVRO", "compatibility": "FULL"}, changes["new-value"].After)
+	s.Equal(PlanAction_UPDATE, changes["changed-value"].Action)
+	s.Equal(NVPairs{"schema": "version 2"}, changes["changed-value"].Before)
+	s.Equal(NVPairs{"schema": "changed.avsc"}, changes["changed-value"].After)
+	s.Equal(NVPairs{"compatibility": "FULL"}, changes["level-value"].After)
+	s.Equal(PlanAction_DELETE, changes["removed-key"].Action, "Only the subjects of the topics missing from the configurations and the cluster should be deleted")
+
+	s.Len(PlanSubjectChanges("c1", expected, provisioned, topics, clusterTopics, true, true, false), 3)
+	s.Len(PlanSubjectChanges("c1", expected, provisioned, topics, clusterTopics, false, false, false), 0)
+}
@@ -0,0 +1,5 @@
+/* The queries reading the pageviews. */
+CREATE TABLE page_counts AS
+  SELECT page, COUNT(*) AS views FROM pageviews_home GROUP BY page EMIT CHANGES;
+INSERT INTO pageviews_home SELECT * FROM pageviews WHERE page = 'index;html' EMIT CHANGES;
+CREATE STREAM pageviews_home AS SELECT * FROM pageviews WHERE page = 'home' EMIT CHANGES;
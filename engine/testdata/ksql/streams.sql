@@ -0,0 +1,3 @@
+-- The source stream of the queries; not created by a query.
+CREATE STREAM pageviews (id VARCHAR, page VARCHAR)
+  WITH (KAFKA_TOPIC = 'test.1', VALUE_FORMAT = 'JSON');
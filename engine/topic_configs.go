@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	topicConfigDurationPattern *regexp.Regexp     = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)\s*(ms|s|m|h|d|w)$`)
+	topicConfigSizePattern     *regexp.Regexp     = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)\s*([kmgt]i?b)$`)
+	topicConfigDurationUnits   map[string]float64 = map[string]float64{
+		"ms": 1,
+		"s":  1000,
+		"m":  60 * 1000,
+		"h":  60 * 60 * 1000,
+		"d":  24 * 60 * 60 * 1000,
+		"w":  7 * 24 * 60 * 60 * 1000,
+	}
+	topicConfigSizeUnits map[string]float64 = map[string]float64{
+		"kb":  1000,
+		"mb":  1000 * 1000,
+		"gb":  1000 * 1000 * 1000,
+		"tb":  1000 * 1000 * 1000 * 1000,
+		"kib": 1 << 10,
+		"mib": 1 << 20,
+		"gib": 1 << 30,
+		"tib": 1 << 40,
+	}
+)
+
+/*
+	Converts a topic property value to the format used by the Kafka brokers, so that the values from the
+	configurations can be sent to (and compared with) the cluster. The properties in milliseconds accept
+	the units ms, s, m, h, d and w (e.g. 7d for 604800000), the properties in bytes accept KB, MB, GB, TB
+	(powers of 1000) and KiB, MiB, GiB, TiB (powers of 1024). Lists are sorted, as their order does not
+	matter to Kafka, and booleans are lowercased. Any other value is only trimmed.
+*/
+func NormalizeTopicConfigValue(propName string, value string) string {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasSuffix(propName, ".ms"):
+		if m := topicConfigDurationPattern.FindStringSubmatch(lower); m != nil {
+			return scaleTopicConfigValue(m[1], topicConfigDurationUnits[m[2]], value)
+		}
+	case strings.HasSuffix(propName, ".bytes"):
+		if m := topicConfigSizePattern.FindStringSubmatch(lower); m != nil {
+			return scaleTopicConfigValue(m[1], topicConfigSizeUnits[m[2]], value)
+		}
+	}
+	switch {
+	case lower == "true" || lower == "false":
+		return lower
+	case strings.Contains(value, ","):
+		items := strings.Split(value, ",")
+		for idx := range items {
+			items[idx] = strings.TrimSpace(items[idx])
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+	return value
+}
+
+func scaleTopicConfigValue(num string, unit float64, def string) string {
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return def
+	}
+	return strconv.FormatInt(int64(math.Round(v*unit)), 10)
+}
+
+/*
+	Reports if the two values of a topic property mean the same to Kafka. Both the values are normalized
+	with NormalizeTopicConfigValue, and numbers are compared by their value (e.g. 0.5 and 0.50).
+*/
+func TopicConfigValuesEqual(propName string, a string, b string) bool {
+	a, b = NormalizeTopicConfigValue(propName, a), NormalizeTopicConfigValue(propName, b)
+	if a == b {
+		return true
+	}
+	aNum, aErr := strconv.ParseFloat(a, 64)
+	bNum, bErr := strconv.ParseFloat(b, 64)
+	return aErr == nil && bErr == nil && aNum == bNum
+}
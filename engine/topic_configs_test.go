@@ -0,0 +1,33 @@
+package engine
+
+func (s *StackSuite) TestStackSuite_TopicConfigs_Normalization() {
+	cases := []struct {
+		inProp  string
+		inValue string
+		out     string
+	}{
+		{"retention.ms", "604800000", "604800000"},
+		{"retention.ms", "7d", "604800000"},
+		{"retention.ms", " 168h ", "604800000"},
+		{"retention.ms", "1w", "604800000"},
+		{"retention.ms", "1.5s", "1500"},
+		{"retention.ms", "-1", "-1"},
+		{"segment.ms", "10m", "600000"},
+		{"retention.bytes", "1GiB", "1073741824"},
+		{"retention.bytes", "2MB", "2000000"},
+		{"max.message.bytes", "512kib", "524288"},
+		{"cleanup.policy", "delete, compact", "compact,delete"},
+		{"unclean.leader.election.enable", "True", "true"},
+		{"compression.type", "producer", "producer"},
+		{"cleanup.policy", "7d", "7d"},
+	}
+	for _, c := range cases {
+		s.Equal(c.out, NormalizeTopicConfigValue(c.inProp, c.inValue), "Property %s with value %s", c.inProp, c.inValue)
+	}
+
+	s.True(TopicConfigValuesEqual("retention.ms", "604800000", "7d"))
+	s.True(TopicConfigValuesEqual("min.cleanable.dirty.ratio", "0.5", "0.50"))
+	s.True(TopicConfigValuesEqual("cleanup.policy", "compact,delete", "delete,compact"))
+	s.False(TopicConfigValuesEqual("retention.ms", "604800000", "6d"))
+	s.False(TopicConfigValuesEqual("cleanup.policy", "delete", "compact"))
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTopicDeletionStateFile string = "shepherd_topic_deletion_state.json"
+
+/*
+	Safeguards for the deletion of the topics that are not in the configurations (deleteUnknownTopics).
+	The protected topics (along with the built in ones, see BuiltinProtectedTopics) are never deleted.
+	All the deletions of a run are refused if there are more than MaxDeletionsPerRun of them (0 means
+	no limit). Topics that received messages in the last ActivityWindow (0 disables the check) are kept.
+	A topic is only deleted once it has been missing from the configurations for AbsentRuns consecutive
+	runs, which are tracked in StateFile.
+*/
+type TopicDeletionConfig struct {
+	Protected          ProtectedTopics `yaml:"protected,omitempty"`
+	MaxDeletionsPerRun int             `yaml:"maxDeletionsPerRun"`
+	ActivityWindow     time.Duration   `yaml:"activityWindow"`
+	AbsentRuns         int             `yaml:"absentRuns"`
+	StateFile          string          `yaml:"stateFile"`
+	// The Protected regexes, compiled by validate.
+	protectedRegexes []*regexp.Regexp
+}
+
+type ProtectedTopics struct {
+	Literals []string `yaml:"literals,flow"`
+	Prefixes []string `yaml:"prefixes,flow"`
+	Regexes  []string `yaml:"regexes,flow"`
+}
+
+// The internal topics of Kafka, Confluent Platform, Kafka Connect and Kafka Streams, which are always protected.
+var BuiltinProtectedTopics ProtectedTopics = ProtectedTopics{
+	Literals: []string{"_schemas", "connect-configs", "connect-offsets", "connect-status"},
+	Prefixes: []string{"__", "_confluent"},
+	Regexes:  []string{`-(changelog|repartition)$`},
+}
+
+var builtinProtectedRegexes []*regexp.Regexp = func() []*regexp.Regexp {
+	ret := []*regexp.Regexp{}
+	for _, v := range BuiltinProtectedTopics.Regexes {
+		ret = append(ret, regexp.MustCompile(v))
+	}
+	return ret
+}()
+
+// Validates the safeguards and compiles the protected regexes, which ProtectionRule relies on.
+func (c *TopicDeletionConfig) validate() error {
+	switch {
+	case c.MaxDeletionsPerRun < 0:
+		return configError("core.topicDeletion.maxDeletionsPerRun cannot be negative. Provided: %d", c.MaxDeletionsPerRun)
+	case c.ActivityWindow < 0:
+		return configError("core.topicDeletion.activityWindow cannot be negative. Provided: %s", c.ActivityWindow)
+	case c.AbsentRuns < 0:
+		return configError("core.topicDeletion.absentRuns cannot be negative. Provided: %d", c.AbsentRuns)
+	}
+	regexes := make([]*regexp.Regexp, 0, len(c.Protected.Regexes))
+	for _, r := range c.Protected.Regexes {
+		compiled, err := regexp.Compile(r)
+		if err != nil {
+			return NewShepherdError(ErrConfigInvalid, fmt.Sprintf("core.topicDeletion.protected.regexes has an invalid regular expression: %s", r), err)
+		}
+		regexes = append(regexes, compiled)
+	}
+	c.protectedRegexes = regexes
+	return nil
+}
+
+/*
+	Returns the protection rule matching the topic, if the topic is protected by the built in rules or the
+	configured ones. The configured regexes are the ones compiled while validating the configurations.
+*/
+func (c TopicDeletionConfig) ProtectionRule(tName string) (string, bool) {
+	rules := []struct {
+		topics  ProtectedTopics
+		regexes []*regexp.Regexp
+	}{{BuiltinProtectedTopics, builtinProtectedRegexes}, {c.Protected, c.protectedRegexes}}
+	for _, p := range rules {
+		for _, v := range p.topics.Literals {
+			if tName == v {
+				return "literal " + v, true
+			}
+		}
+		for _, v := range p.topics.Prefixes {
+			if strings.HasPrefix(tName, v) {
+				return "prefix " + v, true
+			}
+		}
+		for _, v := range p.regexes {
+			if v.MatchString(tName) {
+				return "regex " + v.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Refuses the deletion of all the candidates if there are more of them than allowed for a run.
+func (c TopicDeletionConfig) CheckDeletionLimit(clusterName string, candidates []string) error {
+	if c.MaxDeletionsPerRun == 0 || len(candidates) <= c.MaxDeletionsPerRun {
+		return nil
+	}
+	return NewShepherdError(ErrConfigInvalid,
+		fmt.Sprintf("%d topics are eligible for deletion in cluster %s, which is more than core.topicDeletion.maxDeletionsPerRun (%d). No topic has been deleted. Check the configurations, or raise the limit if the deletions are expected",
+			len(candidates), clusterName, c.MaxDeletionsPerRun), nil)
+}
+
+var topicDeletionStateLock sync.Mutex
+
+/*
+	Counts one more run for every candidate and returns the candidates that have been missing from the
+	configurations for AbsentRuns consecutive runs (including this one). The topics that are not candidates
+	anymore start from scratch the next time. The counts are only saved to StateFile if persist is set, so
+	that plans and dry runs do not count as runs.
+*/
+func (c TopicDeletionConfig) TrackAbsentTopics(clusterName string, candidates []string, persist bool) ([]string, error) {
+	if c.AbsentRuns <= 1 {
+		return candidates, nil
+	}
+	topicDeletionStateLock.Lock()
+	defer topicDeletionStateLock.Unlock()
+
+	path := c.StateFile
+	if path == "" {
+		path = defaultTopicDeletionStateFile
+	}
+	state := map[string]map[string]int{}
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &state); err != nil {
+			return nil, fmt.Errorf("cannot parse the topic deletion state file %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("cannot read the topic deletion state file %s: %w", path, err)
+	}
+
+	counts, eligible := map[string]int{}, []string{}
+	for _, tName := range candidates {
+		counts[tName] = state[clusterName][tName] + 1
+		if counts[tName] >= c.AbsentRuns {
+			eligible = append(eligible, tName)
+		} else {
+			logger.Infow("Topic is missing from the configurations, but will only be deleted after more runs.",
+				"Cluster Name", clusterName,
+				"Topic Name", tName,
+				"Absent Runs", counts[tName],
+				"Required Runs", c.AbsentRuns)
+		}
+	}
+	if !persist {
+		return eligible, nil
+	}
+	// The deleted topics are not candidates anymore in the next run, so their counts go away then.
+	state[clusterName] = counts
+	if b, err = json.MarshalIndent(state, "", "  "); err == nil {
+		err = ioutil.WriteFile(path, b, 0644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot save the topic deletion state file %s: %w", path, err)
+	}
+	return eligible, nil
+}
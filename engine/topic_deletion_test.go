@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func (s *StackSuite) TestStackSuite_TopicDeletion_ProtectedTopics() {
+	c := TopicDeletionConfig{Protected: ProtectedTopics{
+		Literals: []string{"audit"},
+		Prefixes: []string{"keep."},
+		Regexes:  []string{`^legacy-[0-9]+$`},
+	}}
+	s.Require().NoError(c.validate())
+	cases := []struct {
+		in        string
+		protected bool
+	}{
+		{"__consumer_offsets", true},
+		{"__transaction_state", true},
+		{"_schemas", true},
+		{"_confluent-metrics", true},
+		{"connect-offsets", true},
+		{"app-KSTREAM-AGGREGATE-STATE-STORE-0000000001-changelog", true},
+		{"audit", true},
+		{"audit.1", false},
+		{"keep.me", true},
+		{"legacy-42", true},
+		{"legacy-new", false},
+		{"test.1", false},
+	}
+	for _, v := range cases {
+		_, protected := c.ProtectionRule(v.in)
+		s.Equal(v.protected, protected, "Topic Name: %s", v.in)
+	}
+
+	rule, _ := c.ProtectionRule("legacy-42")
+	s.Equal("regex ^legacy-[0-9]+$", rule)
+
+	s.True(errors.Is((&TopicDeletionConfig{Protected: ProtectedTopics{Regexes: []string{"("}}}).validate(), ErrConfigInvalid))
+	s.True(errors.Is((&TopicDeletionConfig{MaxDeletionsPerRun: -1}).validate(), ErrConfigInvalid))
+}
+
+func (s *StackSuite) TestStackSuite_TopicDeletion_Limits() {
+	c := TopicDeletionConfig{MaxDeletionsPerRun: 2}
+	s.NoError(c.CheckDeletionLimit("dev", []string{"a", "b"}))
+	s.True(errors.Is(c.CheckDeletionLimit("dev", []string{"a", "b", "c"}), ErrConfigInvalid))
+	s.NoError(TopicDeletionConfig{}.CheckDeletionLimit("dev", []string{"a", "b", "c"}))
+
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	c = TopicDeletionConfig{AbsentRuns: 2, StateFile: filepath.Join(dir, "state.json")}
+
+	// Plans and dry runs do not count as runs.
+	eligible, err := c.TrackAbsentTopics("dev", []string{"a", "b"}, false)
+	s.NoError(err)
+	s.Empty(eligible)
+	eligible, err = c.TrackAbsentTopics("dev", []string{"a", "b"}, true)
+	s.NoError(err)
+	s.Empty(eligible)
+	// "b" is back in the configurations for a run, so it starts from scratch.
+	eligible, err = c.TrackAbsentTopics("dev", []string{"a"}, true)
+	s.NoError(err)
+	s.Equal([]string{"a"}, eligible)
+	eligible, err = c.TrackAbsentTopics("dev", []string{"a", "b"}, true)
+	s.NoError(err)
+	s.Equal([]string{"a"}, eligible)
+	// The runs are counted separately for every cluster.
+	eligible, err = c.TrackAbsentTopics("prod", []string{"a"}, true)
+	s.NoError(err)
+	s.Empty(eligible)
+
+	eligible, err = TopicDeletionConfig{}.TrackAbsentTopics("dev", []string{"a"}, true)
+	s.NoError(err)
+	s.Equal([]string{"a"}, eligible)
+
+	// A broken state file is not a configuration issue.
+	s.NoError(ioutil.WriteFile(c.StateFile, []byte("{"), 0644))
+	_, err = c.TrackAbsentTopics("dev", []string{"a"}, true)
+	s.Error(err)
+	s.False(errors.Is(err, ErrConfigInvalid))
+	s.Contains(err.Error(), c.StateFile)
+}
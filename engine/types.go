@@ -11,25 +11,20 @@ const (
 )
 
 var (
-	topicsInConfig mapset.Set      = mapset.NewSet()
-	Shepherd       ShepherdManager = ShepherdManagerBaseImpl{}
+	Shepherd ShepherdManager = ShepherdManagerBaseImpl{}
 )
 
 type (
 	ShepherdManager interface {
-		GetTopicList(forceRefresh bool) mapset.Set
 		// GetShepherdACLList() *ACLMapping
 		RenderACLMappings(clusterName string, mappings *ACLMapping, inputACLType ACLOperationsInterface) *ACLMapping
 		GetLogger() *ShepherdLogger
 	}
 
 	ShepherdManagerBaseImpl struct {
-		ShepherdTopicManagerImpl
 		ShepherdACLConfigManager
 	}
 
-	ShepherdTopicManagerImpl struct{}
-
 	ShepherdLogger struct {
 		*zap.SugaredLogger
 	}
@@ -52,16 +47,16 @@ func (s ShepherdManagerBaseImpl) RenderACLMappings(clusterName string, in *ACLMa
 	(.*) suffixed topics. Technically, this is the unique list of topics
 	that the configuration is expecting to be created.
 */
-func (s ShepherdTopicManagerImpl) GetTopicList(forceRefresh bool) mapset.Set {
-	if forceRefresh || topicsInConfig.Cardinality() == 0 {
-		topicsInConfig = mapset.NewSet()
-		for topicName := range ConfMaps.TCM {
-			if ksmisc.IsTopicName(topicName, SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken) {
-				topicsInConfig.Add(topicName)
+func (st *State) GetTopicList(forceRefresh bool) mapset.Set {
+	if forceRefresh || st.topicsInConfig.Cardinality() == 0 {
+		st.topicsInConfig = mapset.NewSet()
+		for topicName := range st.Maps.TCM {
+			if ksmisc.IsTopicName(topicName, st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken) {
+				st.topicsInConfig.Add(topicName)
 			}
 		}
 	}
-	return topicsInConfig
+	return st.topicsInConfig
 }
 
 func (s ShepherdManagerBaseImpl) GetLogger() (lg *ShepherdLogger) {
@@ -6,17 +6,19 @@ package engine
 	type to another. Eg: Convert Kafka ACLs to Confluent RBAC while migrating the cluster objects.
 	The Value is marked as an interface but is expected to be of type NVPairs which is of type map[string]string.
 	This greatly enhances the interface capability to pass in more arbitrary data as required for supporting
-	different types of Security Implementations.
+	different types of Security Implementations. The PermissionType is part of the key, so a DENY rule is never
+	mistaken for the ALLOW rule with the same resource and operation.
 */
 type (
 	ACLMapping map[ACLDetails]interface{}
 	ACLDetails struct {
-		ResourceType ACLResourceInterface
-		ResourceName string
-		PatternType  ACLPatternInterface
-		Principal    string
-		Operation    ACLOperationsInterface
-		Hostname     string
+		ResourceType   ACLResourceInterface
+		ResourceName   string
+		PatternType    ACLPatternInterface
+		Principal      string
+		Operation      ACLOperationsInterface
+		Hostname       string
+		PermissionType KafkaACLPermissionType
 	}
 )
 
@@ -58,15 +60,27 @@ type (
 func constructACLDetailsObject(resType ACLResourceInterface, resName string, patType ACLPatternInterface,
 	prin string, op ACLOperationsInterface, host string) ACLDetails {
 	return ACLDetails{
-		ResourceType: resType,
-		ResourceName: resName,
-		PatternType:  patType,
-		Principal:    prin,
-		Operation:    op,
-		Hostname:     host,
+		ResourceType:   resType,
+		ResourceName:   resName,
+		PatternType:    patType,
+		Principal:      prin,
+		Operation:      op,
+		Hostname:       host,
+		PermissionType: KafkaACLPermissionType_ALLOW,
 	}
 }
 
+func constructDenyACLDetailsObject(resType ACLResourceInterface, resName string, patType ACLPatternInterface,
+	prin string, op ACLOperationsInterface, host string) ACLDetails {
+	ret := constructACLDetailsObject(resType, resName, patType, prin, op, host)
+	ret.PermissionType = KafkaACLPermissionType_DENY
+	return ret
+}
+
+func (k ACLDetails) IsDeny() bool {
+	return k.PermissionType == KafkaACLPermissionType_DENY
+}
+
 // func (c *ACLMapping) prettyPrintACLMapping() {
 // 	ksmisc.DottedLineOutput("List ACLMapping", "=", 80)
 // 	for k := range *c {
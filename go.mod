@@ -3,7 +3,8 @@ module github.com/waliaabhishek/kafka-shepherd
 go 1.15
 
 require (
-	github.com/Shopify/sarama v1.29.1
+	github.com/Shopify/sarama v1.30.0
+	github.com/Shopify/toxiproxy v2.1.4+incompatible // indirect
 	github.com/deckarep/golang-set v1.7.1
 	github.com/go-resty/resty/v2 v2.6.0
 	github.com/golang/snappy v0.0.4 // indirect
@@ -13,6 +14,7 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/testcontainers/testcontainers-go v0.11.1
 	github.com/xdg/scram v1.0.3
+	github.com/xdg/stringprep v1.0.3 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
 	go.uber.org/zap v1.18.1
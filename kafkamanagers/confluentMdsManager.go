@@ -1,7 +1,9 @@
 package kafkamanagers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/url"
 
 	"github.com/go-resty/resty/v2"
@@ -27,9 +29,11 @@ const (
 // 	ConfAdminConnection ConnectionObject = &ConfluentMDSConnection{}
 // )
 
-func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.ShepherdCluster) {
+func (c *ConfluentMDSConnection) InitiateAdminConnection(ctx context.Context, cConfig ksengine.ShepherdCluster) error {
 	if c.MDS == nil {
-		c.validateInputDetails(cConfig)
+		if err := c.validateInputDetails(cConfig); err != nil {
+			return err
+		}
 		erpNeeded := true
 		if cConfig.Configs[0]["kafka-cluster"] != "" {
 			c.KafkaClusterID = cConfig.Configs[0]["kafka-cluster"]
@@ -64,10 +68,9 @@ func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.Shephe
 		if cConfig.TLSDetails.Enable2WaySSL {
 			cert, err := ksengine.GetClientCertificateFromCertNKey(cConfig.TLSDetails.ClientCert, cConfig.TLSDetails.PrivateKey, cConfig.TLSDetails.PrivateKeyPassword)
 			if err != nil {
-				logger.Fatalw("Expected to Enable 2 Way SSL. But I was not able to create the Keystore. Cannot set up connection without it",
-					"Private Cert Path", cConfig.TLSDetails.ClientCert,
-					"Private Key Path", cConfig.TLSDetails.PrivateKey,
-					"Error Received", err)
+				return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+					fmt.Sprintf("Expected to Enable 2 Way SSL. But I was not able to create the Keystore. Private Cert Path: %s, Private Key Path: %s",
+						cConfig.TLSDetails.ClientCert, cConfig.TLSDetails.PrivateKey), err)
 			}
 			client1.SetCertificates(*cert)
 			if erpNeeded {
@@ -85,26 +88,22 @@ func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.Shephe
 
 			url, err := url.Parse(cConfig.Configs[0]["erp.url"])
 			if err != nil {
-				logger.Fatalw("Cannot parse the URL. Please check the URL and try again.",
-					"URL", cConfig.Configs[0]["erp.url"])
+				return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, "Cannot parse the ERP URL. Please check the URL and try again", err)
 			}
 			client2.SetHostURL(url.String())
 
-			resp, err := client2.SetCloseConnection(true).R().Get(erp_kafkaClusterID)
+			resp, err := client2.SetCloseConnection(true).R().SetContext(ctx).Get(erp_kafkaClusterID)
 			if err != nil || resp.StatusCode() > 400 {
-				resp, err = client2.SetCloseConnection(true).R().Get(erp_altKafkaClusterID)
+				resp, err = client2.SetCloseConnection(true).R().SetContext(ctx).Get(erp_altKafkaClusterID)
 				altResp = true
 			}
 			if err != nil || resp.StatusCode() > 400 {
-				logger.Fatalw("Not able to call the ERP URL with provided details. Cannot proceed",
-					"Error", string(resp.Body()))
+				return NewMDSError("Not able to call the ERP URL with provided details", resp, err)
 			}
 			var r map[string]interface{}
 			err = json.Unmarshal(resp.Body(), &r)
 			if err != nil {
-				logger.Fatalw("Error while Parsing Response Data. Please try again.",
-					"Response Received", resp,
-					"Error", err)
+				return ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing ERP Response Data", err)
 			}
 			var cluster_id string
 			if altResp {
@@ -125,21 +124,12 @@ func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.Shephe
 		client1.SetDebug(ksengine.IsDebugEnabled())
 		url, err := url.Parse(cConfig.Configs[0]["mds.url"])
 		if err != nil {
-			logger.Fatalw("Cannot parse the URL. Please check the URL and try again.",
-				"URL", cConfig.Configs[0]["mds.url"])
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, "Cannot parse the MDS URL. Please check the URL and try again", err)
 		}
 		client1.SetHostURL(url.String())
-		resp, err := client1.R().Get(mds_kafkaClusterID)
-		if err != nil {
-			logger.Fatalw("Failed Request Execution with MDS Server using provided details. Cannot proceed.",
-				"Error", err)
-		}
-
-		if resp.StatusCode() > 400 && resp.StatusCode() < 500 {
-			logger.Fatalw("Authorization failed using the current credentials. Please ensure correct credentials. Cannot Proceed.",
-				"Status Code", resp.StatusCode(),
-				"Response", resp,
-				"Error Details", err)
+		resp, err := client1.R().SetContext(ctx).Get(mds_kafkaClusterID)
+		if err := NewMDSError("Failed to authenticate with the MDS Server using provided details", resp, err); err != nil {
+			return err
 		}
 
 		logger.Infow("Authentication successful with MDS.")
@@ -148,9 +138,7 @@ func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.Shephe
 		r := make(map[string]interface{})
 		err = json.Unmarshal(resp.Body(), &r)
 		if err != nil {
-			logger.Fatalw("Error while Parsing Response Data. Please try again.",
-				"Response Received", resp,
-				"Error", err)
+			return ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing MDS Response Data", err)
 		}
 		auth_token := r["auth_token"].(string)
 		client1.SetAuthScheme("Bearer")
@@ -159,22 +147,31 @@ func (c *ConfluentMDSConnection) InitiateAdminConnection(cConfig ksengine.Shephe
 		logger.Debugw("Set MDS Client")
 		c.MDS = client1
 	}
+	return nil
 }
 
-func (c *ConfluentMDSConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) {
-	c.executeBaseValidations(&cConfig)
+// Returns the error for a request to MDS (or the Embedded REST Proxy), see NewRESTError.
+func NewMDSError(msg string, resp *resty.Response, err error) error {
+	return NewRESTError(msg, resp, err)
+}
+
+func (c *ConfluentMDSConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) error {
+	if err := c.executeBaseValidations(&cConfig); err != nil {
+		return err
+	}
 	if cConfig.Configs[0]["erp.url"] == "" && cConfig.Configs[0]["kafka-cluster"] == "" {
-		c.generateCustomError(true, "cluster.configOverrides[\"erp.url\"]", "Need Embedded REST Proxy URL for Cluster Identification or the Kafka Cluster ID. Ensure that the user has the right access for ACL Execution")
+		return c.generateCustomError("cluster.configOverrides[\"erp.url\"]", "Need Embedded REST Proxy URL for Cluster Identification or the Kafka Cluster ID. Ensure that the user has the right access for ACL Execution")
 	}
 	if cConfig.Configs[0]["mds.url"] == "" {
-		c.generateCustomError(true, "cluster.configOverrides[\"mds.url\"]", "Need Server URL for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
+		return c.generateCustomError("cluster.configOverrides[\"mds.url\"]", "Need Server URL for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
 	}
 	if cConfig.Configs[0]["mds.username"] == "" {
-		c.generateCustomError(true, "cluster.configOverrides[\"mds.username\"]", "Need MDS Server Username for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
+		return c.generateCustomError("cluster.configOverrides[\"mds.username\"]", "Need MDS Server Username for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
 	}
 	if cConfig.Configs[0]["mds.password"] == "" {
-		c.generateCustomError(true, "cluster.configOverrides[\"mds.password\"]", "Need MDS Server Password for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
+		return c.generateCustomError("cluster.configOverrides[\"mds.password\"]", "Need MDS Server Password for MDS Connectivity. Ensure that the user has the right access for ACL Execution")
 	}
+	return nil
 }
 
 func (c *ConfluentMDSConnection) CloseAdminConnection() {
@@ -0,0 +1,54 @@
+package kafkamanagers
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+/*
+	Holds a REST client for every Kafka Connect cluster configured for a cluster, keyed by the
+	Connect cluster name used in the connector definitions.
+*/
+type KafkaConnectConnection struct {
+	ConnectionObjectBaseImpl
+	Clusters map[string]*resty.Client
+}
+
+func (c *KafkaConnectConnection) InitiateAdminConnection(ctx context.Context, cConfig ksengine.ShepherdCluster) error {
+	if c.Clusters != nil {
+		return nil
+	}
+	if err := c.validateInputDetails(cConfig); err != nil {
+		return err
+	}
+	clusters := make(map[string]*resty.Client)
+	for _, v := range cConfig.ConnectClusters {
+		client, err := newRESTClient(ctx, v, "/")
+		if err != nil {
+			return err
+		}
+		logger.Debugw("Set Kafka Connect Client",
+			"Cluster Name", cConfig.Name,
+			"Connect Cluster", v.Name)
+		clusters[v.Name] = client
+	}
+	c.Clusters = clusters
+	return nil
+}
+
+func (c *KafkaConnectConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) error {
+	names := make(map[string]bool)
+	for _, v := range cConfig.ConnectClusters {
+		if names[v.Name] {
+			return c.generateCustomError("cluster.connectClusters.name", "Connect cluster names need to be unique for a cluster. Duplicate Name: "+v.Name)
+		}
+		names[v.Name] = true
+	}
+	return nil
+}
+
+func (c *KafkaConnectConnection) CloseAdminConnection() {
+	c.Clusters = nil
+}
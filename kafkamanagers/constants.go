@@ -14,14 +14,21 @@ const (
 	ConnectionType_SARAMA
 	ConnectionType_KAFKA_ACLS
 	ConnectionType_CONFLUENT_MDS
+	// Set up for the clusters with Connect clusters, a Schema Registry or ksqlDB clusters, they cannot be used as an ACL or Topic Manager.
+	ConnectionType_KAFKA_CONNECT
+	ConnectionType_SCHEMA_REGISTRY
+	ConnectionType_KSQL
 )
 
 func (a ConnectionType) String() string {
 	mapping := map[ConnectionType]string{
-		ConnectionType_UNKNOWN:       "unknown",
-		ConnectionType_SARAMA:        "sarama",
-		ConnectionType_KAFKA_ACLS:    "kafka_acl",
-		ConnectionType_CONFLUENT_MDS: "confluent_mds",
+		ConnectionType_UNKNOWN:         "unknown",
+		ConnectionType_SARAMA:          "sarama",
+		ConnectionType_KAFKA_ACLS:      "kafka_acl",
+		ConnectionType_CONFLUENT_MDS:   "confluent_mds",
+		ConnectionType_KAFKA_CONNECT:   "kafka_connect",
+		ConnectionType_SCHEMA_REGISTRY: "schema_registry",
+		ConnectionType_KSQL:            "ksql",
 	}
 	s, ok := mapping[a]
 	if !ok {
@@ -0,0 +1,54 @@
+package kafkamanagers
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+/*
+	Holds a REST client for every ksqlDB cluster configured for a cluster, keyed by the ksqlDB
+	cluster name used in the ksql definitions.
+*/
+type KSQLConnection struct {
+	ConnectionObjectBaseImpl
+	Clusters map[string]*resty.Client
+}
+
+func (c *KSQLConnection) InitiateAdminConnection(ctx context.Context, cConfig ksengine.ShepherdCluster) error {
+	if c.Clusters != nil {
+		return nil
+	}
+	if err := c.validateInputDetails(cConfig); err != nil {
+		return err
+	}
+	clusters := make(map[string]*resty.Client)
+	for _, v := range cConfig.KSQLClusters {
+		client, err := newRESTClient(ctx, v, "/info")
+		if err != nil {
+			return err
+		}
+		logger.Debugw("Set ksqlDB Client",
+			"Cluster Name", cConfig.Name,
+			"KSQL Cluster", v.Name)
+		clusters[v.Name] = client
+	}
+	c.Clusters = clusters
+	return nil
+}
+
+func (c *KSQLConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) error {
+	names := make(map[string]bool)
+	for _, v := range cConfig.KSQLClusters {
+		if names[v.Name] {
+			return c.generateCustomError("cluster.ksqlClusters.name", "ksqlDB cluster names need to be unique for a cluster. Duplicate Name: "+v.Name)
+		}
+		names[v.Name] = true
+	}
+	return nil
+}
+
+func (c *KSQLConnection) CloseAdminConnection() {
+	c.Clusters = nil
+}
@@ -0,0 +1,78 @@
+package kafkamanagers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+/*
+	Returns the error for a request to a REST server that failed or was answered with an error status code,
+	or nil if the request succeeded. 401 and 403 mean that the credentials are wrong or not authorized, any
+	other failure is treated as the server being unreachable.
+*/
+func NewRESTError(msg string, resp *resty.Response, err error) error {
+	if err != nil {
+		return ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, msg, err)
+	}
+	if resp.StatusCode() < 400 {
+		return nil
+	}
+	kind := ksengine.ErrClusterUnreachable
+	if resp.StatusCode() == 401 || resp.StatusCode() == 403 {
+		kind = ksengine.ErrAuthFailed
+	}
+	return ksengine.NewShepherdError(kind, msg, &HTTPStatusError{StatusCode: resp.StatusCode(), Body: string(resp.Body())})
+}
+
+/*
+	Sets up a JSON client for a REST endpoint working with the cluster and checks that the endpoint can be
+	reached with the provided details by calling checkPath.
+*/
+func newRESTClient(ctx context.Context, endpoint ksengine.RESTEndpoint, checkPath string) (*resty.Client, error) {
+	c := ConnectionObjectBaseImpl{}
+	if endpoint.TLSDetails.Enable2WaySSL {
+		if endpoint.TLSDetails.ClientCert == "" {
+			return nil, c.generateCustomError("tlsDetails.clientCert", fmt.Sprintf("2 Way SSL is enabled for %s. Need Keystore.", endpoint.Name))
+		}
+		if endpoint.TLSDetails.PrivateKey == "" {
+			return nil, c.generateCustomError("tlsDetails.privateKey", fmt.Sprintf("2 Way SSL is enabled for %s. Need Keystore Password.", endpoint.Name))
+		}
+	}
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+			fmt.Sprintf("Cannot parse the URL. Please check the URL and try again. Name: %s", endpoint.Name), err)
+	}
+
+	client := resty.New()
+	for _, cert := range endpoint.TLSDetails.TrustedCerts {
+		client.SetRootCertificate(cert)
+	}
+	if endpoint.TLSDetails.Enable2WaySSL {
+		cert, err := ksengine.GetClientCertificateFromCertNKey(endpoint.TLSDetails.ClientCert, endpoint.TLSDetails.PrivateKey, endpoint.TLSDetails.PrivateKeyPassword)
+		if err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+				fmt.Sprintf("Expected to Enable 2 Way SSL. But I was not able to create the Keystore. Private Cert Path: %s, Private Key Path: %s",
+					endpoint.TLSDetails.ClientCert, endpoint.TLSDetails.PrivateKey), err)
+		}
+		client.SetCertificates(*cert)
+	}
+	if endpoint.Username != "" {
+		client.SetBasicAuth(endpoint.Username, endpoint.Password)
+	}
+	client.SetHeader("Accept", "application/json")
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHostURL(u.String())
+	client.SetLogger(logger)
+	client.SetDebug(ksengine.IsDebugEnabled())
+
+	resp, err := client.R().SetContext(ctx).Get(checkPath)
+	if err := NewRESTError(fmt.Sprintf("Not able to call the REST endpoint with provided details. Name: %s", endpoint.Name), resp, err); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
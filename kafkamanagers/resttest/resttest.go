@@ -0,0 +1,73 @@
+/*
+	Package resttest provides the fake REST server used by the tests of the managers working with the REST
+	APIs (Kafka Connect, Schema Registry and ksqlDB).
+*/
+package resttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+/*
+	Handles a request to the fake API, with the state of the fake kept in memory. Returns the description of
+	the change made by the request, or an empty string if it did not change anything.
+*/
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) string
+
+/*
+	A REST server serving a fake API, recording the requests changing its state. The requests are handled
+	one at a time, so the handler does not need to protect its state. Failures can be injected for some
+	requests, which are then answered with an error status code without reaching the handler.
+*/
+type Server struct {
+	*httptest.Server
+	lock     sync.Mutex
+	handler  HandlerFunc
+	failures map[string]int
+	changes  []string
+}
+
+// Starts the server. It needs to be closed once the test is done.
+func NewServer(handler HandlerFunc) *Server {
+	s := &Server{handler: handler, failures: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if statusCode, found := s.failures[r.Method+" "+r.URL.Path]; found {
+		w.WriteHeader(statusCode)
+		w.Write([]byte(`{"message": "injected failure"}`))
+		return
+	}
+	if change := s.handler(w, r); change != "" {
+		s.changes = append(s.changes, change)
+	}
+}
+
+// Returns a client sending its requests to the server.
+func (s *Server) Client() *resty.Client {
+	return resty.New().SetHostURL(s.URL)
+}
+
+// Answers the requests with the method and path provided (e.g. "GET /subjects") with the status code instead.
+func (s *Server) Fail(methodAndPath string, statusCode int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[methodAndPath] = statusCode
+}
+
+// Returns the changes made since the last call, in the order they were made.
+func (s *Server) Changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ret := s.changes
+	s.changes = nil
+	return ret
+}
@@ -0,0 +1,238 @@
+package kafkamanagers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/Shopify/sarama"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+/*
+	RetryPolicy is shared by all the managers to execute their requests to the clusters. Every attempt gets
+	its own deadline (AttemptTimeout) and the wait between the attempts grows exponentially with some jitter,
+	until either the attempts run out or the max elapsed time is reached. Only the failures that can go away
+	on their own (see IsRetriable) are retried.
+*/
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	MaxElapsedTime  time.Duration
+	AttemptTimeout  time.Duration
+}
+
+var defaultRetryJitter float64 = 0.5
+
+// The values used for the settings that are not provided in the retry section of the core configuration.
+var DefaultRetryConfig ksengine.RetryConfig = ksengine.RetryConfig{
+	MaxAttempts:     5,
+	InitialInterval: 1 * time.Second,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          &defaultRetryJitter,
+	MaxElapsedTime:  2 * time.Minute,
+}
+
+// Creates the RetryPolicy from the retry section of the core configuration, falling back to DefaultRetryConfig.
+func NewRetryPolicy(c ksengine.RetryConfig, attemptTimeout time.Duration) RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts:     c.MaxAttempts,
+		InitialInterval: c.InitialInterval,
+		MaxInterval:     c.MaxInterval,
+		Multiplier:      c.Multiplier,
+		MaxElapsedTime:  c.MaxElapsedTime,
+		AttemptTimeout:  attemptTimeout,
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryConfig.MaxAttempts
+	}
+	if p.InitialInterval == 0 {
+		p.InitialInterval = DefaultRetryConfig.InitialInterval
+	}
+	if p.MaxInterval == 0 {
+		p.MaxInterval = DefaultRetryConfig.MaxInterval
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = DefaultRetryConfig.Multiplier
+	}
+	if c.Jitter != nil {
+		p.Jitter = *c.Jitter
+	} else {
+		p.Jitter = *DefaultRetryConfig.Jitter
+	}
+	if p.MaxElapsedTime == 0 {
+		p.MaxElapsedTime = DefaultRetryConfig.MaxElapsedTime
+	}
+	return p
+}
+
+/*
+	Executes f till it succeeds and returns the last error otherwise. f receives the context for the attempt,
+	so the clients accepting a context are cancelled along with it. The clients that do not accept one are
+	abandoned once the context is done, and keep running in the background till they give up on their own.
+*/
+func (p RetryPolicy) Do(ctx context.Context, opName string, f func(ctx context.Context) error) error {
+	_, err := p.DoWithResult(ctx, opName, func(ctx context.Context) (interface{}, error) {
+		return nil, f(ctx)
+	})
+	return err
+}
+
+/*
+	Same as Do, but returns the value returned by the successful attempt. An abandoned attempt may still
+	complete later, so f should return its result instead of writing it to a shared variable.
+*/
+func (p RetryPolicy) DoWithResult(ctx context.Context, opName string, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		v, err := p.attempt(ctx, f)
+		if err == nil {
+			return v, nil
+		}
+		if ctx.Err() != nil || !IsRetriable(err) || attempt >= p.MaxAttempts {
+			return nil, err
+		}
+		wait := p.Backoff(attempt)
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return nil, err
+		}
+		logger.Warnw("Request failed. Will try again.",
+			"Operation", opName,
+			"Try Count", attempt,
+			"Error", err.Error(),
+			"Cooldown before retry", wait.String())
+		if err := ksmisc.SleepWithContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type attemptResult struct {
+	value interface{}
+	err   error
+}
+
+func (p RetryPolicy) attempt(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	opCtx, cancel := ksmisc.OperationContext(ctx, p.AttemptTimeout)
+	defer cancel()
+	if err := opCtx.Err(); err != nil {
+		return nil, err
+	}
+	done := make(chan attemptResult, 1)
+	go func() {
+		v, err := f(opCtx)
+		done <- attemptResult{value: v, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-opCtx.Done():
+		return nil, opCtx.Err()
+	}
+}
+
+/*
+	Returns the wait after the provided attempt (starting at 1). The interval is multiplied for every attempt
+	up to MaxInterval, and then randomized by up to Jitter times the interval in either direction.
+*/
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	interval := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt-1))
+	if interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+	delta := p.Jitter * interval
+	return time.Duration(interval - delta + rand.Float64()*2*delta)
+}
+
+// The Kafka error codes that the brokers may stop returning once the cluster settles down.
+var retriableKafkaErrors = map[sarama.KError]bool{
+	sarama.ErrLeaderNotAvailable:              true,
+	sarama.ErrNotLeaderForPartition:           true,
+	sarama.ErrRequestTimedOut:                 true,
+	sarama.ErrBrokerNotAvailable:              true,
+	sarama.ErrReplicaNotAvailable:             true,
+	sarama.ErrNetworkException:                true,
+	sarama.ErrOffsetsLoadInProgress:           true,
+	sarama.ErrConsumerCoordinatorNotAvailable: true,
+	sarama.ErrNotCoordinatorForConsumer:       true,
+	sarama.ErrNotEnoughReplicas:               true,
+	sarama.ErrNotEnoughReplicasAfterAppend:    true,
+	sarama.ErrNotController:                   true,
+	sarama.ErrKafkaStorageError:               true,
+	sarama.ErrReassignmentInProgress:          true,
+	sarama.ErrPreferredLeaderNotAvailable:     true,
+	sarama.ErrEligibleLeadersNotAvailable:     true,
+}
+
+// The HTTP status codes returned by MDS (or any other REST server) that are worth trying again.
+var retriableHTTPStatuses = map[int]bool{
+	408: true,
+	429: true,
+	500: true,
+	502: true,
+	503: true,
+	504: true,
+}
+
+/*
+	Reports if the failure may go away if the request is sent again. The retriable Kafka error codes and
+	HTTP statuses, network failures and attempts that ran out of time are retriable. Anything else (like
+	the authorization failures or invalid requests) is not, as sending the same request again will fail
+	the same way.
+*/
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return retriableHTTPStatuses[statusErr.StatusCode]
+	}
+	if kErr, ok := kafkaErrorCode(err); ok {
+		return retriableKafkaErrors[kErr]
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, sarama.ErrOutOfBrokers),
+		errors.Is(err, sarama.ErrNotConnected),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// Finds the Kafka error code in err. Sarama returns the codes either as they are or within a TopicError.
+func kafkaErrorCode(err error) (sarama.KError, bool) {
+	var kErr sarama.KError
+	if errors.As(err, &kErr) {
+		return kErr, true
+	}
+	var topicErr *sarama.TopicError
+	if errors.As(err, &topicErr) {
+		return topicErr.Err, true
+	}
+	return sarama.ErrNoError, false
+}
+
+// The error status code (and the body) of a response from a REST server.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Status Code: %d, Response: %s", e.StatusCode, e.Body)
+}
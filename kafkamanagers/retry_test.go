@@ -0,0 +1,105 @@
+package kafkamanagers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+func (s *StackSuite) TestStackSuite_Retry_IsRetriable() {
+	cases := []struct {
+		in  error
+		out bool
+	}{
+		{nil, false},
+		{sarama.ErrLeaderNotAvailable, true},
+		{sarama.ErrTopicAuthorizationFailed, false},
+		{sarama.ErrUnknownTopicOrPartition, false},
+		{&sarama.TopicError{Err: sarama.ErrRequestTimedOut}, true},
+		{&sarama.TopicError{Err: sarama.ErrTopicAlreadyExists}, false},
+		{NewSaramaError("wrapped", sarama.ErrNotController), true},
+		{engine.NewShepherdError(engine.ErrClusterUnreachable, "mds", &HTTPStatusError{StatusCode: 503}), true},
+		{engine.NewShepherdError(engine.ErrAuthFailed, "mds", &HTTPStatusError{StatusCode: 403}), false},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{fmt.Errorf("wrapped: %w", sarama.ErrOutOfBrokers), true},
+		{errors.New("something else"), false},
+	}
+	for _, c := range cases {
+		s.Equal(c.out, IsRetriable(c.in), "Error: %v", c.in)
+	}
+}
+
+func (s *StackSuite) TestStackSuite_Retry_Backoff() {
+	p := RetryPolicy{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 2, Jitter: 0.5}
+	for attempt, base := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		for i := 0; i < 20; i++ {
+			wait := p.Backoff(attempt)
+			s.GreaterOrEqual(int64(wait), int64(base/2), "Attempt %d", attempt)
+			s.LessOrEqual(int64(wait), int64(base*3/2), "Attempt %d", attempt)
+		}
+	}
+
+	// Only a jitter that is not provided is defaulted, so an explicit 0 turns it off.
+	s.Equal(0.5, NewRetryPolicy(engine.RetryConfig{}, 0).Jitter)
+	noJitter := 0.0
+	p = NewRetryPolicy(engine.RetryConfig{InitialInterval: 100 * time.Millisecond, Jitter: &noJitter}, 0)
+	s.Equal(0.0, p.Jitter)
+	s.Equal(200*time.Millisecond, p.Backoff(2))
+}
+
+func (s *StackSuite) TestStackSuite_Retry_Do() {
+	p := NewRetryPolicy(engine.RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}, time.Second)
+
+	count := 0
+	err := p.Do(context.Background(), "Retriable", func(context.Context) error {
+		count++
+		return sarama.ErrLeaderNotAvailable
+	})
+	s.Equal(sarama.ErrLeaderNotAvailable, err)
+	s.Equal(3, count, "All the attempts should be used for retriable errors.")
+
+	count = 0
+	err = p.Do(context.Background(), "Non Retriable", func(context.Context) error {
+		count++
+		return sarama.ErrTopicAuthorizationFailed
+	})
+	s.Equal(sarama.ErrTopicAuthorizationFailed, err)
+	s.Equal(1, count, "Non retriable errors should not be retried.")
+
+	count = 0
+	v, err := p.DoWithResult(context.Background(), "Recovers", func(context.Context) (interface{}, error) {
+		count++
+		if count < 2 {
+			return nil, sarama.ErrNotController
+		}
+		return "done", nil
+	})
+	s.NoError(err)
+	s.Equal("done", v)
+	s.Equal(2, count)
+
+	// The attempts that run out of time are abandoned, so the counter is shared with their goroutines.
+	p.AttemptTimeout = 10 * time.Millisecond
+	var started int32
+	err = p.Do(context.Background(), "Attempt Timeout", func(ctx context.Context) error {
+		atomic.AddInt32(&started, 1)
+		<-ctx.Done()
+		return nil
+	})
+	s.True(errors.Is(err, context.DeadlineExceeded))
+	s.Equal(int32(3), atomic.LoadInt32(&started), "Attempts that run out of time should be retried.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = p.Do(ctx, "Cancelled", func(context.Context) error {
+		s.Fail("No attempt should be made once the context is done.")
+		return nil
+	})
+	s.True(errors.Is(err, context.Canceled))
+}
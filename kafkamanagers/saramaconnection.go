@@ -1,16 +1,14 @@
 package kafkamanagers
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"io/ioutil"
-	"os"
-	"os/signal"
-	"sync"
-	"syscall"
 
 	"github.com/Shopify/sarama"
 	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
@@ -19,63 +17,113 @@ import (
 )
 
 var (
-	wg     sync.WaitGroup
 	SHA256 scram.HashGeneratorFcn = sha256.New
 	SHA512 scram.HashGeneratorFcn = sha512.New
-	term                          = make(chan os.Signal)
-	logger *ksengine.ShepherdLogger
+	logger                        = ksengine.Shepherd.GetLogger()
 )
 
 type SaramaConnection struct {
 	ConnectionObjectBaseImpl
 	SCA *sarama.ClusterAdmin
+	// The client used by SCA. It is closed along with SCA.
+	Client sarama.Client
+	// The Kafka version the requests are made with, as per the kafkaVersion of the cluster.
+	Version sarama.KafkaVersion
 }
 
-func (c *SaramaConnection) InitiateAdminConnection(cConfig ksengine.ShepherdCluster) {
-	logger = ksengine.Shepherd.GetLogger()
+/*
+	Sarama does not accept a context while connecting, so the connection is abandoned if the context is done
+	before the brokers respond. The abandoned attempt gives up on its own after the Sarama network timeouts.
+*/
+func (c *SaramaConnection) InitiateAdminConnection(ctx context.Context, cConfig ksengine.ShepherdCluster) error {
 	if c.SCA == nil {
-		c.validateInputDetails(cConfig)
-		conf := c.understandClusterTopology(&cConfig)
-		ca, err := sarama.NewClusterAdmin(cConfig.BootstrapServers, conf)
+		if err := c.validateInputDetails(cConfig); err != nil {
+			return err
+		}
+		conf, err := c.understandClusterTopology(&cConfig)
+		if err != nil {
+			return err
+		}
+		var ca sarama.ClusterAdmin
+		var client sarama.Client
+		err = ksmisc.RunWithContext(ctx, func() (err error) {
+			cl, err := sarama.NewClient(cConfig.BootstrapServers, conf)
+			if err != nil {
+				return err
+			}
+			admin, err := sarama.NewClusterAdminFromClient(cl)
+			if err != nil {
+				cl.Close()
+				return err
+			}
+			// Nobody waits for an abandoned connection, so it is closed right away.
+			if ctx.Err() != nil {
+				admin.Close()
+				return ctx.Err()
+			}
+			ca, client = admin, cl
+			return nil
+		})
 		if err != nil {
-			logger.Fatalw("Cannot set up the connection to Kafka Cluster. ",
-				"Bootstrap Server", cConfig.BootstrapServers,
-				"Error", err)
+			return NewSaramaError(fmt.Sprintf("Cannot set up the connection to Kafka Cluster. Bootstrap Server: %v", cConfig.BootstrapServers), err)
 		}
-		addShutdownHook(&ca)
-		c.SCA = &ca
+		c.SCA, c.Client, c.Version = &ca, client, conf.Version
 	}
+	return nil
 }
 
-func (c *SaramaConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) {
-	c.executeBaseValidations(&cConfig)
+func (c *SaramaConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) error {
+	if err := c.executeBaseValidations(&cConfig); err != nil {
+		return err
+	}
 	if len(cConfig.BootstrapServers) == 0 {
-		c.generateCustomError(true, "BootstrapServers", "")
+		return c.generateCustomError("BootstrapServers", "")
 	}
 	if cConfig.Configs[0]["security.protocol"] == "" {
-		c.generateCustomError(true, "security.protocol", "")
+		return c.generateCustomError("security.protocol", "")
 	}
-
+	return nil
 }
 
 func (c *SaramaConnection) CloseAdminConnection() {
-	wg.Add(1)
-	term <- syscall.SIGQUIT
-	wg.Wait()
+	if c.SCA == nil {
+		return
+	}
+	logger.Info("Trying to Close Kafka Cluster connection. Initial Try")
+	err := (*c.SCA).Close()
+	for i := 2; i <= 5 && err != nil; i++ {
+		logger.Errorw("Failed to Close Kafka Cluster connection.", "Error", err)
+		logger.Warnw("Trying to Close Kafka Cluster connection again.", "Try", i)
+		err = (*c.SCA).Close()
+	}
+	if err != nil {
+		logger.Errorw("Aborting retries as I was still not able to close the connection even after 5 retries.", "Error", err)
+		return
+	}
+	logger.Info("Kafka Cluster Connection Successfully closed")
+	c.SCA, c.Client = nil, nil
 }
 
-func (conn *SaramaConnection) understandClusterTopology(sc *ksengine.ShepherdCluster) (conf *sarama.Config) {
+func (conn *SaramaConnection) understandClusterTopology(sc *ksengine.ShepherdCluster) (conf *sarama.Config, err error) {
 	c := sarama.NewConfig()
 
 	c.ClientID = sc.ClientID
 	// This is the minimum version required to support prefixed ACLs.
 	c.Version = sarama.V2_0_0_0
+	if sc.KafkaVersion != "" {
+		version, err := sarama.ParseKafkaVersion(sc.KafkaVersion)
+		if err != nil || !version.IsAtLeast(sarama.V2_0_0_0) {
+			return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+				fmt.Sprintf("Kafka Version needs to be a supported version of at least 2.0.0. Cluster Name: %s, Kafka Version Provided: %q", sc.Name, sc.KafkaVersion), err)
+		}
+		c.Version = version
+	}
 	// Figure Out the Security Protocol
 	switch s := sc.Configs[0]["security.protocol"]; s {
 	case "SASL_SSL":
 		logger.Debugf("Inside the %v switch statement", s)
 		if sc.Configs[0]["sasl.jaas.config"] == "" {
-			conn.generateCustomError(true, "sasl.jaas.config", "SASL_SSL security protocol needs sasl.jaas.config to be configured. Exiting process.")
+			return nil, conn.generateCustomError("sasl.jaas.config", "SASL_SSL security protocol needs sasl.jaas.config to be configured.")
 		}
 		c.Net.SASL.Enable = true
 		c.Net.SASL.User = ksmisc.FindSASLValues(sc.Configs[0]["sasl.jaas.config"], "username")
@@ -86,7 +134,7 @@ func (conn *SaramaConnection) understandClusterTopology(sc *ksengine.ShepherdClu
 	case "SASL_PLAINTEXT":
 		logger.Debugf("Inside the %v switch statement", s)
 		if sc.Configs[0]["sasl.jaas.config"] == "" {
-			conn.generateCustomError(true, "sasl.jaas.config", "SASL_SSL security protocol needs sasl.jaas.config to be configured. Exiting process.")
+			return nil, conn.generateCustomError("sasl.jaas.config", "SASL_SSL security protocol needs sasl.jaas.config to be configured.")
 		}
 		c.Net.SASL.Enable = true
 		c.Net.SASL.User = ksmisc.FindSASLValues(sc.Configs[0]["sasl.jaas.config"], "username")
@@ -102,9 +150,8 @@ func (conn *SaramaConnection) understandClusterTopology(sc *ksengine.ShepherdClu
 		// Do Nothing
 		logger.Debug("Inside the PLAINTEXT switch statement")
 	default:
-		logger.Fatalw("Unknown security mode supplied for Cluster Config",
-			"Cluster Name", sc.Name,
-			"Cluster Security Protocol Provided", s)
+		return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+			fmt.Sprintf("Unknown security mode supplied for Cluster Config. Cluster Name: %s, Cluster Security Protocol Provided: %q", sc.Name, s), nil)
 	}
 
 	// Figure out the sasl mechanism
@@ -129,32 +176,7 @@ func (conn *SaramaConnection) understandClusterTopology(sc *ksengine.ShepherdClu
 		//
 	}
 
-	return c
-}
-
-func addShutdownHook(sca *sarama.ClusterAdmin) {
-	var err error = nil
-	signal.Notify(term, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
-	go func() {
-		defer wg.Done()
-		s := <-term
-		logger.Infof("%s received. Shutdown Process Initiated", s.String())
-		logger.Info("Trying to Close Kafka Cluster connection. Initial Try")
-		err = (*sca).Close()
-		for i := 2; i <= 5 && err != nil; i++ {
-			logger.Errorw("Failed to Close Kafka Cluster connection.", "Error", err)
-			logger.Warnw("Trying to Close Kafka Cluster connection again.", "Try", i)
-			err = (*sca).Close()
-			if err != nil {
-				logger.Errorw("Failed to Close Kafka Cluster connection.", "Error", err)
-			}
-		}
-		if err != nil {
-			logger.Fatalf("Aborting retries as I was still not able to close the connection even after 5 retries. Will exit ungracefully.")
-		} else {
-			logger.Info("Kafka Cluster Connection Successfully closed")
-		}
-	}()
+	return c, nil
 }
 
 func createTLSConfig(sc *ksengine.ShepherdCluster) *tls.Config {
@@ -1,6 +1,7 @@
 package kafkamanagers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -52,7 +53,8 @@ func (s *StackSuite) TestStackSuite_SaramaConnection() {
 		for _, ver := range c.inClusterVersion {
 			pwd, _ := os.Getwd()
 			os.Setenv("SHEPHERD_CONFIG_FILE_LOCATION", strings.ReplaceAll(c.inConfigFile, "{relativePath}", pwd))
-			engine.Init()
+			st, err := engine.Load(engine.Options{})
+			s.Require().NoError(err)
 			misc.DottedLineOutput(fmt.Sprintf("Testing for %v cluster with Kafka Version %v", c.inClusterType, ver), "=", 80)
 			cMap, err := setupContainers(c.inClusterType, ver)
 			if err != nil {
@@ -68,14 +70,15 @@ func (s *StackSuite) TestStackSuite_SaramaConnection() {
 				}
 				s.Fail("Unable to setup the Kafka containers for testing. Error: ", err)
 			}
-			InitiateAllKafkaConnections(engine.SpdCore.Configs.ConfigRoot)
-			conn := Connections[KafkaConnectionsKey{ClusterName: c.inClusterName, ConnectionType: ConnectionType_SARAMA}].Connection.(*SaramaConnection)
-			brokers, controller, _ := (*conn.SCA).DescribeCluster()
+			connections := NewKafkaConnections()
+			s.NoError(connections.InitiateAllKafkaConnections(context.Background(), st.Core.Configs.ConfigRoot))
+			brokers, controller, _ := (*connections.GetSaramaConnection(c.inClusterName)).DescribeCluster()
 			fmt.Println(brokers, controller)
 
 			s.EqualValues(c.out[1], controller, fmt.Sprintf("FileName: %v\nCluster Type: %v\nCluster Version: %v\nError: %v; %v", c.inConfigFile, c.inClusterType, c.inClusterVersion, c.err, err))
 			s.EqualValues(c.out[0], len(brokers), fmt.Sprintf("FileName: %v\nCluster Type: %v\nCluster Version: %v\nError: %v; %v", c.inConfigFile, c.inClusterType, c.inClusterVersion, c.err, err))
 
+			connections.CloseAllKafkaConnections()
 			for _, ctr := range cMap.containers {
 				ctr.Terminate(cMap.ctx)
 			}
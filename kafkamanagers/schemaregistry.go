@@ -0,0 +1,43 @@
+package kafkamanagers
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+// Holds the REST client of the Schema Registry configured for a cluster.
+type SchemaRegistryConnection struct {
+	ConnectionObjectBaseImpl
+	SR *resty.Client
+}
+
+func (c *SchemaRegistryConnection) InitiateAdminConnection(ctx context.Context, cConfig ksengine.ShepherdCluster) error {
+	if c.SR != nil {
+		return nil
+	}
+	if err := c.validateInputDetails(cConfig); err != nil {
+		return err
+	}
+	client, err := newRESTClient(ctx, cConfig.SchemaRegistry, "/subjects")
+	if err != nil {
+		return err
+	}
+	logger.Debugw("Set Schema Registry Client",
+		"Cluster Name", cConfig.Name,
+		"Schema Registry", cConfig.SchemaRegistry.URL)
+	c.SR = client
+	return nil
+}
+
+func (c *SchemaRegistryConnection) validateInputDetails(cConfig ksengine.ShepherdCluster) error {
+	if cConfig.SchemaRegistry.URL == "" {
+		return c.generateCustomError("cluster.schemaRegistry.url", "Need the Schema Registry URL to manage the topic schemas.")
+	}
+	return nil
+}
+
+func (c *SchemaRegistryConnection) CloseAdminConnection() {
+	c.SR = nil
+}
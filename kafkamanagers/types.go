@@ -1,8 +1,13 @@
 package kafkamanagers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 
+	"github.com/Shopify/sarama"
 	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
 )
 
@@ -21,21 +26,42 @@ type KafkaConnectionsValue struct {
 }
 
 type ConnectionObject interface {
-	InitiateAdminConnection(ksengine.ShepherdCluster)
-	validateInputDetails(ksengine.ShepherdCluster)
+	InitiateAdminConnection(context.Context, ksengine.ShepherdCluster) error
+	validateInputDetails(ksengine.ShepherdCluster) error
 	CloseAdminConnection()
 }
 
 type ConnectionObjectBaseImpl struct{}
 
-var (
-	Connections KafkaConnections = make(KafkaConnections)
-)
+/*
+	Every Shepherd instance keeps its own connection registry, so the registries are created with
+	NewKafkaConnections instead of being shared by the process.
+*/
+func NewKafkaConnections() KafkaConnections {
+	return make(KafkaConnections)
+}
+
+func (c KafkaConnections) InitiateAllKafkaConnections(ctx context.Context, clusters ksengine.ConfigRoot) error {
+	for _, cluster := range clusters.Clusters {
+		if cluster.IsEnabled {
+			if err := c.InitiateKafkaConnection(ctx, cluster); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-func InitiateAllKafkaConnections(clusters ksengine.ConfigRoot) {
+/*
+	Sets up the ACL Manager and the Topic Manager connections for a single cluster, along with the Kafka
+	Connect, the Schema Registry and the ksqlDB connections if the cluster has Connect clusters, a Schema
+	Registry or ksqlDB clusters.
+	The connection registry is not guarded, so this should not be called concurrently for different clusters.
+*/
+func (c KafkaConnections) InitiateKafkaConnection(ctx context.Context, cluster ksengine.ShepherdCluster) error {
 	var temp ConnectionType
 	f := func(clusterName string, cType ConnectionType) KafkaConnectionsValue {
-		v, found := Connections[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: cType}]
+		v, found := c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: cType}]
 		if !found {
 			wg := new(sync.WaitGroup)
 			switch cType {
@@ -47,7 +73,7 @@ func InitiateAllKafkaConnections(clusters ksengine.ConfigRoot) {
 					WaitGroupRef:   wg,
 					IsInitiated:    false,
 				}
-				Connections[key] = val
+				c[key] = val
 				return val
 			case ConnectionType_CONFLUENT_MDS:
 				key := KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_CONFLUENT_MDS}
@@ -57,70 +83,243 @@ func InitiateAllKafkaConnections(clusters ksengine.ConfigRoot) {
 					WaitGroupRef:   wg,
 					IsInitiated:    false,
 				}
-				Connections[key] = val
+				c[key] = val
+				return val
+			case ConnectionType_KAFKA_CONNECT:
+				key := KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_KAFKA_CONNECT}
+				val := KafkaConnectionsValue{
+					Connection:     &KafkaConnectConnection{},
+					ConnectionType: ConnectionType_KAFKA_CONNECT,
+					WaitGroupRef:   wg,
+					IsInitiated:    false,
+				}
+				c[key] = val
+				return val
+			case ConnectionType_SCHEMA_REGISTRY:
+				key := KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_SCHEMA_REGISTRY}
+				val := KafkaConnectionsValue{
+					Connection:     &SchemaRegistryConnection{},
+					ConnectionType: ConnectionType_SCHEMA_REGISTRY,
+					WaitGroupRef:   wg,
+					IsInitiated:    false,
+				}
+				c[key] = val
+				return val
+			case ConnectionType_KSQL:
+				key := KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_KSQL}
+				val := KafkaConnectionsValue{
+					Connection:     &KSQLConnection{},
+					ConnectionType: ConnectionType_KSQL,
+					WaitGroupRef:   wg,
+					IsInitiated:    false,
+				}
+				c[key] = val
 				return val
 			}
 		}
 		return v
 	}
 
-	for _, cluster := range clusters.Clusters {
-		if cluster.IsEnabled {
-			v, err := temp.GetValue(cluster.ACLManager)
-			if err != nil {
-				logger.Fatalw("Cannot Proceed with unknown Connection Type.",
-					"Cluster Name", cluster.Name,
-					"Is Enabled", cluster.IsEnabled,
-					"ACL Type provided", cluster.ACLManager,
-					"Expected Types", temp.stringJoin())
-			}
-			val := f(cluster.Name, v)
-			val.Connection.InitiateAdminConnection(cluster)
-
-			v, err = temp.GetValue(cluster.TopicManager)
-			if err != nil {
-				logger.Fatalw("Cannot Proceed with unknown Connection Type.",
-					"Cluster Name", cluster.Name,
-					"Is Enabled", cluster.IsEnabled,
-					"ACL Type provided", cluster.ACLManager,
-					"Expected Types", temp.stringJoin())
-			}
-			val = f(cluster.Name, v)
-			val.Connection.InitiateAdminConnection(cluster)
+	v, err := temp.GetValue(cluster.ACLManager)
+	if err != nil {
+		return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+			fmt.Sprintf("Cannot Proceed with unknown Connection Type. Cluster Name: %s, ACL Type provided: %q, Expected Types: %s",
+				cluster.Name, cluster.ACLManager, temp.stringJoin()), nil)
+	}
+	val := f(cluster.Name, v)
+	if err := val.Connection.InitiateAdminConnection(ctx, cluster); err != nil {
+		return err
+	}
+
+	v, err = temp.GetValue(cluster.TopicManager)
+	if err != nil {
+		return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+			fmt.Sprintf("Cannot Proceed with unknown Connection Type. Cluster Name: %s, Topic Manager Type provided: %q, Expected Types: %s",
+				cluster.Name, cluster.TopicManager, temp.stringJoin()), nil)
+	}
+	val = f(cluster.Name, v)
+	if err := val.Connection.InitiateAdminConnection(ctx, cluster); err != nil {
+		return err
+	}
+
+	if len(cluster.ConnectClusters) != 0 {
+		if err := f(cluster.Name, ConnectionType_KAFKA_CONNECT).Connection.InitiateAdminConnection(ctx, cluster); err != nil {
+			return err
+		}
+	}
+	if cluster.SchemaRegistry.URL != "" {
+		if err := f(cluster.Name, ConnectionType_SCHEMA_REGISTRY).Connection.InitiateAdminConnection(ctx, cluster); err != nil {
+			return err
 		}
 	}
+	if len(cluster.KSQLClusters) == 0 {
+		return nil
+	}
+	return f(cluster.Name, ConnectionType_KSQL).Connection.InitiateAdminConnection(ctx, cluster)
 }
 
-func CloseAllKafkaConnections() {
+func (c KafkaConnections) CloseAllKafkaConnections() {
 	wg := new(sync.WaitGroup)
-	for _, v := range Connections {
-		go v.Connection.CloseAdminConnection()
+	for _, v := range c {
+		wg.Add(1)
+		go func(conn ConnectionObject) {
+			defer wg.Done()
+			conn.CloseAdminConnection()
+		}(v.Connection)
 	}
 	wg.Wait()
 }
 
-func (c *ConnectionObjectBaseImpl) generateCustomError(isFatal bool, attrName string, errMsg string) {
-	errVal := "Cannot set up connection without the attribute. Exiting process."
+/*
+	Returns the Sarama Cluster Admin for the cluster. The connection is expected to be initiated already
+	by InitiateKafkaConnection.
+*/
+func (c KafkaConnections) GetSaramaConnection(clusterName string) *sarama.ClusterAdmin {
+	return c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_SARAMA}].Connection.(*SaramaConnection).SCA
+}
+
+// Returns the client used by the Sarama connection of the cluster, for the requests the Cluster Admin does not support.
+func (c KafkaConnections) GetSaramaClient(clusterName string) sarama.Client {
+	return c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_SARAMA}].Connection.(*SaramaConnection).Client
+}
+
+/*
+	Fails with ErrConfigInvalid if the requests of the feature cannot be made to the cluster, as its Sarama
+	connection is set up for a Kafka version before the one provided (see the kafkaVersion of the cluster).
+*/
+func (c KafkaConnections) RequireSaramaVersion(clusterName string, version sarama.KafkaVersion, feature string) error {
+	current := c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_SARAMA}].Connection.(*SaramaConnection).Version
+	if current.IsAtLeast(version) {
+		return nil
+	}
+	return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+		fmt.Sprintf("%s needs the kafkaVersion of the cluster to be at least %s. Cluster Name: %s, Kafka Version: %s", feature, version, clusterName, current), nil)
+}
+
+/*
+	Returns the MDS connection for the cluster. The connection is expected to be initiated already
+	by InitiateKafkaConnection.
+*/
+func (c KafkaConnections) GetConfluentMDSConnection(clusterName string) *ConfluentMDSConnection {
+	return c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_CONFLUENT_MDS}].Connection.(*ConfluentMDSConnection)
+}
+
+/*
+	Returns the Kafka Connect connection for the cluster, or nil if the cluster has no Connect clusters.
+	The connection is expected to be initiated already by InitiateKafkaConnection.
+*/
+func (c KafkaConnections) GetKafkaConnectConnection(clusterName string) *KafkaConnectConnection {
+	v, found := c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_KAFKA_CONNECT}]
+	if !found {
+		return nil
+	}
+	return v.Connection.(*KafkaConnectConnection)
+}
+
+/*
+	Returns the Schema Registry connection for the cluster, or nil if the cluster has no Schema Registry.
+	The connection is expected to be initiated already by InitiateKafkaConnection.
+*/
+func (c KafkaConnections) GetSchemaRegistryConnection(clusterName string) *SchemaRegistryConnection {
+	v, found := c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_SCHEMA_REGISTRY}]
+	if !found {
+		return nil
+	}
+	return v.Connection.(*SchemaRegistryConnection)
+}
+
+/*
+	Returns the ksqlDB connection for the cluster, or nil if the cluster has no ksqlDB clusters.
+	The connection is expected to be initiated already by InitiateKafkaConnection.
+*/
+func (c KafkaConnections) GetKSQLConnection(clusterName string) *KSQLConnection {
+	v, found := c[KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: ConnectionType_KSQL}]
+	if !found {
+		return nil
+	}
+	return v.Connection.(*KSQLConnection)
+}
+
+func (c *ConnectionObjectBaseImpl) generateCustomError(attrName string, errMsg string) error {
+	errVal := "Cannot set up connection without the attribute."
 	if errMsg != "" {
 		errVal = errMsg
 	}
-	if isFatal {
-		logger.Fatalw("Attribute missing but is required to prepare proper connection",
-			"Attribute Name", attrName,
-			"Error Details", errVal)
-	}
-	logger.Errorw("Attribute missing but is required to prepare proper connection",
-		"Attribute Name", attrName,
-		"Error Details", errVal)
+	return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+		fmt.Sprintf("Attribute %s missing but is required to prepare proper connection. %s", attrName, errVal), nil)
 }
 
-func (c *ConnectionObjectBaseImpl) executeBaseValidations(cConfig *ksengine.ShepherdCluster) {
+func (c *ConnectionObjectBaseImpl) executeBaseValidations(cConfig *ksengine.ShepherdCluster) error {
 	if cConfig.TLSDetails.Enable2WaySSL {
 		if cConfig.TLSDetails.ClientCert == "" {
-			c.generateCustomError(true, "cluster.tlsDetails.clientCert", "2 Way SSL is enabled. Need Keystore.")
+			return c.generateCustomError("cluster.tlsDetails.clientCert", "2 Way SSL is enabled. Need Keystore.")
 		}
 		if cConfig.TLSDetails.PrivateKey == "" {
-			c.generateCustomError(true, "cluster.tlsDetails.privateKey", "2 Way SSL is enabled. Need Keystore Password.")
+			return c.generateCustomError("cluster.tlsDetails.privateKey", "2 Way SSL is enabled. Need Keystore Password.")
 		}
 	}
+	return nil
+}
+
+/*
+	Wraps an error received from Sarama as a ShepherdError. The authentication and authorization failures
+	reported by the brokers are ErrAuthFailed, anything else is treated as the cluster being unreachable.
+*/
+func NewSaramaError(msg string, err error) error {
+	kind := ksengine.ErrClusterUnreachable
+	if kErr, ok := kafkaErrorCode(err); ok {
+		switch kErr {
+		case sarama.ErrSASLAuthenticationFailed, sarama.ErrClusterAuthorizationFailed, sarama.ErrTopicAuthorizationFailed,
+			sarama.ErrGroupAuthorizationFailed, sarama.ErrTransactionalIDAuthorizationFailed, sarama.ErrDelegationTokenAuthorizationFailed:
+			kind = ksengine.ErrAuthFailed
+		}
+	}
+	return ksengine.NewShepherdError(kind, msg, err)
+}
+
+/*
+	Collects the failures of the requests executed concurrently, so that they can be returned once all the
+	requests are done. It is safe to use from multiple goroutines.
+*/
+type ErrorCollector struct {
+	lock sync.Mutex
+	errs []error
+}
+
+func (c *ErrorCollector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+/*
+	Returns nil if nothing failed, or a ShepherdError listing every failure and wrapping the first one. The
+	kind is ErrAuthFailed if any of the failures is an authentication failure, the kind of the first failure
+	that has one otherwise, and ErrClusterUnreachable if none of them has a kind.
+*/
+func (c *ErrorCollector) Err(msg string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+	var kind error
+	details := make([]string, 0, len(c.errs))
+	for _, err := range c.errs {
+		details = append(details, err.Error())
+		var sErr *ksengine.ShepherdError
+		switch {
+		case errors.Is(err, ksengine.ErrAuthFailed):
+			kind = ksengine.ErrAuthFailed
+		case kind == nil && errors.As(err, &sErr):
+			kind = sErr.Kind
+		}
+	}
+	if kind == nil {
+		kind = ksengine.ErrClusterUnreachable
+	}
+	return ksengine.NewShepherdError(kind, fmt.Sprintf("%s. %d request(s) failed: %s", msg, len(c.errs), strings.Join(details, "; ")), c.errs[0])
 }
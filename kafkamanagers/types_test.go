@@ -0,0 +1,68 @@
+package kafkamanagers
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+func (s *StackSuite) TestStackSuite_ErrorCollector() {
+	c := new(ErrorCollector)
+	c.Add(nil)
+	s.NoError(c.Err("Nothing failed"))
+
+	wg := new(sync.WaitGroup)
+	for _, err := range []error{
+		NewSaramaError("Cannot create Topic a", sarama.ErrRequestTimedOut),
+		NewSaramaError("Cannot create Topic b", sarama.ErrTopicAuthorizationFailed),
+		nil,
+	} {
+		wg.Add(1)
+		go func(err error) {
+			defer wg.Done()
+			c.Add(err)
+		}(err)
+	}
+	wg.Wait()
+	err := c.Err("Topic Creation failed")
+	s.True(errors.Is(err, engine.ErrAuthFailed))
+	s.Contains(err.Error(), "2 request(s) failed")
+	s.Contains(err.Error(), "Cannot create Topic a")
+	s.Contains(err.Error(), "Cannot create Topic b")
+
+	c = new(ErrorCollector)
+	c.Add(engine.NewShepherdError(engine.ErrConfigInvalid, "Refused", nil))
+	s.True(errors.Is(c.Err("Failed"), engine.ErrConfigInvalid))
+	c = new(ErrorCollector)
+	c.Add(errors.New("plain"))
+	s.True(errors.Is(c.Err("Failed"), engine.ErrClusterUnreachable))
+}
+
+func (s *StackSuite) TestStackSuite_SaramaVersion() {
+	conn := &SaramaConnection{}
+	cluster := engine.ShepherdCluster{Name: "c1", Configs: []engine.NVPairs{{"security.protocol": "PLAINTEXT"}}}
+	conf, err := conn.understandClusterTopology(&cluster)
+	s.NoError(err)
+	s.Equal(sarama.V2_0_0_0, conf.Version, "Prefixed ACLs need at least 2.0.0")
+
+	cluster.KafkaVersion = "2.7.0"
+	conf, err = conn.understandClusterTopology(&cluster)
+	s.NoError(err)
+	s.Equal(sarama.V2_7_0_0, conf.Version)
+
+	for _, v := range []string{"1.1.0", "latest"} {
+		cluster.KafkaVersion = v
+		_, err = conn.understandClusterTopology(&cluster)
+		s.True(errors.Is(err, engine.ErrConfigInvalid), "Kafka Version: %s, Error: %v", v, err)
+	}
+
+	connections := KafkaConnections{
+		{ClusterName: "c1", ConnectionType: ConnectionType_SARAMA}: {Connection: &SaramaConnection{Version: sarama.V2_6_0_0}},
+	}
+	s.NoError(connections.RequireSaramaVersion("c1", sarama.V2_6_0_0, "Client Quota Management"))
+	err = connections.RequireSaramaVersion("c1", sarama.V2_7_0_0, "SCRAM Credential Management")
+	s.True(errors.Is(err, engine.ErrConfigInvalid))
+	s.EqualError(err, "invalid configuration: SCRAM Credential Management needs the kafkaVersion of the cluster to be at least 2.7.0. Cluster Name: c1, Kafka Version: 2.6.0")
+}
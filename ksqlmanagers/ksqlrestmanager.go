@@ -0,0 +1,233 @@
+package ksqlmanagers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+type KSQLRESTExecutionManagerImpl struct {
+	connections kafkamanagers.KafkaConnections
+	expected    *ksengine.KSQLConfigMapping
+	retry       kafkamanagers.RetryPolicy
+}
+
+const (
+	ksql_Statements = "/ksql"
+	ksql_ListState  = "SHOW STREAMS; SHOW TABLES; SHOW QUERIES;"
+)
+
+/*
+	Creates the KSQL Manager working with the ksqlDB connections in the provided registry. The statements
+	are the ones declared by the configurations (usually State.Maps.KSQL). Every request to the ksqlDB
+	clusters is executed with the provided retry policy.
+*/
+func NewKSQLRESTManager(connections kafkamanagers.KafkaConnections, expected *ksengine.KSQLConfigMapping, retry kafkamanagers.RetryPolicy) KSQLExecutionManager {
+	return KSQLRESTExecutionManagerImpl{connections: connections, expected: expected, retry: retry}
+}
+
+// The REST clients of the ksqlDB clusters configured for the cluster, keyed by the ksqlDB cluster name.
+func (c KSQLRESTExecutionManagerImpl) getKSQLClients(clusterName string) map[string]*resty.Client {
+	connObj := c.connections.GetKSQLConnection(clusterName)
+	if connObj == nil {
+		return map[string]*resty.Client{}
+	}
+	return connObj.Clusters
+}
+
+// The statements declared for the ksqlDB clusters configured for the cluster.
+func (c KSQLRESTExecutionManagerImpl) expectedStatements(clusterName string) ksengine.KSQLConfigMapping {
+	names := []string{}
+	for name := range c.getKSQLClients(clusterName) {
+		names = append(names, name)
+	}
+	return c.expected.Filter(names)
+}
+
+/*
+	Sends the statements to the /ksql endpoint of the ksqlDB cluster using the retry policy. Every attempt
+	gets a new request bound to the context of the attempt.
+*/
+func (c KSQLRESTExecutionManagerImpl) executeKSQLRequest(ctx context.Context, client *resty.Client, opName string, errMsg string,
+	statements string) (*resty.Response, error) {
+	resp, err := c.retry.DoWithResult(ctx, opName, func(opCtx context.Context) (interface{}, error) {
+		resp, err := client.R().SetContext(opCtx).
+			SetBody(map[string]interface{}{"ksql": statements, "streamsProperties": map[string]string{}}).
+			Post(ksql_Statements)
+		if err := kafkamanagers.NewRESTError(errMsg, resp, err); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*resty.Response), nil
+}
+
+// Returns the streams, the tables and the persistent queries of the ksqlDB clusters configured for the cluster.
+func (c KSQLRESTExecutionManagerImpl) GetClusterKSQL(ctx context.Context, clusterName string) (ksengine.KSQLStateMapping, error) {
+	type object struct {
+		Name string `json:"name"`
+	}
+	type entity struct {
+		Streams []object `json:"streams"`
+		Tables  []object `json:"tables"`
+		Queries []struct {
+			ID          string   `json:"id"`
+			QueryString string   `json:"queryString"`
+			Sinks       []string `json:"sinks"`
+			QueryType   string   `json:"queryType"`
+		} `json:"queries"`
+	}
+	ret := ksengine.KSQLStateMapping{}
+	for name, client := range c.getKSQLClients(clusterName) {
+		resp, err := c.executeKSQLRequest(ctx, client, "List KSQL State", fmt.Sprintf("Cannot list the streams, the tables and the queries of the ksqlDB cluster %s", name),
+			ksql_ListState)
+		if err != nil {
+			return nil, err
+		}
+		r := []entity{}
+		if err := client.JSONUnmarshal(resp.Body(), &r); err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing ksqlDB Response Data", err)
+		}
+		state := ksengine.KSQLState{Objects: make(map[string]bool), Queries: []ksengine.KSQLQuery{}}
+		for _, e := range r {
+			for _, o := range append(e.Streams, e.Tables...) {
+				state.Objects[o.Name] = true
+			}
+			// The push queries only live as long as their requests, only the persistent ones are managed.
+			for _, q := range e.Queries {
+				if q.QueryType == "" || q.QueryType == "PERSISTENT" {
+					state.Queries = append(state.Queries, ksengine.KSQLQuery{ID: q.ID, Sinks: q.Sinks, Statement: q.QueryString})
+				}
+			}
+		}
+		ret[name] = state
+	}
+	return ret, nil
+}
+
+/*
+	Lists the statements to be applied and the queries to be terminated to align the ksqlDB clusters of the
+	cluster with the configurations as PlanChanges. Nothing is executed.
+*/
+func (c KSQLRESTExecutionManagerImpl) PlanKSQL(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	provisioned, err := c.GetClusterKSQL(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return ksengine.PlanKSQLChanges(clusterName, c.expectedStatements(clusterName), provisioned, executeCreateFlow, executeModifyFlow, executeDeleteFlow), nil
+}
+
+func (c KSQLRESTExecutionManagerImpl) ExecuteKSQL(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool, dryRun bool) error {
+	changes, err := c.PlanKSQL(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	if err != nil {
+		return err
+	}
+	return c.ApplyKSQLPlan(ctx, clusterName, changes, dryRun)
+}
+
+/*
+	Executes the KSQL changes of a saved plan for the cluster. The queries to be terminated are terminated
+	first, then the statements are applied in the order they are declared in, so the streams and the tables
+	are created before the queries reading from them. The drifted queries are replaced with CREATE OR REPLACE.
+*/
+func (c KSQLRESTExecutionManagerImpl) ApplyKSQLPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	planned := make(map[string]ksengine.PlanChange)
+	terminations := []ksengine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType != ksengine.PlanResourceType_KSQL {
+			continue
+		}
+		if v.Action == ksengine.PlanAction_DELETE {
+			terminations = append(terminations, v)
+		} else {
+			planned[v.Name] = v
+		}
+	}
+	ksmisc.DottedLineOutput(fmt.Sprintf("KSQL Changes: %s", clusterName), "=", 80)
+	if len(planned) == 0 && len(terminations) == 0 {
+		logger.Infow("No KSQL changes needed.",
+			"Cluster Name", clusterName)
+		return nil
+	}
+
+	// Everything planned is resolved first, so that nothing is executed if the plan does not match the configurations.
+	clients := c.getKSQLClients(clusterName)
+	expected := c.expectedStatements(clusterName)
+	ksqlClusters := []string{}
+	known := make(map[string]bool)
+	for ksqlCluster, statements := range expected {
+		ksqlClusters = append(ksqlClusters, ksqlCluster)
+		for _, s := range statements {
+			known[s.PlanName(ksqlCluster)] = true
+		}
+	}
+	sort.Strings(ksqlClusters)
+	for name := range planned {
+		if !known[name] {
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned KSQL statement not found. Statement: %s", name), nil)
+		}
+	}
+	sort.Slice(terminations, func(i, j int) bool {
+		return terminations[i].Name < terminations[j].Name
+	})
+	for _, v := range terminations {
+		if _, found := clients[strings.SplitN(v.Name, "/", 2)[0]]; !found {
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned ksqlDB cluster not found. Query: %s", v.Name), nil)
+		}
+	}
+
+	for _, v := range terminations {
+		parts := strings.SplitN(v.Name, "/", 2)
+		logger.Infow("KSQL Change",
+			"Cluster Name", clusterName,
+			"Action", v.Action.String(),
+			"KSQL Cluster", parts[0],
+			"Query ID", parts[1],
+			"Dry Run", dryRun)
+		if dryRun {
+			continue
+		}
+		if _, err := c.executeKSQLRequest(ctx, clients[parts[0]], "Terminate Query", fmt.Sprintf("Cannot terminate the query %s", v.Name),
+			fmt.Sprintf("TERMINATE %s;", parts[1])); err != nil {
+			return err
+		}
+	}
+	for _, ksqlCluster := range ksqlClusters {
+		for _, s := range expected[ksqlCluster] {
+			v, found := planned[s.PlanName(ksqlCluster)]
+			if !found {
+				continue
+			}
+			logger.Infow("KSQL Change",
+				"Cluster Name", clusterName,
+				"Action", v.Action.String(),
+				"KSQL Cluster", ksqlCluster,
+				"Statement", s.Key(),
+				"KSQL File", s.File,
+				"Dry Run", dryRun)
+			if dryRun {
+				continue
+			}
+			statement := s.Statement
+			if v.Action == ksengine.PlanAction_UPDATE {
+				statement = s.ReplaceStatement()
+			}
+			if _, err := c.executeKSQLRequest(ctx, clients[ksqlCluster], "Execute KSQL Statement", fmt.Sprintf("Cannot execute the KSQL statement %s", v.Name),
+				statement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
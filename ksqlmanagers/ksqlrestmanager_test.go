@@ -0,0 +1,179 @@
+package ksqlmanagers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers/resttest"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+var fakeStatementPattern = regexp.MustCompile(`^(?:CREATE (?:OR REPLACE )?(STREAM|TABLE) (\w+)|INSERT INTO (\w+))`)
+
+// The streams, tables and persistent queries of a fake ksqlDB cluster, served by a resttest.Server.
+type fakeKSQLCluster struct {
+	objects  map[string]string
+	queries  []ksengine.KSQLQuery
+	executed int
+}
+
+func (f *fakeKSQLCluster) handle(w http.ResponseWriter, r *http.Request) string {
+	body := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&body)
+	statement := body["ksql"].(string)
+	if statement == ksql_ListState {
+		type object struct {
+			Name string `json:"name"`
+		}
+		streams, tables, queries := []object{}, []object{}, []map[string]interface{}{}
+		for name, kind := range f.objects {
+			if kind == "STREAM" {
+				streams = append(streams, object{Name: name})
+			} else {
+				tables = append(tables, object{Name: name})
+			}
+		}
+		for _, q := range f.queries {
+			queries = append(queries, map[string]interface{}{"id": q.ID, "sinks": q.Sinks, "queryString": q.Statement, "queryType": "PERSISTENT"})
+		}
+		// A push query of another client, it should not be managed.
+		queries = append(queries, map[string]interface{}{"id": "transient_1", "sinks": []string{}, "queryString": "SELECT 1;", "queryType": "PUSH"})
+		json.NewEncoder(w).Encode([]interface{}{
+			map[string]interface{}{"@type": "streams", "streams": streams},
+			map[string]interface{}{"@type": "tables", "tables": tables},
+			map[string]interface{}{"@type": "queries", "queries": queries},
+		})
+		return ""
+	}
+	f.executed++
+	if strings.HasPrefix(statement, "TERMINATE ") {
+		id := strings.TrimSuffix(strings.TrimPrefix(statement, "TERMINATE "), ";")
+		for i, q := range f.queries {
+			if q.ID == id {
+				f.queries = append(f.queries[:i], f.queries[i+1:]...)
+				break
+			}
+		}
+	} else if m := fakeStatementPattern.FindStringSubmatch(statement); m != nil {
+		sink := strings.ToUpper(m[2] + m[3])
+		if m[1] != "" {
+			f.objects[sink] = m[1]
+		}
+		if strings.Contains(statement, "SELECT") {
+			replaced := false
+			for i, q := range f.queries {
+				if m[1] != "" && q.ID == "CSAS_"+sink {
+					f.queries[i].Statement, replaced = statement, true
+				}
+			}
+			if !replaced {
+				id := "CSAS_" + sink
+				if m[1] == "" {
+					id = fmt.Sprintf("INSERTQUERY_%d", f.executed)
+				}
+				f.queries = append(f.queries, ksengine.KSQLQuery{ID: id, Sinks: []string{sink}, Statement: statement})
+			}
+		}
+	}
+	json.NewEncoder(w).Encode([]interface{}{map[string]interface{}{"@type": "currentStatus"}})
+	return statement
+}
+
+// Starts a ksqlDB cluster serving the objects of fake, as the "ksql-1" ksqlDB cluster of c1.
+func (s *StackSuite) newManager(fake *fakeKSQLCluster, expected *ksengine.KSQLConfigMapping) (KSQLExecutionManager, *resttest.Server) {
+	srv := resttest.NewServer(fake.handle)
+	connections := kafkamanagers.KafkaConnections{
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_KSQL}: {
+			Connection: &kafkamanagers.KSQLConnection{Clusters: map[string]*resty.Client{"ksql-1": srv.Client()}},
+		},
+	}
+	return NewKSQLRESTManager(connections, expected, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{MaxAttempts: 1}, time.Second)), srv
+}
+
+func (s *StackSuite) TestStackSuite_KSQLREST_ExecuteKSQL() {
+	fake := &fakeKSQLCluster{
+		objects: map[string]string{"DRIFTED": "STREAM", "OTHER": "STREAM"},
+		queries: []ksengine.KSQLQuery{
+			{ID: "CSAS_DRIFTED", Sinks: []string{"DRIFTED"}, Statement: "CREATE STREAM DRIFTED AS SELECT * FROM SRC WHERE ID = 'a';"},
+			{ID: "CSAS_OTHER", Sinks: []string{"OTHER"}, Statement: "CREATE STREAM OTHER AS SELECT * FROM SRC;"},
+		},
+	}
+	expected := ksengine.KSQLConfigMapping{
+		"ksql-1": {
+			{File: "q.sql", Kind: "STREAM", Name: "SRC", Statement: "CREATE STREAM SRC (ID VARCHAR) WITH (KAFKA_TOPIC='t');", Sources: []string{}},
+			{File: "q.sql", Kind: "STREAM", Name: "NEW", Statement: "CREATE STREAM NEW AS SELECT * FROM SRC;", Query: true, Sources: []string{"SRC"}},
+			{File: "q.sql", Kind: "STREAM", Name: "DRIFTED", Statement: "CREATE STREAM DRIFTED AS SELECT * FROM SRC WHERE ID = 'b';", Query: true, Sources: []string{"SRC"}},
+			{File: "q.sql", Kind: "INSERT", Name: "NEW", Statement: "INSERT INTO NEW SELECT * FROM SRC;", Query: true, Sources: []string{"NEW", "SRC"}},
+		},
+		// ksqlDB clusters that are not configured for the cluster are left alone.
+		"ksql-2": {{File: "q.sql", Kind: "STREAM", Name: "X", Statement: "CREATE STREAM X (ID VARCHAR) WITH (KAFKA_TOPIC='x');", Sources: []string{}}},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	s.NoError(m.ExecuteKSQL(ctx, "c1", true, true, true, true))
+	s.Empty(srv.Changes(), "No statement should be executed by a dry run")
+
+	s.NoError(m.ExecuteKSQL(ctx, "c1", true, true, false, false))
+	s.Equal([]string{
+		"CREATE STREAM SRC (ID VARCHAR) WITH (KAFKA_TOPIC='t');",
+		"CREATE STREAM NEW AS SELECT * FROM SRC;",
+		"CREATE OR REPLACE STREAM DRIFTED AS SELECT * FROM SRC WHERE ID = 'b';",
+		"INSERT INTO NEW SELECT * FROM SRC;",
+	}, srv.Changes(), "Statements should be applied in the declared order")
+
+	s.NoError(m.ExecuteKSQL(ctx, "c1", true, true, true, false))
+	s.Equal([]string{"TERMINATE CSAS_OTHER;"}, srv.Changes(), "Only the unknown persistent queries should be terminated")
+
+	changes, err := m.PlanKSQL(ctx, "c1", true, true, true)
+	s.NoError(err)
+	s.Empty(changes, "Every declared statement should now be running on ksql-1")
+
+	err = m.ApplyKSQLPlan(ctx, "c1", []ksengine.PlanChange{
+		{Cluster: "c1", Action: ksengine.PlanAction_CREATE, ResourceType: ksengine.PlanResourceType_KSQL, Name: "ksql-1/MISSING"},
+	}, false)
+	s.Error(err, "Statements that are not in the configurations cannot be applied")
+
+	// c2 has no ksqlDB cluster configured, so no statement is planned for it.
+	changes, err = m.PlanKSQL(ctx, "c2", true, true, true)
+	s.NoError(err)
+	s.Empty(changes)
+}
+
+func (s *StackSuite) TestStackSuite_KSQLREST_Failures() {
+	fake := &fakeKSQLCluster{objects: map[string]string{}}
+	expected := ksengine.KSQLConfigMapping{
+		"ksql-1": {{File: "q.sql", Kind: "STREAM", Name: "SRC", Statement: "CREATE STREAM SRC (ID VARCHAR) WITH (KAFKA_TOPIC='t');", Sources: []string{}}},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	srv.Fail("POST /ksql", http.StatusUnauthorized)
+	_, err := m.PlanKSQL(ctx, "c1", true, true, true)
+	s.True(errors.Is(err, ksengine.ErrAuthFailed), "Error: %v", err)
+
+	srv.Fail("POST /ksql", http.StatusServiceUnavailable)
+	err = m.ExecuteKSQL(ctx, "c1", true, true, true, false)
+	s.True(errors.Is(err, ksengine.ErrClusterUnreachable), "Error: %v", err)
+	s.Empty(fake.objects)
+}
@@ -0,0 +1,22 @@
+package ksqlmanagers
+
+import (
+	"context"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+var (
+	logger = ksengine.Shepherd.GetLogger()
+)
+
+/*
+	Any KSQL Manager will need to implement this interface. Only the statements of the ksqlDB clusters
+	configured for a cluster are applied for that cluster.
+*/
+type KSQLExecutionManager interface {
+	GetClusterKSQL(ctx context.Context, clusterName string) (ksengine.KSQLStateMapping, error)
+	ExecuteKSQL(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error
+	PlanKSQL(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplyKSQLPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
+}
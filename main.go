@@ -1,19 +1,134 @@
 package main
 
 import (
-	workflow "github.com/waliaabhishek/kafka-shepherd/workflowmanagers"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+const (
+	exitCodeSuccess int = 0
+	exitCodeFailure int = 1
+	exitCodeUsage   int = 2
+)
+
+// Global flag values, used to load the engine once the command is known.
+var (
+	opts                 engine.Options
+	enableDebug          bool
+	enableStructuredLogs bool
+	runModeString        string
+	timeout              time.Duration
 )
 
+/*
+	Usage:
+		kafka-shepherd [global flags] <command> [sub command] [command flags]
+
+	The global flags are the engine options (config file paths, logging, run mode etc.) and need
+	to be provided before the command. Command flags follow the command they belong to.
+*/
 func main() {
-	workflow.ExecuteTopicManagementWorkflow(true, true, true)
-	workflow.ExecuteACLManagementWorkflow(true, true)
-	workflow.DeleteShepherdTopics(true)
-	workflow.DeleteShepherdACLs(true)
+	flag.Usage = printUsage
+	resolveFlags()
+	engine.ConfigureLogger(enableDebug, enableStructuredLogs)
+	opts.RunMode = engine.ParseRunMode(runModeString)
+	ctx, cancel := interruptContext()
+	code := run(ctx, flag.Args())
+	cancel()
+	os.Exit(code)
 }
 
-// TODO: Generate the Baseline YAML files from pre-existing clusters.
+/*
+	Returns a context that is cancelled on the first SIGINT or SIGTERM, so the running command stops
+	sending requests to the clusters and returns. A second signal terminates the process right away.
+	The context is also done once the global timeout expires, if one is set.
+*/
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		timeoutCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
+		cancelInterrupt := cancel
+		ctx, cancel = timeoutCtx, func() {
+			cancelTimeout()
+			cancelInterrupt()
+		}
+	}
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case s := <-term:
+			fmt.Fprintf(os.Stderr, "%s received. Cancelling the running requests.\n", s.String())
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(term)
+	}()
+	return ctx, cancel
+}
+
+func resolveFlags() {
+	flag.BoolVar(&opts.DryRun, "dryRun", false, "Does not execute anything but lists what will be executed.")
+	flag.BoolVar(&opts.IsTest, "testRun", false, "Executes the whole flow and then wipes out all the objects that the configurations provide (not just for this run but configured in the earlier runs as well). This helps in testing the same configurations multiple times without wiping your clusters and starting over.")
+	flag.BoolVar(&enableDebug, "debug", false, "Turns on Debug mode log")
+	flag.BoolVar(&enableStructuredLogs, "enableStructuredLogs", false, "Turns off unstructured mode logging features and use Structured logging instead.")
+	flag.StringVar(&opts.ConfigFile, "configPath", "./configs/shepherd.yaml", "Absolute file Path for Core Configuration file. Please note that this might still be overwritten by the SHEPHERD_CONFIG_FILE_LOCATION for additional flexibility.")
+	flag.StringVar(&opts.BlueprintsFile, "blueprintsPath", "./configs/blueprints.yaml", "Absolute file Path for Shepherd Blueprints file. Please note that this might still be overwritten by the SHEPHERD_BLUEPRINTS_FILE_LOCATION for additional flexibility.")
+	flag.StringVar(&opts.DefinitionsFile, "definitionsPath", "./configs/definitions_dev.yaml", "Absolute file Path for Shepherd Definitions file. Please note that this might still be overwritten by the SHEPHERD_DEFINITIONS_FILE_LOCATION for additional flexibility.")
+	flag.DurationVar(&timeout, "timeout", 0, "Maximum time for the whole command, e.g. 10m. The command is cancelled once it expires. 0 means no limit.")
+	flag.DurationVar(&opts.OperationTimeout, "operationTimeout", 30*time.Second, "Maximum time for every single request to a cluster (each retry gets its own). 0 means no limit.")
+	flag.StringVar(&runModeString, "runmode", engine.RunMode_SINGLE_CLUSTER.String(), "Changes the mode in which the tool is operating. Options are SINGLE_CLUSTER, MULTI_CLUSTER, MIGRATION, CREATE_CONFIGS")
+	flag.Parse()
+}
+
+func run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return exitCodeUsage
+	}
+	cmd, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "Unknown command: %v\n\n", args)
+		printUsage()
+		return exitCodeUsage
+	}
+	fs := flag.NewFlagSet(cmd.path, flag.ContinueOnError)
+	cmd.flags(fs)
+	if err := fs.Parse(args[cmd.depth():]); err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeSuccess
+		}
+		return exitCodeUsage
+	}
+	if fs.NArg() > cmd.maxArgs {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments for %s: %v\n", cmd.path, fs.Args())
+		fs.Usage()
+		return exitCodeUsage
+	}
+	cmdArgs = fs.Args()
+	if err := cmd.execute(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", cmd.path, err)
+		return exitCodeFailure
+	}
+	return exitCodeSuccess
+}
 
-// TODO: Full Cluster migration plans.
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [global flags] <command> [sub command] [command flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-18s %s\n", c.path, c.desc)
+	}
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+}
 
 /*
 	Current Status:
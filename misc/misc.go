@@ -1,6 +1,7 @@
 package misc
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -203,6 +204,50 @@ func GenerateRandomDuration(interval int, unit string) time.Duration {
 	return dur
 }
 
+/*
+	Returns a context for a single request to a cluster, which is done when the parent is done or the timeout
+	expires. A timeout of 0 does not set any deadline of its own.
+*/
+func OperationContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+/*
+	Executes f and waits till it returns or the context is done, whichever happens first. This is meant for
+	the client calls that do not accept a context (like the Sarama Cluster Admin). If the context is done
+	first, f keeps running in the background till the client gives up on its own.
+*/
+func RunWithContext(ctx context.Context, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Waits for the duration, unless the context is done earlier in which case the context error is returned.
+func SleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func DottedLineOutput(comment string, seperator string, length int) {
 	right := (length - len(comment)) / 2
 	left := right
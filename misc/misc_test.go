@@ -1,7 +1,10 @@
 package misc
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/stretchr/testify/suite"
@@ -252,6 +255,39 @@ func (s *StackSuite) TestStackSuite_Misc_GenerateRandomNumber() {
 	}
 }
 
+func (s *StackSuite) TestStackSuite_Misc_RunWithContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errTest := errors.New("test error")
+	s.NoError(RunWithContext(ctx, func() error { return nil }), "Successful call case")
+	s.Equal(errTest, RunWithContext(ctx, func() error { return errTest }), "Failed call case")
+
+	block := make(chan struct{})
+	defer close(block)
+	timeoutCtx, timeoutCancel := OperationContext(ctx, 10*time.Millisecond)
+	defer timeoutCancel()
+	s.True(errors.Is(RunWithContext(timeoutCtx, func() error { <-block; return nil }), context.DeadlineExceeded), "Hung call case")
+
+	cancel()
+	called := false
+	s.True(errors.Is(RunWithContext(ctx, func() error { called = true; return nil }), context.Canceled), "Cancelled context case")
+	s.False(called, "The function should not be called with a cancelled context.")
+}
+
+func (s *StackSuite) TestStackSuite_Misc_SleepWithContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.NoError(SleepWithContext(ctx, time.Millisecond), "Completed sleep case")
+	cancel()
+	start := time.Now()
+	s.True(errors.Is(SleepWithContext(ctx, time.Minute), context.Canceled), "Cancelled sleep case")
+	s.Less(int64(time.Since(start)), int64(time.Second), "The sleep was not interrupted by the cancellation.")
+
+	noDeadline, noDeadlineCancel := OperationContext(context.Background(), 0)
+	defer noDeadlineCancel()
+	_, found := noDeadline.Deadline()
+	s.False(found, "A zero timeout should not set a deadline.")
+}
+
 func (s *StackSuite) TestStackSuite_Misc_GetLogger() {
 	cases := []struct {
 		isDebug      bool
@@ -0,0 +1,209 @@
+package quotamanagers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+type SaramaQuotaExecutionManagerImpl struct {
+	connections kafkamanagers.KafkaConnections
+	expected    *ksengine.QuotaConfigMapping
+	retry       kafkamanagers.RetryPolicy
+}
+
+/*
+	Creates the Quota Manager working with the Sarama connections in the provided registry. The quotas are
+	the ones expected by the configurations (usually State.Maps.Quotas). Every request to the cluster is
+	executed with the provided retry policy.
+*/
+func NewSaramaQuotaManager(connections kafkamanagers.KafkaConnections, expected *ksengine.QuotaConfigMapping, retry kafkamanagers.RetryPolicy) QuotaExecutionManager {
+	return SaramaQuotaExecutionManagerImpl{connections: connections, expected: expected, retry: retry}
+}
+
+func (q SaramaQuotaExecutionManagerImpl) getSaramaConnectionObject(clusterName string) *sarama.ClusterAdmin {
+	return q.connections.GetSaramaConnection(clusterName)
+}
+
+// The quota entity of a user, along with its client id if one is set.
+func quotaEntity(k ksengine.QuotaConfigMappingKey) []sarama.QuotaEntityComponent {
+	ret := []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: k.User}}
+	if k.ClientID != "" {
+		ret = append(ret, sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchExact, Name: k.ClientID})
+	}
+	return ret
+}
+
+/*
+	Returns the quotas of the users and of the client ids of the users set in the cluster (see
+	clusterQuotaMapping). The client quota requests need the Kafka version of the cluster to be 2.6.0 or later.
+*/
+func (q SaramaQuotaExecutionManagerImpl) GetClusterQuotas(ctx context.Context, clusterName string) (ksengine.QuotaConfigMapping, error) {
+	if err := q.connections.RequireSaramaVersion(clusterName, sarama.V2_6_0_0, "Client Quota Management"); err != nil {
+		return nil, err
+	}
+	entries, err := q.retry.DoWithResult(ctx, "Describe Client Quotas", func(context.Context) (interface{}, error) {
+		return (*q.getSaramaConnectionObject(clusterName)).DescribeClientQuotas(nil, false)
+	})
+	if err != nil {
+		return nil, kafkamanagers.NewSaramaError("Something Went Wrong while Describing Client Quotas", err)
+	}
+	return clusterQuotaMapping(entries.([]sarama.DescribeClientQuotasEntry)), nil
+}
+
+/*
+	Maps the quota entries described by the cluster to the users and client ids of the users. The entities
+	using the defaults (e.g. the default client id of a user) and the other entity types (like the IPs)
+	are not part of the mapping.
+*/
+func clusterQuotaMapping(entries []sarama.DescribeClientQuotasEntry) ksengine.QuotaConfigMapping {
+	ret := ksengine.QuotaConfigMapping{}
+	for _, entry := range entries {
+		key, managed := ksengine.QuotaConfigMappingKey{}, true
+		for _, c := range entry.Entity {
+			switch {
+			case c.MatchType != sarama.QuotaMatchExact:
+				managed = false
+			case c.EntityType == sarama.QuotaEntityUser:
+				key.User = c.Name
+			case c.EntityType == sarama.QuotaEntityClientID:
+				key.ClientID = c.Name
+			default:
+				managed = false
+			}
+		}
+		if !managed || key.User == "" {
+			continue
+		}
+		quotas := ksengine.NVPairs{}
+		for k, v := range entry.Values {
+			quotas[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		ret[key] = quotas
+	}
+	return ret
+}
+
+/*
+	Lists the quota changes needed to align the cluster with the configurations as PlanChanges. Nothing
+	is executed.
+*/
+func (q SaramaQuotaExecutionManagerImpl) PlanQuotas(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	provisioned, err := q.GetClusterQuotas(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return ksengine.PlanQuotaChanges(clusterName, *q.expected, provisioned, executeCreateFlow, executeModifyFlow, executeDeleteFlow), nil
+}
+
+func (q SaramaQuotaExecutionManagerImpl) ExecuteQuotas(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool, dryRun bool) error {
+	changes, err := q.PlanQuotas(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	if err != nil {
+		return err
+	}
+	return q.ApplyQuotaPlan(ctx, clusterName, changes, dryRun)
+}
+
+/*
+	Executes the quota changes of a saved plan for the cluster. The planned values are set as they are, and
+	the quotas planned without a value (and every quota of a deleted entity) are removed.
+*/
+func (q SaramaQuotaExecutionManagerImpl) ApplyQuotaPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	planned := []ksengine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType == ksengine.PlanResourceType_QUOTA {
+			planned = append(planned, v)
+		}
+	}
+	sort.Slice(planned, func(i, j int) bool {
+		return planned[i].Name < planned[j].Name
+	})
+	ksmisc.DottedLineOutput(fmt.Sprintf("Quota Changes: %s", clusterName), "=", 80)
+	if len(planned) == 0 {
+		logger.Infow("No quota changes needed.",
+			"Cluster Name", clusterName)
+		return nil
+	}
+
+	// The planned entities are looked up by name, the deleted ones are only known to the cluster.
+	provisioned, err := q.GetClusterQuotas(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]ksengine.QuotaConfigMappingKey)
+	for _, m := range []ksengine.QuotaConfigMapping{provisioned, *q.expected} {
+		for k := range m {
+			byName[k.PlanName()] = k
+		}
+	}
+	ops := make([][]sarama.ClientQuotasOp, len(planned))
+	for i, v := range planned {
+		if _, found := byName[v.Name]; !found {
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned quota entity not found. Quota Entity: %s", v.Name), nil)
+		}
+		if ops[i], err = quotaOps(v); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range planned {
+		logger.Infow("Quota Change",
+			"Cluster Name", clusterName,
+			"Action", v.Action.String(),
+			"Quota Entity", v.Name,
+			"Dry Run", dryRun)
+		if dryRun {
+			continue
+		}
+		entity := quotaEntity(byName[v.Name])
+		for _, op := range ops[i] {
+			err := q.retry.Do(ctx, "Alter Client Quotas", func(context.Context) error {
+				return (*q.getSaramaConnectionObject(clusterName)).AlterClientQuotas(entity, op, false)
+			})
+			if err != nil {
+				return kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Altering the Quota %s of %s", op.Key, v.Name), err)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+	Returns the alterations executing the planned quota change, sorted by quota. The planned values are set
+	as they are, and the quotas planned without a value (and every quota of a deleted entity) are removed.
+*/
+func quotaOps(v ksengine.PlanChange) ([]sarama.ClientQuotasOp, error) {
+	values := v.After
+	if v.Action == ksengine.PlanAction_DELETE {
+		values = ksengine.NVPairs{}
+		for k := range v.Before {
+			values[k] = ""
+		}
+	}
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := []sarama.ClientQuotasOp{}
+	for _, k := range keys {
+		if values[k] == "" {
+			ret = append(ret, sarama.ClientQuotasOp{Key: k, Remove: true})
+			continue
+		}
+		f, err := strconv.ParseFloat(values[k], 64)
+		if err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned quota value is not a number. Quota Entity: %s, Quota: %s", v.Name, k), err)
+		}
+		ret = append(ret, sarama.ClientQuotasOp{Key: k, Value: f})
+	}
+	return ret, nil
+}
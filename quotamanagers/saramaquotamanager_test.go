@@ -0,0 +1,127 @@
+package quotamanagers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+func (s *StackSuite) TestStackSuite_Quotas_ClusterQuotaMapping() {
+	user := func(name string) sarama.QuotaEntityComponent {
+		return sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: name}
+	}
+	client := func(name string) sarama.QuotaEntityComponent {
+		return sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchExact, Name: name}
+	}
+	entries := []sarama.DescribeClientQuotasEntry{
+		{Entity: []sarama.QuotaEntityComponent{user("alice")}, Values: map[string]float64{"producer_byte_rate": 1024}},
+		{Entity: []sarama.QuotaEntityComponent{user("alice"), client("app")}, Values: map[string]float64{"request_percentage": 12.5}},
+		// The defaults, the other entity types and the client ids without a user are not managed.
+		{Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchDefault}},
+			Values: map[string]float64{"producer_byte_rate": 1}},
+		{Entity: []sarama.QuotaEntityComponent{user("bob"), {EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchDefault}},
+			Values: map[string]float64{"producer_byte_rate": 1}},
+		{Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityIP, MatchType: sarama.QuotaMatchExact, Name: "10.0.0.1"}},
+			Values: map[string]float64{"connection_creation_rate": 1}},
+		{Entity: []sarama.QuotaEntityComponent{client("lonely")}, Values: map[string]float64{"producer_byte_rate": 1}},
+	}
+	s.Equal(ksengine.QuotaConfigMapping{
+		{User: "alice"}:                  {"producer_byte_rate": "1024"},
+		{User: "alice", ClientID: "app"}: {"request_percentage": "12.5"},
+	}, clusterQuotaMapping(entries))
+}
+
+func (s *StackSuite) TestStackSuite_Quotas_QuotaOps() {
+	ops, err := quotaOps(ksengine.PlanChange{Action: ksengine.PlanAction_UPDATE, Name: "user=alice",
+		Before: ksengine.NVPairs{"producer_byte_rate": "1024", "consumer_byte_rate": "1024"},
+		After:  ksengine.NVPairs{"producer_byte_rate": "2048", "consumer_byte_rate": ""}})
+	s.NoError(err)
+	s.Equal([]sarama.ClientQuotasOp{
+		{Key: "consumer_byte_rate", Remove: true},
+		{Key: "producer_byte_rate", Value: 2048},
+	}, ops)
+
+	ops, err = quotaOps(ksengine.PlanChange{Action: ksengine.PlanAction_DELETE, Name: "user=bob",
+		Before: ksengine.NVPairs{"request_percentage": "50", "producer_byte_rate": "1024"}})
+	s.NoError(err)
+	s.Equal([]sarama.ClientQuotasOp{
+		{Key: "producer_byte_rate", Remove: true},
+		{Key: "request_percentage", Remove: true},
+	}, ops, "Every quota of a deleted entity should be removed")
+
+	_, err = quotaOps(ksengine.PlanChange{Action: ksengine.PlanAction_CREATE, Name: "user=carol", After: ksengine.NVPairs{"producer_byte_rate": "fast"}})
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid))
+}
+
+// A Cluster Admin with the provided quota entries, recording the alterations.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	entries []sarama.DescribeClientQuotasEntry
+	altered []string
+}
+
+func (a *fakeClusterAdmin) DescribeClientQuotas(components []sarama.QuotaFilterComponent, strict bool) ([]sarama.DescribeClientQuotasEntry, error) {
+	return a.entries, nil
+}
+
+func (a *fakeClusterAdmin) AlterClientQuotas(entity []sarama.QuotaEntityComponent, op sarama.ClientQuotasOp, validateOnly bool) error {
+	name := ""
+	for _, c := range entity {
+		name += string(c.EntityType) + "=" + c.Name + " "
+	}
+	if op.Remove {
+		a.altered = append(a.altered, name+"remove "+op.Key)
+	} else {
+		a.altered = append(a.altered, name+"set "+op.Key)
+	}
+	return nil
+}
+
+func (s *StackSuite) TestStackSuite_Quotas_ExecuteQuotas() {
+	admin := &fakeClusterAdmin{entries: []sarama.DescribeClientQuotasEntry{
+		{Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: "alice"}},
+			Values: map[string]float64{"producer_byte_rate": 1024, "controller_mutation_rate": 10}},
+		{Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: "old"}},
+			Values: map[string]float64{"consumer_byte_rate": 1024}},
+	}}
+	var ca sarama.ClusterAdmin = admin
+	connections := kafkamanagers.KafkaConnections{
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}: {Connection: &kafkamanagers.SaramaConnection{SCA: &ca, Version: sarama.V2_6_0_0}},
+		{ClusterName: "c2", ConnectionType: kafkamanagers.ConnectionType_SARAMA}: {Connection: &kafkamanagers.SaramaConnection{SCA: &ca, Version: sarama.V2_5_0_0}},
+	}
+	expected := ksengine.QuotaConfigMapping{
+		{User: "alice"}:                  {"producer_byte_rate": "2048"},
+		{User: "alice", ClientID: "app"}: {"request_percentage": "50"},
+	}
+	q := NewSaramaQuotaManager(connections, &expected, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{MaxAttempts: 1}, 0))
+
+	s.NoError(q.ExecuteQuotas(context.Background(), "c1", true, true, true, true))
+	s.Empty(admin.altered, "Dry run should not change the quotas")
+
+	s.NoError(q.ExecuteQuotas(context.Background(), "c1", true, true, true, false))
+	s.Equal([]string{
+		"user=alice set producer_byte_rate",
+		"user=alice client-id=app set request_percentage",
+		"user=old remove consumer_byte_rate",
+	}, admin.altered)
+
+	// The client quota requests cannot be made with the version of c2.
+	admin.altered = nil
+	err := q.ExecuteQuotas(context.Background(), "c2", true, true, true, false)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Contains(err.Error(), "at least 2.6.0")
+	s.Empty(admin.altered)
+}
@@ -0,0 +1,22 @@
+package quotamanagers
+
+import (
+	"context"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+var (
+	logger = ksengine.Shepherd.GetLogger()
+)
+
+/*
+	Any Quota Manager will need to implement this interface. Only the quotas of the users and of the client
+	ids of the users are managed, the default quotas and the quotas of the IPs are left alone.
+*/
+type QuotaExecutionManager interface {
+	GetClusterQuotas(ctx context.Context, clusterName string) (ksengine.QuotaConfigMapping, error)
+	ExecuteQuotas(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error
+	PlanQuotas(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplyQuotaPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
+}
@@ -0,0 +1,303 @@
+package schemamanagers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	mapset "github.com/deckarep/golang-set"
+	"github.com/go-resty/resty/v2"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+type SchemaRegistryExecutionManagerImpl struct {
+	connections kafkamanagers.KafkaConnections
+	expected    *ksengine.SubjectConfigMapping
+	retry       kafkamanagers.RetryPolicy
+}
+
+const (
+	sr_ListSubjects         = "/subjects"
+	sr_LookupSchema         = "/subjects/{subject}"
+	sr_DeleteSubject        = "/subjects/{subject}"
+	sr_LatestVersion        = "/subjects/{subject}/versions/latest"
+	sr_RegisterSchema       = "/subjects/{subject}/versions"
+	sr_CheckCompatibility   = "/compatibility/subjects/{subject}/versions/latest"
+	sr_SubjectCompatibility = "/config/{subject}"
+)
+
+/*
+	Creates the Subject Manager working with the Schema Registry connections in the provided registry. The
+	subjects are the ones expected by the configurations (usually State.Maps.Subjects). Every request to
+	the Schema Registry is executed with the provided retry policy.
+*/
+func NewSchemaRegistryManager(connections kafkamanagers.KafkaConnections, expected *ksengine.SubjectConfigMapping, retry kafkamanagers.RetryPolicy) SubjectExecutionManager {
+	return SchemaRegistryExecutionManagerImpl{connections: connections, expected: expected, retry: retry}
+}
+
+/*
+	Sends the request built by send to the Schema Registry using the retry policy. Every attempt gets a new
+	request bound to the context of the attempt. If notFoundOK is set, a 404 response is returned as is, as
+	the Schema Registry uses it for the subjects and the schemas that are not registered.
+*/
+func (c SchemaRegistryExecutionManagerImpl) executeSRRequest(ctx context.Context, client *resty.Client, opName string, errMsg string, notFoundOK bool,
+	send func(req *resty.Request) (*resty.Response, error)) (*resty.Response, error) {
+	resp, err := c.retry.DoWithResult(ctx, opName, func(opCtx context.Context) (interface{}, error) {
+		resp, err := send(client.R().SetContext(opCtx))
+		if err == nil && notFoundOK && resp.StatusCode() == http.StatusNotFound {
+			return resp, nil
+		}
+		if err := kafkamanagers.NewRESTError(errMsg, resp, err); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*resty.Response), nil
+}
+
+// The body of the requests registering or checking a schema. The Schema Registry defaults to AVRO.
+func schemaRequestBody(v ksengine.SubjectConfigMappingValue) map[string]string {
+	body := map[string]string{"schema": v.Schema}
+	if v.SchemaType != "AVRO" {
+		body["schemaType"] = v.SchemaType
+	}
+	return body
+}
+
+/*
+	Returns the subjects registered in the Schema Registry of the cluster, or an empty mapping if the cluster
+	has no Schema Registry. The expected schemas are looked up under their subjects, so the state tells whether
+	they are registered already.
+*/
+func (c SchemaRegistryExecutionManagerImpl) GetClusterSubjects(ctx context.Context, clusterName string) (ksengine.SubjectStateMapping, error) {
+	ret := ksengine.SubjectStateMapping{}
+	connObj := c.connections.GetSchemaRegistryConnection(clusterName)
+	if connObj == nil {
+		return ret, nil
+	}
+	resp, err := c.executeSRRequest(ctx, connObj.SR, "List Subjects", "Cannot list the subjects of the Schema Registry", false,
+		func(req *resty.Request) (*resty.Response, error) {
+			return req.Get(sr_ListSubjects)
+		})
+	if err != nil {
+		return nil, err
+	}
+	subjects := []string{}
+	if err := connObj.SR.JSONUnmarshal(resp.Body(), &subjects); err != nil {
+		return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Schema Registry Response Data", err)
+	}
+
+	type versionResp struct {
+		Version int `json:"version"`
+	}
+	type configResp struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}
+	for _, subject := range subjects {
+		pathParams := map[string]string{"subject": subject}
+		state := ksengine.SubjectState{}
+		resp, err := c.executeSRRequest(ctx, connObj.SR, "Get Latest Version", fmt.Sprintf("Cannot get the latest version of the subject %s", subject), false,
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).Get(sr_LatestVersion)
+			})
+		if err != nil {
+			return nil, err
+		}
+		v := versionResp{}
+		if err := connObj.SR.JSONUnmarshal(resp.Body(), &v); err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Schema Registry Response Data", err)
+		}
+		state.LatestVersion = v.Version
+
+		// The subjects without a compatibility level of their own are answered with 404.
+		resp, err = c.executeSRRequest(ctx, connObj.SR, "Get Subject Compatibility", fmt.Sprintf("Cannot get the compatibility level of the subject %s", subject), true,
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).Get(sr_SubjectCompatibility)
+			})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode() != http.StatusNotFound {
+			cfg := configResp{}
+			if err := connObj.SR.JSONUnmarshal(resp.Body(), &cfg); err != nil {
+				return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Schema Registry Response Data", err)
+			}
+			state.Compatibility = cfg.CompatibilityLevel
+		}
+
+		if expected, found := (*c.expected)[subject]; found {
+			resp, err = c.executeSRRequest(ctx, connObj.SR, "Lookup Schema", fmt.Sprintf("Cannot look up the schema of the subject %s", subject), true,
+				func(req *resty.Request) (*resty.Response, error) {
+					return req.SetPathParams(pathParams).SetBody(schemaRequestBody(expected)).Post(sr_LookupSchema)
+				})
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode() != http.StatusNotFound {
+				v := versionResp{}
+				if err := connObj.SR.JSONUnmarshal(resp.Body(), &v); err != nil {
+					return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Schema Registry Response Data", err)
+				}
+				state.SchemaVersion = v.Version
+			}
+		}
+		ret[subject] = state
+	}
+	return ret, nil
+}
+
+// Lists the topics in the Kafka Cluster, as the subjects of the existing topics are never deleted.
+func (c SchemaRegistryExecutionManagerImpl) getClusterTopics(ctx context.Context, clusterName string) (mapset.Set, error) {
+	topics, err := c.retry.DoWithResult(ctx, "List Topics", func(context.Context) (interface{}, error) {
+		return (*c.connections.GetSaramaConnection(clusterName)).ListTopics()
+	})
+	if err != nil {
+		return nil, kafkamanagers.NewSaramaError("Something Went Wrong while Listing Topics", err)
+	}
+	ret := mapset.NewSet()
+	for tName := range topics.(map[string]sarama.TopicDetail) {
+		ret.Add(tName)
+	}
+	return ret, nil
+}
+
+/*
+	Lists the subject registrations, compatibility level changes and soft deletions needed to align the Schema
+	Registry of the cluster with the configurations as PlanChanges. The new schemas are checked against the
+	latest version of their subjects, and the planning fails if any of them is not compatible. Nothing is executed.
+*/
+func (c SchemaRegistryExecutionManagerImpl) PlanSubjects(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool,
+	executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	connObj := c.connections.GetSchemaRegistryConnection(clusterName)
+	if connObj == nil {
+		return []ksengine.PlanChange{}, nil
+	}
+	provisioned, err := c.GetClusterSubjects(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	clusterTopics := mapset.NewSet()
+	if executeDeleteFlow {
+		if clusterTopics, err = c.getClusterTopics(ctx, clusterName); err != nil {
+			return nil, err
+		}
+	}
+	changes := ksengine.PlanSubjectChanges(clusterName, *c.expected, provisioned, topics, clusterTopics, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+
+	type compatibilityResp struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	for _, v := range changes {
+		// The compatibility is checked with the level of the subject, so it is left to the registration if the level changes too.
+		_, schemaChanged := v.After["schema"]
+		_, levelChanged := v.After["compatibility"]
+		if v.Action != ksengine.PlanAction_UPDATE || !schemaChanged || levelChanged {
+			continue
+		}
+		resp, err := c.executeSRRequest(ctx, connObj.SR, "Check Compatibility", fmt.Sprintf("Cannot check the compatibility of the schema of the subject %s", v.Name), false,
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(map[string]string{"subject": v.Name}).SetBody(schemaRequestBody((*c.expected)[v.Name])).Post(sr_CheckCompatibility)
+			})
+		if err != nil {
+			return nil, err
+		}
+		r := compatibilityResp{}
+		if err := connObj.SR.JSONUnmarshal(resp.Body(), &r); err != nil {
+			return nil, ksengine.NewShepherdError(ksengine.ErrClusterUnreachable, "Error while Parsing Schema Registry Response Data", err)
+		}
+		if !r.IsCompatible {
+			return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+				fmt.Sprintf("Schema is not compatible with the latest version of the subject. Subject: %s, Schema File: %s", v.Name, v.After["schema"]), nil)
+		}
+	}
+	return changes, nil
+}
+
+func (c SchemaRegistryExecutionManagerImpl) ExecuteSubjects(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool,
+	executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error {
+	changes, err := c.PlanSubjects(ctx, clusterName, topics, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	if err != nil {
+		return err
+	}
+	return c.ApplySubjectPlan(ctx, clusterName, changes, dryRun)
+}
+
+/*
+	Executes the subject changes of a saved plan for the cluster. The schemas are taken from the configurations,
+	the plan only names the schema files. The compatibility level of a subject is set before its schema is
+	registered, so the new schema is checked with the new level.
+*/
+func (c SchemaRegistryExecutionManagerImpl) ApplySubjectPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	planned := []ksengine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType == ksengine.PlanResourceType_SUBJECT {
+			planned = append(planned, v)
+		}
+	}
+	if len(planned) == 0 {
+		return nil
+	}
+	sort.Slice(planned, func(i, j int) bool {
+		return planned[i].Name < planned[j].Name
+	})
+	ksmisc.DottedLineOutput(fmt.Sprintf("Subject Changes: %s", clusterName), "=", 80)
+	connObj := c.connections.GetSchemaRegistryConnection(clusterName)
+	if connObj == nil {
+		return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, "Schema Registry is not configured for the cluster, but the plan has subject changes", nil)
+	}
+
+	for _, v := range planned {
+		expected, found := (*c.expected)[v.Name]
+		if !found && v.Action != ksengine.PlanAction_DELETE {
+			return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned subject not found. Subject: %s", v.Name), nil)
+		}
+		logger.Infow("Subject Change",
+			"Cluster Name", clusterName,
+			"Action", v.Action.String(),
+			"Subject", v.Name,
+			"Dry Run", dryRun)
+		if dryRun {
+			continue
+		}
+		if err := c.executeSubjectChange(ctx, connObj.SR, expected, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c SchemaRegistryExecutionManagerImpl) executeSubjectChange(ctx context.Context, client *resty.Client, expected ksengine.SubjectConfigMappingValue, change ksengine.PlanChange) error {
+	pathParams := map[string]string{"subject": change.Name}
+	if change.Action == ksengine.PlanAction_DELETE {
+		// Soft deletion, the versions stay in the Schema Registry until the subject is deleted permanently.
+		_, err := c.executeSRRequest(ctx, client, "Delete Subject", fmt.Sprintf("Cannot delete the subject %s", change.Name), false,
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).Delete(sr_DeleteSubject)
+			})
+		return err
+	}
+	if level, found := change.After["compatibility"]; found {
+		_, err := c.executeSRRequest(ctx, client, "Set Subject Compatibility", fmt.Sprintf("Cannot set the compatibility level of the subject %s", change.Name), false,
+			func(req *resty.Request) (*resty.Response, error) {
+				return req.SetPathParams(pathParams).SetBody(map[string]string{"compatibility": level}).Put(sr_SubjectCompatibility)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if _, found := change.After["schema"]; !found {
+		return nil
+	}
+	_, err := c.executeSRRequest(ctx, client, "Register Schema", fmt.Sprintf("Cannot register the schema of the subject %s", change.Name), false,
+		func(req *resty.Request) (*resty.Response, error) {
+			return req.SetPathParams(pathParams).SetBody(schemaRequestBody(expected)).Post(sr_RegisterSchema)
+		})
+	return err
+}
@@ -0,0 +1,194 @@
+package schemamanagers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	mapset "github.com/deckarep/golang-set"
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers/resttest"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+// The schema versions and the compatibility levels of the subjects of a fake Schema Registry, served by a resttest.Server.
+type fakeSchemaRegistry struct {
+	subjects      map[string][]string
+	compatibility map[string]string
+	incompatible  map[string]bool
+}
+
+func (f *fakeSchemaRegistry) handle(w http.ResponseWriter, r *http.Request) string {
+	body := map[string]string{}
+	json.NewDecoder(r.Body).Decode(&body)
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/subjects":
+		out := []string{}
+		for k := range f.subjects {
+			out = append(out, k)
+		}
+		json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodGet && path[0] == "subjects":
+		json.NewEncoder(w).Encode(map[string]int{"version": len(f.subjects[path[1]])})
+	case r.Method == http.MethodGet && path[0] == "config":
+		if level, found := f.compatibility[path[1]]; found {
+			json.NewEncoder(w).Encode(map[string]string{"compatibilityLevel": level})
+			return ""
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPost && path[0] == "compatibility":
+		json.NewEncoder(w).Encode(map[string]bool{"is_compatible": !f.incompatible[path[2]]})
+	case r.Method == http.MethodPost && len(path) == 2:
+		for i, v := range f.subjects[path[1]] {
+			if v == body["schema"] {
+				json.NewEncoder(w).Encode(map[string]int{"version": i + 1})
+				return ""
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(f.subjects, path[1])
+		case r.Method == http.MethodPut:
+			f.compatibility[path[1]] = body["compatibility"]
+		case r.Method == http.MethodPost:
+			f.subjects[path[1]] = append(f.subjects[path[1]], body["schema"])
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+		return r.Method + " " + r.URL.Path
+	}
+	return ""
+}
+
+// A Cluster Admin listing the provided topics.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	topics []string
+}
+
+func (a fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	ret := map[string]sarama.TopicDetail{}
+	for _, tName := range a.topics {
+		ret[tName] = sarama.TopicDetail{}
+	}
+	return ret, nil
+}
+
+// Starts a Schema Registry serving the subjects of fake, as the Schema Registry of c1 with the cluster topics provided.
+func (s *StackSuite) newManager(fake *fakeSchemaRegistry, expected *ksengine.SubjectConfigMapping, clusterTopics ...string) (SubjectExecutionManager, *resttest.Server) {
+	srv := resttest.NewServer(fake.handle)
+	var ca sarama.ClusterAdmin = fakeClusterAdmin{topics: clusterTopics}
+	connections := kafkamanagers.KafkaConnections{
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SCHEMA_REGISTRY}: {
+			Connection: &kafkamanagers.SchemaRegistryConnection{SR: srv.Client()},
+		},
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}: {
+			Connection: &kafkamanagers.SaramaConnection{SCA: &ca},
+		},
+	}
+	return NewSchemaRegistryManager(connections, expected, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{MaxAttempts: 1}, time.Second)), srv
+}
+
+func (s *StackSuite) TestStackSuite_SchemaRegistry_ExecuteSubjects() {
+	fake := &fakeSchemaRegistry{
+		subjects: map[string][]string{
+			"changed-value": {"v1"},
+			"level-value":   {"l1"},
+			"removed-value": {"r1"},
+			"kept-value":    {"k1"},
+			"other":         {"o1"},
+		},
+		compatibility: map[string]string{"level-value": "BACKWARD"},
+	}
+	expected := ksengine.SubjectConfigMapping{
+		"new-value":     {Topic: "new", SchemaType: "AVRO", SchemaFile: "new.avsc", Schema: "n1", Compatibility: "FULL"},
+		"changed-value": {Topic: "changed", SchemaType: "JSON", SchemaFile: "changed.json", Schema: "v2"},
+		"level-value":   {Topic: "level", SchemaType: "AVRO", SchemaFile: "level.avsc", Schema: "l1", Compatibility: "NONE"},
+	}
+	// The topic of "kept-value" is not in the configurations anymore, but it is still in the cluster.
+	m, srv := s.newManager(fake, &expected, "changed", "level", "kept")
+	defer srv.Close()
+	ctx := context.Background()
+	topics := mapset.NewSet("new", "changed", "level")
+
+	s.NoError(m.ExecuteSubjects(ctx, "c1", topics, true, true, true, true))
+	s.Empty(srv.Changes(), "Subjects were changed by a dry run")
+
+	s.NoError(m.ExecuteSubjects(ctx, "c1", topics, true, true, false, false))
+	s.Equal([]string{
+		"POST /subjects/changed-value/versions",
+		"PUT /config/level-value",
+		"PUT /config/new-value",
+		"POST /subjects/new-value/versions",
+	}, srv.Changes(), "The compatibility level should be set before the schema is registered")
+	s.Equal([]string{"v1", "v2"}, fake.subjects["changed-value"])
+
+	s.NoError(m.ExecuteSubjects(ctx, "c1", topics, true, true, true, false))
+	s.Equal([]string{"DELETE /subjects/removed-value"}, srv.Changes(), "Subjects not named after a topic, or of the topics in the cluster, should not be deleted")
+
+	provisioned, err := m.GetClusterSubjects(ctx, "c1")
+	s.NoError(err)
+	s.Equal(ksengine.SubjectState{LatestVersion: 2, SchemaVersion: 2}, provisioned["changed-value"])
+	s.Equal(ksengine.SubjectState{LatestVersion: 1, SchemaVersion: 1, Compatibility: "NONE"}, provisioned["level-value"])
+
+	changes, err := m.PlanSubjects(ctx, "c1", topics, true, true, true)
+	s.NoError(err)
+	s.Empty(changes, "Registered subjects should match the expected schemas and levels")
+}
+
+func (s *StackSuite) TestStackSuite_SchemaRegistry_IncompatibleSchema() {
+	fake := &fakeSchemaRegistry{
+		subjects:      map[string][]string{"changed-value": {"v1"}},
+		compatibility: map[string]string{},
+		incompatible:  map[string]bool{"changed-value": true},
+	}
+	expected := ksengine.SubjectConfigMapping{
+		"changed-value": {Topic: "changed", SchemaType: "AVRO", SchemaFile: "changed.avsc", Schema: "v2"},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+
+	_, err := m.PlanSubjects(context.Background(), "c1", mapset.NewSet("changed"), true, true, true)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Incompatible schemas should fail the planning. Error: %v", err)
+
+	// No Schema Registry is configured for c2, so none of its subjects are looked at.
+	changes, err := m.PlanSubjects(context.Background(), "c2", mapset.NewSet(), true, true, true)
+	s.NoError(err)
+	s.Empty(changes)
+}
+
+func (s *StackSuite) TestStackSuite_SchemaRegistry_Failures() {
+	fake := &fakeSchemaRegistry{subjects: map[string][]string{}, compatibility: map[string]string{}}
+	expected := ksengine.SubjectConfigMapping{
+		"new-value": {Topic: "new", SchemaType: "AVRO", SchemaFile: "new.avsc", Schema: "n1"},
+	}
+	m, srv := s.newManager(fake, &expected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	srv.Fail("POST /subjects/new-value/versions", http.StatusServiceUnavailable)
+	err := m.ExecuteSubjects(ctx, "c1", mapset.NewSet("new"), true, true, false, false)
+	s.True(errors.Is(err, ksengine.ErrClusterUnreachable), "Error: %v", err)
+	s.Empty(fake.subjects)
+
+	srv.Fail("GET /subjects", http.StatusForbidden)
+	_, err = m.PlanSubjects(ctx, "c1", mapset.NewSet("new"), true, true, true)
+	s.True(errors.Is(err, ksengine.ErrAuthFailed), "Error: %v", err)
+}
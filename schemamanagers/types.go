@@ -0,0 +1,23 @@
+package schemamanagers
+
+import (
+	"context"
+
+	mapset "github.com/deckarep/golang-set"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+var (
+	logger = ksengine.Shepherd.GetLogger()
+)
+
+/*
+	Any Subject Manager will need to implement this interface. The topics are the topic names from the
+	configurations, the subjects of the other topics are the ones eligible for deletion.
+*/
+type SubjectExecutionManager interface {
+	GetClusterSubjects(ctx context.Context, clusterName string) (ksengine.SubjectStateMapping, error)
+	ExecuteSubjects(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error
+	PlanSubjects(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplySubjectPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
+}
@@ -0,0 +1,232 @@
+package scrammanagers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+const scramSaltLength int = 32
+
+type SaramaScramExecutionManagerImpl struct {
+	connections kafkamanagers.KafkaConnections
+	expected    *ksengine.ScramCredentialMapping
+	retry       kafkamanagers.RetryPolicy
+	config      ksengine.ConfigRoot
+}
+
+/*
+	Creates the SCRAM Credential Manager working with the Sarama connections in the provided registry. The
+	credentials are the ones expected by the configurations (usually State.Maps.ScramCredentials), and the
+	passwords set are tracked as per the SCRAM settings of the provided configurations. Every request to the
+	cluster is executed with the provided retry policy.
+*/
+func NewSaramaScramManager(connections kafkamanagers.KafkaConnections, expected *ksengine.ScramCredentialMapping, retry kafkamanagers.RetryPolicy,
+	config ksengine.ConfigRoot) ScramExecutionManager {
+	return SaramaScramExecutionManagerImpl{connections: connections, expected: expected, retry: retry, config: config}
+}
+
+func (m SaramaScramExecutionManagerImpl) getSaramaConnectionObject(clusterName string) *sarama.ClusterAdmin {
+	return m.connections.GetSaramaConnection(clusterName)
+}
+
+// Executes the alteration with the retry policy, failing if the alteration of any user failed.
+func (m SaramaScramExecutionManagerImpl) alterScramCredentials(ctx context.Context, operation string,
+	f func(ca sarama.ClusterAdmin) ([]*sarama.AlterUserScramCredentialsResult, error), clusterName string) error {
+	return m.retry.Do(ctx, operation, func(context.Context) error {
+		results, err := f(*m.getSaramaConnectionObject(clusterName))
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.ErrorCode != sarama.ErrNoError {
+				return r.ErrorCode
+			}
+		}
+		return nil
+	})
+}
+
+func scramMechanism(in string) sarama.ScramMechanismType {
+	switch in {
+	case sarama.SCRAM_MECHANISM_SHA_256.String():
+		return sarama.SCRAM_MECHANISM_SHA_256
+	case sarama.SCRAM_MECHANISM_SHA_512.String():
+		return sarama.SCRAM_MECHANISM_SHA_512
+	}
+	return sarama.SCRAM_MECHANISM_UNKNOWN
+}
+
+/*
+	Returns the mechanisms of every SCRAM user of the cluster, along with their iterations. The SCRAM
+	credential requests need the Kafka version of the cluster to be 2.7.0 or later.
+*/
+func (m SaramaScramExecutionManagerImpl) GetClusterScramCredentials(ctx context.Context, clusterName string) (ksengine.ScramUserMapping, error) {
+	if err := m.connections.RequireSaramaVersion(clusterName, sarama.V2_7_0_0, "SCRAM Credential Management"); err != nil {
+		return nil, err
+	}
+	results, err := m.retry.DoWithResult(ctx, "Describe SCRAM Credentials", func(context.Context) (interface{}, error) {
+		return (*m.getSaramaConnectionObject(clusterName)).DescribeUserScramCredentials(nil)
+	})
+	if err != nil {
+		return nil, kafkamanagers.NewSaramaError("Something Went Wrong while Describing SCRAM Credentials", err)
+	}
+	ret := ksengine.ScramUserMapping{}
+	for _, r := range results.([]*sarama.DescribeUserScramCredentialsResult) {
+		if r.ErrorCode != sarama.ErrNoError {
+			return nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Describing the SCRAM Credentials of %s", r.User), r.ErrorCode)
+		}
+		mechanisms := ksengine.NVPairs{}
+		for _, v := range r.CredentialInfos {
+			mechanisms[v.Mechanism.String()] = strconv.Itoa(int(v.Iterations))
+		}
+		ret[r.User] = mechanisms
+	}
+	return ret, nil
+}
+
+/*
+	Lists the SCRAM credential changes needed to align the cluster with the configurations as PlanChanges.
+	Nothing is executed.
+*/
+func (m SaramaScramExecutionManagerImpl) PlanScramCredentials(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	provisioned, err := m.GetClusterScramCredentials(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := m.config.ShepherdCoreConfig.ScramCredentials.TrackedScramCredentials(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return ksengine.PlanScramChanges(clusterName, *m.expected, provisioned, tracked, m.config.ProtectedScramUsers(clusterName),
+		executeCreateFlow, executeModifyFlow, executeDeleteFlow), nil
+}
+
+func (m SaramaScramExecutionManagerImpl) ExecuteScramCredentials(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool,
+	executeDeleteFlow bool, dryRun bool) error {
+	changes, err := m.PlanScramCredentials(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	if err != nil {
+		return err
+	}
+	return m.ApplyScramPlan(ctx, clusterName, changes, dryRun)
+}
+
+/*
+	Executes the SCRAM credential changes of a saved plan for the cluster. The planned mechanisms are set with
+	the password of the configurations and a new salt, and the mechanisms planned without iterations (and
+	every mechanism of a deleted user) are removed. The password fingerprints are tracked once the changes
+	of a user are made, and the users failing to change are returned once every user is tried.
+*/
+func (m SaramaScramExecutionManagerImpl) ApplyScramPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	planned := []ksengine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType == ksengine.PlanResourceType_SCRAM {
+			planned = append(planned, v)
+		}
+	}
+	sort.Slice(planned, func(i, j int) bool {
+		return planned[i].Name < planned[j].Name
+	})
+	ksmisc.DottedLineOutput(fmt.Sprintf("SCRAM Credential Changes: %s", clusterName), "=", 80)
+	if len(planned) == 0 {
+		logger.Infow("No SCRAM credential changes needed.",
+			"Cluster Name", clusterName)
+		return nil
+	}
+	if err := m.connections.RequireSaramaVersion(clusterName, sarama.V2_7_0_0, "SCRAM Credential Management"); err != nil {
+		return err
+	}
+
+	upserts := make([][]sarama.AlterUserScramCredentialsUpsert, len(planned))
+	deletes := make([][]sarama.AlterUserScramCredentialsDelete, len(planned))
+	fingerprints := make([]ksengine.NVPairs, len(planned))
+	for i, v := range planned {
+		fingerprints[i] = ksengine.NVPairs{}
+		values := v.After
+		if v.Action == ksengine.PlanAction_DELETE {
+			values = ksengine.NVPairs{}
+			for k := range v.Before {
+				values[k] = ""
+			}
+		}
+		for k, iterations := range values {
+			if k == ksengine.ScramPasswordKey {
+				continue
+			}
+			mechanism := scramMechanism(k)
+			if mechanism == sarama.SCRAM_MECHANISM_UNKNOWN {
+				return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned SCRAM mechanism is unknown. User: %s, Mechanism: %s", v.Name, k), nil)
+			}
+			if iterations == "" {
+				deletes[i] = append(deletes[i], sarama.AlterUserScramCredentialsDelete{Name: v.Name, Mechanism: mechanism})
+				fingerprints[i][k] = ""
+				continue
+			}
+			credential, found := (*m.expected)[v.Name]
+			if !found {
+				return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned SCRAM user not found in the configurations. User: %s", v.Name), nil)
+			}
+			count, err := strconv.Atoi(iterations)
+			if err != nil {
+				return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Planned SCRAM iterations are not a number. User: %s, Mechanism: %s", v.Name, k), err)
+			}
+			salt := make([]byte, scramSaltLength)
+			if _, err := rand.Read(salt); err != nil {
+				return err
+			}
+			upserts[i] = append(upserts[i], sarama.AlterUserScramCredentialsUpsert{Name: v.Name, Mechanism: mechanism, Iterations: int32(count),
+				Salt: salt, Password: []byte(credential.Password)})
+			fingerprints[i][k] = ksengine.ScramPasswordFingerprint(salt, credential.Password)
+		}
+	}
+
+	errs := new(kafkamanagers.ErrorCollector)
+	for i, v := range planned {
+		logger.Infow("SCRAM Credential Change",
+			"Cluster Name", clusterName,
+			"Action", v.Action.String(),
+			"User", v.Name,
+			"Dry Run", dryRun)
+		if dryRun {
+			continue
+		}
+		if err := m.applyScramChange(ctx, clusterName, v.Name, upserts[i], deletes[i], fingerprints[i]); err != nil {
+			logger.Errorw("SCRAM Credential Change failed.",
+				"Cluster Name", clusterName,
+				"User", v.Name,
+				"Error", err)
+			errs.Add(err)
+		}
+	}
+	return errs.Err("SCRAM Credential Changes failed")
+}
+
+// Sets and removes the planned mechanisms of a user, then tracks the fingerprints of the passwords set.
+func (m SaramaScramExecutionManagerImpl) applyScramChange(ctx context.Context, clusterName string, user string,
+	upserts []sarama.AlterUserScramCredentialsUpsert, deletes []sarama.AlterUserScramCredentialsDelete, fingerprints ksengine.NVPairs) error {
+	if len(upserts) != 0 {
+		err := m.alterScramCredentials(ctx, "Upsert SCRAM Credentials", func(ca sarama.ClusterAdmin) ([]*sarama.AlterUserScramCredentialsResult, error) {
+			return ca.UpsertUserScramCredentials(upserts)
+		}, clusterName)
+		if err != nil {
+			return kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Setting the SCRAM Credentials of %s", user), err)
+		}
+	}
+	if len(deletes) != 0 {
+		err := m.alterScramCredentials(ctx, "Delete SCRAM Credentials", func(ca sarama.ClusterAdmin) ([]*sarama.AlterUserScramCredentialsResult, error) {
+			return ca.DeleteUserScramCredentials(deletes)
+		}, clusterName)
+		if err != nil {
+			return kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Deleting the SCRAM Credentials of %s", user), err)
+		}
+	}
+	return m.config.ShepherdCoreConfig.ScramCredentials.TrackScramCredentials(clusterName, user, fingerprints)
+}
@@ -0,0 +1,226 @@
+package scrammanagers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/suite"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+)
+
+type StackSuite struct {
+	suite.Suite
+	dir string
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+func (s *StackSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *StackSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+// A Cluster Admin keeping the iterations of the SCRAM mechanisms of its users, recording the alterations. The
+// alterations of the failing users are answered with their error code.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	users    map[string]map[sarama.ScramMechanismType]int32
+	failing  map[string]sarama.KError
+	upserts  []sarama.AlterUserScramCredentialsUpsert
+	deletes  []sarama.AlterUserScramCredentialsDelete
+	requests int
+}
+
+func (a *fakeClusterAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	ret := []*sarama.DescribeUserScramCredentialsResult{}
+	for user, mechanisms := range a.users {
+		r := &sarama.DescribeUserScramCredentialsResult{User: user}
+		for m, iterations := range mechanisms {
+			r.CredentialInfos = append(r.CredentialInfos, &sarama.UserScramCredentialsResponseInfo{Mechanism: m, Iterations: iterations})
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func (a *fakeClusterAdmin) UpsertUserScramCredentials(upserts []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	a.requests++
+	ret := []*sarama.AlterUserScramCredentialsResult{}
+	for _, u := range upserts {
+		if code, found := a.failing[u.Name]; found {
+			ret = append(ret, &sarama.AlterUserScramCredentialsResult{User: u.Name, ErrorCode: code})
+			continue
+		}
+		a.upserts = append(a.upserts, u)
+		if a.users[u.Name] == nil {
+			a.users[u.Name] = map[sarama.ScramMechanismType]int32{}
+		}
+		a.users[u.Name][u.Mechanism] = u.Iterations
+		ret = append(ret, &sarama.AlterUserScramCredentialsResult{User: u.Name})
+	}
+	return ret, nil
+}
+
+func (a *fakeClusterAdmin) DeleteUserScramCredentials(deletes []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	a.requests++
+	ret := []*sarama.AlterUserScramCredentialsResult{}
+	for _, d := range deletes {
+		if code, found := a.failing[d.Name]; found {
+			ret = append(ret, &sarama.AlterUserScramCredentialsResult{User: d.Name, ErrorCode: code})
+			continue
+		}
+		a.deletes = append(a.deletes, d)
+		delete(a.users[d.Name], d.Mechanism)
+		if len(a.users[d.Name]) == 0 {
+			delete(a.users, d.Name)
+		}
+		ret = append(ret, &sarama.AlterUserScramCredentialsResult{User: d.Name})
+	}
+	return ret, nil
+}
+
+// Creates the SCRAM Credential Manager of the c1 cluster served by admin, with the Kafka version provided.
+func (s *StackSuite) newManager(admin *fakeClusterAdmin, version sarama.KafkaVersion, expected *ksengine.ScramCredentialMapping) ScramExecutionManager {
+	var ca sarama.ClusterAdmin = admin
+	connections := kafkamanagers.KafkaConnections{
+		{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}: {Connection: &kafkamanagers.SaramaConnection{SCA: &ca, Version: version}},
+	}
+	config := ksengine.ConfigRoot{ShepherdCoreConfig: ksengine.ShepherdCoreConfig{ScramCredentials: ksengine.ScramCredentialsConfig{
+		Mechanisms: []string{"SCRAM-SHA-512"},
+		StateFile:  filepath.Join(s.dir, "scram_state.json"),
+	}}}
+	return NewSaramaScramManager(connections, expected, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{MaxAttempts: 1}, 0), config)
+}
+
+func (s *StackSuite) tracked() ksengine.ScramUserMapping {
+	tracked, err := ksengine.ScramCredentialsConfig{StateFile: filepath.Join(s.dir, "scram_state.json")}.TrackedScramCredentials("c1")
+	s.Require().NoError(err)
+	return tracked
+}
+
+func (s *StackSuite) TestStackSuite_Scram_ExecuteScramCredentials() {
+	admin := &fakeClusterAdmin{users: map[string]map[sarama.ScramMechanismType]int32{}}
+	expected := ksengine.ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}},
+	}
+	m := s.newManager(admin, sarama.V2_7_0_0, &expected)
+	ctx := context.Background()
+
+	s.NoError(m.ExecuteScramCredentials(ctx, "c1", true, true, true, true))
+	s.Zero(admin.requests, "Credentials were changed by a dry run")
+	s.Empty(s.tracked())
+
+	// Every password is set with a new salt, which is kept in the fingerprint tracked.
+	s.NoError(m.ExecuteScramCredentials(ctx, "c1", true, true, true, false))
+	s.Require().Len(admin.upserts, 1)
+	upsert := admin.upserts[0]
+	s.Equal(sarama.SCRAM_MECHANISM_SHA_512, upsert.Mechanism)
+	s.EqualValues(4096, upsert.Iterations)
+	s.Len(upsert.Salt, scramSaltLength)
+	s.Equal([]byte("alice-secret"), upsert.Password)
+	s.Equal(ksengine.ScramUserMapping{"alice": {"SCRAM-SHA-512": ksengine.ScramPasswordFingerprint(upsert.Salt, "alice-secret")}}, s.tracked())
+
+	changes, err := m.PlanScramCredentials(ctx, "c1", true, true, true)
+	s.NoError(err)
+	s.Empty(changes, "The password set should be recognised from its fingerprint")
+
+	expected["alice"] = ksengine.ScramCredential{Password: "rotated-secret", Mechanisms: expected["alice"].Mechanisms}
+	s.NoError(m.ExecuteScramCredentials(ctx, "c1", true, true, true, false))
+	s.Require().Len(admin.upserts, 2)
+	s.NotEqual(upsert.Salt, admin.upserts[1].Salt)
+	s.Equal(ksengine.ScramPasswordFingerprint(admin.upserts[1].Salt, "rotated-secret"), s.tracked()["alice"]["SCRAM-SHA-512"])
+}
+
+func (s *StackSuite) TestStackSuite_Scram_DeleteOnlyMechanisms() {
+	admin := &fakeClusterAdmin{users: map[string]map[sarama.ScramMechanismType]int32{
+		"alice": {sarama.SCRAM_MECHANISM_SHA_256: 4096, sarama.SCRAM_MECHANISM_SHA_512: 4096},
+		"old":   {sarama.SCRAM_MECHANISM_SHA_256: 8192},
+	}}
+	expected := ksengine.ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}},
+	}
+	m := s.newManager(admin, sarama.V2_7_0_0, &expected)
+	fingerprint := ksengine.ScramPasswordFingerprint([]byte("salt"), "alice-secret")
+	config := ksengine.ScramCredentialsConfig{StateFile: filepath.Join(s.dir, "scram_state.json")}
+	s.Require().NoError(config.TrackScramCredentials("c1", "alice", ksengine.NVPairs{"SCRAM-SHA-256": fingerprint, "SCRAM-SHA-512": fingerprint}))
+	s.Require().NoError(config.TrackScramCredentials("c1", "old", ksengine.NVPairs{"SCRAM-SHA-256": fingerprint}))
+
+	// The password of alice is unchanged, so her remaining mechanism is left alone.
+	s.NoError(m.ExecuteScramCredentials(context.Background(), "c1", true, true, true, false))
+	s.Empty(admin.upserts)
+	s.ElementsMatch([]sarama.AlterUserScramCredentialsDelete{
+		{Name: "alice", Mechanism: sarama.SCRAM_MECHANISM_SHA_256},
+		{Name: "old", Mechanism: sarama.SCRAM_MECHANISM_SHA_256},
+	}, admin.deletes)
+	s.Equal(ksengine.ScramUserMapping{"alice": {"SCRAM-SHA-512": fingerprint}}, s.tracked(), "The removed mechanisms should not be tracked anymore")
+}
+
+func (s *StackSuite) TestStackSuite_Scram_UnknownMechanism() {
+	admin := &fakeClusterAdmin{users: map[string]map[sarama.ScramMechanismType]int32{}}
+	expected := ksengine.ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}},
+	}
+	m := s.newManager(admin, sarama.V2_7_0_0, &expected)
+
+	err := m.ApplyScramPlan(context.Background(), "c1", []ksengine.PlanChange{
+		{Cluster: "c1", Action: ksengine.PlanAction_CREATE, ResourceType: ksengine.PlanResourceType_SCRAM, Name: "alice",
+			After: ksengine.NVPairs{"SCRAM-SHA-512": "4096", "SCRAM-SHA-1": "4096"}},
+	}, false)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Contains(err.Error(), "SCRAM-SHA-1")
+	s.Zero(admin.requests, "Nothing should be changed once the plan is known to be invalid")
+	s.Empty(s.tracked())
+}
+
+func (s *StackSuite) TestStackSuite_Scram_UserFailures() {
+	admin := &fakeClusterAdmin{
+		users:   map[string]map[sarama.ScramMechanismType]int32{},
+		failing: map[string]sarama.KError{"bob": sarama.ErrClusterAuthorizationFailed},
+	}
+	expected := ksengine.ScramCredentialMapping{}
+	for _, user := range []string{"alice", "bob", "carol"} {
+		expected[user] = ksengine.ScramCredential{Password: user + "-secret", Mechanisms: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}}
+	}
+	m := s.newManager(admin, sarama.V2_7_0_0, &expected)
+
+	// The users after the failing one are still changed, and the failing one is not tracked.
+	err := m.ExecuteScramCredentials(context.Background(), "c1", true, true, true, false)
+	s.True(errors.Is(err, ksengine.ErrAuthFailed), "Error: %v", err)
+	s.Contains(err.Error(), "SCRAM Credentials of bob")
+	s.Contains(err.Error(), "1 request(s) failed")
+	tracked := s.tracked()
+	s.Len(tracked, 2)
+	s.Contains(tracked, "alice")
+	s.Contains(tracked, "carol")
+}
+
+func (s *StackSuite) TestStackSuite_Scram_KafkaVersion() {
+	admin := &fakeClusterAdmin{users: map[string]map[sarama.ScramMechanismType]int32{}}
+	expected := ksengine.ScramCredentialMapping{
+		"alice": {Password: "alice-secret", Mechanisms: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}},
+	}
+	m := s.newManager(admin, sarama.V2_6_0_0, &expected)
+
+	_, err := m.PlanScramCredentials(context.Background(), "c1", true, true, true)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Contains(err.Error(), "at least 2.7.0")
+	err = m.ApplyScramPlan(context.Background(), "c1", []ksengine.PlanChange{
+		{Cluster: "c1", Action: ksengine.PlanAction_CREATE, ResourceType: ksengine.PlanResourceType_SCRAM, Name: "alice",
+			After: ksengine.NVPairs{"SCRAM-SHA-512": "4096"}},
+	}, false)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Zero(admin.requests)
+}
@@ -0,0 +1,22 @@
+package scrammanagers
+
+import (
+	"context"
+
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+var (
+	logger = ksengine.Shepherd.GetLogger()
+)
+
+/*
+	Any SCRAM Credential Manager will need to implement this interface. Only the mechanisms and the iterations
+	of the credentials can be read from the cluster, the passwords are tracked by the engine.
+*/
+type ScramExecutionManager interface {
+	GetClusterScramCredentials(ctx context.Context, clusterName string) (ksengine.ScramUserMapping, error)
+	ExecuteScramCredentials(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool, dryRun bool) error
+	PlanScramCredentials(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplyScramPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
+}
@@ -0,0 +1,122 @@
+package topicmanagers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	kafkamanagers "github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+)
+
+/*
+	Applies the topic deletion safeguards of the core configuration to the topics missing from the
+	configurations, and returns the ones that can be deleted. The protected topics and the topics with
+	recent produce activity are dropped first, then the ones that have not been missing for enough runs.
+	The whole run is refused if the remaining deletions are more than allowed. The runs are only counted
+	if persist is set.
+*/
+func (t SaramaTopicExecutionManagerImpl) filterUnknownTopicDeletions(ctx context.Context, clusterName string, candidates mapset.Set, persist bool) (mapset.Set, error) {
+	cfg := t.core.TopicDeletion
+	names := []string{}
+	for _, tName := range t.GetTopicsAsSlice(t.withoutProtectedTopics(clusterName, candidates)) {
+		if cfg.ActivityWindow > 0 {
+			active, err := t.hasRecentActivity(ctx, clusterName, tName, cfg.ActivityWindow)
+			if err != nil {
+				return nil, err
+			}
+			if active {
+				logger.Infow("Topic has received messages recently and will not be deleted.",
+					"Cluster Name", clusterName,
+					"Topic Name", tName,
+					"Activity Window", cfg.ActivityWindow.String())
+				continue
+			}
+		}
+		names = append(names, tName)
+	}
+	names, err := cfg.TrackAbsentTopics(clusterName, names, persist)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.CheckDeletionLimit(clusterName, names); err != nil {
+		return nil, err
+	}
+	ret := mapset.NewSet()
+	for _, tName := range names {
+		ret.Add(tName)
+	}
+	return ret, nil
+}
+
+/*
+	Applies the topic deletion safeguards again to the deletions of a saved plan, as the topics may have
+	received messages since the plan was created. The run is counted for all the topics missing from the
+	configurations, as DeleteUnknownTopics does, if persist is set. Only the planned deletions that are
+	still allowed are returned.
+*/
+func (t SaramaTopicExecutionManagerImpl) filterPlannedTopicDeletions(ctx context.Context, clusterName string, planned mapset.Set, persist bool) (mapset.Set, error) {
+	topics := mapset.NewSet()
+	for tName := range *t.configTCM {
+		topics.Add(tName)
+	}
+	candidates, err := t.findNonExistentTopicsInConfig(ctx, clusterName, topics)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := t.filterUnknownTopicDeletions(ctx, clusterName, candidates, persist)
+	if err != nil {
+		return nil, err
+	}
+	for _, tName := range t.GetTopicsAsSlice(planned.Difference(allowed)) {
+		logger.Warnw("Planned topic deletion is not allowed anymore and will be skipped.",
+			"Cluster Name", clusterName,
+			"Topic Name", tName)
+	}
+	return planned.Intersect(allowed), nil
+}
+
+func (t SaramaTopicExecutionManagerImpl) withoutProtectedTopics(clusterName string, in mapset.Set) mapset.Set {
+	ret := mapset.NewSet()
+	for _, tName := range t.GetTopicsAsSlice(in) {
+		if rule, protected := t.core.TopicDeletion.ProtectionRule(tName); protected {
+			logger.Infow("Topic is protected and will not be deleted.",
+				"Cluster Name", clusterName,
+				"Topic Name", tName,
+				"Protection Rule", rule)
+			continue
+		}
+		ret.Add(tName)
+	}
+	return ret
+}
+
+/*
+	Reports if any partition of the topic has a message produced within the window. Asked for the offset
+	at the start of the window, the brokers return the offset of the first message produced since then, or
+	-1 if the end offset of the partition was already reached by that time.
+*/
+func (t SaramaTopicExecutionManagerImpl) hasRecentActivity(ctx context.Context, clusterName string, topicName string, window time.Duration) (bool, error) {
+	client := t.connections.GetSaramaClient(clusterName)
+	since := time.Now().Add(-window).UnixNano() / int64(time.Millisecond)
+	active, err := t.retry.DoWithResult(ctx, "Topic Activity Check", func(context.Context) (interface{}, error) {
+		partitions, err := client.Partitions(topicName)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range partitions {
+			offset, err := client.GetOffset(topicName, p, since)
+			if err != nil {
+				return false, err
+			}
+			if offset >= 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, kafkamanagers.NewSaramaError(fmt.Sprintf("Cannot check the recent activity of Topic %s", topicName), err)
+	}
+	return active.(bool), nil
+}
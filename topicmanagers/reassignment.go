@@ -0,0 +1,333 @@
+package topicmanagers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	kafkamanagers "github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+const defaultReassignmentPollInterval time.Duration = 10 * time.Second
+
+var (
+	replicationThrottleRateProps     []string = []string{"leader.replication.throttled.rate", "follower.replication.throttled.rate"}
+	replicationThrottleReplicasProps []string = []string{"leader.replication.throttled.replicas", "follower.replication.throttled.replicas"}
+)
+
+/*
+	Changes the replication factor of the topic by reassigning the replicas of all its partitions. The
+	new replicas are spread across the racks (see planReplicaAssignment) and the replication traffic is
+	throttled while the reassignment is in progress, if a throttle is configured. The call only returns
+	once the cluster reports the reassignment as complete, or the context is done. In dry run mode, the
+	planned assignment is only logged.
+*/
+func (t SaramaTopicExecutionManagerImpl) modifyTopicReplicationFactor(ctx context.Context, clusterName string, topicName string, rf int16, dryRun bool) error {
+	conn := t.getSaramaConnectionObject(clusterName)
+	current, racks, err := t.describeReplicas(ctx, conn, topicName)
+	if err != nil {
+		return err
+	}
+	target, err := planReplicaAssignment(current, racks, int(rf))
+	if err != nil {
+		return ksengine.NewShepherdError(ksengine.ErrConfigInvalid, fmt.Sprintf("Cannot change the replication factor of topic %s", topicName), err)
+	}
+	logger.Infow("Replica reassignment planned.",
+		"Topic Name", topicName,
+		"Replication Factor", rf,
+		"Current Assignment", current,
+		"Target Assignment", target)
+	if dryRun {
+		return nil
+	}
+
+	var restore []throttledResource
+	if t.core.Reassignment.ThrottleBytesPerSec > 0 {
+		if restore, err = t.setReplicationThrottle(ctx, conn, topicName, involvedBrokers(current, target)); err != nil {
+			return err
+		}
+	}
+	err = t.retry.Do(ctx, "Replica Reassignment", func(context.Context) error {
+		return (*conn).AlterPartitionReassignments(topicName, target)
+	})
+	if err == nil {
+		err = t.waitForReassignment(ctx, conn, topicName, len(target))
+	}
+	if err != nil {
+		if len(restore) != 0 {
+			logger.Warnw("The replication throttle has been left in place, as the reassignment may still be in progress. Remove it once the reassignment completes.",
+				"Topic Name", topicName)
+		}
+		return kafkamanagers.NewSaramaError(fmt.Sprintf("Replica reassignment failed for topic %s", topicName), err)
+	}
+	t.removeReplicationThrottle(ctx, conn, restore)
+	return nil
+}
+
+// Returns the replicas of every partition of the topic (indexed by the partition ID) and the rack of every broker.
+func (t SaramaTopicExecutionManagerImpl) describeReplicas(ctx context.Context, conn *sarama.ClusterAdmin, topicName string) ([][]int32, map[int32]string, error) {
+	metadata, err := t.retry.DoWithResult(ctx, "Describe Topic", func(context.Context) (interface{}, error) {
+		return (*conn).DescribeTopics([]string{topicName})
+	})
+	if err != nil {
+		return nil, nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Describing Topic %s", topicName), err)
+	}
+	topics := metadata.([]*sarama.TopicMetadata)
+	if len(topics) != 1 {
+		return nil, nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Topic %s was not found in the cluster", topicName), nil)
+	}
+	if topics[0].Err != sarama.ErrNoError {
+		return nil, nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Something Went Wrong while Describing Topic %s", topicName), topics[0].Err)
+	}
+	current := make([][]int32, len(topics[0].Partitions))
+	for _, p := range topics[0].Partitions {
+		if int(p.ID) >= len(current) {
+			return nil, nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Topic %s does not have contiguous partition IDs", topicName), nil)
+		}
+		current[p.ID] = p.Replicas
+	}
+
+	brokers, err := t.retry.DoWithResult(ctx, "Describe Cluster", func(context.Context) (interface{}, error) {
+		brokers, _, err := (*conn).DescribeCluster()
+		return brokers, err
+	})
+	if err != nil {
+		return nil, nil, kafkamanagers.NewSaramaError("Something Went Wrong while Describing the Cluster", err)
+	}
+	racks := make(map[int32]string)
+	for _, b := range brokers.([]*sarama.Broker) {
+		racks[b.ID()] = b.Rack()
+	}
+	return current, racks, nil
+}
+
+/*
+	Computes the replicas of every partition for the replication factor rf. The current replicas are kept
+	as far as possible, so that only the added replicas need to copy any data, and the first replica (the
+	preferred leader) is never moved. When replicas are added, the brokers in racks that the partition is
+	not using yet are picked first, then the brokers with the fewest replicas of the topic. When replicas
+	are removed, the ones sharing a rack with another replica are removed first.
+*/
+func planReplicaAssignment(current [][]int32, racks map[int32]string, rf int) ([][]int32, error) {
+	if rf < 1 || rf > len(racks) {
+		return nil, fmt.Errorf("the replication factor should be between 1 and the broker count (%d). Provided: %d", len(racks), rf)
+	}
+	brokerIDs := make([]int32, 0, len(racks))
+	for id := range racks {
+		brokerIDs = append(brokerIDs, id)
+	}
+	sort.Slice(brokerIDs, func(i, j int) bool { return brokerIDs[i] < brokerIDs[j] })
+	load := make(map[int32]int)
+	for _, replicas := range current {
+		for _, id := range replicas {
+			load[id]++
+		}
+	}
+
+	out := make([][]int32, len(current))
+	for p, replicas := range current {
+		if len(replicas) >= rf {
+			out[p] = shrinkReplicas(replicas, racks, rf)
+			for _, id := range replicas {
+				load[id]--
+			}
+			for _, id := range out[p] {
+				load[id]++
+			}
+			continue
+		}
+		assigned := append([]int32{}, replicas...)
+		for len(assigned) < rf {
+			usedRacks := make(map[string]bool)
+			inUse := make(map[int32]bool)
+			for _, id := range assigned {
+				usedRacks[racks[id]] = true
+				inUse[id] = true
+			}
+			best := int32(-1)
+			for _, id := range brokerIDs {
+				if inUse[id] {
+					continue
+				}
+				if best == -1 || usedRacks[racks[best]] && !usedRacks[racks[id]] ||
+					usedRacks[racks[best]] == usedRacks[racks[id]] && load[id] < load[best] {
+					best = id
+				}
+			}
+			assigned = append(assigned, best)
+			load[best]++
+		}
+		out[p] = assigned
+	}
+	return out, nil
+}
+
+// Keeps rf replicas, starting with the first one and then the ones in racks not kept yet. The result is ordered as the input.
+func shrinkReplicas(replicas []int32, racks map[int32]string, rf int) []int32 {
+	keep := map[int32]bool{replicas[0]: true}
+	usedRacks := map[string]bool{racks[replicas[0]]: true}
+	for _, distinctRack := range []bool{true, false} {
+		for _, id := range replicas[1:] {
+			if len(keep) == rf {
+				break
+			}
+			if !keep[id] && (!distinctRack || !usedRacks[racks[id]]) {
+				keep[id] = true
+				usedRacks[racks[id]] = true
+			}
+		}
+	}
+	kept := []int32{}
+	for _, id := range replicas {
+		if keep[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+func involvedBrokers(current [][]int32, target [][]int32) []int32 {
+	set := make(map[int32]bool)
+	for _, assignment := range [][][]int32{current, target} {
+		for _, replicas := range assignment {
+			for _, id := range replicas {
+				set[id] = true
+			}
+		}
+	}
+	ret := make([]int32, 0, len(set))
+	for id := range set {
+		ret = append(ret, id)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// A topic or broker whose dynamic configurations were changed to throttle the replication, with its original configurations.
+type throttledResource struct {
+	resourceType sarama.ConfigResourceType
+	kind         string
+	name         string
+	entries      map[string]*string
+}
+
+/*
+	Throttles the replication of the topic on the provided brokers. AlterConfig replaces all the dynamic
+	configurations of a resource, so the throttle is added to the configurations already set on the
+	brokers and the topic. The sensitive configurations cannot be read back, so the throttle is refused
+	for any resource that has them. Returns the original configurations of the resources changed.
+*/
+func (t SaramaTopicExecutionManagerImpl) setReplicationThrottle(ctx context.Context, conn *sarama.ClusterAdmin, topicName string, brokers []int32) ([]throttledResource, error) {
+	resources := []throttledResource{}
+	for _, id := range brokers {
+		resources = append(resources, throttledResource{resourceType: sarama.BrokerResource, kind: "Broker", name: strconv.FormatInt(int64(id), 10)})
+	}
+	resources = append(resources, throttledResource{resourceType: sarama.TopicResource, kind: "Topic", name: topicName})
+	for idx := range resources {
+		r := &resources[idx]
+		entries, err := t.retry.DoWithResult(ctx, "Describe Configs", func(context.Context) (interface{}, error) {
+			return (*conn).DescribeConfig(sarama.ConfigResource{Type: r.resourceType, Name: r.name})
+		})
+		if err != nil {
+			return nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Cannot read the configurations of %s %s to set the replication throttle", r.kind, r.name), err)
+		}
+		r.entries = make(map[string]*string)
+		for _, entry := range entries.([]sarama.ConfigEntry) {
+			if entry.Source != sarama.SourceDynamicBroker && !(r.resourceType == sarama.TopicResource && isTopicOverride(entry)) {
+				continue
+			}
+			if entry.Sensitive {
+				return nil, ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+					fmt.Sprintf("Cannot throttle the replication, as %s %s has the sensitive configuration %s that would be lost", r.kind, r.name, entry.Name), nil)
+			}
+			value := entry.Value
+			r.entries[entry.Name] = &value
+		}
+	}
+
+	rate := strconv.FormatInt(t.core.Reassignment.ThrottleBytesPerSec, 10)
+	all := "*"
+	for idx, r := range resources {
+		r := r
+		throttled := make(map[string]*string)
+		for k, v := range r.entries {
+			throttled[k] = v
+		}
+		props := replicationThrottleRateProps
+		if r.resourceType == sarama.TopicResource {
+			props = replicationThrottleReplicasProps
+		}
+		for _, prop := range props {
+			if r.resourceType == sarama.TopicResource {
+				throttled[prop] = &all
+			} else {
+				throttled[prop] = &rate
+			}
+		}
+		err := t.retry.Do(ctx, "Set Replication Throttle", func(context.Context) error {
+			return (*conn).AlterConfig(r.resourceType, r.name, throttled, false)
+		})
+		if err != nil {
+			t.removeReplicationThrottle(ctx, conn, resources[:idx])
+			return nil, kafkamanagers.NewSaramaError(fmt.Sprintf("Cannot set the replication throttle on %s %s", r.kind, r.name), err)
+		}
+	}
+	logger.Infow("Replication throttle set.",
+		"Topic Name", topicName,
+		"Brokers", brokers,
+		"Throttle (Bytes/Sec)", t.core.Reassignment.ThrottleBytesPerSec)
+	return resources, nil
+}
+
+func (t SaramaTopicExecutionManagerImpl) removeReplicationThrottle(ctx context.Context, conn *sarama.ClusterAdmin, resources []throttledResource) {
+	for _, r := range resources {
+		r := r
+		err := t.retry.Do(ctx, "Remove Replication Throttle", func(context.Context) error {
+			return (*conn).AlterConfig(r.resourceType, r.name, r.entries, false)
+		})
+		if err != nil {
+			logger.Errorw("Cannot remove the replication throttle. Remove it manually.",
+				"Resource Type", r.kind,
+				"Resource Name", r.name,
+				"Error", err.Error())
+		}
+	}
+}
+
+// Polls the cluster till none of the partitions of the topic are being reassigned.
+func (t SaramaTopicExecutionManagerImpl) waitForReassignment(ctx context.Context, conn *sarama.ClusterAdmin, topicName string, partitionCount int) error {
+	partitions := make([]int32, partitionCount)
+	for idx := range partitions {
+		partitions[idx] = int32(idx)
+	}
+	interval := t.core.Reassignment.PollInterval
+	if interval == 0 {
+		interval = defaultReassignmentPollInterval
+	}
+	for {
+		status, err := t.retry.DoWithResult(ctx, "List Partition Reassignments", func(context.Context) (interface{}, error) {
+			return (*conn).ListPartitionReassignments(topicName, partitions)
+		})
+		if err != nil {
+			return err
+		}
+		remaining := len(status.(map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus)[topicName])
+		if remaining == 0 {
+			logger.Infow("Replica reassignment completed.",
+				"Topic Name", topicName)
+			return nil
+		}
+		logger.Infow("Replica reassignment in progress.",
+			"Topic Name", topicName,
+			"Partitions Remaining", remaining,
+			"Total Partitions", partitionCount)
+		if err := ksmisc.SleepWithContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package topicmanagers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+func (s *StackSuite) TestStackSuite_Reassignment_PlanReplicaAssignment() {
+	racks := map[int32]string{1: "a", 2: "a", 3: "b", 4: "b", 5: "c", 6: "c"}
+	cases := []struct {
+		inCurrent [][]int32
+		inRF      int
+		out       [][]int32
+		err       string
+	}{
+		// New replicas go to the racks not used by the partition first.
+		{[][]int32{{1}, {2}}, 3, [][]int32{{1, 3, 5}, {2, 4, 6}}, ""},
+		{[][]int32{{1, 2}}, 3, [][]int32{{1, 2, 3}}, ""},
+		// The brokers with the fewest replicas of the topic are preferred within the racks.
+		{[][]int32{{1, 3}, {2, 3}}, 3, [][]int32{{1, 3, 5}, {2, 3, 6}}, ""},
+		// Replicas sharing a rack are removed first and the leader is always kept.
+		{[][]int32{{1, 2, 3}}, 2, [][]int32{{1, 3}}, ""},
+		{[][]int32{{2, 1, 3, 5}}, 1, [][]int32{{2}}, ""},
+		{[][]int32{{1, 2}}, 2, [][]int32{{1, 2}}, ""},
+		{[][]int32{{1}}, 7, nil, "the replication factor should be between 1 and the broker count (6). Provided: 7"},
+		{[][]int32{{1}}, 0, nil, "the replication factor should be between 1 and the broker count (6). Provided: 0"},
+	}
+	for _, c := range cases {
+		out, err := planReplicaAssignment(c.inCurrent, racks, c.inRF)
+		if c.err != "" {
+			s.EqualError(err, c.err)
+			continue
+		}
+		s.NoError(err)
+		s.Equal(c.out, out, "Current: %v, Replication Factor: %d", c.inCurrent, c.inRF)
+	}
+}
+
+func (s *StackSuite) TestStackSuite_Reassignment_PartitionDecrease() {
+	topic := clusterTopic{}
+	topic.details.NumPartitions = 6
+	topic.details.ReplicationFactor = 3
+
+	diff := compareTopicConfigs(topic, map[string]string{"num.partitions": "3", "replication.factor": "3"})
+	s.True(isPartitionDecrease(diff))
+	diff = compareTopicConfigs(topic, map[string]string{"num.partitions": "12", "replication.factor": "2"})
+	s.False(isPartitionDecrease(diff))
+	s.Equal("3", diff.before["replication.factor"])
+	s.Equal("2", diff.after["replication.factor"])
+}
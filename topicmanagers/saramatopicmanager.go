@@ -1,24 +1,38 @@
 package topicmanagers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/Shopify/sarama"
 	mapset "github.com/deckarep/golang-set"
 	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
 	kafkamanagers "github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
-	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
 )
 
 type SaramaTopicExecutionManagerImpl struct {
 	TopicExecutionManagerBaseImpl
+	connections kafkamanagers.KafkaConnections
+	configTCM   *ksengine.TopicConfigMapping
+	retry       kafkamanagers.RetryPolicy
+	core        ksengine.ShepherdCoreConfig
 }
 
-var (
-	SaramaTopicManager TopicExecutionManager = SaramaTopicExecutionManagerImpl{}
-)
+/*
+	Creates the Topic Manager working with the connections in the provided registry. The topic
+	configurations are the ones expected by the configurations (usually State.Maps.TCM). Every
+	request to the cluster is executed with the provided retry policy. The replication factor changes and
+	the topic deletions follow the reassignment and topic deletion settings of the core configuration.
+*/
+func NewSaramaTopicManager(connections kafkamanagers.KafkaConnections, configTCM *ksengine.TopicConfigMapping, retry kafkamanagers.RetryPolicy,
+	core ksengine.ShepherdCoreConfig) TopicExecutionManager {
+	return SaramaTopicExecutionManagerImpl{connections: connections, configTCM: configTCM, retry: retry, core: core}
+}
 
 /*
 	The cluster name is the only known entity for the Engine. The Kafka Connection manager
@@ -27,199 +41,343 @@ var (
 	it to execute any functionality in this module.
 */
 func (t SaramaTopicExecutionManagerImpl) getSaramaConnectionObject(clusterName string) *sarama.ClusterAdmin {
-	return kafkamanagers.Connections[kafkamanagers.KafkaConnectionsKey{ClusterName: clusterName, ConnectionType: kafkamanagers.ConnectionType_SARAMA}].Connection.(*kafkamanagers.SaramaConnection).SCA
+	return t.connections.GetSaramaConnection(clusterName)
 }
 
-func (t SaramaTopicExecutionManagerImpl) GetTopicsAsSet(clusterName string) *mapset.Set {
+func (t SaramaTopicExecutionManagerImpl) GetTopicsAsSet(ctx context.Context, clusterName string) (*mapset.Set, error) {
+	topics, err := t.getTopicListFromKafkaCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
 	tSet := mapset.NewSet()
-	for k := range *t.getTopicListFromKafkaCluster(clusterName) {
+	for k := range *topics {
 		tSet.Add(string(k))
 	}
-	return &tSet
+	return &tSet, nil
 }
 
 /*
 	This function returns the list of topics from Kafka Cluster.
 */
-func (t SaramaTopicExecutionManagerImpl) getTopicListFromKafkaCluster(clusterName string) (list *map[string]sarama.TopicDetail) {
-	topics, err := (*t.getSaramaConnectionObject(clusterName)).ListTopics()
+func (t SaramaTopicExecutionManagerImpl) getTopicListFromKafkaCluster(ctx context.Context, clusterName string) (list *map[string]sarama.TopicDetail, err error) {
+	topics, err := t.retry.DoWithResult(ctx, "List Topics", func(context.Context) (interface{}, error) {
+		return (*t.getSaramaConnectionObject(clusterName)).ListTopics()
+	})
+	if err != nil {
+		return nil, kafkamanagers.NewSaramaError("Something Went Wrong while Listing Topics", err)
+	}
+	tList := topics.(map[string]sarama.TopicDetail)
+	return &tList, nil
+}
+
+// The partitions and replication factor of a topic in the Kafka Cluster along with all its configurations.
+type clusterTopic struct {
+	details sarama.TopicDetail
+	entries map[string]sarama.ConfigEntry
+}
+
+/*
+	Describes the configurations of the topics in the Kafka Cluster for which include returns true (or all
+	of them if include is nil). The configurations are described one topic at a time, as the source of
+	every value (topic override, broker setting or default) is only returned by DescribeConfig.
+*/
+func (t SaramaTopicExecutionManagerImpl) describeClusterTopics(ctx context.Context, clusterName string, include func(tName string) bool) (map[string]clusterTopic, error) {
+	topics, err := t.getTopicListFromKafkaCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]clusterTopic)
+	for tName, details := range *topics {
+		if include != nil && !include(tName) {
+			continue
+		}
+		entries, err := t.describeTopicConfigs(ctx, clusterName, tName)
+		if err != nil {
+			return nil, err
+		}
+		ret[tName] = clusterTopic{details: details, entries: entries}
+	}
+	return ret, nil
+}
+
+func (t SaramaTopicExecutionManagerImpl) describeTopicConfigs(ctx context.Context, clusterName string, topicName string) (map[string]sarama.ConfigEntry, error) {
+	entries, err := t.retry.DoWithResult(ctx, "Describe Topic Configs", func(context.Context) (interface{}, error) {
+		return (*t.getSaramaConnectionObject(clusterName)).DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topicName})
+	})
+	if err != nil {
+		return nil, kafkamanagers.NewSaramaError("Something Went Wrong while Describing Topic "+topicName, err)
+	}
+	ret := make(map[string]sarama.ConfigEntry)
+	for _, entry := range entries.([]sarama.ConfigEntry) {
+		ret[entry.Name] = entry
+	}
+	return ret, nil
+}
+
+/*
+	Reports if the value is set on the topic itself, rather than coming from the broker settings or the
+	defaults. The brokers before 1.1 do not return the source, so any value that is not a default is
+	considered to be set on the topic.
+*/
+func isTopicOverride(entry sarama.ConfigEntry) bool {
+	switch entry.Source {
+	case sarama.SourceTopic:
+		return true
+	case sarama.SourceUnknown:
+		return !entry.Default
+	}
+	return false
+}
+
+/*
+	Returns the topics in the Kafka Cluster along with their partitions, replication factor and the
+	configurations set on the topics, in the same format as the topic configurations parsed by the engine.
+*/
+func (t SaramaTopicExecutionManagerImpl) GetTopicConfigMapping(ctx context.Context, clusterName string) (*ksengine.TopicConfigMapping, error) {
+	topics, err := t.describeClusterTopics(ctx, clusterName, nil)
 	if err != nil {
-		logger.Fatalw("Something Went Wrong while Listing Topics.",
-			"Error Details", err)
+		return nil, err
+	}
+	clusterTCM := make(ksengine.TopicConfigMapping)
+	for tName, topic := range topics {
+		t.generateTopicConfigMappings(&clusterTCM, tName, topic)
 	}
-	return &topics
+	return &clusterTCM, nil
 }
 
-func (t SaramaTopicExecutionManagerImpl) CreateTopics(clusterName string, topics mapset.Set, dryRun bool) {
-	tSet := topics.Difference(*t.GetTopicsAsSet(clusterName))
+func (t SaramaTopicExecutionManagerImpl) CreateTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error {
+	tSet, err := t.findNonExistentTopicsInCluster(ctx, clusterName, topics)
+	if err != nil {
+		return err
+	}
 	// logger.Info("Topic List that will be executed")
 	t.ListTopics(tSet, "Create Eligible Topic List")
 	if !dryRun {
 		wg := new(sync.WaitGroup)
 		conn := t.getSaramaConnectionObject(clusterName)
+		errs := new(kafkamanagers.ErrorCollector)
 		wg.Add(tSet.Cardinality())
 		for item := range tSet.Iterator().C {
-			go t.createTopic(conn, wg, item.(string))
+			go t.createTopic(ctx, conn, wg, errs, item.(string), t.getTopicConfigProperties(item.(string)))
 		}
 		wg.Wait()
+		if err := errs.Err("Topic Creation failed"); err != nil {
+			return err
+		}
 	}
+	return ctx.Err()
 }
 
-func (t SaramaTopicExecutionManagerImpl) createTopic(conn *sarama.ClusterAdmin, wg *sync.WaitGroup, topicName string) {
-	defer wg.Done()
-	retry := true
-	retryCount := 0
-	for retry {
-		if retryCount < 5 {
-			if err := (*conn).CreateTopic(topicName, getTopicConfigProperties(topicName), false); err != nil {
-				dur := ksmisc.GenerateRandomDuration(ksmisc.GenerateRandomNumber(5, 10), "s")
-				logger.Errorw("Topic Creation failed. Will try again",
-					"Try Count", retryCount,
-					"Topic Name", topicName,
-					"Error", err.Error(),
-					"Cooldown before retry", dur.String())
-				retryCount += 1
-				time.Sleep(dur)
-			} else {
-				break
-			}
-		} else {
-			logger.Errorw("Topic Creation request failed consecutively. Will not retry",
-				"Try Count", retryCount,
-				"Topic Name", topicName)
-			retry = false
+/*
+	Creates the topics in the provided mapping instead of the ones from the configurations, using the
+	properties provided in the mapping. Topics already present in the Kafka Cluster are not touched.
+*/
+func (t SaramaTopicExecutionManagerImpl) CreateTopicsFromMapping(ctx context.Context, clusterName string, in *ksengine.TopicConfigMapping, dryRun bool) error {
+	tSet := mapset.NewSet()
+	for tName := range *in {
+		tSet.Add(tName)
+	}
+	tSet, err := t.findNonExistentTopicsInCluster(ctx, clusterName, tSet)
+	if err != nil {
+		return err
+	}
+	t.ListTopics(tSet, "Create Eligible Topic List")
+	if !dryRun {
+		wg := new(sync.WaitGroup)
+		conn := t.getSaramaConnectionObject(clusterName)
+		errs := new(kafkamanagers.ErrorCollector)
+		wg.Add(tSet.Cardinality())
+		for item := range tSet.Iterator().C {
+			go t.createTopic(ctx, conn, wg, errs, item.(string), getTopicDetails((*in)[item.(string)]))
+		}
+		wg.Wait()
+		if err := errs.Err("Topic Creation failed"); err != nil {
+			return err
 		}
 	}
+	return ctx.Err()
 }
 
-func (t SaramaTopicExecutionManagerImpl) DeleteProvisionedTopics(clusterName string, topics mapset.Set, dryRun bool) {
-	tSet := topics.Intersect(*t.GetTopicsAsSet(clusterName))
-	t.deleteTopics(clusterName, &tSet, dryRun)
+func (t SaramaTopicExecutionManagerImpl) createTopic(ctx context.Context, conn *sarama.ClusterAdmin, wg *sync.WaitGroup, errs *kafkamanagers.ErrorCollector,
+	topicName string, td *sarama.TopicDetail) {
+	defer wg.Done()
+	err := t.retry.Do(ctx, "Topic Creation", func(context.Context) error {
+		return (*conn).CreateTopic(topicName, td, false)
+	})
+	if err != nil {
+		logger.Errorw("Topic Creation request failed. Will not retry",
+			"Topic Name", topicName,
+			"Error", err.Error())
+		errs.Add(kafkamanagers.NewSaramaError("Cannot create Topic "+topicName, err))
+	}
 }
 
-func (t SaramaTopicExecutionManagerImpl) DeleteUnknownTopics(clusterName string, topics mapset.Set, dryRun bool) {
-	tSet := (*t.GetTopicsAsSet(clusterName)).Difference(topics)
-	t.deleteTopics(clusterName, &tSet, dryRun)
+func (t SaramaTopicExecutionManagerImpl) DeleteProvisionedTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error {
+	clusterTopics, err := t.GetTopicsAsSet(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	tSet := topics.Intersect(*clusterTopics)
+	return t.deleteTopics(ctx, clusterName, &tSet, dryRun)
 }
 
-func (t SaramaTopicExecutionManagerImpl) deleteTopics(clusterName string, tSet *mapset.Set, dryRun bool) {
+/*
+	Deletes the topics in the cluster that are not in the configurations, as long as the topic deletion
+	safeguards allow it (see filterUnknownTopicDeletions).
+*/
+func (t SaramaTopicExecutionManagerImpl) DeleteUnknownTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error {
+	tSet, err := t.findNonExistentTopicsInConfig(ctx, clusterName, topics)
+	if err != nil {
+		return err
+	}
+	if tSet, err = t.filterUnknownTopicDeletions(ctx, clusterName, tSet, !dryRun); err != nil {
+		return err
+	}
+	return t.deleteTopics(ctx, clusterName, &tSet, dryRun)
+}
+
+// The protected topics are never deleted, whichever flow they come from.
+func (t SaramaTopicExecutionManagerImpl) deleteTopics(ctx context.Context, clusterName string, tSet *mapset.Set, dryRun bool) error {
+	*tSet = t.withoutProtectedTopics(clusterName, *tSet)
 	// logger.Info("Topic List eligible for Deletion")
 	t.ListTopics(*tSet, "Delete Eligible Topic List")
 	if !dryRun {
 		wg := new(sync.WaitGroup)
 		conn := t.getSaramaConnectionObject(clusterName)
+		errs := new(kafkamanagers.ErrorCollector)
 		wg.Add((*tSet).Cardinality())
 		for item := range (*tSet).Iterator().C {
-			go t.deleteTopic(conn, wg, item.(string))
+			go t.deleteTopic(ctx, conn, wg, errs, item.(string))
 		}
 		wg.Wait()
+		if err := errs.Err("Topic Deletion failed"); err != nil {
+			return err
+		}
 	}
+	return ctx.Err()
 }
 
-func (t SaramaTopicExecutionManagerImpl) deleteTopic(conn *sarama.ClusterAdmin, wg *sync.WaitGroup, topicName string) {
+func (t SaramaTopicExecutionManagerImpl) deleteTopic(ctx context.Context, conn *sarama.ClusterAdmin, wg *sync.WaitGroup, errs *kafkamanagers.ErrorCollector, topicName string) {
 	defer wg.Done()
-	retry := true
-	retryCount := 0
-	for retry {
-		if retryCount < 5 {
-			if err := (*conn).DeleteTopic(topicName); err != nil {
-				dur := ksmisc.GenerateRandomDuration(ksmisc.GenerateRandomNumber(5, 10), "s")
-				logger.Errorw("Topic deletion failed. Will try again.",
-					"Try Count", retryCount,
-					"Topic Name", topicName,
-					"Error", err.Error(),
-					"Cooldown before retry", dur.String())
-				retryCount += 1
-				time.Sleep(dur)
-			} else {
-				break
-			}
-		} else {
-			logger.Errorw("Topic Deletion request failed consecutively. Will not retry",
-				"Try Count", retryCount,
-				"Topic Name", topicName)
-			retry = false
-		}
+	err := t.retry.Do(ctx, "Topic Deletion", func(context.Context) error {
+		return (*conn).DeleteTopic(topicName)
+	})
+	if err != nil {
+		logger.Errorw("Topic Deletion request failed. Will not retry",
+			"Topic Name", topicName,
+			"Error", err.Error())
+		errs.Add(kafkamanagers.NewSaramaError("Cannot delete Topic "+topicName, err))
 	}
 }
 
-func (t SaramaTopicExecutionManagerImpl) ModifyTopics(clusterName string, dryRun bool) {
-	cDiff, pDiff := t.findMismatchedConfigTopics(clusterName)
+/*
+	Aligns the topics of the cluster with the configurations. The topics whose partition count would have to
+	decrease are returned as failed, once the changes of the other topics are made.
+*/
+func (t SaramaTopicExecutionManagerImpl) ModifyTopics(ctx context.Context, clusterName string, dryRun bool) error {
+	cDiff, pDiff, rDiff, refused, err := t.findMismatchedConfigTopics(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	errs := new(kafkamanagers.ErrorCollector)
+	errs.Add(refused)
+	errs.Add(t.modifyTopics(ctx, clusterName, cDiff, pDiff, rDiff, dryRun))
+	return errs.Err("Topic Modification failed")
+}
+
+/*
+	Executes the partition count changes and the configuration changes concurrently for all the topics,
+	and then the replication factor changes one topic at a time, as every one of them may move a lot of
+	data and changes the throttles of the brokers.
+*/
+func (t SaramaTopicExecutionManagerImpl) modifyTopics(ctx context.Context, clusterName string, cDiff mapset.Set, pDiff mapset.Set, rDiff mapset.Set, dryRun bool) error {
 	// logger.Info("Configurations will be updated for the following topics")
 	t.ListTopics(cDiff, "Update Topic Config List")
 	// logger.Info("Partition Count will be updated for the following topics")
 	t.ListTopics(pDiff, "Update Topic Partition count")
+	t.ListTopics(rDiff, "Update Topic Replication Factor")
 
 	if !dryRun {
 		wg := new(sync.WaitGroup)
 		conn := t.getSaramaConnectionObject(clusterName)
+		errs := new(kafkamanagers.ErrorCollector)
 		wg.Add(pDiff.Cardinality())
 		for item := range pDiff.Iterator().C {
-			// go t.createTopic(conn, wg, item.(string))
-			go modifyTopicPartitions(conn, wg, item.(string))
+			go t.modifyTopicPartitions(ctx, conn, wg, errs, item.(string), t.getTopicConfigProperties(item.(string)))
 		}
 		wg.Wait()
 
 		wg.Add(cDiff.Cardinality())
-		for item := range pDiff.Iterator().C {
-			go modifyTopicConfig(conn, wg, item.(string))
+		for item := range cDiff.Iterator().C {
+			go t.modifyTopicConfig(ctx, conn, wg, errs, item.(string), t.getTopicConfigProperties(item.(string)))
 		}
 		wg.Wait()
+		if err := errs.Err("Topic Modification failed"); err != nil {
+			return err
+		}
+	}
+	if rDiff.Cardinality() == 0 {
+		return ctx.Err()
 	}
+	// The replica reassignment requests are only known from 2.4.0 onwards.
+	if err := t.connections.RequireSaramaVersion(clusterName, sarama.V2_4_0_0, "Topic Replication Factor change"); err != nil {
+		return err
+	}
+	errs := new(kafkamanagers.ErrorCollector)
+	for _, tName := range t.GetTopicsAsSlice(rDiff) {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := t.modifyTopicReplicationFactor(ctx, clusterName, tName, t.getTopicConfigProperties(tName).ReplicationFactor, dryRun); err != nil {
+			logger.Errorw("Topic replication factor change failed. Will not retry",
+				"Topic Name", tName,
+				"Error", err.Error())
+			var sErr *ksengine.ShepherdError
+			if !errors.As(err, &sErr) {
+				err = kafkamanagers.NewSaramaError("Cannot change the replication factor of topic "+tName, err)
+			}
+			errs.Add(err)
+		}
+	}
+	if err := errs.Err("Topic Replication Factor change failed"); err != nil {
+		return err
+	}
+	return ctx.Err()
 }
 
-func modifyTopicConfig(conn *sarama.ClusterAdmin, wg *sync.WaitGroup, topicName string) {
+func (t SaramaTopicExecutionManagerImpl) modifyTopicConfig(ctx context.Context, conn *sarama.ClusterAdmin, wg *sync.WaitGroup, errs *kafkamanagers.ErrorCollector,
+	topicName string, td *sarama.TopicDetail) {
 	defer wg.Done()
-	retry := true
-	retryCount := 0
-	for retry {
-		if retryCount < 5 {
-			// if err := (*conn).CreateTopic(topicName, getTopicConfigProperties(topicName), false); err != nil {
-			if err := (*conn).AlterConfig(sarama.TopicResource, topicName, getTopicConfigProperties(topicName).ConfigEntries, false); err != nil {
-				retryCount += 1
-				dur := ksmisc.GenerateRandomDuration(ksmisc.GenerateRandomNumber(5, 10), "s")
-				logger.Errorw("Topic Configuration update failed. Will try again",
-					"Topic Name", topicName,
-					"Error", err.Error(),
-					"Cooldown before retry", dur.String())
-				time.Sleep(dur)
-			} else {
-				break
-			}
-		} else {
-			logger.Errorw("Topic Configuration update request failed consecutively. Will not retry",
-				"Topic Name", topicName)
-			retry = false
-		}
+	err := t.retry.Do(ctx, "Topic Configuration update", func(context.Context) error {
+		return (*conn).AlterConfig(sarama.TopicResource, topicName, td.ConfigEntries, false)
+	})
+	if err != nil {
+		logger.Errorw("Topic Configuration update request failed. Will not retry",
+			"Topic Name", topicName,
+			"Error", err.Error())
+		errs.Add(kafkamanagers.NewSaramaError("Cannot update the configurations of Topic "+topicName, err))
 	}
 }
 
-func modifyTopicPartitions(conn *sarama.ClusterAdmin, wg *sync.WaitGroup, topicName string) {
+func (t SaramaTopicExecutionManagerImpl) modifyTopicPartitions(ctx context.Context, conn *sarama.ClusterAdmin, wg *sync.WaitGroup, errs *kafkamanagers.ErrorCollector,
+	topicName string, td *sarama.TopicDetail) {
 	defer wg.Done()
-	retry := true
-	retryCount := 0
-	for retry {
-		if retryCount < 5 {
-			if err := (*conn).CreatePartitions(topicName, getTopicConfigProperties(topicName).NumPartitions, nil, false); err != nil {
-				retryCount += 1
-				dur := ksmisc.GenerateRandomDuration(ksmisc.GenerateRandomNumber(5, 10), "s")
-				logger.Errorw("Topic Partition Count failed. Will try again",
-					"Topic Name", topicName,
-					"Error", err.Error(),
-					"Cooldown before retry", dur.String())
-				time.Sleep(dur)
-			} else {
-				break
-			}
-		} else {
-			logger.Errorw("Topic partition count change request failed consecutively. Will not retry",
-				"Topic Name", topicName)
-			retry = false
-		}
+	err := t.retry.Do(ctx, "Topic partition count change", func(context.Context) error {
+		return (*conn).CreatePartitions(topicName, td.NumPartitions, nil, false)
+	})
+	if err != nil {
+		logger.Errorw("Topic partition count change request failed. Will not retry",
+			"Topic Name", topicName,
+			"Error", err.Error())
+		errs.Add(kafkamanagers.NewSaramaError("Cannot change the partition count of Topic "+topicName, err))
 	}
 }
 
-func getTopicConfigProperties(topicName string) *sarama.TopicDetail {
+func (t SaramaTopicExecutionManagerImpl) getTopicConfigProperties(topicName string) *sarama.TopicDetail {
+	return getTopicDetails((*t.configTCM)[topicName])
+}
+
+func getTopicDetails(temp ksengine.NVPairs) *sarama.TopicDetail {
 	// TODO: Add default Values  in the config file and update it here.
 	var td sarama.TopicDetail = sarama.TopicDetail{
 		NumPartitions:     1,
@@ -228,7 +386,6 @@ func getTopicConfigProperties(topicName string) *sarama.TopicDetail {
 		ConfigEntries:     nil,
 	}
 
-	temp := ksengine.ConfMaps.TCM[topicName]
 	for k, v := range temp {
 		switch k {
 		case "num.partitions":
@@ -243,7 +400,7 @@ func getTopicConfigProperties(topicName string) *sarama.TopicDetail {
 			// TODO: Update the correct property for Replica Assignment here.
 			break
 		default:
-			strValue := v
+			strValue := ksengine.NormalizeTopicConfigValue(k, v)
 			if td.ConfigEntries != nil {
 				td.ConfigEntries[k] = &strValue
 			} else {
@@ -255,41 +412,249 @@ func getTopicConfigProperties(topicName string) *sarama.TopicDetail {
 	return &td
 }
 
-func (t SaramaTopicExecutionManagerImpl) findMismatchedConfigTopics(clusterName string) (configDiff mapset.Set, partitionDiff mapset.Set) {
-	configDiff, partitionDiff = mapset.NewSet(), mapset.NewSet()
-	clusterTCM := make(ksengine.TopicConfigMapping)
-	for tName, configs := range *t.getTopicListFromKafkaCluster(clusterName) {
-		t.generateTopicConfigMappings(&clusterTCM, tName, &configs)
-	}
-
-	for tName, cPairs := range clusterTCM {
-		if (ksengine.ConfMaps.TCM)[tName] != nil {
-			for propName, propVal := range cPairs {
-				switch propName {
-				case "num.partitions":
-					if propVal != (ksengine.ConfMaps.TCM)[tName][propName] {
-						partitionDiff.Add(tName)
-					}
-				default:
-					if propVal != (ksengine.ConfMaps.TCM)[tName][propName] {
-						configDiff.Add(tName)
-					}
-				}
-			}
+func (t SaramaTopicExecutionManagerImpl) findNonExistentTopicsInCluster(ctx context.Context, clusterName string, topics mapset.Set) (mapset.Set, error) {
+	clusterTopics, err := t.GetTopicsAsSet(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return topics.Difference(*clusterTopics), nil
+}
+
+func (t SaramaTopicExecutionManagerImpl) findNonExistentTopicsInConfig(ctx context.Context, clusterName string, topics mapset.Set) (mapset.Set, error) {
+	clusterTopics, err := t.GetTopicsAsSet(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return (*clusterTopics).Difference(topics), nil
+}
+
+/*
+	Classifies the topics that differ from the configurations by the changes fixing them. The topics whose
+	partition count would have to decrease are left out and returned as the refused error.
+*/
+func (t SaramaTopicExecutionManagerImpl) findMismatchedConfigTopics(ctx context.Context, clusterName string) (configDiff mapset.Set, partitionDiff mapset.Set,
+	replicationDiff mapset.Set, refused error, err error) {
+	diffs, err := t.findTopicConfigDiffs(ctx, clusterName)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	refused = removePartitionDecreases(diffs)
+	configDiff, partitionDiff, replicationDiff = mapset.NewSet(), mapset.NewSet(), mapset.NewSet()
+	for tName, diff := range diffs {
+		for propName := range diff.before {
+			logger.Infow("Topic configuration drift found.",
+				"Topic Name", tName,
+				"Property", propName,
+				"Cluster Value", diff.before[propName],
+				"Config Value", diff.after[propName])
 		}
+		classifyTopicDiff(tName, diff.before, configDiff, partitionDiff, replicationDiff)
 	}
 	return
 }
 
-func (t SaramaTopicExecutionManagerImpl) generateTopicConfigMappings(ctcm *ksengine.TopicConfigMapping, topicName string, topicDetails *sarama.TopicDetail) {
+/*
+	Adds the topic to the set of the changes that can fix the provided properties. The partition count and
+	the replication factor are changed separately from the other configurations.
+*/
+func classifyTopicDiff(tName string, props ksengine.NVPairs, configDiff mapset.Set, partitionDiff mapset.Set, replicationDiff mapset.Set) {
+	for propName := range props {
+		switch propName {
+		case "num.partitions":
+			partitionDiff.Add(tName)
+		case "replication.factor":
+			replicationDiff.Add(tName)
+		default:
+			configDiff.Add(tName)
+		}
+	}
+}
+
+// The properties that differ between the cluster and the configurations for a topic.
+type topicConfigDiff struct {
+	before ksengine.NVPairs
+	after  ksengine.NVPairs
+}
+
+/*
+	Compares the properties of the topics present in the cluster as well as in the configurations. Every
+	property declared in the configurations is compared with the value used by the cluster, whatever its
+	source, after normalizing both (see ksengine.TopicConfigValuesEqual). The properties set on a topic in
+	the cluster but not declared in the configurations go back to their default values, so they are only
+	part of the before values. The other values coming from the broker settings or the defaults are ignored.
+*/
+func (t SaramaTopicExecutionManagerImpl) findTopicConfigDiffs(ctx context.Context, clusterName string) (map[string]topicConfigDiff, error) {
+	topics, err := t.describeClusterTopics(ctx, clusterName, func(tName string) bool {
+		return (*t.configTCM)[tName] != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]topicConfigDiff)
+	for tName, topic := range topics {
+		if diff := compareTopicConfigs(topic, (*t.configTCM)[tName]); len(diff.before) != 0 {
+			ret[tName] = diff
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Removes the topics whose partition count would have to decrease from the diffs, as Kafka cannot decrease
+	it, and returns them as an ErrConfigInvalid error (nil if there are none). The other topics are kept.
+*/
+func removePartitionDecreases(diffs map[string]topicConfigDiff) error {
+	decreases := []string{}
+	for tName, diff := range diffs {
+		if isPartitionDecrease(diff) {
+			decreases = append(decreases, fmt.Sprintf("%s (%s -> %s)", tName, diff.before["num.partitions"], diff.after["num.partitions"]))
+			delete(diffs, tName)
+		}
+	}
+	if len(decreases) == 0 {
+		return nil
+	}
+	sort.Strings(decreases)
+	return ksengine.NewShepherdError(ksengine.ErrConfigInvalid,
+		fmt.Sprintf("Kafka cannot decrease the partition count of a topic. Recreate the topics or restore their partition count in the configurations: %s",
+			strings.Join(decreases, ", ")), nil)
+}
+
+func isPartitionDecrease(diff topicConfigDiff) bool {
+	before, bErr := strconv.Atoi(diff.before["num.partitions"])
+	after, aErr := strconv.Atoi(diff.after["num.partitions"])
+	return bErr == nil && aErr == nil && after < before
+}
+
+func compareTopicConfigs(topic clusterTopic, configs ksengine.NVPairs) topicConfigDiff {
+	diff := topicConfigDiff{before: ksengine.NVPairs{}, after: ksengine.NVPairs{}}
+	for propName, configVal := range configs {
+		var clusterVal string
+		switch propName {
+		case "num.partitions":
+			clusterVal = strconv.FormatInt(int64(topic.details.NumPartitions), 10)
+		case "replication.factor", "default.replication.factor":
+			propName = "replication.factor"
+			clusterVal = strconv.FormatInt(int64(topic.details.ReplicationFactor), 10)
+		case "ReplicaAssignment":
+			continue
+		default:
+			entry, found := topic.entries[propName]
+			if found && entry.Sensitive {
+				continue
+			}
+			// A property unknown to the cluster is reported with an empty value, so that it does not go unnoticed.
+			clusterVal = entry.Value
+		}
+		if !ksengine.TopicConfigValuesEqual(propName, clusterVal, configVal) {
+			diff.before[propName] = clusterVal
+			diff.after[propName] = ksengine.NormalizeTopicConfigValue(propName, configVal)
+		}
+	}
+	for propName, entry := range topic.entries {
+		if _, found := configs[propName]; !found && isTopicOverride(entry) && !entry.Sensitive {
+			diff.before[propName] = entry.Value
+		}
+	}
+	return diff
+}
+
+/*
+	Lists the changes that CreateTopics, ModifyTopics and DeleteUnknownTopics would execute for the
+	cluster as PlanChanges, using the same comparisons as those functions. Nothing is executed.
+*/
+func (t SaramaTopicExecutionManagerImpl) PlanTopics(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error) {
+	ret := []ksengine.PlanChange{}
+	clusterTCM, err := t.GetTopicConfigMapping(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	clusterTopics := mapset.NewSet()
+	for tName := range *clusterTCM {
+		clusterTopics.Add(tName)
+	}
+	if executeCreateFlow {
+		for _, tName := range t.GetTopicsAsSlice(topics.Difference(clusterTopics)) {
+			ret = append(ret, ksengine.PlanChange{Cluster: clusterName, Action: ksengine.PlanAction_CREATE, ResourceType: ksengine.PlanResourceType_TOPIC,
+				Name: tName, After: (*t.configTCM)[tName]})
+		}
+	}
+	if executeModifyFlow {
+		diffs, err := t.findTopicConfigDiffs(ctx, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		// The other changes can still be applied, so the refused topics are only left out of the plan.
+		if err := removePartitionDecreases(diffs); err != nil {
+			logger.Errorw("Some topic changes cannot be planned.",
+				"Cluster Name", clusterName,
+				"Error", err)
+		}
+		for tName, diff := range diffs {
+			ret = append(ret, ksengine.PlanChange{Cluster: clusterName, Action: ksengine.PlanAction_UPDATE, ResourceType: ksengine.PlanResourceType_TOPIC,
+				Name: tName, Before: diff.before, After: diff.after})
+		}
+	}
+	if executeDeleteFlow {
+		deleteSet, err := t.filterUnknownTopicDeletions(ctx, clusterName, clusterTopics.Difference(topics), false)
+		if err != nil {
+			return nil, err
+		}
+		for _, tName := range t.GetTopicsAsSlice(deleteSet) {
+			ret = append(ret, ksengine.PlanChange{Cluster: clusterName, Action: ksengine.PlanAction_DELETE, ResourceType: ksengine.PlanResourceType_TOPIC,
+				Name: tName, Before: (*clusterTCM)[tName]})
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Executes the topic changes of a saved plan for the cluster. The changes are expected to come from
+	PlanTopics on the same configurations, so the updates use the current topic configurations. The
+	topic deletion safeguards are applied again before anything is executed (see filterPlannedTopicDeletions).
+*/
+func (t SaramaTopicExecutionManagerImpl) ApplyTopicPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error {
+	createMapping := ksengine.TopicConfigMapping{}
+	cDiff, pDiff, rDiff, deleteSet := mapset.NewSet(), mapset.NewSet(), mapset.NewSet(), mapset.NewSet()
+	for _, v := range changes {
+		if v.ResourceType != ksengine.PlanResourceType_TOPIC {
+			continue
+		}
+		switch v.Action {
+		case ksengine.PlanAction_CREATE:
+			createMapping[v.Name] = v.After
+		case ksengine.PlanAction_UPDATE:
+			classifyTopicDiff(v.Name, v.Before, cDiff, pDiff, rDiff)
+		case ksengine.PlanAction_DELETE:
+			deleteSet.Add(v.Name)
+		}
+	}
+	if t.core.DeleteUnknownTopics || deleteSet.Cardinality() != 0 {
+		var err error
+		if deleteSet, err = t.filterPlannedTopicDeletions(ctx, clusterName, deleteSet, !dryRun); err != nil {
+			return err
+		}
+	}
+	if err := t.CreateTopicsFromMapping(ctx, clusterName, &createMapping, dryRun); err != nil {
+		return err
+	}
+	if err := t.modifyTopics(ctx, clusterName, cDiff, pDiff, rDiff, dryRun); err != nil {
+		return err
+	}
+	return t.deleteTopics(ctx, clusterName, &deleteSet, dryRun)
+}
+
+func (t SaramaTopicExecutionManagerImpl) generateTopicConfigMappings(ctcm *ksengine.TopicConfigMapping, topicName string, topic clusterTopic) {
 	// Anon Function
 	assignment := func(v *ksengine.NVPairs) {
-		(*v)["num.partitions"] = strconv.FormatInt(int64(topicDetails.NumPartitions), 10)
-		(*v)["replication.factor"] = strconv.FormatInt(int64(topicDetails.ReplicationFactor), 10)
+		(*v)["num.partitions"] = strconv.FormatInt(int64(topic.details.NumPartitions), 10)
+		(*v)["replication.factor"] = strconv.FormatInt(int64(topic.details.ReplicationFactor), 10)
 		// TODO: Replica Assignment is completely ignored at this time due to format restrictions.
 		// Not even sure if that will be something that folks would need in the long run or not.
-		for pName, pVal := range topicDetails.ConfigEntries {
-			(*v)[pName] = *pVal
+		for pName, entry := range topic.entries {
+			if isTopicOverride(entry) && !entry.Sensitive {
+				(*v)[pName] = entry.Value
+			}
 		}
 	}
 
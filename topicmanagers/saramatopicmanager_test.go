@@ -0,0 +1,171 @@
+package topicmanagers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	mapset "github.com/deckarep/golang-set"
+	ksengine "github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+)
+
+// A Cluster Admin with the provided topics, of a single partition unless set in partitions, failing to
+// describe any topic with describeErr.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	lock        sync.Mutex
+	topics      []string
+	partitions  map[string]int32
+	deleted     []string
+	grown       []string
+	describeErr error
+}
+
+func (a *fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	ret := map[string]sarama.TopicDetail{}
+	for _, tName := range a.topics {
+		ret[tName] = sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+		if count, found := a.partitions[tName]; found {
+			ret[tName] = sarama.TopicDetail{NumPartitions: count, ReplicationFactor: 1}
+		}
+	}
+	return ret, nil
+}
+
+func (a *fakeClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return nil, nil
+}
+
+func (a *fakeClusterAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.grown = append(a.grown, topic)
+	return nil
+}
+
+func (a *fakeClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetadata, error) {
+	return nil, a.describeErr
+}
+
+func (a *fakeClusterAdmin) DeleteTopic(topic string) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.deleted = append(a.deleted, topic)
+	return nil
+}
+
+// A client where only the active topics have messages produced recently.
+type fakeClient struct {
+	sarama.Client
+	active map[string]bool
+}
+
+func (c *fakeClient) Partitions(topic string) ([]int32, error) {
+	return []int32{0}, nil
+}
+
+func (c *fakeClient) GetOffset(topic string, partitionID int32, time int64) (int64, error) {
+	if c.active[topic] {
+		return 10, nil
+	}
+	return -1, nil
+}
+
+func (s *StackSuite) TestStackSuite_Topics_ReplicationFactorFailure() {
+	var ca sarama.ClusterAdmin = &fakeClusterAdmin{describeErr: sarama.ErrTopicAuthorizationFailed}
+	connections := kafkamanagers.NewKafkaConnections()
+	connections[kafkamanagers.KafkaConnectionsKey{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}] =
+		kafkamanagers.KafkaConnectionsValue{Connection: &kafkamanagers.SaramaConnection{SCA: &ca, Version: sarama.V2_4_0_0}, ConnectionType: kafkamanagers.ConnectionType_SARAMA}
+	connections[kafkamanagers.KafkaConnectionsKey{ClusterName: "c2", ConnectionType: kafkamanagers.ConnectionType_SARAMA}] =
+		kafkamanagers.KafkaConnectionsValue{Connection: &kafkamanagers.SaramaConnection{SCA: &ca, Version: sarama.V2_3_0_0}, ConnectionType: kafkamanagers.ConnectionType_SARAMA}
+	tcm := ksengine.TopicConfigMapping{
+		"test.first":  ksengine.NVPairs{"replication.factor": "3"},
+		"test.second": ksengine.NVPairs{"replication.factor": "3"},
+	}
+	t := NewSaramaTopicManager(connections, &tcm, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{}, 0), ksengine.ShepherdCoreConfig{}).(SaramaTopicExecutionManagerImpl)
+
+	// Every topic is tried, and the failures are returned once they all are.
+	err := t.modifyTopics(context.Background(), "c1", mapset.NewSet(), mapset.NewSet(), mapset.NewSet("test.first", "test.second"), true)
+	s.Error(err)
+	s.True(errors.Is(err, ksengine.ErrAuthFailed), "Error: %v", err)
+	s.Contains(err.Error(), "2 request(s) failed")
+	s.Contains(err.Error(), "test.first")
+	s.Contains(err.Error(), "test.second")
+
+	// The replicas cannot be reassigned with the version of c2, so nothing is tried.
+	err = t.modifyTopics(context.Background(), "c2", mapset.NewSet(), mapset.NewSet(), mapset.NewSet("test.first"), true)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Contains(err.Error(), "at least 2.4.0")
+}
+
+func (s *StackSuite) TestStackSuite_Topics_PartitionDecrease() {
+	admin := &fakeClusterAdmin{topics: []string{"test.shrunk", "test.grown"}, partitions: map[string]int32{"test.shrunk": 6}}
+	var ca sarama.ClusterAdmin = admin
+	connections := kafkamanagers.NewKafkaConnections()
+	connections[kafkamanagers.KafkaConnectionsKey{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}] =
+		kafkamanagers.KafkaConnectionsValue{Connection: &kafkamanagers.SaramaConnection{SCA: &ca}, ConnectionType: kafkamanagers.ConnectionType_SARAMA}
+	tcm := ksengine.TopicConfigMapping{
+		"test.shrunk": ksengine.NVPairs{"num.partitions": "3", "replication.factor": "1"},
+		"test.grown":  ksengine.NVPairs{"num.partitions": "4", "replication.factor": "1"},
+	}
+	t := NewSaramaTopicManager(connections, &tcm, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{}, 0), ksengine.ShepherdCoreConfig{})
+	ctx := context.Background()
+
+	// Only the topic that cannot be changed is left out of the plan.
+	changes, err := t.PlanTopics(ctx, "c1", mapset.NewSet("test.shrunk", "test.grown"), false, true, false)
+	s.NoError(err)
+	s.Require().Len(changes, 1)
+	s.Equal("test.grown", changes[0].Name)
+
+	// The other topics are still changed, and the topic that cannot be is reported as failed.
+	err = t.ModifyTopics(ctx, "c1", false)
+	s.True(errors.Is(err, ksengine.ErrConfigInvalid), "Error: %v", err)
+	s.Contains(err.Error(), "test.shrunk (6 -> 3)")
+	s.NotContains(err.Error(), "test.grown")
+	s.Equal([]string{"test.grown"}, admin.grown)
+}
+
+func (s *StackSuite) TestStackSuite_Topics_ApplyPlannedDeletions() {
+	dir, err := ioutil.TempDir("", "shepherd")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+	s.Require().NoError(ioutil.WriteFile(stateFile, []byte(`{"c1": {"old.idle": 1, "old.active": 1}}`), 0644))
+
+	admin := &fakeClusterAdmin{topics: []string{"test.kept", "old.idle", "old.active", "old.pending"}}
+	var ca sarama.ClusterAdmin = admin
+	connections := kafkamanagers.NewKafkaConnections()
+	connections[kafkamanagers.KafkaConnectionsKey{ClusterName: "c1", ConnectionType: kafkamanagers.ConnectionType_SARAMA}] =
+		kafkamanagers.KafkaConnectionsValue{
+			Connection:     &kafkamanagers.SaramaConnection{SCA: &ca, Client: &fakeClient{active: map[string]bool{"old.active": true}}},
+			ConnectionType: kafkamanagers.ConnectionType_SARAMA,
+		}
+	tcm := ksengine.TopicConfigMapping{"test.kept": ksengine.NVPairs{}}
+	core := ksengine.ShepherdCoreConfig{
+		DeleteUnknownTopics: true,
+		TopicDeletion:       ksengine.TopicDeletionConfig{ActivityWindow: time.Hour, AbsentRuns: 2, StateFile: stateFile},
+	}
+	t := NewSaramaTopicManager(connections, &tcm, kafkamanagers.NewRetryPolicy(ksengine.RetryConfig{}, 0), core)
+
+	// "old.active" received messages since the plan was created.
+	changes := []ksengine.PlanChange{
+		{Cluster: "c1", Action: ksengine.PlanAction_DELETE, ResourceType: ksengine.PlanResourceType_TOPIC, Name: "old.idle"},
+		{Cluster: "c1", Action: ksengine.PlanAction_DELETE, ResourceType: ksengine.PlanResourceType_TOPIC, Name: "old.active"},
+	}
+	s.NoError(t.ApplyTopicPlan(context.Background(), "c1", changes, false))
+	s.Equal([]string{"old.idle"}, admin.deleted)
+
+	// The run is counted for the topics missing from the configurations.
+	b, err := ioutil.ReadFile(stateFile)
+	s.Require().NoError(err)
+	state := map[string]map[string]int{}
+	s.Require().NoError(json.Unmarshal(b, &state))
+	s.Equal(map[string]int{"old.idle": 2, "old.pending": 1}, state["c1"])
+}
@@ -1,6 +1,7 @@
 package topicmanagers
 
 import (
+	"context"
 	"sort"
 
 	mapset "github.com/deckarep/golang-set"
@@ -13,11 +14,15 @@ var (
 )
 
 type TopicExecutionManager interface {
-	GetTopicsAsSet(clusterName string) *mapset.Set
-	CreateTopics(clusterName string, topics mapset.Set, dryRun bool)
-	ModifyTopics(clusterName string, dryRun bool)
-	DeleteProvisionedTopics(clusterName string, topics mapset.Set, dryRun bool)
-	DeleteUnknownTopics(clusterName string, topics mapset.Set, dryRun bool)
+	GetTopicsAsSet(ctx context.Context, clusterName string) (*mapset.Set, error)
+	GetTopicConfigMapping(ctx context.Context, clusterName string) (*ksengine.TopicConfigMapping, error)
+	CreateTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error
+	CreateTopicsFromMapping(ctx context.Context, clusterName string, in *ksengine.TopicConfigMapping, dryRun bool) error
+	ModifyTopics(ctx context.Context, clusterName string, dryRun bool) error
+	DeleteProvisionedTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error
+	DeleteUnknownTopics(ctx context.Context, clusterName string, topics mapset.Set, dryRun bool) error
+	PlanTopics(ctx context.Context, clusterName string, topics mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ([]ksengine.PlanChange, error)
+	ApplyTopicPlan(ctx context.Context, clusterName string, changes []ksengine.PlanChange, dryRun bool) error
 }
 
 type TopicExecutionManagerBaseImpl struct{}
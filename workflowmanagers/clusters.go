@@ -0,0 +1,129 @@
+package workflowmanagers
+
+import (
+	"context"
+
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+// The outcome of executing a workflow against a single cluster.
+type ClusterResult struct {
+	ClusterName string
+	Duration    time.Duration
+	Err         error
+}
+
+type ClusterResults []ClusterResult
+
+func (r ClusterResults) Failed() (out []string) {
+	for _, v := range r {
+		if v.Err != nil {
+			out = append(out, v.ClusterName)
+		}
+	}
+	return out
+}
+
+// Returns an error naming every failed cluster, or nil if all the clusters succeeded.
+func (r ClusterResults) Err() error {
+	if failed := r.Failed(); len(failed) != 0 {
+		return fmt.Errorf("workflow failed for %d of %d cluster(s): %v", len(failed), len(r), failed)
+	}
+	return nil
+}
+
+func (r ClusterResults) PrintSummary() {
+	ksmisc.DottedLineOutput("Cluster Execution Summary", "=", 80)
+	for _, v := range r {
+		if v.Err != nil {
+			logger.Errorw("Cluster execution failed.",
+				"Cluster Name", v.ClusterName,
+				"Duration", v.Duration,
+				"Error", v.Err)
+			continue
+		}
+		logger.Infow("Cluster execution succeeded.",
+			"Cluster Name", v.ClusterName,
+			"Duration", v.Duration)
+	}
+	logger.Infow("Execution completed.",
+		"Total Clusters", len(r),
+		"Succeeded", len(r)-len(r.Failed()),
+		"Failed", len(r.Failed()))
+}
+
+/*
+	Executes f for every enabled cluster and collects the per cluster results. The connections are set up
+	one cluster at a time as the connection registry is shared, after which f is executed for all the
+	connected clusters. In MULTI_CLUSTER run mode the clusters are executed in parallel. The error returned
+	by f (or a panic, which is recovered) is recorded against the cluster without affecting the others.
+	Once the context is done, the clusters that have not started yet fail with the context error.
+*/
+func (s *Shepherd) runForEachCluster(ctx context.Context, f func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error) ClusterResults {
+	clusters := []engine.ShepherdCluster{}
+	for _, v := range s.State.Core.Configs.ConfigRoot.Clusters {
+		if v.IsEnabled {
+			clusters = append(clusters, v)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	results := make(ClusterResults, len(clusters))
+	for i, v := range clusters {
+		start := time.Now()
+		results[i] = ClusterResult{ClusterName: v.Name}
+		results[i].Err = s.connect(ctx, v)
+		results[i].Duration = time.Since(start)
+	}
+
+	wg := new(sync.WaitGroup)
+	for i, v := range clusters {
+		if results[i].Err != nil {
+			continue
+		}
+		exec := func(i int, cluster engine.ShepherdCluster) {
+			defer wg.Done()
+			start := time.Now()
+			if results[i].Err = ctx.Err(); results[i].Err != nil {
+				return
+			}
+			results[i].Err = isolate(func() error {
+				return f(ctx, cluster.Name, s.State.Maps.CCM[engine.ClusterConfigMappingKey{IsEnabled: true, Name: cluster.Name}])
+			})
+			results[i].Duration += time.Since(start)
+		}
+		wg.Add(1)
+		if s.State.RunMode == engine.RunMode_MULTI_CLUSTER {
+			go exec(i, v)
+			continue
+		}
+		exec(i, v)
+	}
+	wg.Wait()
+	return results
+}
+
+// Sets up the connections for the cluster, giving up after the operation timeout.
+func (s *Shepherd) connect(ctx context.Context, cluster engine.ShepherdCluster) error {
+	opCtx, cancel := ksmisc.OperationContext(ctx, s.State.OperationTimeout)
+	defer cancel()
+	return isolate(func() error { return s.Connections.InitiateKafkaConnection(opCtx, cluster) })
+}
+
+// Returns the error returned by f, or the panic raised by f as an error.
+func isolate(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,86 @@
+package workflowmanagers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/suite"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/topicmanagers"
+)
+
+type StackSuite struct {
+	suite.Suite
+}
+
+func TestStackSuite(t *testing.T) {
+	suite.Run(t, new(StackSuite))
+}
+
+// A Cluster Admin with the provided topics, failing every topic creation with createErr (if set).
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	lock      sync.Mutex
+	topics    []string
+	createErr error
+	created   []string
+}
+
+func (a *fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	ret := map[string]sarama.TopicDetail{}
+	for _, tName := range a.topics {
+		ret[tName] = sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+	}
+	return ret, nil
+}
+
+func (a *fakeClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return nil, nil
+}
+
+func (a *fakeClusterAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.createErr != nil {
+		return a.createErr
+	}
+	a.created = append(a.created, topic)
+	return nil
+}
+
+func (s *StackSuite) TestStackSuite_Clusters_FailedManagerFailsCluster() {
+	admins := map[string]*fakeClusterAdmin{
+		"failing":    {createErr: sarama.ErrTopicAuthorizationFailed},
+		"succeeding": {},
+	}
+	st := &engine.State{Options: engine.Options{RunMode: engine.RunMode_MULTI_CLUSTER}}
+	st.Core.Configs.ConfigRoot.ShepherdCoreConfig.SeperatorToken = "."
+	st.Maps.TCM = engine.TopicConfigMapping{"test.topic": engine.NVPairs{"num.partitions": "1"}}
+	connections := kafkamanagers.NewKafkaConnections()
+	for name, admin := range admins {
+		st.Core.Configs.ConfigRoot.Clusters = append(st.Core.Configs.ConfigRoot.Clusters,
+			engine.ShepherdCluster{Name: name, IsEnabled: true, ACLManager: "kafka_acl", TopicManager: "sarama"})
+		var ca sarama.ClusterAdmin = admin
+		// The connection is already set up (for both managers), so nothing is connected to.
+		val := kafkamanagers.KafkaConnectionsValue{Connection: &kafkamanagers.SaramaConnection{SCA: &ca}, ConnectionType: kafkamanagers.ConnectionType_SARAMA}
+		connections[kafkamanagers.KafkaConnectionsKey{ClusterName: name, ConnectionType: kafkamanagers.ConnectionType_SARAMA}] = val
+		connections[kafkamanagers.KafkaConnectionsKey{ClusterName: name, ConnectionType: kafkamanagers.ConnectionType_KAFKA_ACLS}] = val
+	}
+	sp := &Shepherd{
+		State:        st,
+		Connections:  connections,
+		topicManager: topicmanagers.NewSaramaTopicManager(connections, &st.Maps.TCM, kafkamanagers.NewRetryPolicy(engine.RetryConfig{}, 0), engine.ShepherdCoreConfig{}),
+	}
+
+	results := sp.ExecuteTopicManagementWorkflow(context.Background(), true, false, false)
+	s.Len(results, 2)
+	s.Equal([]string{"failing"}, results.Failed())
+	s.True(errors.Is(results[0].Err, engine.ErrAuthFailed), "Error: %v", results[0].Err)
+	s.NoError(results[1].Err)
+	s.Equal([]string{"test.topic"}, admins["succeeding"].created)
+	s.EqualError(results.Err(), "workflow failed for 1 of 2 cluster(s): [failing]")
+}
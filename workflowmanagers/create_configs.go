@@ -0,0 +1,73 @@
+package workflowmanagers
+
+import (
+	"context"
+
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+/*
+	The Shepherd configurations generated from the topics and ACLs of an existing cluster, along with the
+	ACLs that could not be represented in the generated definitions.
+*/
+type CreateConfigsReport struct {
+	ClusterName string
+	ACLsSkipped string
+	*engine.GeneratedConfigs
+}
+
+/*
+	Reads the topics with their configurations and the ACLs from the enabled cluster and reverse engineers
+	the Blueprints and Definitions for them. This is the starting point for onboarding an existing cluster.
+*/
+func (s *Shepherd) ExecuteCreateConfigsWorkflow(ctx context.Context) (*CreateConfigsReport, error) {
+	r := &CreateConfigsReport{}
+	results := s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		r.ClusterName = clusterName
+		acls := &engine.ACLMapping{}
+		if ccm.IsACLManagementEnabled {
+			aclManager, _ := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+			var err error
+			if acls, err = aclManager.GetClusterACL(ctx, clusterName); err != nil {
+				return err
+			}
+		} else {
+			r.ACLsSkipped = "ACL management is disabled for the cluster"
+		}
+		topics, err := s.topicManager.GetTopicConfigMapping(ctx, clusterName)
+		if err != nil {
+			return err
+		}
+		r.GeneratedConfigs = engine.GenerateConfigsFromCluster(topics, acls)
+		return nil
+	})
+	if err := results.Err(); err != nil {
+		results.PrintSummary()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CreateConfigsReport) Print() {
+	ksmisc.DottedLineOutput("Create Configs Report", "=", 80)
+	logger.Infow("Generated Configuration Details",
+		"Cluster Name", r.ClusterName,
+		"Topic Blueprints", len(r.Blueprints.Blueprint.Topic.TopicConfigs),
+		"Topic Definitions", len(r.Definitions.DefinitionRoot.AdhocConfigs.Topics),
+		"ACLs Not Mapped", len(r.UnmappedACLs))
+	if r.ACLsSkipped != "" {
+		logger.Warnw("ACLs not read from the cluster.", "Reason", r.ACLsSkipped)
+	}
+	for k, v := range r.UnmappedACLs {
+		logger.Warnw("ACL could not be mapped to the definitions.",
+			"Resource Type", k.ResourceType.GetACLResourceString(),
+			"Resource Name", k.ResourceName,
+			"Resource Pattern Type", k.PatternType.GetACLPatternString(),
+			"Principal Name", k.Principal,
+			"Host", k.Hostname,
+			"ACL Operation", k.Operation.String(),
+			"Reason", v.(engine.NVPairs)["Reason"],
+		)
+	}
+}
@@ -0,0 +1,204 @@
+package workflowmanagers
+
+import (
+	"context"
+
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/waliaabhishek/kafka-shepherd/aclmanagers"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	ksmisc "github.com/waliaabhishek/kafka-shepherd/misc"
+)
+
+/*
+	The outcome of migrating the topics and ACLs from the source cluster to the target cluster. In dry run
+	mode this is the migration plan. Anything that is not migrated is listed along with the reason.
+*/
+type MigrationReport struct {
+	SourceCluster    string
+	TargetCluster    string
+	DryRun           bool
+	Topics           engine.TopicConfigMapping
+	SkippedTopics    map[string]string
+	ACLs             engine.ACLMapping
+	UntranslatedACLs engine.ACLMapping
+	ACLsSkipped      string
+	ACLConversion    aclmanagers.ACLConversionReport
+}
+
+/*
+	Reads the topics with their configurations and the ACLs (or Role Bindings) from the source cluster and
+	creates them on the target cluster. The ACLs are translated through the ACL operation interface of the
+	target cluster, so Kafka ACLs can be migrated to Confluent RBAC and vice versa where a translation exists.
+*/
+func (s *Shepherd) ExecuteMigrationWorkflow(ctx context.Context, sourceCluster string, targetCluster string) (*MigrationReport, error) {
+	source, err := s.findEnabledCluster(sourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.findEnabledCluster(targetCluster)
+	if err != nil {
+		return nil, err
+	}
+	if source.Name == target.Name {
+		return nil, fmt.Errorf("source and target cluster cannot be the same: %s", source.Name)
+	}
+
+	r := &MigrationReport{
+		SourceCluster:    source.Name,
+		TargetCluster:    target.Name,
+		DryRun:           s.State.DryRun,
+		Topics:           engine.TopicConfigMapping{},
+		SkippedTopics:    make(map[string]string),
+		ACLs:             engine.ACLMapping{},
+		UntranslatedACLs: engine.ACLMapping{},
+	}
+	for _, v := range []engine.ShepherdCluster{source, target} {
+		if err := s.connect(ctx, v); err != nil {
+			return nil, fmt.Errorf("cannot connect to cluster %s: %w", v.Name, err)
+		}
+	}
+	if err := isolate(func() error { return s.migrateTopics(ctx, r) }); err != nil {
+		return r, fmt.Errorf("topic migration failed: %w", err)
+	}
+	if err := isolate(func() error { return s.migrateACLs(ctx, r) }); err != nil {
+		return r, fmt.Errorf("ACL migration failed: %w", err)
+	}
+	return r, nil
+}
+
+func (s *Shepherd) findEnabledCluster(clusterName string) (engine.ShepherdCluster, error) {
+	for _, v := range s.State.Core.Configs.ConfigRoot.Clusters {
+		if v.IsEnabled && v.Name == clusterName {
+			return v, nil
+		}
+	}
+	return engine.ShepherdCluster{}, fmt.Errorf("cluster %q is not configured or not enabled", clusterName)
+}
+
+func (s *Shepherd) migrateTopics(ctx context.Context, r *MigrationReport) error {
+	targetTopics, err := s.topicManager.GetTopicsAsSet(ctx, r.TargetCluster)
+	if err != nil {
+		return err
+	}
+	sourceTopics, err := s.topicManager.GetTopicConfigMapping(ctx, r.SourceCluster)
+	if err != nil {
+		return err
+	}
+	// The internal topics (like the ones of Kafka Connect and Kafka Streams) belong to the source cluster.
+	deletion := s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.TopicDeletion
+	for tName, configs := range *sourceTopics {
+		rule, protected := deletion.ProtectionRule(tName)
+		switch {
+		case strings.HasPrefix(tName, "_"):
+			r.SkippedTopics[tName] = "Internal topic"
+		case protected:
+			r.SkippedTopics[tName] = "Internal or protected topic, matching the " + rule
+		case (*targetTopics).Contains(tName):
+			r.SkippedTopics[tName] = "Already present in the target cluster"
+		default:
+			r.Topics[tName] = configs
+		}
+	}
+	return s.topicManager.CreateTopicsFromMapping(ctx, r.TargetCluster, &r.Topics, s.State.DryRun)
+}
+
+func (s *Shepherd) migrateACLs(ctx context.Context, r *MigrationReport) error {
+	sourceCCM := s.State.Maps.CCM[engine.ClusterConfigMappingKey{IsEnabled: true, Name: r.SourceCluster}]
+	targetCCM := s.State.Maps.CCM[engine.ClusterConfigMappingKey{IsEnabled: true, Name: r.TargetCluster}]
+	switch {
+	case !sourceCCM.IsACLManagementEnabled:
+		r.ACLsSkipped = "ACL management is disabled for the source cluster"
+		return nil
+	case !targetCCM.IsACLManagementEnabled:
+		r.ACLsSkipped = "ACL management is disabled for the target cluster"
+		return nil
+	}
+	sourceManager, _ := s.aclControllers.GetACLControllerDetails(r.SourceCluster, sourceCCM.ACLManager)
+	targetManager, targetInterface := s.aclControllers.GetACLControllerDetails(r.TargetCluster, targetCCM.ACLManager)
+
+	sourceACLs, err := sourceManager.GetClusterACL(ctx, r.SourceCluster)
+	if err != nil {
+		return err
+	}
+	/*
+		The Kafka ACLs of a principal on a resource have to be converted together, so that they map to the least
+		privileged Role Bindings allowing all of them. Every other ACL is translated on its own, so that the ones
+		without a translation can be reported.
+	*/
+	if converter, ok := targetManager.(aclmanagers.KafkaACLConverter); ok {
+		kafkaACLs, others := engine.ACLMapping{}, engine.ACLMapping{}
+		for k, v := range *sourceACLs {
+			if _, isKafkaACL := k.Operation.(engine.KafkaACLOperation); isKafkaACL {
+				kafkaACLs.Append(k, v)
+			} else {
+				others.Append(k, v)
+			}
+		}
+		converted, untranslated, report := converter.ConvertKafkaACLs(r.TargetCluster, &kafkaACLs)
+		for k, v := range *converted {
+			r.ACLs.Append(k, v)
+		}
+		for k, v := range *untranslated {
+			r.UntranslatedACLs.Append(k, v)
+		}
+		r.ACLConversion = report
+		sourceACLs = &others
+	}
+	for k, v := range *sourceACLs {
+		translated := targetInterface.GenerateACLMappingStructures(r.TargetCluster, &engine.ACLMapping{k: v})
+		if len(*translated) == 0 {
+			r.UntranslatedACLs.Append(k, v)
+			continue
+		}
+		for tk, tv := range *translated {
+			r.ACLs.Append(tk, tv)
+		}
+	}
+	return targetManager.CreateACL(ctx, r.TargetCluster, &r.ACLs, s.State.DryRun)
+}
+
+func (r *MigrationReport) Print() {
+	ksmisc.DottedLineOutput("Migration Report", "=", 80)
+	logger.Infow("Migration Details",
+		"Source Cluster", r.SourceCluster,
+		"Target Cluster", r.TargetCluster,
+		"Dry Run", r.DryRun,
+		"Topics Migrated", len(r.Topics),
+		"Topics Skipped", len(r.SkippedTopics),
+		"ACLs Migrated", len(r.ACLs),
+		"ACLs Not Translated", len(r.UntranslatedACLs))
+	skipped := make([]string, 0, len(r.SkippedTopics))
+	for tName := range r.SkippedTopics {
+		skipped = append(skipped, tName)
+	}
+	sort.Strings(skipped)
+	for _, tName := range skipped {
+		logger.Infow("Topic not migrated.",
+			"Topic Name", tName,
+			"Reason", r.SkippedTopics[tName])
+	}
+	if r.ACLsSkipped != "" {
+		logger.Warnw("ACLs not migrated.", "Reason", r.ACLsSkipped)
+	}
+	for k := range r.UntranslatedACLs {
+		logger.Warnw("ACL could not be translated for the target cluster.",
+			"Resource Type", k.ResourceType.GetACLResourceString(),
+			"Resource Name", k.ResourceName,
+			"Resource Pattern Type", k.PatternType.GetACLPatternString(),
+			"Principal Name", k.Principal,
+			"Host", k.Hostname,
+			"ACL Operation", k.Operation.String(),
+		)
+	}
+	if len(r.ACLConversion) != 0 {
+		ksmisc.DottedLineOutput("Kafka ACLs Converted to Role Bindings", "=", 80)
+		r.ACLConversion.WriteTable(os.Stdout)
+		if r.ACLConversion.HasWidening() {
+			logger.Warnw("The Role Bindings allow more than the Kafka ACLs they were converted from. Review the WIDENED column before migrating.")
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package workflowmanagers
+
+import (
+	"context"
+
+	"github.com/Shopify/sarama"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/topicmanagers"
+)
+
+func (s *StackSuite) TestStackSuite_Migration_InternalTopics() {
+	source := &fakeClusterAdmin{topics: []string{"orders", "present", "_internal", "__consumer_offsets", "connect-offsets",
+		"connect-status", "app-store-changelog", "app-repartition", "legacy.audit"}}
+	target := &fakeClusterAdmin{topics: []string{"present"}}
+	st := &engine.State{}
+	st.Core.Configs.ConfigRoot.ShepherdCoreConfig.TopicDeletion.Protected.Literals = []string{"legacy.audit"}
+	connections := kafkamanagers.NewKafkaConnections()
+	for name, admin := range map[string]*fakeClusterAdmin{"source": source, "target": target} {
+		var ca sarama.ClusterAdmin = admin
+		connections[kafkamanagers.KafkaConnectionsKey{ClusterName: name, ConnectionType: kafkamanagers.ConnectionType_SARAMA}] =
+			kafkamanagers.KafkaConnectionsValue{Connection: &kafkamanagers.SaramaConnection{SCA: &ca}, ConnectionType: kafkamanagers.ConnectionType_SARAMA}
+	}
+	sp := &Shepherd{
+		State:        st,
+		Connections:  connections,
+		topicManager: topicmanagers.NewSaramaTopicManager(connections, &st.Maps.TCM, kafkamanagers.NewRetryPolicy(engine.RetryConfig{}, 0), engine.ShepherdCoreConfig{}),
+	}
+
+	r := &MigrationReport{SourceCluster: "source", TargetCluster: "target", Topics: engine.TopicConfigMapping{}, SkippedTopics: map[string]string{}}
+	s.NoError(sp.migrateTopics(context.Background(), r))
+	s.Equal([]string{"orders"}, target.created)
+	s.Equal(map[string]string{
+		"_internal":           "Internal topic",
+		"__consumer_offsets":  "Internal topic",
+		"connect-offsets":     "Internal or protected topic, matching the literal connect-offsets",
+		"connect-status":      "Internal or protected topic, matching the literal connect-status",
+		"app-store-changelog": "Internal or protected topic, matching the regex -(changelog|repartition)$",
+		"app-repartition":     "Internal or protected topic, matching the regex -(changelog|repartition)$",
+		"legacy.audit":        "Internal or protected topic, matching the literal legacy.audit",
+		"present":             "Already present in the target cluster",
+	}, r.SkippedTopics)
+}
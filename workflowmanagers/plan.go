@@ -0,0 +1,273 @@
+package workflowmanagers
+
+import (
+	"context"
+
+	"fmt"
+
+	"github.com/waliaabhishek/kafka-shepherd/aclmanagers"
+	"github.com/waliaabhishek/kafka-shepherd/engine"
+)
+
+/*
+	Builds the Plan of every change ExecuteAllWorkflows would make to the enabled clusters. The topic, ACL,
+	quota, SCRAM credential, subject, KSQL and connector comparisons are the same as the ones used during the execution, but
+	nothing is executed.
+*/
+func (s *Shepherd) PlanAllWorkflows(ctx context.Context) (*engine.Plan, ClusterResults) {
+	plan := engine.NewPlan()
+	configFingerprint, err := s.State.ConfigFingerprint(plan.FingerprintSalt)
+	if err != nil {
+		logger.Warnw("Cannot fingerprint the configuration files. The plan cannot be applied later.",
+			"Error", err)
+	}
+	plan.ConfigFingerprint = configFingerprint
+	configTopicList := s.State.GetTopicList(true)
+	results := s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		fingerprint, err := s.clusterFingerprint(ctx, clusterName, ccm)
+		if err != nil {
+			return err
+		}
+		plan.SetClusterFingerprint(clusterName, fingerprint)
+		topicChanges, err := s.topicManager.PlanTopics(ctx, clusterName, configTopicList, true, true,
+			s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics)
+		if err != nil {
+			return err
+		}
+		aclChanges, err := s.planACLManagement(ctx, clusterName, ccm)
+		if err != nil {
+			return err
+		}
+		quotaChanges := []engine.PlanChange{}
+		if s.quotasManaged() {
+			if quotaChanges, err = s.quotaManager.PlanQuotas(ctx, clusterName, true, true,
+				s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownQuotas); err != nil {
+				return err
+			}
+		}
+		scramChanges := []engine.PlanChange{}
+		if s.scramManaged() {
+			if scramChanges, err = s.scramManager.PlanScramCredentials(ctx, clusterName, true, true,
+				s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownScramUsers); err != nil {
+				return err
+			}
+		}
+		subjectChanges, err := s.subjectManager.PlanSubjects(ctx, clusterName, configTopicList, true, true,
+			s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownSubjects)
+		if err != nil {
+			return err
+		}
+		ksqlChanges, err := s.ksqlManager.PlanKSQL(ctx, clusterName, true, true,
+			s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownKSQLQueries)
+		if err != nil {
+			return err
+		}
+		connectorChanges, err := s.connectorManager.PlanConnectors(ctx, clusterName, true, true,
+			s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownConnectors)
+		if err != nil {
+			return err
+		}
+		plan.Append(topicChanges...)
+		plan.Append(aclChanges...)
+		plan.Append(quotaChanges...)
+		plan.Append(scramChanges...)
+		plan.Append(subjectChanges...)
+		plan.Append(ksqlChanges...)
+		plan.Append(connectorChanges...)
+		return nil
+	})
+	plan.Sort()
+	return plan, results
+}
+
+func (s *Shepherd) planACLManagement(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) ([]engine.PlanChange, error) {
+	ret := []engine.PlanChange{}
+	if !ccm.IsACLManagementEnabled {
+		return ret, nil
+	}
+	aclManager, aclInterface := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+	expected := s.expectedACLs(clusterName, aclInterface)
+	provisioned, err := aclManager.GetClusterACL(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	base := aclmanagers.ACLExecutionManagerBaseImpl{}
+	for k := range *base.FindNonExistentACLsInCluster(expected, provisioned) {
+		ret = append(ret, engine.NewACLPlanChange(clusterName, engine.PlanAction_CREATE, k))
+	}
+	if s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs {
+		for k := range *base.FindNonExistentACLsInConfig(expected, provisioned) {
+			ret = append(ret, engine.NewACLPlanChange(clusterName, engine.PlanAction_DELETE, k))
+		}
+	}
+	return ret, nil
+}
+
+func (s *Shepherd) clusterFingerprint(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) (string, error) {
+	acls := &engine.ACLMapping{}
+	if ccm.IsACLManagementEnabled {
+		aclManager, _ := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+		var err error
+		if acls, err = aclManager.GetClusterACL(ctx, clusterName); err != nil {
+			return "", err
+		}
+	}
+	topics, err := s.topicManager.GetTopicConfigMapping(ctx, clusterName)
+	if err != nil {
+		return "", err
+	}
+	fingerprint := engine.ClusterStateFingerprint(topics, acls)
+	if s.quotasManaged() {
+		quotas, err := s.quotaManager.GetClusterQuotas(ctx, clusterName)
+		if err != nil {
+			return "", err
+		}
+		fingerprint += "-" + engine.QuotaStateFingerprint(quotas)
+	}
+	if s.scramManaged() {
+		credentials, err := s.scramManager.GetClusterScramCredentials(ctx, clusterName)
+		if err != nil {
+			return "", err
+		}
+		fingerprint += "-" + engine.ScramStateFingerprint(credentials)
+	}
+	// The fingerprints of the clusters without Connect clusters, a Schema Registry or ksqlDB clusters stay the same as before they were managed.
+	if s.Connections.GetSchemaRegistryConnection(clusterName) != nil {
+		subjects, err := s.subjectManager.GetClusterSubjects(ctx, clusterName)
+		if err != nil {
+			return "", err
+		}
+		fingerprint += "-" + engine.SubjectStateFingerprint(subjects)
+	}
+	if s.Connections.GetKSQLConnection(clusterName) != nil {
+		state, err := s.ksqlManager.GetClusterKSQL(ctx, clusterName)
+		if err != nil {
+			return "", err
+		}
+		fingerprint += "-" + engine.KSQLStateFingerprint(state)
+	}
+	if s.Connections.GetKafkaConnectConnection(clusterName) == nil {
+		return fingerprint, nil
+	}
+	connectors, err := s.connectorManager.GetClusterConnectors(ctx, clusterName)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint + "-" + engine.ConnectorStateFingerprint(connectors), nil
+}
+
+/*
+	Executes the changes of a saved plan, and only those. The plan is refused if the configuration files
+	changed since it was created. A cluster is not touched if its state drifted since the plan was created
+	and is reported as failed instead.
+*/
+func (s *Shepherd) ApplyPlan(ctx context.Context, plan *engine.Plan) (ClusterResults, error) {
+	configFingerprint, err := s.State.ConfigFingerprint(plan.FingerprintSalt)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fingerprint the configuration files: %w", err)
+	}
+	if plan.ConfigFingerprint != configFingerprint {
+		return nil, fmt.Errorf("configuration files have changed since the plan was created")
+	}
+	for clusterName := range plan.ClusterFingerprints {
+		if _, err := s.findEnabledCluster(clusterName); err != nil {
+			return nil, fmt.Errorf("planned cluster is not available: %w", err)
+		}
+	}
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		expected, found := plan.ClusterFingerprints[clusterName]
+		if !found {
+			return fmt.Errorf("cluster was not part of the plan")
+		}
+		fingerprint, err := s.clusterFingerprint(ctx, clusterName, ccm)
+		if err != nil {
+			return err
+		}
+		if fingerprint != expected {
+			return fmt.Errorf("cluster state has changed since the plan was created")
+		}
+		changes := plan.ClusterChanges(clusterName)
+		// The ACLs are resolved first, so that nothing is executed if the plan does not match the cluster.
+		createSet, deleteSet, err := s.resolveACLPlan(ctx, clusterName, ccm, changes)
+		if err != nil {
+			return err
+		}
+		if err := s.topicManager.ApplyTopicPlan(ctx, clusterName, changes, s.State.DryRun); err != nil {
+			return err
+		}
+		if len(*createSet) != 0 || len(*deleteSet) != 0 {
+			aclManager, _ := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+			if err := aclManager.CreateACL(ctx, clusterName, createSet, s.State.DryRun); err != nil {
+				return err
+			}
+			if err := aclManager.DeleteProvisionedACL(ctx, clusterName, deleteSet, s.State.DryRun); err != nil {
+				return err
+			}
+		}
+		if s.quotasManaged() {
+			if err := s.quotaManager.ApplyQuotaPlan(ctx, clusterName, changes, s.State.DryRun); err != nil {
+				return err
+			}
+		}
+		if s.scramManaged() {
+			if err := s.scramManager.ApplyScramPlan(ctx, clusterName, changes, s.State.DryRun); err != nil {
+				return err
+			}
+		}
+		if err := s.subjectManager.ApplySubjectPlan(ctx, clusterName, changes, s.State.DryRun); err != nil {
+			return err
+		}
+		if err := s.ksqlManager.ApplyKSQLPlan(ctx, clusterName, changes, s.State.DryRun); err != nil {
+			return err
+		}
+		return s.connectorManager.ApplyConnectorPlan(ctx, clusterName, changes, s.State.DryRun)
+	}), nil
+}
+
+// Finds the ACLs to be created and deleted for the planned ACL changes.
+func (s *Shepherd) resolveACLPlan(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue, changes []engine.PlanChange) (createSet *engine.ACLMapping, deleteSet *engine.ACLMapping, err error) {
+	createSet, deleteSet = &engine.ACLMapping{}, &engine.ACLMapping{}
+	planned := []engine.PlanChange{}
+	for _, v := range changes {
+		if v.ResourceType == engine.PlanResourceType_ACL {
+			planned = append(planned, v)
+		}
+	}
+	if len(planned) == 0 {
+		return createSet, deleteSet, nil
+	}
+	if !ccm.IsACLManagementEnabled {
+		return nil, nil, fmt.Errorf("ACL management is disabled for the cluster, but the plan has ACL changes")
+	}
+
+	// The planned ACLs are looked up by name, as the ACL managers need the ACL details along with their values.
+	aclManager, aclInterface := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+	provisioned, err := aclManager.GetClusterACL(ctx, clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := engine.ACLMapping{}
+	for _, m := range []*engine.ACLMapping{s.expectedACLs(clusterName, aclInterface), provisioned} {
+		for k, v := range *m {
+			known[k] = v
+		}
+	}
+	byName := make(map[string]engine.ACLDetails)
+	for k := range known {
+		byName[k.PlanName()] = k
+	}
+
+	for _, v := range planned {
+		k, found := byName[v.Name]
+		if !found {
+			return nil, nil, fmt.Errorf("planned ACL not found: %s", v.Name)
+		}
+		switch v.Action {
+		case engine.PlanAction_CREATE:
+			createSet.Append(k, known[k])
+		case engine.PlanAction_DELETE:
+			deleteSet.Append(k, known[k])
+		}
+	}
+	return createSet, deleteSet, nil
+}
@@ -1,84 +1,301 @@
 package workflowmanagers
 
 import (
+	"context"
+
+	mapset "github.com/deckarep/golang-set"
 	"github.com/waliaabhishek/kafka-shepherd/aclmanagers"
+	"github.com/waliaabhishek/kafka-shepherd/connectormanagers"
 	"github.com/waliaabhishek/kafka-shepherd/engine"
 	"github.com/waliaabhishek/kafka-shepherd/kafkamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/ksqlmanagers"
+	"github.com/waliaabhishek/kafka-shepherd/quotamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/schemamanagers"
+	"github.com/waliaabhishek/kafka-shepherd/scrammanagers"
 	"github.com/waliaabhishek/kafka-shepherd/topicmanagers"
 )
 
-var (
-	configTopicList     = engine.Shepherd.GetTopicList(true)
-	logger              = engine.Shepherd.GetLogger()
-	dryRun              = engine.DryRun
-	deleteUnknownTopics = engine.SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics
-	deleteUnknownACLs   = engine.SpdCore.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs
-	topicManager        = topicmanagers.SaramaTopicManager
-)
+var logger = engine.Shepherd.GetLogger()
 
-func init() {
-	kafkamanagers.InitiateAllKafkaConnections(engine.SpdCore.Configs.ConfigRoot)
+/*
+	A Shepherd instance holds one loaded configuration set along with its own connection registry and
+	managers, so multiple instances can be used in the same process without affecting each other.
+	The connections are only set up when a workflow is executed; Close tears them down. Every workflow
+	takes a context and stops sending requests to the clusters once the context is done.
+*/
+type Shepherd struct {
+	State            *engine.State
+	Connections      kafkamanagers.KafkaConnections
+	topicManager     topicmanagers.TopicExecutionManager
+	aclControllers   *aclmanagers.ACLControllers
+	connectorManager connectormanagers.ConnectorExecutionManager
+	subjectManager   schemamanagers.SubjectExecutionManager
+	ksqlManager      ksqlmanagers.KSQLExecutionManager
+	quotaManager     quotamanagers.QuotaExecutionManager
+	scramManager     scrammanagers.ScramExecutionManager
+}
+
+func New(opts engine.Options) (*Shepherd, error) {
+	st, err := engine.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	connections := kafkamanagers.NewKafkaConnections()
+	retry := kafkamanagers.NewRetryPolicy(st.Core.Configs.ConfigRoot.ShepherdCoreConfig.Retry, st.OperationTimeout)
+	return &Shepherd{
+		State:            st,
+		Connections:      connections,
+		topicManager:     topicmanagers.NewSaramaTopicManager(connections, &st.Maps.TCM, retry, st.Core.Configs.ConfigRoot.ShepherdCoreConfig),
+		aclControllers:   aclmanagers.NewACLControllers(connections, retry),
+		connectorManager: connectormanagers.NewConnectRESTManager(connections, &st.Maps.Connectors, retry),
+		subjectManager:   schemamanagers.NewSchemaRegistryManager(connections, &st.Maps.Subjects, retry),
+		ksqlManager:      ksqlmanagers.NewKSQLRESTManager(connections, &st.Maps.KSQL, retry),
+		quotaManager:     quotamanagers.NewSaramaQuotaManager(connections, &st.Maps.Quotas, retry),
+		scramManager:     scrammanagers.NewSaramaScramManager(connections, &st.Maps.ScramCredentials, retry, st.Core.Configs.ConfigRoot),
+	}, nil
 }
 
-func ExecuteTopicManagementWorkflow(executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) {
-	for k := range engine.ConfMaps.CCM {
-		if executeCreateFlow {
-			topicManager.CreateTopics(k.Name, configTopicList, dryRun)
+func (s *Shepherd) Close() {
+	s.Connections.CloseAllKafkaConnections()
+}
+
+/*
+	Reconciles the topics, the ACLs, the quotas, the SCRAM credentials, the subjects, the ksqlDB statements and
+	then the connectors for every enabled cluster. All the flows are executed together for a cluster, so the
+	result of a cluster covers the whole reconciliation. The queries and the connectors come last, so that their
+	topics, ACLs and schemas are in place when they start.
+*/
+func (s *Shepherd) ExecuteAllWorkflows(ctx context.Context) ClusterResults {
+	configTopicList := s.State.GetTopicList(true)
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		if err := s.executeTopicManagement(ctx, clusterName, configTopicList, true, true, true); err != nil {
+			return err
 		}
-		if deleteUnknownTopics && executeDeleteFlow {
-			topicManager.DeleteUnknownTopics(k.Name, configTopicList, dryRun)
+		if err := s.executeACLManagement(ctx, clusterName, ccm, true, true); err != nil {
+			return err
 		}
-		if executeModifyFlow {
-			topicManager.ModifyTopics(k.Name, dryRun)
+		if err := s.executeQuotaManagement(ctx, clusterName, true, true, true); err != nil {
+			return err
 		}
-	}
+		if err := s.executeScramManagement(ctx, clusterName, true, true, true); err != nil {
+			return err
+		}
+		if err := s.executeSubjectManagement(ctx, clusterName, configTopicList, true, true, true); err != nil {
+			return err
+		}
+		if err := s.executeKSQLManagement(ctx, clusterName, true, true, true); err != nil {
+			return err
+		}
+		return s.executeConnectorManagement(ctx, clusterName, true, true, true)
+	})
+}
+
+func (s *Shepherd) ExecuteTopicManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	configTopicList := s.State.GetTopicList(true)
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeTopicManagement(ctx, clusterName, configTopicList, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
+}
+
+func (s *Shepherd) ExecuteACLManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeDeleteFlow bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeACLManagement(ctx, clusterName, ccm, executeCreateFlow, executeDeleteFlow)
+	})
+}
+
+// Reconciles the connectors of the Connect clusters configured for every enabled cluster.
+func (s *Shepherd) ExecuteConnectorManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeConnectorManagement(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
 }
 
-func ExecuteACLManagementWorkflow(executeCreateFlow bool, executeDeleteFlow bool) {
-	for k, v := range engine.ConfMaps.CCM {
-		if v.IsACLManagementEnabled {
-			aclManager, aclInterface := aclmanagers.GetACLControllerDetails(k.Name, v.ACLManager)
-			temp := aclInterface.GenerateACLMappingStructures(k.Name, engine.ShepherdACLList)
-			// temp := engine.Shepherd.RenderACLMappings(k.Name, engine.ShepherdACLList, aclInterface)
-			if executeCreateFlow {
-				aclManager.CreateACL(k.Name, temp, dryRun)
-			}
-			if deleteUnknownACLs && executeDeleteFlow {
-				aclManager.DeleteUnknownACL(k.Name, temp, dryRun)
-			}
-			continue
+// Reconciles the subjects of the topics with the Schema Registry configured for every enabled cluster.
+func (s *Shepherd) ExecuteSubjectManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	configTopicList := s.State.GetTopicList(true)
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeSubjectManagement(ctx, clusterName, configTopicList, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
+}
+
+// Reconciles the client quotas of every enabled cluster.
+func (s *Shepherd) ExecuteQuotaManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeQuotaManagement(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
+}
+
+// Reconciles the SCRAM credentials of the user principals for every enabled cluster.
+func (s *Shepherd) ExecuteScramManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeScramManagement(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
+}
+
+// Applies the KSQL statements to the ksqlDB clusters configured for every enabled cluster.
+func (s *Shepherd) ExecuteKSQLManagementWorkflow(ctx context.Context, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.executeKSQLManagement(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow)
+	})
+}
+
+func (s *Shepherd) executeTopicManagement(ctx context.Context, clusterName string, configTopicList mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	if executeCreateFlow {
+		if err := s.topicManager.CreateTopics(ctx, clusterName, configTopicList, s.State.DryRun); err != nil {
+			return err
 		}
+	}
+	if s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownTopics && executeDeleteFlow {
+		if err := s.topicManager.DeleteUnknownTopics(ctx, clusterName, configTopicList, s.State.DryRun); err != nil {
+			return err
+		}
+	}
+	if executeModifyFlow {
+		return s.topicManager.ModifyTopics(ctx, clusterName, s.State.DryRun)
+	}
+	return nil
+}
+
+func (s *Shepherd) executeACLManagement(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue, executeCreateFlow bool, executeDeleteFlow bool) error {
+	if !ccm.IsACLManagementEnabled {
 		logger.Warnw("ACL management is disabled for the cluster. Skipping ACL Execution.",
-			"Cluster Name", k.Name,
-			"Cluster Security Protocol", v.ClusterSecurityProtocol.String(),
+			"Cluster Name", clusterName,
+			"Cluster Security Protocol", ccm.ClusterSecurityProtocol.String(),
 		)
+		return nil
+	}
+	aclManager, aclInterface := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+	temp := s.expectedACLs(clusterName, aclInterface)
+	if executeCreateFlow {
+		if err := aclManager.CreateACL(ctx, clusterName, temp, s.State.DryRun); err != nil {
+			return err
+		}
 	}
+	if s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownACLs && executeDeleteFlow {
+		return aclManager.DeleteUnknownACL(ctx, clusterName, temp, s.State.DryRun)
+	}
+	return nil
 }
 
-func DeleteShepherdTopics(executeDeleteFlow bool) {
-	if engine.IsTest {
-		for k := range engine.ConfMaps.CCM {
-			if executeDeleteFlow {
-				topicManager.DeleteProvisionedTopics(k.Name, configTopicList, dryRun)
-			}
-		}
+func (s *Shepherd) executeSubjectManagement(ctx context.Context, clusterName string, configTopicList mapset.Set, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	executeDeleteFlow = executeDeleteFlow && s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownSubjects
+	return s.subjectManager.ExecuteSubjects(ctx, clusterName, configTopicList, executeCreateFlow, executeModifyFlow, executeDeleteFlow, s.State.DryRun)
+}
+
+/*
+	The quotas are only managed if some are declared or the unknown ones are to be deleted, so the clusters
+	with a kafkaVersion before 2.6.0 (the first one with the client quota requests) keep working without quotas.
+*/
+func (s *Shepherd) quotasManaged() bool {
+	return len(s.State.Maps.Quotas) != 0 || s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownQuotas
+}
+
+func (s *Shepherd) executeQuotaManagement(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	if !s.quotasManaged() {
+		return nil
+	}
+	executeDeleteFlow = executeDeleteFlow && s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownQuotas
+	return s.quotaManager.ExecuteQuotas(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow, s.State.DryRun)
+}
+
+// The SCRAM credentials are only managed once the mechanisms to manage are configured.
+func (s *Shepherd) scramManaged() bool {
+	return s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.ScramCredentials.Enabled()
+}
+
+func (s *Shepherd) executeScramManagement(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	if !s.scramManaged() {
+		return nil
 	}
+	executeDeleteFlow = executeDeleteFlow && s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownScramUsers
+	return s.scramManager.ExecuteScramCredentials(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow, s.State.DryRun)
+}
+
+func (s *Shepherd) executeKSQLManagement(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	executeDeleteFlow = executeDeleteFlow && s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownKSQLQueries
+	return s.ksqlManager.ExecuteKSQL(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow, s.State.DryRun)
+}
+
+func (s *Shepherd) executeConnectorManagement(ctx context.Context, clusterName string, executeCreateFlow bool, executeModifyFlow bool, executeDeleteFlow bool) error {
+	executeDeleteFlow = executeDeleteFlow && s.State.Core.Configs.ConfigRoot.ShepherdCoreConfig.DeleteUnknownConnectors
+	return s.connectorManager.ExecuteConnectors(ctx, clusterName, executeCreateFlow, executeModifyFlow, executeDeleteFlow, s.State.DryRun)
+}
+
+// The ACLs expected in the cluster, collapsed into prefixed ACLs if aclPolicy.optimizeACLs is set.
+func (s *Shepherd) expectedACLs(clusterName string, aclInterface engine.ACLOperationsInterface) *engine.ACLMapping {
+	return s.State.OptimizeACLs(aclInterface.GenerateACLMappingStructures(clusterName, s.State.ACLList))
+}
+
+/*
+	Lists the ACLs for every enabled cluster. If fromCluster is true, the ACLs provisioned
+	in the cluster are listed, otherwise the ACLs generated from the configuration files
+	are listed in the format expected by the cluster's ACL manager.
+*/
+func (s *Shepherd) ListACLs(ctx context.Context, fromCluster bool) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		if !ccm.IsACLManagementEnabled {
+			logger.Warnw("ACL management is disabled for the cluster. Skipping ACL Listing.",
+				"Cluster Name", clusterName,
+				"Cluster Security Protocol", ccm.ClusterSecurityProtocol.String(),
+			)
+			return nil
+		}
+		aclManager, aclInterface := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+		if fromCluster {
+			return aclManager.ListClusterACL(ctx, clusterName, true)
+		}
+		aclManager.ListConfigACL(s.expectedACLs(clusterName, aclInterface))
+		return nil
+	})
 }
 
-func DeleteShepherdACLs(executeDeleteFlow bool) {
-	if engine.IsTest {
-		for k, v := range engine.ConfMaps.CCM {
-			if v.IsACLManagementEnabled && executeDeleteFlow {
-				aclManager, aclInterface := aclmanagers.GetACLControllerDetails(k.Name, v.ACLManager)
-				temp := aclInterface.GenerateACLMappingStructures(k.Name, engine.ShepherdACLList)
-				// temp := engine.Shepherd.RenderACLMappings(k.Name, engine.ShepherdACLList, aclInterface)
-				aclManager.DeleteProvisionedACL(k.Name, temp, dryRun)
-				continue
-			}
-			logger.Warnw("ACL management is disabled for the cluster. Skipping ACL Execution.",
-				"Cluster Name", k.Name,
-				"Cluster Security Protocol", v.ClusterSecurityProtocol.String(),
+/*
+	Lists the ACLs provisioned in every enabled cluster as the Shepherd client roles (producers, consumers,
+	connectors, streams and ksqlDB clusters), along with the ACLs that are not part of any role.
+*/
+func (s *Shepherd) AuditACLs(ctx context.Context) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		if !ccm.IsACLManagementEnabled {
+			logger.Warnw("ACL management is disabled for the cluster. Skipping ACL Audit.",
+				"Cluster Name", clusterName,
+				"Cluster Security Protocol", ccm.ClusterSecurityProtocol.String(),
 			)
+			return nil
 		}
+		aclManager, _ := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+		return aclManager.AuditClusterACL(ctx, clusterName)
+	})
+}
+
+// Only sets up the connections for every enabled cluster. Useful to validate the connection details.
+func (s *Shepherd) ValidateConnections(ctx context.Context) ClusterResults {
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error { return nil })
+}
+
+func (s *Shepherd) DeleteShepherdTopics(ctx context.Context, executeDeleteFlow bool) ClusterResults {
+	if !s.State.IsTest || !executeDeleteFlow {
+		return ClusterResults{}
+	}
+	configTopicList := s.State.GetTopicList(true)
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		return s.topicManager.DeleteProvisionedTopics(ctx, clusterName, configTopicList, s.State.DryRun)
+	})
+}
+
+func (s *Shepherd) DeleteShepherdACLs(ctx context.Context, executeDeleteFlow bool) ClusterResults {
+	if !s.State.IsTest {
+		return ClusterResults{}
 	}
+	return s.runForEachCluster(ctx, func(ctx context.Context, clusterName string, ccm engine.ClusterConfigMappingValue) error {
+		if ccm.IsACLManagementEnabled && executeDeleteFlow {
+			aclManager, aclInterface := s.aclControllers.GetACLControllerDetails(clusterName, ccm.ACLManager)
+			temp := s.expectedACLs(clusterName, aclInterface)
+			return aclManager.DeleteProvisionedACL(ctx, clusterName, temp, s.State.DryRun)
+		}
+		logger.Warnw("ACL management is disabled for the cluster. Skipping ACL Execution.",
+			"Cluster Name", clusterName,
+			"Cluster Security Protocol", ccm.ClusterSecurityProtocol.String(),
+		)
+		return nil
+	})
 }